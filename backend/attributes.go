@@ -0,0 +1,151 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"laserscribe/backend/clb"
+	"laserscribe/backend/db"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// MATERIAL ATTRIBUTES
+// =====================
+
+// maxThicknessMm bounds thickness to what fits under a laser
+const maxThicknessMm = 500
+
+// MaterialAttributes describes the stock a setting was made on, so thickness,
+// color, finish/coating and grade don't have to be spelled into material
+// names. Empty values are stored as NULL.
+type MaterialAttributes struct {
+	ThicknessMm *string `json:"thicknessMm"`
+	Color       *string `json:"color"`
+	Finish      *string `json:"finish"`
+	Grade       *string `json:"grade"`
+}
+
+// attributeValue trims a free-text attribute, treating blank as unset
+func attributeValue(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	v := strings.TrimSpace(*s)
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
+// thickness validates ThicknessMm as a positive number of millimetres
+func (a MaterialAttributes) thickness() (sql.NullString, error) {
+	v := attributeValue(a.ThicknessMm)
+	if !v.Valid {
+		return v, nil
+	}
+	f, err := strconv.ParseFloat(v.String, 64)
+	if err != nil || f <= 0 || f > maxThicknessMm {
+		return sql.NullString{}, fmt.Errorf("thicknessMm must be a number between 0 and %d", maxThicknessMm)
+	}
+	return v, nil
+}
+
+// thicknessTolerance is how far apart two thicknesses can be and still be
+// the same stock: nominal metric and imperial sheet sizes (3mm vs 1/8in)
+// differ by up to about 10%
+func thicknessTolerance(mm float64) float64 {
+	return math.Max(0.3, 0.1*mm)
+}
+
+// parseThickness reads a thickness such as "3", "3mm", "0.6 cm", "1/8in",
+// "1/8\"" or a bare fraction "1/8" (inches). A bare number is millimetres.
+func parseThickness(s string) (float64, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	m := thicknessPattern.FindStringSubmatch(s)
+	if m == nil || strings.TrimSpace(m[0]) != s {
+		return 0, fmt.Errorf("invalid thickness: %s", s)
+	}
+	number, unit := m[1], m[2]
+	if unit == "" && !strings.Contains(number, "/") {
+		unit = "mm"
+	}
+	mm, ok := thicknessToMm(number, unit)
+	if !ok || mm <= 0 || mm > maxThicknessMm {
+		return 0, fmt.Errorf("invalid thickness: %s", s)
+	}
+	return mm, nil
+}
+
+// formatThickness renders millimetres for the thickness_mm column
+func formatThickness(mm float64) string {
+	return strconv.FormatFloat(mm, 'f', 3, 64)
+}
+
+// importThickness is the thickness of an imported entry: its Thickness
+// attribute, or else a size in the material name such as "Plywood 3mm"
+func importThickness(entryThickness, materialName string) sql.NullString {
+	if f, err := strconv.ParseFloat(strings.TrimSpace(entryThickness), 64); err == nil && f > 0 {
+		return sql.NullString{String: formatThickness(f), Valid: true}
+	}
+	if parsed := parseMaterialName(materialName); parsed.hasThickness {
+		return sql.NullString{String: formatThickness(parsed.thicknessMm), Valid: true}
+	}
+	return sql.NullString{}
+}
+
+// applyAttributeFilters reads the material attribute filters: thickness
+// (matching the same stock in any unit, e.g. thickness=1/8in finds 3mm) or
+// a thickness range in mm, and exact color, finish and grade
+func applyAttributeFilters(c *gin.Context, params *db.SearchSettingsParams) error {
+	if v := c.Query("thickness"); v != "" {
+		mm, err := parseThickness(v)
+		if err != nil {
+			return err
+		}
+		tolerance := thicknessTolerance(mm)
+		params.MinThickness = sql.NullString{String: formatThickness(math.Max(0, mm-tolerance)), Valid: true}
+		params.MaxThickness = sql.NullString{String: formatThickness(mm + tolerance), Valid: true}
+	}
+
+	ranges := []struct {
+		name  string
+		field *sql.NullString
+	}{
+		{"min_thickness", &params.MinThickness},
+		{"max_thickness", &params.MaxThickness},
+	}
+	for _, r := range ranges {
+		v := c.Query(r.name)
+		if v == "" {
+			continue
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return fmt.Errorf("%s must be a number", r.name)
+		}
+		*r.field = sql.NullString{String: v, Valid: true}
+	}
+
+	for name, field := range map[string]*sql.NullString{
+		"color":  &params.Color,
+		"finish": &params.Finish,
+		"grade":  &params.Grade,
+	} {
+		v := c.Query(name)
+		*field = attributeValue(&v)
+	}
+	return nil
+}
+
+// entryThickness formats a stored thickness the way LightBurn writes the
+// Entry Thickness attribute
+func entryThickness(thicknessMm sql.NullString) string {
+	if !thicknessMm.Valid {
+		return clb.NoThickness
+	}
+	f, err := strconv.ParseFloat(thicknessMm.String, 64)
+	if err != nil || f <= 0 {
+		return clb.NoThickness
+	}
+	return strconv.FormatFloat(f, 'f', 4, 64)
+}
@@ -0,0 +1,238 @@
+// Package clb reads and writes LightBurn material library (.clb) files.
+//
+// The format is undocumented by LightBurn; see docs/lightburn-clb-format.md
+// for the reverse-engineered reference these types follow. Every element is
+// optional and absent elements stay absent on output, so decoding a library
+// and encoding it again reproduces the original file.
+package clb
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DisplayName is the library name Laserscribe uses for every exported file
+const DisplayName = "LASERSCRIBED.COM"
+
+// NoThickness is the Entry Thickness value LightBurn uses for "unspecified"
+const NoThickness = "-1.0000"
+
+// CutSetting type attribute values
+const (
+	TypeCut        = "Cut"
+	TypeScan       = "Scan"
+	TypeScanCut    = "Scan+Cut"
+	TypeImage      = "Image"
+	TypeOffsetFill = "Offset Fill"
+)
+
+// Library is the <LightBurnLibrary> root element
+type Library struct {
+	XMLName     xml.Name   `xml:"LightBurnLibrary"`
+	DisplayName string     `xml:"DisplayName,attr"`
+	Materials   []Material `xml:"Material"`
+}
+
+// Material groups entries under a single material name
+type Material struct {
+	Name    string  `xml:"name,attr"`
+	Entries []Entry `xml:"Entry"`
+}
+
+// Entry is a single preset in the library tree
+type Entry struct {
+	Thickness    string     `xml:"Thickness,attr"`
+	Desc         string     `xml:"Desc,attr"`
+	NoThickTitle string     `xml:"NoThickTitle,attr"`
+	CutSetting   CutSetting `xml:"CutSetting"`
+}
+
+// CutSetting holds the laser parameters for an entry. Index, name and
+// LinkPath are written first, followed by the shared Params fields and any
+// nested SubLayers.
+type CutSetting struct {
+	Type     string `xml:"type,attr"`
+	Index    *Value `xml:"index"`
+	Name     *Value `xml:"name"`
+	LinkPath *Value `xml:"LinkPath"`
+	Params
+	SubLayers []SubLayer `xml:"SubLayer"`
+}
+
+// SubLayer is an additional pass nested inside a CutSetting, such as the
+// cleanup pass of a 3D engraving
+type SubLayer struct {
+	Type  string `xml:"type,attr"`
+	Index string `xml:"index,attr"`
+	Params
+}
+
+// Params are the <field Value="..."/> elements shared by CutSetting and
+// SubLayer, declared in the order LightBurn writes them.
+type Params struct {
+	MinPower             *Value `xml:"minPower"`
+	MaxPower             *Value `xml:"maxPower"`
+	MinPower2            *Value `xml:"minPower2"`
+	MaxPower2            *Value `xml:"maxPower2"`
+	Speed                *Value `xml:"speed"`
+	Frequency            *Value `xml:"frequency"`
+	QPulseWidth          *Value `xml:"QPulseWidth"`
+	WobbleEnable         *Value `xml:"wobbleEnable"`
+	WobbleStep           *Value `xml:"wobbleStep"`
+	WobbleSize           *Value `xml:"wobbleSize"`
+	NumPasses            *Value `xml:"numPasses"`
+	ZOffset              *Value `xml:"zOffset"`
+	ZPerPass             *Value `xml:"zPerPass"`
+	AnglePerPass         *Value `xml:"anglePerPass"`
+	ScanOpt              *Value `xml:"scanOpt"`
+	Bidir                *Value `xml:"bidir"`
+	CrossHatch           *Value `xml:"crossHatch"`
+	Overscan             *Value `xml:"overscan"`
+	OverscanPercent      *Value `xml:"overscanPercent"`
+	UseDotCorrection     *Value `xml:"useDotCorrection"`
+	EnableDotWidthAdjust *Value `xml:"enableDotWidthAdjust"`
+	DotWidth             *Value `xml:"dotWidth"`
+	FloodFill            *Value `xml:"floodFill"`
+	PerforationMode      *Value `xml:"perforationMode"`
+	Interval             *Value `xml:"interval"`
+	Angle                *Value `xml:"angle"`
+	AutoRotate           *Value `xml:"autoRotate"`
+	ImageMode            *Value `xml:"imageMode"`
+	DitherMode           *Value `xml:"ditherMode"`
+	NegativeImage        *Value `xml:"negativeImage"`
+	Kerf                 *Value `xml:"kerf"`
+	RunBlower            *Value `xml:"runBlower"`
+	IsCleanup            *Value `xml:"isCleanup"`
+	Subname              *Value `xml:"subname"`
+	Priority             *Value `xml:"priority"`
+	TabCount             *Value `xml:"tabCount"`
+	TabCountMax          *Value `xml:"tabCountMax"`
+
+	// Other holds elements this package does not model so they survive a
+	// decode/encode round trip
+	Other []RawElement `xml:",any"`
+}
+
+// Value is the <field Value="..."/> element used for every parameter
+type Value struct {
+	Value string `xml:"Value,attr"`
+}
+
+// RawElement is an unmodelled element preserved verbatim
+type RawElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   []byte     `xml:",innerxml"`
+}
+
+// V returns a Value element holding s
+func V(s string) *Value {
+	return &Value{Value: s}
+}
+
+// Int returns a Value element holding n
+func Int(n int) *Value {
+	return &Value{Value: strconv.Itoa(n)}
+}
+
+// Bool returns a Value element holding 1 or 0
+func Bool(b bool) *Value {
+	if b {
+		return &Value{Value: "1"}
+	}
+	return &Value{Value: "0"}
+}
+
+// Num returns a Value element for a decimal string with insignificant
+// trailing zeros removed, or nil when s is empty
+func Num(s string) *Value {
+	if s == "" {
+		return nil
+	}
+	return &Value{Value: TrimDecimal(s)}
+}
+
+// String returns the element's value, or "" when the element is absent
+func (v *Value) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.Value
+}
+
+// IsSet reports whether the element is present with a non-empty value
+func (v *Value) IsSet() bool {
+	return v != nil && v.Value != ""
+}
+
+// TrimDecimal removes insignificant trailing zeros from a decimal string, so
+// MySQL DECIMAL values like "70.000" are written as "70"
+func TrimDecimal(s string) string {
+	if !strings.Contains(s, ".") {
+		return s
+	}
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Decode parses a .clb library from r
+func Decode(r io.Reader) (*Library, error) {
+	var lib Library
+	if err := xml.NewDecoder(r).Decode(&lib); err != nil {
+		return nil, err
+	}
+	return &lib, nil
+}
+
+// selfClosing matches elements with no content so they can be collapsed to
+// the <field Value="..."/> form LightBurn writes. Attribute values are
+// escaped by encoding/xml, so a literal '>' cannot appear inside the tag.
+var selfClosing = regexp.MustCompile(`<([A-Za-z][\w.-]*)([^<>]*)></([A-Za-z][\w.-]*)>`)
+
+// Marshal encodes lib in LightBurn's layout: UTF-8 declaration, four-space
+// indentation, self-closing value elements and a trailing newline.
+func Marshal(lib *Library) ([]byte, error) {
+	return MarshalDocument(lib)
+}
+
+// MarshalDocument encodes any LightBurn XML document, such as a project
+// built from these CutSettings, in the layout Marshal uses for libraries
+func MarshalDocument(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	body = selfClosing.ReplaceAllFunc(body, func(m []byte) []byte {
+		parts := selfClosing.FindSubmatch(m)
+		if !bytes.Equal(parts[1], parts[3]) {
+			return m
+		}
+		return []byte("<" + string(parts[1]) + string(parts[2]) + "/>")
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	buf.Write(body)
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// Encode writes lib to w in LightBurn's layout
+func Encode(w io.Writer, lib *Library) error {
+	data, err := Marshal(lib)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// LinkPath builds the MaterialName/NoThickTitle/Desc path LightBurn uses to
+// index an entry
+func LinkPath(material, noThickTitle, desc string) string {
+	return material + "/" + noThickTitle + "/" + desc
+}
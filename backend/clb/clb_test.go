@@ -0,0 +1,193 @@
+package clb
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const goldenLibrary = "../../Gweike_20W_Fiber.clb"
+
+func TestRoundTripGolden(t *testing.T) {
+	golden, err := os.ReadFile(goldenLibrary)
+	if err != nil {
+		t.Fatalf("read golden: %v", err)
+	}
+
+	lib, err := Decode(bytes.NewReader(golden))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(lib.Materials) != 4 {
+		t.Fatalf("got %d materials, want 4", len(lib.Materials))
+	}
+
+	out, err := Marshal(lib)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !bytes.Equal(out, golden) {
+		t.Errorf("round trip differs from golden file\n--- got ---\n%s", out)
+	}
+
+	again, err := Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decode round trip: %v", err)
+	}
+	if !reflect.DeepEqual(lib, again) {
+		t.Errorf("decoded library changed after round trip")
+	}
+}
+
+func TestDecodeGoldenValues(t *testing.T) {
+	f, err := os.Open(goldenLibrary)
+	if err != nil {
+		t.Fatalf("open golden: %v", err)
+	}
+	defer f.Close()
+
+	lib, err := Decode(f)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	entry := lib.Materials[0].Entries[1]
+	if lib.Materials[0].Name != "Stainless Steel" || entry.Desc != "Fill" {
+		t.Fatalf("unexpected first material/entry: %q/%q", lib.Materials[0].Name, entry.Desc)
+	}
+	cs := entry.CutSetting
+	if cs.Type != TypeScan {
+		t.Errorf("type = %q, want %q", cs.Type, TypeScan)
+	}
+	checks := map[string]*Value{
+		"maxPower":  cs.MaxPower,
+		"maxPower2": cs.MaxPower2,
+		"speed":     cs.Speed,
+		"frequency": cs.Frequency,
+		"interval":  cs.Interval,
+		"scanOpt":   cs.ScanOpt,
+	}
+	want := map[string]string{
+		"maxPower":  "75",
+		"maxPower2": "20",
+		"speed":     "1000",
+		"frequency": "30",
+		"interval":  "0.03",
+		"scanOpt":   "mergeAll",
+	}
+	for name, v := range checks {
+		if v.String() != want[name] {
+			t.Errorf("%s = %q, want %q", name, v.String(), want[name])
+		}
+	}
+	if cs.MinPower != nil {
+		t.Errorf("minPower should be absent, got %q", cs.MinPower.String())
+	}
+}
+
+func TestRoundTripSubLayersAndUnknownElements(t *testing.T) {
+	src := `<?xml version="1.0" encoding="UTF-8"?>
+<LightBurnLibrary DisplayName="Test">
+    <Material name="Brass-Engrave">
+        <Entry Thickness="3.0000" Desc="Engrave" NoThickTitle="Optimized Engrave Settings">
+            <CutSetting type="Image">
+                <index Value="0"/>
+                <name Value=""/>
+                <LinkPath Value="Brass-Engrave/Optimized Engrave Settings/Engrave"/>
+                <maxPower Value="85"/>
+                <speed Value="2500"/>
+                <ditherMode Value="3dslice"/>
+                <kerf Value="0.05"/>
+                <runBlower Value="1"/>
+                <subname Value="3D Slice"/>
+                <priority Value="0"/>
+                <futureField Value="x" Extra="y"/>
+                <SubLayer type="Scan" index="1">
+                    <maxPower Value="30"/>
+                    <speed Value="7000"/>
+                    <interval Value="0.02"/>
+                    <isCleanup Value="1"/>
+                    <subname Value="Cleanup"/>
+                </SubLayer>
+            </CutSetting>
+        </Entry>
+    </Material>
+</LightBurnLibrary>
+`
+	lib, err := Decode(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	cs := lib.Materials[0].Entries[0].CutSetting
+	if len(cs.SubLayers) != 1 || cs.SubLayers[0].IsCleanup.String() != "1" {
+		t.Fatalf("sublayer not decoded: %+v", cs.SubLayers)
+	}
+	if len(cs.Other) != 1 || cs.Other[0].XMLName.Local != "futureField" {
+		t.Fatalf("unknown element not preserved: %+v", cs.Other)
+	}
+
+	out, err := Marshal(lib)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(out) != src {
+		t.Errorf("round trip differs\n--- got ---\n%s\n--- want ---\n%s", out, src)
+	}
+}
+
+func TestMarshalEscapesNames(t *testing.T) {
+	name := `Walnut & "Maple" <3mm>`
+	lib := &Library{
+		DisplayName: DisplayName,
+		Materials: []Material{{
+			Name: name,
+			Entries: []Entry{{
+				Thickness:    NoThickness,
+				Desc:         "Line",
+				NoThickTitle: "Line",
+				CutSetting: CutSetting{
+					Type:     TypeCut,
+					Index:    Int(0),
+					LinkPath: V(LinkPath(name, "Line", "Line")),
+				},
+			}},
+		}},
+	}
+
+	out, err := Marshal(lib)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if strings.Contains(string(out), `name="Walnut & "`) {
+		t.Fatalf("material name not escaped:\n%s", out)
+	}
+
+	back, err := Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decode escaped output: %v", err)
+	}
+	if got := back.Materials[0].Name; got != name {
+		t.Errorf("name = %q, want %q", got, name)
+	}
+	if got := back.Materials[0].Entries[0].CutSetting.LinkPath.String(); got != name+"/Line/Line" {
+		t.Errorf("LinkPath = %q", got)
+	}
+}
+
+func TestTrimDecimal(t *testing.T) {
+	cases := map[string]string{
+		"70.000":  "70",
+		"0.0300":  "0.03",
+		"1000":    "1000",
+		"12.5":    "12.5",
+		"-1.0000": "-1",
+		"0.000":   "0",
+	}
+	for in, want := range cases {
+		if got := TrimDecimal(in); got != want {
+			t.Errorf("TrimDecimal(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"laserscribe/backend/clb"
+	"laserscribe/backend/units"
+	"os"
+	"strings"
+)
+
+func main() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: go run ./cmd/csv_to_clb <input.csv> <output.clb>")
+		os.Exit(1)
+	}
+
+	inputFile := os.Args[1]
+	outputFile := os.Args[2]
+
+	// Read CSV
+	file, err := os.Open(inputFile)
+	if err != nil {
+		fmt.Printf("Error opening CSV: %v\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	records, err := reader.ReadAll()
+	if err != nil {
+		fmt.Printf("Error reading CSV: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(records) < 2 {
+		fmt.Println("CSV file is empty or has no data rows")
+		os.Exit(1)
+	}
+
+	// Parse header
+	header := records[0]
+	colMap := make(map[string]int)
+	for i, col := range header {
+		colMap[col] = i
+	}
+
+	// Speed, interval and frequency columns declare their unit in the
+	// header, e.g. "Speed (mm/min)"; .clb files hold mm/s, mm and kHz
+	speedCol, speedUnit, err := unitColumn(colMap, "Speed", units.ParseSpeedUnit)
+	if err == nil && speedCol == "" {
+		err = fmt.Errorf("no %q column", "Speed")
+	}
+	if err != nil {
+		fmt.Printf("Error reading CSV header: %v\n", err)
+		os.Exit(1)
+	}
+	intervalCol, intervalUnit, err := unitColumn(colMap, "Scan Interval", units.ParseIntervalUnit)
+	if err != nil {
+		fmt.Printf("Error reading CSV header: %v\n", err)
+		os.Exit(1)
+	}
+	frequencyCol, frequencyUnit, err := unitColumn(colMap, "Frequency", units.ParseFrequencyUnit)
+	if err != nil {
+		fmt.Printf("Error reading CSV header: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Group by material
+	type Setting struct {
+		Material             string
+		Mode                 string
+		ImageMode            string
+		MaxPower             string
+		MaxPower2            string
+		Speed                string
+		Frequency            string
+		Passes               string
+		ScanInterval         string
+		BiDirectionalFill    string
+		CrossHatch           string
+		ScanAngle            string
+		AngleIncrement       string
+		AutoRotate           string
+		FloodFill            string
+		PerforationMode      string
+		WobbleEnable         string
+		WobbleStep           string
+		WobbleSize           string
+		PulseWidth           string
+		EnableDotWidthAdjust string
+		DotWidth             string
+		NegativeImage        string
+		Notes                string
+	}
+
+	materialSettings := make(map[string][]Setting)
+	var materialOrder []string
+
+	for i := 1; i < len(records); i++ {
+		row := records[i]
+		if len(row) == 0 || row[colMap["Material"]] == "" {
+			continue
+		}
+
+		material := row[colMap["Material"]]
+		setting := Setting{
+			Material:             material,
+			Mode:                 row[colMap["Mode"]],
+			ImageMode:            row[colMap["Image Mode"]],
+			MaxPower:             row[colMap["Max Power (%)"]],
+			MaxPower2:            cell(row, colMap, "Max Power 2 (%)"),
+			Speed:                units.ConvertSpeed(cell(row, colMap, speedCol), speedUnit),
+			Frequency:            units.ConvertFrequency(cell(row, colMap, frequencyCol), frequencyUnit),
+			Passes:               row[colMap["Passes"]],
+			ScanInterval:         units.ConvertInterval(cell(row, colMap, intervalCol), intervalUnit),
+			BiDirectionalFill:    row[colMap["Bi-Directional Fill"]],
+			CrossHatch:           row[colMap["Cross-Hatch"]],
+			ScanAngle:            row[colMap["Scan Angle (deg)"]],
+			AngleIncrement:       row[colMap["Angle Increment (deg)"]],
+			AutoRotate:           row[colMap["Auto Rotate"]],
+			FloodFill:            row[colMap["Flood Fill"]],
+			PerforationMode:      row[colMap["Perforation Mode"]],
+			WobbleEnable:         row[colMap["Wobble Enable"]],
+			WobbleStep:           cell(row, colMap, "Wobble Step (mm)"),
+			WobbleSize:           cell(row, colMap, "Wobble Size (mm)"),
+			PulseWidth:           cell(row, colMap, "Pulse Width (ns)"),
+			EnableDotWidthAdjust: row[colMap["Enable dot-width adjust"]],
+			DotWidth:             row[colMap["Dot Width"]],
+			NegativeImage:        row[colMap["Negative Image"]],
+			Notes:                row[colMap["Notes"]],
+		}
+
+		if materialSettings[material] == nil {
+			materialOrder = append(materialOrder, material)
+		}
+		materialSettings[material] = append(materialSettings[material], setting)
+	}
+
+	// Build the library
+	library := &clb.Library{DisplayName: clb.DisplayName}
+
+	for _, material := range materialOrder {
+		settings := materialSettings[material]
+		mat := clb.Material{Name: material}
+
+		for _, setting := range settings {
+			// Determine CutSetting type
+			cutType := clb.TypeCut
+			noThickTitle := "Line Settings"
+			desc := "Line"
+
+			if setting.ImageMode != "" {
+				cutType = clb.TypeImage
+				// Format: "Image-Jarvis", "Image-3D"
+				imageModeShort := setting.ImageMode
+				if strings.Contains(strings.ToLower(imageModeShort), "3d") {
+					imageModeShort = "3D"
+				}
+				noThickTitle = "Image-" + imageModeShort
+				desc = setting.ImageMode + " Optimized"
+			} else if setting.Mode == "Fill" {
+				cutType = clb.TypeScan
+				noThickTitle = "Fill Settings"
+				desc = "Fill"
+			} else if setting.Mode == "Fill+Line" {
+				cutType = clb.TypeScanCut
+				noThickTitle = "Fill+Line Settings"
+				desc = "Fill+Line"
+			} else if setting.Mode == "Offset Fill" {
+				cutType = clb.TypeOffsetFill
+				noThickTitle = "Offset Fill Settings"
+				desc = "Offset Fill"
+			} else if setting.Mode == "Line" {
+				cutType = clb.TypeCut
+				noThickTitle = "Line Settings"
+				desc = "Line"
+			}
+
+			// Required fields
+			cs := clb.CutSetting{
+				Type:     cutType,
+				Index:    clb.Int(0),
+				Name:     clb.V(""),
+				LinkPath: clb.V(clb.LinkPath(material, noThickTitle, desc)),
+			}
+			cs.MinPower = clb.V("0")
+			cs.MaxPower = clb.V(setting.MaxPower)
+			// The second laser's power is only written when the CSV has it
+			if setting.MaxPower2 != "" {
+				cs.MaxPower2 = clb.V(setting.MaxPower2)
+			}
+			cs.Speed = clb.V(setting.Speed)
+
+			// Optional fields - only include if non-empty
+			if setting.Frequency != "" && setting.Frequency != "0" {
+				cs.Frequency = clb.V(setting.Frequency)
+			}
+
+			if setting.PulseWidth != "" && setting.PulseWidth != "0" {
+				cs.QPulseWidth = clb.V(setting.PulseWidth)
+			}
+
+			if setting.Passes != "" && setting.Passes != "1" {
+				cs.NumPasses = clb.V(setting.Passes)
+			}
+
+			if setting.AngleIncrement != "" && setting.AngleIncrement != "0" {
+				cs.AnglePerPass = clb.V(setting.AngleIncrement)
+			}
+
+			cs.Bidir = clb.Bool(isYes(setting.BiDirectionalFill))
+
+			if isYes(setting.CrossHatch) {
+				cs.CrossHatch = clb.Bool(true)
+			}
+
+			if setting.ScanInterval != "" && setting.ScanInterval != "0" {
+				cs.Interval = clb.V(setting.ScanInterval)
+			}
+
+			if setting.ScanAngle != "" && setting.ScanAngle != "0" {
+				cs.Angle = clb.V(setting.ScanAngle)
+			}
+
+			// ditherMode for Image mode
+			if setting.ImageMode != "" {
+				ditherMode := strings.ToLower(strings.ReplaceAll(setting.ImageMode, " ", ""))
+				// Fix known mappings
+				if ditherMode == "3dsliced" {
+					ditherMode = "3dslice"
+				}
+				cs.DitherMode = clb.V(ditherMode)
+			}
+
+			// Priority
+			cs.Priority = clb.Int(0)
+
+			// Optional boolean fields
+			if isYes(setting.WobbleEnable) {
+				cs.WobbleEnable = clb.Bool(true)
+				if setting.WobbleStep != "" && setting.WobbleStep != "0" {
+					cs.WobbleStep = clb.V(setting.WobbleStep)
+				}
+				if setting.WobbleSize != "" && setting.WobbleSize != "0" {
+					cs.WobbleSize = clb.V(setting.WobbleSize)
+				}
+			}
+
+			if isYes(setting.PerforationMode) {
+				cs.PerforationMode = clb.Bool(true)
+			}
+
+			if isYes(setting.NegativeImage) {
+				cs.NegativeImage = clb.Bool(true)
+			}
+
+			if isYes(setting.EnableDotWidthAdjust) {
+				cs.UseDotCorrection = clb.Bool(true)
+			} else if setting.DotWidth != "" && setting.DotWidth != "0" {
+				fmt.Fprintf(os.Stderr, "WARNING: Material=%s Mode=%s has Dot Width=%s but dot-width adjust is not enabled\n",
+					material, setting.Mode, setting.DotWidth)
+			}
+
+			if setting.DotWidth != "" && setting.DotWidth != "0" {
+				cs.DotWidth = clb.V(setting.DotWidth)
+			}
+
+			if isYes(setting.FloodFill) {
+				cs.FloodFill = clb.Bool(true)
+			}
+
+			if isYes(setting.AutoRotate) {
+				cs.AutoRotate = clb.Bool(true)
+			}
+
+			mat.Entries = append(mat.Entries, clb.Entry{
+				Thickness:    clb.NoThickness,
+				Desc:         desc,
+				NoThickTitle: noThickTitle,
+				CutSetting:   cs,
+			})
+		}
+
+		library.Materials = append(library.Materials, mat)
+	}
+
+	data, err := clb.Marshal(library)
+	if err != nil {
+		fmt.Printf("Error encoding CLB: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Write output file
+	err = os.WriteFile(outputFile, data, 0644)
+	if err != nil {
+		fmt.Printf("Error writing output file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Successfully generated %s from %s\n", outputFile, inputFile)
+	fmt.Printf("Materials: %d\n", len(materialOrder))
+	totalSettings := 0
+	for _, settings := range materialSettings {
+		totalSettings += len(settings)
+	}
+	fmt.Printf("Settings: %d\n", totalSettings)
+}
+
+// unitColumn finds the header of a column named like "Speed (mm/s)" and the
+// unit it declares. A column without a unit is in the canonical unit; a
+// missing column has an empty name, which cell reads as blank.
+func unitColumn(colMap map[string]int, name string, parseUnit func(string) (string, error)) (string, string, error) {
+	for col := range colMap {
+		if col == name {
+			unit, err := parseUnit("")
+			return col, unit, err
+		}
+		if strings.HasPrefix(col, name+" (") && strings.HasSuffix(col, ")") {
+			unit, err := parseUnit(strings.TrimSuffix(strings.TrimPrefix(col, name+" ("), ")"))
+			if err != nil {
+				return "", "", fmt.Errorf("column %q: %w", col, err)
+			}
+			return col, unit, nil
+		}
+	}
+	return "", "", nil
+}
+
+// cell returns a row's value in an optional column, or "" when the CSV
+// doesn't have the column
+func cell(row []string, colMap map[string]int, name string) string {
+	i, ok := colMap[name]
+	if name == "" || !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// isYes reports whether a CSV boolean column is set ("Yes" or "true")
+func isYes(s string) bool {
+	s = strings.TrimSpace(strings.ToLower(s))
+	return s == "yes" || s == "true"
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"laserscribe/backend/db"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// WATTAGE CONVERSION
+// =====================
+
+// A setting is converted to another wattage of the same laser type by keeping
+// its energy per mm of travel constant: watts * power% / speed. Which of power
+// and speed absorbs the difference depends on the conversion mode.
+const (
+	convertModeAuto  = "auto"  // rescale power, slow down or speed up once power is clamped
+	convertModePower = "power" // rescale power only, flag settings it cannot reach
+	convertModeSpeed = "speed" // keep power, rescale speed
+)
+
+// minUsablePower is the lowest power percentage a source fires reliably at.
+// CO2 tubes stop lasing well above 0%; other sources go down to ~1%.
+var minUsablePower = map[db.SettingsLaserType]float64{
+	db.SettingsLaserTypeCO2: 10,
+}
+
+const defaultMinUsablePower = 1.0
+
+// maxBulkConvert bounds the settings one bulk conversion request can name
+const maxBulkConvert = 500
+
+// ConversionInfo describes how a derived setting was produced. Derived
+// settings are computed, never tested, and say so.
+type ConversionInfo struct {
+	Derived         bool     `json:"derived"`
+	SourceSettingID int32    `json:"sourceSettingId"`
+	SourceWattage   int32    `json:"sourceWattage"`
+	TargetWattage   int32    `json:"targetWattage"`
+	Mode            string   `json:"mode"`
+	Reproducible    bool     `json:"reproducible"`
+	Warnings        []string `json:"warnings"`
+}
+
+// ConvertedSetting is a setting rescaled for a different wattage
+type ConvertedSetting struct {
+	SettingDetail
+	Conversion ConversionInfo `json:"conversion"`
+}
+
+// ConvertedLibrarySetting is a ConvertedSetting from the bulk endpoint, which
+// works on the same rows as the export cart
+type ConvertedLibrarySetting struct {
+	db.GetSettingsByIDsRow
+	SubLayers  []db.SettingSublayer `json:"subLayers"`
+	Conversion ConversionInfo       `json:"conversion"`
+}
+
+type energyConversion struct {
+	LaserType db.SettingsLaserType
+	From      int32
+	To        int32
+	Mode      string
+}
+
+// parseConvertMode validates a mode query/body value, defaulting to auto
+func parseConvertMode(s string) (string, bool) {
+	switch strings.ToLower(s) {
+	case "", convertModeAuto:
+		return convertModeAuto, true
+	case convertModePower:
+		return convertModePower, true
+	case convertModeSpeed:
+		return convertModeSpeed, true
+	}
+	return "", false
+}
+
+// newConversionInfo starts the record for converting one setting and adds the
+// warnings that depend only on the setting, not on its power and speed
+func (ec energyConversion) newConversionInfo(settingID int32, frequency sql.NullString) ConversionInfo {
+	info := ConversionInfo{
+		Derived:         true,
+		SourceSettingID: settingID,
+		SourceWattage:   ec.From,
+		TargetWattage:   ec.To,
+		Mode:            ec.Mode,
+		Reproducible:    true,
+		Warnings:        []string{},
+	}
+	if ec.From == ec.To {
+		info.Derived = false
+		return info
+	}
+	ratio := float64(ec.To) / float64(ec.From)
+	if ratio > 2 || ratio < 0.5 {
+		info.Warnings = append(info.Warnings, fmt.Sprintf(
+			"%dW and %dW sources differ in spot size and beam quality; test before use", ec.From, ec.To))
+	}
+	if frequency.Valid && frequency.String != "" && frequency.String != "0" {
+		info.Warnings = append(info.Warnings, "pulse energy changes with wattage; frequency was not adjusted")
+	}
+	return info
+}
+
+// apply rescales one power/speed group in place. Secondary powers (the second
+// laser of a dual-source machine) are scaled by the same factor as maxPower.
+func (ec energyConversion) apply(info *ConversionInfo, label string, maxPower, minPower, speed *string, secondary ...*sql.NullString) {
+	if ec.From == ec.To {
+		return
+	}
+	power, err1 := strconv.ParseFloat(*maxPower, 64)
+	v, err2 := strconv.ParseFloat(*speed, 64)
+	if err1 != nil || err2 != nil || power <= 0 || v <= 0 {
+		return
+	}
+
+	ratio := float64(ec.From) / float64(ec.To)
+	floor, ok := minUsablePower[ec.LaserType]
+	if !ok {
+		floor = defaultMinUsablePower
+	}
+
+	newPower, newSpeed := power, v
+	factor := 1.0
+	if ec.Mode == convertModeSpeed {
+		newSpeed = v / ratio
+	} else {
+		needed := power * ratio
+		newPower = math.Min(math.Max(needed, floor), 100)
+		factor = newPower / power
+		switch {
+		case newPower == needed:
+		case ec.Mode == convertModeAuto:
+			// Keep watts * power / speed constant with the clamped power
+			newSpeed = v * newPower / needed
+			info.Warnings = append(info.Warnings, fmt.Sprintf(
+				"%s power clamped to %s%%; speed changed to compensate", label, formatScaled(newPower)))
+		default:
+			// Scale the other powers as far as they can go on their own
+			factor = ratio
+			info.Reproducible = false
+			info.Warnings = append(info.Warnings, fmt.Sprintf(
+				"%s needs %s%% power at %dW; clamped to %s%%", label, formatScaled(needed), ec.To, formatScaled(newPower)))
+		}
+	}
+
+	// A faster speed than the laser type can run at is clamped, leaving the
+	// setting with more energy per mm than the source had
+	if limits, ok := laserTypeLimits[ec.LaserType]; ok && newSpeed > limits.MaxSpeed {
+		info.Reproducible = false
+		info.Warnings = append(info.Warnings, fmt.Sprintf(
+			"%s needs %s mm/s at %dW; clamped to the %s limit of %s mm/s",
+			label, formatScaled(newSpeed), ec.To, ec.LaserType, formatScaled(limits.MaxSpeed)))
+		newSpeed = limits.MaxSpeed
+	}
+
+	*maxPower = formatScaled(newPower)
+	*minPower = scalePower(*minPower, factor, newPower)
+	*speed = formatScaled(newSpeed)
+	for _, p := range secondary {
+		if p.Valid {
+			p.String = scalePower(p.String, factor, 100)
+		}
+	}
+}
+
+// scalePower multiplies a power percentage by factor, capped at limit
+func scalePower(s string, factor, limit float64) string {
+	p, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return s
+	}
+	return formatScaled(math.Min(p*factor, limit))
+}
+
+// formatScaled rounds a converted value to the two decimals the DECIMAL
+// columns store
+func formatScaled(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+// convertSublayers rescales a setting's sublayers along with it
+func (ec energyConversion) convertSublayers(info *ConversionInfo, subLayers []db.SettingSublayer) {
+	for i := range subLayers {
+		sl := &subLayers[i]
+		ec.apply(info, fmt.Sprintf("sub-layer %d", sl.SublayerIndex),
+			&sl.MaxPower, &sl.MinPower, &sl.Speed, &sl.MaxPower2, &sl.MinPower2)
+	}
+}
+
+// convertLibrarySetting rescales a setting as loaded for export
+func (ec energyConversion) convertLibrarySetting(setting *db.GetSettingsByIDsRow, subLayers []db.SettingSublayer) ConversionInfo {
+	conv := ec
+	conv.From = setting.Wattage
+	info := conv.newConversionInfo(setting.ID, setting.Frequency)
+	conv.apply(&info, "setting", &setting.MaxPower, &setting.MinPower, &setting.Speed, &setting.MaxPower2, &setting.MinPower2)
+	conv.convertSublayers(&info, subLayers)
+	setting.Wattage = ec.To
+	return info
+}
+
+// parseTargetWattage reads the wattage to convert to
+func parseTargetWattage(s string) (int32, error) {
+	w, err := strconv.Atoi(s)
+	if err != nil || w <= 0 {
+		return 0, fmt.Errorf("wattage must be a positive number")
+	}
+	return int32(w), nil
+}
+
+// convertSettingHandler returns a setting rescaled to ?wattage= for the same
+// laser type. ?mode= picks power, speed or auto (default).
+func convertSettingHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid setting id"})
+		return
+	}
+	wattage, err := parseTargetWattage(c.Query("wattage"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	mode, ok := parseConvertMode(c.Query("mode"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be auto, power or speed"})
+		return
+	}
+
+	setting, err := queries.GetSettingByID(ctx, int32(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "setting not found"})
+		return
+	}
+	if v := c.Query("laser_type"); v != "" && db.SettingsLaserType(v) != setting.LaserType {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("cannot convert a %s setting to a %s laser", setting.LaserType, v)})
+		return
+	}
+
+	subLayers, err := queries.GetSublayersBySetting(ctx, setting.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if subLayers == nil {
+		subLayers = []db.SettingSublayer{}
+	}
+
+	ec := energyConversion{LaserType: setting.LaserType, From: setting.Wattage, To: wattage, Mode: mode}
+	info := ec.newConversionInfo(setting.ID, setting.Frequency)
+	ec.apply(&info, "setting", &setting.MaxPower, &setting.MinPower, &setting.Speed, &setting.MaxPower2, &setting.MinPower2)
+	ec.convertSublayers(&info, subLayers)
+	setting.Wattage = wattage
+	setting.LaserModelID = sql.NullInt32{}
+	// The stored metrics describe the source setting
+	setting.EffectiveWatts = sql.NullString{}
+	setting.LineEnergy = sql.NullString{}
+	setting.Fluence = sql.NullString{}
+	setting.PulseEnergy = sql.NullString{}
+
+	c.JSON(http.StatusOK, ConvertedSetting{
+		SettingDetail: SettingDetail{GetSettingByIDRow: setting, SubLayers: subLayers},
+		Conversion:    info,
+	})
+}
+
+type BulkConvertRequest struct {
+	SettingIDs []int32 `json:"settingIds" binding:"required"`
+	Wattage    int32   `json:"wattage" binding:"required"`
+	LaserType  string  `json:"laserType"`
+	Mode       string  `json:"mode"`
+}
+
+// convertBulkSettingsHandler converts a cart of settings to one wattage
+func convertBulkSettingsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req BulkConvertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Wattage <= 0 || len(req.SettingIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "settingIds and a positive wattage are required"})
+		return
+	}
+	if len(req.SettingIDs) > maxBulkConvert {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d settings can be converted at once", maxBulkConvert)})
+		return
+	}
+	mode, ok := parseConvertMode(req.Mode)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be auto, power or speed"})
+		return
+	}
+
+	settings, err := queries.GetSettingsByIDs(ctx, req.SettingIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// Like the export, refuse a partial result and name the IDs that no
+	// longer exist
+	found := make(map[int32]bool, len(settings))
+	for _, setting := range settings {
+		found[setting.ID] = true
+	}
+	missing := []int32{}
+	for _, id := range req.SettingIDs {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "some requested settings were not found",
+			"missing": missing,
+		})
+		return
+	}
+
+	subLayers, err := queries.GetSublayersBySettingIDs(ctx, req.SettingIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	subLayersBySetting := make(map[int32][]db.SettingSublayer)
+	for _, sl := range subLayers {
+		subLayersBySetting[sl.SettingID] = append(subLayersBySetting[sl.SettingID], sl)
+	}
+
+	laserType, errs := conversionLaserType(settings, req.LaserType)
+	if len(errs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "settings use different laser types", "details": errs})
+		return
+	}
+
+	ec := energyConversion{LaserType: laserType, To: req.Wattage, Mode: mode}
+	converted := make([]ConvertedLibrarySetting, len(settings))
+	for i, setting := range settings {
+		sls := subLayersBySetting[setting.ID]
+		if sls == nil {
+			sls = []db.SettingSublayer{}
+		}
+		info := ec.convertLibrarySetting(&setting, sls)
+		converted[i] = ConvertedLibrarySetting{GetSettingsByIDsRow: setting, SubLayers: sls, Conversion: info}
+	}
+	c.JSON(http.StatusOK, converted)
+}
+
+// conversionLaserType returns the laser type shared by settings, which must
+// also match want when it is set. Wattage scaling never crosses laser types.
+func conversionLaserType(settings []db.GetSettingsByIDsRow, want string) (db.SettingsLaserType, []string) {
+	laserType := db.SettingsLaserType(want)
+	var errs []string
+	for _, setting := range settings {
+		if laserType == "" {
+			laserType = setting.LaserType
+		}
+		if setting.LaserType != laserType {
+			errs = append(errs, fmt.Sprintf("setting %d is %s, not %s", setting.ID, setting.LaserType, laserType))
+		}
+	}
+	return laserType, errs
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"database/sql"
+	"laserscribe/backend/db"
+	"strings"
+	"testing"
+)
+
+func TestEnergyConversionApply(t *testing.T) {
+	// max power, min power, speed
+	type group [3]string
+	cases := []struct {
+		name         string
+		conv         energyConversion
+		in, want     group
+		reproducible bool
+		warning      string
+	}{
+		{
+			name:         "same wattage is unchanged",
+			conv:         energyConversion{db.SettingsLaserTypeCO2, 60, 60, convertModeAuto},
+			in:           group{"50", "20", "100"},
+			want:         group{"50", "20", "100"},
+			reproducible: true,
+		},
+		{
+			name:         "power mode halves power on twice the wattage",
+			conv:         energyConversion{db.SettingsLaserTypeCO2, 40, 80, convertModePower},
+			in:           group{"60", "30", "100"},
+			want:         group{"30", "15", "100"},
+			reproducible: true,
+		},
+		{
+			name:         "power mode flags a power above 100%",
+			conv:         energyConversion{db.SettingsLaserTypeCO2, 80, 40, convertModePower},
+			in:           group{"80", "20", "100"},
+			want:         group{"100", "40", "100"},
+			reproducible: false,
+			warning:      "needs 160% power at 40W",
+		},
+		{
+			name:         "auto mode slows down once power is clamped",
+			conv:         energyConversion{db.SettingsLaserTypeCO2, 80, 40, convertModeAuto},
+			in:           group{"80", "20", "100"},
+			want:         group{"100", "25", "62.5"},
+			reproducible: true,
+			warning:      "power clamped to 100%",
+		},
+		{
+			name:         "auto mode speeds up below the CO2 power floor",
+			conv:         energyConversion{db.SettingsLaserTypeCO2, 20, 80, convertModeAuto},
+			in:           group{"20", "12", "100"},
+			want:         group{"10", "6", "200"},
+			reproducible: true,
+			warning:      "power clamped to 10%",
+		},
+		{
+			name:         "speed mode keeps power",
+			conv:         energyConversion{db.SettingsLaserTypeFiber, 20, 60, convertModeSpeed},
+			in:           group{"75", "0", "1000"},
+			want:         group{"75", "0", "3000"},
+			reproducible: true,
+		},
+		{
+			name:         "speed mode clamps to the laser type's top speed",
+			conv:         energyConversion{db.SettingsLaserTypeDiode, 5, 20, convertModeSpeed},
+			in:           group{"100", "0", "2000"},
+			want:         group{"100", "0", "5000"},
+			reproducible: false,
+			warning:      "clamped to the Diode limit of 5000 mm/s",
+		},
+		{
+			name:         "unparseable values are left alone",
+			conv:         energyConversion{db.SettingsLaserTypeCO2, 40, 80, convertModePower},
+			in:           group{"", "0", "100"},
+			want:         group{"", "0", "100"},
+			reproducible: true,
+		},
+	}
+	for _, tc := range cases {
+		info := ConversionInfo{Reproducible: true}
+		got := tc.in
+		tc.conv.apply(&info, "setting", &got[0], &got[1], &got[2])
+		if got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+		if info.Reproducible != tc.reproducible {
+			t.Errorf("%s: reproducible = %v, want %v", tc.name, info.Reproducible, tc.reproducible)
+		}
+		warnings := strings.Join(info.Warnings, "; ")
+		if tc.warning == "" && warnings != "" {
+			t.Errorf("%s: unexpected warnings %q", tc.name, warnings)
+		}
+		if tc.warning != "" && !strings.Contains(warnings, tc.warning) {
+			t.Errorf("%s: warnings %q do not mention %q", tc.name, warnings, tc.warning)
+		}
+	}
+}
+
+func TestEnergyConversionApplySecondaryPower(t *testing.T) {
+	conv := energyConversion{db.SettingsLaserTypeFiber, 20, 40, convertModePower}
+	info := ConversionInfo{Reproducible: true}
+	maxPower, minPower, speed := "80", "10", "500"
+	maxPower2 := sql.NullString{String: "60", Valid: true}
+	minPower2 := sql.NullString{}
+	conv.apply(&info, "setting", &maxPower, &minPower, &speed, &maxPower2, &minPower2)
+	if maxPower != "40" || maxPower2.String != "30" {
+		t.Errorf("got max %s, max2 %s, want 40 and 30", maxPower, maxPower2.String)
+	}
+	if minPower2.Valid {
+		t.Errorf("unset minPower2 became %q", minPower2.String)
+	}
+}
+
+func TestConvertSublayers(t *testing.T) {
+	conv := energyConversion{db.SettingsLaserTypeCO2, 80, 40, convertModePower}
+	subLayers := []db.SettingSublayer{
+		{SublayerIndex: 1, MaxPower: "40", MinPower: "10", Speed: "200"},
+		{SublayerIndex: 2, MaxPower: "90", MinPower: "10", Speed: "50"},
+	}
+	info := ConversionInfo{Reproducible: true}
+	conv.convertSublayers(&info, subLayers)
+
+	if subLayers[0].MaxPower != "80" || subLayers[0].MinPower != "20" {
+		t.Errorf("sub-layer 1 = %s/%s, want 80/20", subLayers[0].MaxPower, subLayers[0].MinPower)
+	}
+	if subLayers[1].MaxPower != "100" {
+		t.Errorf("sub-layer 2 max power = %s, want 100", subLayers[1].MaxPower)
+	}
+	if info.Reproducible {
+		t.Errorf("clamped sub-layer left the conversion reproducible")
+	}
+	if len(info.Warnings) != 1 || !strings.HasPrefix(info.Warnings[0], "sub-layer 2 ") {
+		t.Errorf("warnings = %q, want one for sub-layer 2", info.Warnings)
+	}
+}
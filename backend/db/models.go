@@ -10,6 +10,226 @@ import (
 	"fmt"
 )
 
+type ImportsLaserType string
+
+const (
+	ImportsLaserTypeCO2      ImportsLaserType = "CO2"
+	ImportsLaserTypeFiber    ImportsLaserType = "Fiber"
+	ImportsLaserTypeDiode    ImportsLaserType = "Diode"
+	ImportsLaserTypeUV       ImportsLaserType = "UV"
+	ImportsLaserTypeInfrared ImportsLaserType = "Infrared"
+)
+
+func (e *ImportsLaserType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ImportsLaserType(s)
+	case string:
+		*e = ImportsLaserType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ImportsLaserType: %T", src)
+	}
+	return nil
+}
+
+type NullImportsLaserType struct {
+	ImportsLaserType ImportsLaserType
+	Valid            bool // Valid is true if ImportsLaserType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullImportsLaserType) Scan(value interface{}) error {
+	if value == nil {
+		ns.ImportsLaserType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ImportsLaserType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullImportsLaserType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ImportsLaserType), nil
+}
+
+type LaserModelsLaserType string
+
+const (
+	LaserModelsLaserTypeCO2      LaserModelsLaserType = "CO2"
+	LaserModelsLaserTypeFiber    LaserModelsLaserType = "Fiber"
+	LaserModelsLaserTypeDiode    LaserModelsLaserType = "Diode"
+	LaserModelsLaserTypeUV       LaserModelsLaserType = "UV"
+	LaserModelsLaserTypeInfrared LaserModelsLaserType = "Infrared"
+)
+
+func (e *LaserModelsLaserType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LaserModelsLaserType(s)
+	case string:
+		*e = LaserModelsLaserType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LaserModelsLaserType: %T", src)
+	}
+	return nil
+}
+
+type NullLaserModelsLaserType struct {
+	LaserModelsLaserType LaserModelsLaserType
+	Valid                bool // Valid is true if LaserModelsLaserType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLaserModelsLaserType) Scan(value interface{}) error {
+	if value == nil {
+		ns.LaserModelsLaserType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LaserModelsLaserType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLaserModelsLaserType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LaserModelsLaserType), nil
+}
+
+type LaserModelsMotionSystem string
+
+const (
+	LaserModelsMotionSystemGalvo  LaserModelsMotionSystem = "Galvo"
+	LaserModelsMotionSystemGantry LaserModelsMotionSystem = "Gantry"
+)
+
+func (e *LaserModelsMotionSystem) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LaserModelsMotionSystem(s)
+	case string:
+		*e = LaserModelsMotionSystem(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LaserModelsMotionSystem: %T", src)
+	}
+	return nil
+}
+
+type NullLaserModelsMotionSystem struct {
+	LaserModelsMotionSystem LaserModelsMotionSystem
+	Valid                   bool // Valid is true if LaserModelsMotionSystem is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLaserModelsMotionSystem) Scan(value interface{}) error {
+	if value == nil {
+		ns.LaserModelsMotionSystem, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LaserModelsMotionSystem.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLaserModelsMotionSystem) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LaserModelsMotionSystem), nil
+}
+
+type RecipeSetsLaserType string
+
+const (
+	RecipeSetsLaserTypeCO2      RecipeSetsLaserType = "CO2"
+	RecipeSetsLaserTypeFiber    RecipeSetsLaserType = "Fiber"
+	RecipeSetsLaserTypeDiode    RecipeSetsLaserType = "Diode"
+	RecipeSetsLaserTypeUV       RecipeSetsLaserType = "UV"
+	RecipeSetsLaserTypeInfrared RecipeSetsLaserType = "Infrared"
+)
+
+func (e *RecipeSetsLaserType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RecipeSetsLaserType(s)
+	case string:
+		*e = RecipeSetsLaserType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RecipeSetsLaserType: %T", src)
+	}
+	return nil
+}
+
+type NullRecipeSetsLaserType struct {
+	RecipeSetsLaserType RecipeSetsLaserType
+	Valid               bool // Valid is true if RecipeSetsLaserType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullRecipeSetsLaserType) Scan(value interface{}) error {
+	if value == nil {
+		ns.RecipeSetsLaserType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.RecipeSetsLaserType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullRecipeSetsLaserType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.RecipeSetsLaserType), nil
+}
+
+type SettingRevisionsAction string
+
+const (
+	SettingRevisionsActionCreate SettingRevisionsAction = "create"
+	SettingRevisionsActionUpdate SettingRevisionsAction = "update"
+	SettingRevisionsActionRevert SettingRevisionsAction = "revert"
+)
+
+func (e *SettingRevisionsAction) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = SettingRevisionsAction(s)
+	case string:
+		*e = SettingRevisionsAction(s)
+	default:
+		return fmt.Errorf("unsupported scan type for SettingRevisionsAction: %T", src)
+	}
+	return nil
+}
+
+type NullSettingRevisionsAction struct {
+	SettingRevisionsAction SettingRevisionsAction
+	Valid                  bool // Valid is true if SettingRevisionsAction is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullSettingRevisionsAction) Scan(value interface{}) error {
+	if value == nil {
+		ns.SettingRevisionsAction, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.SettingRevisionsAction.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullSettingRevisionsAction) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.SettingRevisionsAction), nil
+}
+
 type SettingsLaserType string
 
 const (
@@ -58,9 +278,11 @@ func (ns NullSettingsLaserType) Value() (driver.Value, error) {
 type SettingsOperationType string
 
 const (
-	SettingsOperationTypeCut     SettingsOperationType = "Cut"
-	SettingsOperationTypeScan    SettingsOperationType = "Scan"
-	SettingsOperationTypeScanCut SettingsOperationType = "ScanCut"
+	SettingsOperationTypeCut        SettingsOperationType = "Cut"
+	SettingsOperationTypeScan       SettingsOperationType = "Scan"
+	SettingsOperationTypeScanCut    SettingsOperationType = "ScanCut"
+	SettingsOperationTypeImage      SettingsOperationType = "Image"
+	SettingsOperationTypeOffsetFill SettingsOperationType = "OffsetFill"
 )
 
 func (e *SettingsOperationType) Scan(src interface{}) error {
@@ -98,6 +320,219 @@ func (ns NullSettingsOperationType) Value() (driver.Value, error) {
 	return string(ns.SettingsOperationType), nil
 }
 
+type TaxonomyAuditEntityType string
+
+const (
+	TaxonomyAuditEntityTypeCategory TaxonomyAuditEntityType = "category"
+	TaxonomyAuditEntityTypeMaterial TaxonomyAuditEntityType = "material"
+	TaxonomyAuditEntityTypeAlias    TaxonomyAuditEntityType = "alias"
+)
+
+func (e *TaxonomyAuditEntityType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TaxonomyAuditEntityType(s)
+	case string:
+		*e = TaxonomyAuditEntityType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TaxonomyAuditEntityType: %T", src)
+	}
+	return nil
+}
+
+type NullTaxonomyAuditEntityType struct {
+	TaxonomyAuditEntityType TaxonomyAuditEntityType
+	Valid                   bool // Valid is true if TaxonomyAuditEntityType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTaxonomyAuditEntityType) Scan(value interface{}) error {
+	if value == nil {
+		ns.TaxonomyAuditEntityType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TaxonomyAuditEntityType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTaxonomyAuditEntityType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TaxonomyAuditEntityType), nil
+}
+
+type TestReportsLaserType string
+
+const (
+	TestReportsLaserTypeCO2      TestReportsLaserType = "CO2"
+	TestReportsLaserTypeFiber    TestReportsLaserType = "Fiber"
+	TestReportsLaserTypeDiode    TestReportsLaserType = "Diode"
+	TestReportsLaserTypeUV       TestReportsLaserType = "UV"
+	TestReportsLaserTypeInfrared TestReportsLaserType = "Infrared"
+)
+
+func (e *TestReportsLaserType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TestReportsLaserType(s)
+	case string:
+		*e = TestReportsLaserType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TestReportsLaserType: %T", src)
+	}
+	return nil
+}
+
+type NullTestReportsLaserType struct {
+	TestReportsLaserType TestReportsLaserType
+	Valid                bool // Valid is true if TestReportsLaserType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTestReportsLaserType) Scan(value interface{}) error {
+	if value == nil {
+		ns.TestReportsLaserType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TestReportsLaserType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTestReportsLaserType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TestReportsLaserType), nil
+}
+
+type TestReportsOutcome string
+
+const (
+	TestReportsOutcomeWorked   TestReportsOutcome = "worked"
+	TestReportsOutcomeAdjusted TestReportsOutcome = "adjusted"
+	TestReportsOutcomeFailed   TestReportsOutcome = "failed"
+)
+
+func (e *TestReportsOutcome) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TestReportsOutcome(s)
+	case string:
+		*e = TestReportsOutcome(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TestReportsOutcome: %T", src)
+	}
+	return nil
+}
+
+type NullTestReportsOutcome struct {
+	TestReportsOutcome TestReportsOutcome
+	Valid              bool // Valid is true if TestReportsOutcome is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTestReportsOutcome) Scan(value interface{}) error {
+	if value == nil {
+		ns.TestReportsOutcome, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TestReportsOutcome.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTestReportsOutcome) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TestReportsOutcome), nil
+}
+
+type UserMachinesLaserType string
+
+const (
+	UserMachinesLaserTypeCO2      UserMachinesLaserType = "CO2"
+	UserMachinesLaserTypeFiber    UserMachinesLaserType = "Fiber"
+	UserMachinesLaserTypeDiode    UserMachinesLaserType = "Diode"
+	UserMachinesLaserTypeUV       UserMachinesLaserType = "UV"
+	UserMachinesLaserTypeInfrared UserMachinesLaserType = "Infrared"
+)
+
+func (e *UserMachinesLaserType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = UserMachinesLaserType(s)
+	case string:
+		*e = UserMachinesLaserType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for UserMachinesLaserType: %T", src)
+	}
+	return nil
+}
+
+type NullUserMachinesLaserType struct {
+	UserMachinesLaserType UserMachinesLaserType
+	Valid                 bool // Valid is true if UserMachinesLaserType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullUserMachinesLaserType) Scan(value interface{}) error {
+	if value == nil {
+		ns.UserMachinesLaserType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.UserMachinesLaserType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullUserMachinesLaserType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.UserMachinesLaserType), nil
+}
+
+type Import struct {
+	ID             int32
+	UserID         int32
+	Filename       string
+	ContentHash    string
+	LaserMakeModel string
+	LaserType      ImportsLaserType
+	Wattage        int32
+	ImportedCount  int32
+	SkippedCount   int32
+	ConflictCount  int32
+	FailedCount    int32
+	Errors         sql.NullString
+	CreatedAt      sql.NullTime
+}
+
+type LaserManufacturer struct {
+	ID        int32
+	Name      string
+	Slug      string
+	CreatedAt sql.NullTime
+}
+
+type LaserModel struct {
+	ID             int32
+	ManufacturerID int32
+	Name           string
+	Slug           string
+	LaserType      LaserModelsLaserType
+	Wattage        int32
+	Source         sql.NullString
+	LensMm         sql.NullString
+	FieldSizeMm    sql.NullString
+	MotionSystem   LaserModelsMotionSystem
+	CreatedAt      sql.NullTime
+}
+
 type Material struct {
 	ID         int32
 	CategoryID int32
@@ -116,12 +551,46 @@ type MaterialCategory struct {
 	Name string
 }
 
+type RecipeSet struct {
+	ID           int32
+	UserID       int32
+	MaterialID   int32
+	LaserType    RecipeSetsLaserType
+	Wattage      int32
+	LaserModelID sql.NullInt32
+	Name         string
+	Description  sql.NullString
+	PublishedAt  sql.NullTime
+	CreatedAt    sql.NullTime
+	UpdatedAt    sql.NullTime
+}
+
+type RecipeSetColor struct {
+	ID          int32
+	RecipeSetID int32
+	SettingID   int32
+	Position    int32
+	ColorName   sql.NullString
+	Hex         string
+}
+
+type RecipeSetVote struct {
+	ID          int32
+	UserID      int32
+	RecipeSetID int32
+	Value       int8
+	CreatedAt   sql.NullTime
+}
+
 type Setting struct {
 	ID                   int32
 	UserID               int32
 	MaterialID           int32
+	ImportID             sql.NullInt32
+	ForkedFromID         sql.NullInt32
 	LaserType            SettingsLaserType
 	Wattage              int32
+	LaserModelID         sql.NullInt32
 	OperationType        SettingsOperationType
 	MaxPower             string
 	MinPower             string
@@ -144,6 +613,9 @@ type Setting struct {
 	Frequency            sql.NullString
 	WobbleEnable         sql.NullBool
 	UseDotCorrection     sql.NullBool
+	PulseWidth           sql.NullString
+	WobbleDiameter       sql.NullString
+	WobbleStep           sql.NullString
 	PerforationMode      bool
 	EnableDotWidthAdjust bool
 	DotWidth             sql.NullString
@@ -156,11 +628,76 @@ type Setting struct {
 	Priority             sql.NullInt32
 	TabCount             sql.NullInt32
 	TabCountMax          sql.NullInt32
+	ThicknessMm          sql.NullString
+	Color                sql.NullString
+	Finish               sql.NullString
+	Grade                sql.NullString
+	TestCount            int32
+	Confidence           sql.NullString
+	EffectiveWatts       sql.NullString
+	LineEnergy           sql.NullString
+	Fluence              sql.NullString
+	PulseEnergy          sql.NullString
 	Notes                sql.NullString
 	CreatedAt            sql.NullTime
 	UpdatedAt            sql.NullTime
 }
 
+type SettingRevision struct {
+	ID           int32
+	SettingID    int32
+	Revision     int32
+	UserID       sql.NullInt32
+	Action       SettingRevisionsAction
+	RevertedFrom sql.NullInt32
+	Snapshot     string
+	CreatedAt    sql.NullTime
+}
+
+type SettingSublayer struct {
+	ID            int32
+	SettingID     int32
+	SublayerIndex int32
+	SublayerType  string
+	IsCleanup     bool
+	MaxPower      string
+	MinPower      string
+	MaxPower2     sql.NullString
+	MinPower2     sql.NullString
+	Speed         string
+	NumPasses     int32
+	Frequency     sql.NullString
+	ScanInterval  sql.NullString
+	Subname       sql.NullString
+}
+
+type TaxonomyAudit struct {
+	ID          int32
+	AdminUserID sql.NullInt32
+	Action      string
+	EntityType  TaxonomyAuditEntityType
+	EntityID    int32
+	Details     sql.NullString
+	CreatedAt   sql.NullTime
+}
+
+type TestReport struct {
+	ID             int32
+	SettingID      int32
+	UserID         int32
+	Revision       int32
+	LaserModelID   sql.NullInt32
+	MakeModel      sql.NullString
+	LaserType      TestReportsLaserType
+	Wattage        int32
+	MaterialBatch  sql.NullString
+	Outcome        TestReportsOutcome
+	AdjustedValues sql.NullString
+	Notes          sql.NullString
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+}
+
 type User struct {
 	ID                  int32
 	FirstName           string
@@ -172,13 +709,30 @@ type User struct {
 	IsAdmin             bool
 	VerificationToken   sql.NullString
 	VerificationExpires sql.NullTime
+	SpeedUnit           string
+	IntervalUnit        string
 	CreatedAt           sql.NullTime
 }
 
+type UserMachine struct {
+	ID           int32
+	UserID       int32
+	LaserModelID sql.NullInt32
+	MakeModel    sql.NullString
+	LaserType    UserMachinesLaserType
+	Wattage      int32
+	LensMm       sql.NullString
+	Notes        sql.NullString
+	IsDefault    bool
+	CreatedAt    sql.NullTime
+	UpdatedAt    sql.NullTime
+}
+
 type Vote struct {
 	ID        int32
 	UserID    int32
 	SettingID int32
 	Value     int8
+	Revision  sql.NullInt32
 	CreatedAt sql.NullTime
 }
@@ -29,6 +29,15 @@ WHERE verification_token = ?;
 UPDATE users SET email_verified = TRUE, verification_token = NULL, verification_expires = NULL
 WHERE id = ?;
 
+-- name: GetUserUnits :one
+SELECT speed_unit, interval_unit
+FROM users
+WHERE id = ?;
+
+-- name: UpdateUserUnits :exec
+UPDATE users SET speed_unit = ?, interval_unit = ?
+WHERE id = ?;
+
 -- =====================
 -- MATERIAL CATEGORIES
 -- =====================
@@ -90,6 +99,15 @@ FROM material_aliases
 WHERE material_id = ?
 ORDER BY alias;
 
+-- name: GetAllMaterialAliases :many
+SELECT id, material_id, alias
+FROM material_aliases
+ORDER BY material_id, alias;
+
+-- name: CreateMaterialAlias :execresult
+INSERT INTO material_aliases (material_id, alias)
+VALUES (?, ?);
+
 -- name: GetMaterialByName :one
 SELECT id, category_id, name, slug
 FROM materials
@@ -100,22 +118,132 @@ LIMIT 1;
 INSERT INTO materials (category_id, name, slug)
 VALUES (?, ?, ?);
 
+-- =====================
+-- LASER MACHINES
+-- =====================
+
+-- name: GetLaserManufacturers :many
+SELECT id, name, slug, created_at
+FROM laser_manufacturers
+ORDER BY name;
+
+-- name: GetLaserManufacturerBySlug :one
+SELECT id, name, slug, created_at
+FROM laser_manufacturers
+WHERE slug = ?;
+
+-- name: CreateLaserManufacturer :execresult
+INSERT INTO laser_manufacturers (name, slug)
+VALUES (?, ?);
+
+-- name: SearchLaserModels :many
+SELECT lm.id, lm.manufacturer_id, lm.name, lm.slug, lm.laser_type, lm.wattage,
+       lm.source, lm.lens_mm, lm.field_size_mm, lm.motion_system,
+       mf.name as manufacturer_name
+FROM laser_models lm
+JOIN laser_manufacturers mf ON lm.manufacturer_id = mf.id
+WHERE (sqlc.narg(query) IS NULL
+       OR CONCAT(mf.name, ' ', lm.name) LIKE CONCAT('%', sqlc.narg(query), '%')
+       OR lm.name LIKE CONCAT('%', sqlc.narg(query), '%'))
+  AND (sqlc.narg(laser_type) IS NULL OR lm.laser_type = sqlc.narg(laser_type))
+  AND (sqlc.narg(wattage) IS NULL OR lm.wattage = sqlc.narg(wattage))
+ORDER BY mf.name, lm.name, lm.wattage
+LIMIT 20;
+
+-- name: GetLaserModelByID :one
+SELECT lm.id, lm.manufacturer_id, lm.name, lm.slug, lm.laser_type, lm.wattage,
+       lm.source, lm.lens_mm, lm.field_size_mm, lm.motion_system,
+       mf.name as manufacturer_name
+FROM laser_models lm
+JOIN laser_manufacturers mf ON lm.manufacturer_id = mf.id
+WHERE lm.id = ?;
+
+-- name: GetLaserModelBySlug :one
+SELECT id, manufacturer_id, name, slug, laser_type, wattage,
+       source, lens_mm, field_size_mm, motion_system, created_at
+FROM laser_models
+WHERE manufacturer_id = ? AND slug = ? AND laser_type = ? AND wattage = ?;
+
+-- name: CreateLaserModel :execresult
+INSERT INTO laser_models (
+    manufacturer_id, name, slug, laser_type, wattage,
+    source, lens_mm, field_size_mm, motion_system
+) VALUES (
+    ?, ?, ?, ?, ?,
+    ?, ?, ?, ?
+);
+
+-- =====================
+-- USER MACHINES
+-- =====================
+
+-- name: GetUserMachines :many
+SELECT id, user_id, laser_model_id, make_model, laser_type, wattage,
+       lens_mm, notes, is_default, created_at, updated_at
+FROM user_machines
+WHERE user_id = ?
+ORDER BY is_default DESC, created_at;
+
+-- name: GetUserMachineByID :one
+SELECT id, user_id, laser_model_id, make_model, laser_type, wattage,
+       lens_mm, notes, is_default, created_at, updated_at
+FROM user_machines
+WHERE id = ? AND user_id = ?;
+
+-- name: GetDefaultUserMachine :one
+SELECT id, user_id, laser_model_id, make_model, laser_type, wattage,
+       lens_mm, notes, is_default, created_at, updated_at
+FROM user_machines
+WHERE user_id = ? AND is_default = TRUE
+LIMIT 1;
+
+-- name: CreateUserMachine :execresult
+INSERT INTO user_machines (
+    user_id, laser_model_id, make_model, laser_type, wattage,
+    lens_mm, notes, is_default
+) VALUES (
+    ?, ?, ?, ?, ?,
+    ?, ?, ?
+);
+
+-- name: UpdateUserMachine :exec
+UPDATE user_machines SET
+    laser_model_id = ?, make_model = ?, laser_type = ?, wattage = ?,
+    lens_mm = ?, notes = ?
+WHERE id = ? AND user_id = ?;
+
+-- name: DeleteUserMachine :execrows
+DELETE FROM user_machines
+WHERE id = ? AND user_id = ?;
+
+-- name: ClearDefaultUserMachine :exec
+UPDATE user_machines SET is_default = FALSE
+WHERE user_id = ?;
+
+-- name: SetDefaultUserMachine :execrows
+UPDATE user_machines SET is_default = TRUE
+WHERE id = ? AND user_id = ?;
+
 -- =====================
 -- SETTINGS
 -- =====================
 
 -- name: GetSettingByID :one
-SELECT s.id, s.user_id, s.material_id,
-       s.laser_type, s.wattage, s.operation_type,
+SELECT s.id, s.user_id, s.material_id, s.forked_from_id,
+       s.laser_type, s.wattage, s.laser_model_id, s.operation_type,
        s.max_power, s.min_power, s.max_power2, s.min_power2, s.speed,
        s.num_passes, s.z_offset, s.z_per_pass,
        s.scan_interval, s.angle, s.angle_per_pass,
        s.cross_hatch, s.bidir, s.scan_opt,
        s.flood_fill, s.auto_rotate, s.overscan, s.overscan_percent,
        s.frequency, s.wobble_enable, s.use_dot_correction,
+       s.pulse_width, s.wobble_diameter, s.wobble_step,
        s.kerf, s.run_blower,
        s.layer_name, s.layer_subname,
        s.priority, s.tab_count, s.tab_count_max,
+       s.thickness_mm, s.color, s.finish, s.grade,
+       s.test_count, s.confidence,
+       s.effective_watts, s.line_energy, s.fluence, s.pulse_energy,
        s.notes, s.created_at, s.updated_at,
        u.first_name, u.last_name, u.display_name,
        mat.name as material_name, mc.name as category_name,
@@ -134,28 +262,146 @@ SELECT s.id, s.user_id, s.material_id,
        s.laser_type, s.wattage, s.operation_type,
        s.max_power, s.min_power, s.speed,
        s.num_passes, s.scan_interval, s.frequency,
+       s.pulse_width, s.wobble_enable, s.wobble_diameter, s.wobble_step,
        s.cross_hatch, s.bidir, s.angle, s.angle_per_pass,
        s.image_mode, s.negative_image,
        s.use_dot_correction, s.dot_width,
+       s.thickness_mm, s.color, s.finish, s.grade,
+       s.test_count, s.confidence,
+       s.effective_watts, s.line_energy, s.fluence, s.pulse_energy,
        s.notes, s.created_at,
        u.first_name, u.last_name, u.display_name,
        mat.name as material_name, mc.name as category_name,
        CAST(COALESCE(SUM(v.value), 0) AS SIGNED) as vote_score,
-       COUNT(v.id) as vote_count
+       COUNT(v.id) as vote_count,
+       CAST((MATCH(mat.name) AGAINST (sqlc.arg(keyword_query)) * 4
+             + COALESCE((SELECT MAX(MATCH(ma.alias) AGAINST (sqlc.arg(keyword_query)))
+                         FROM material_aliases ma WHERE ma.material_id = s.material_id), 0) * 3
+             + MATCH(mc.name) AGAINST (sqlc.arg(keyword_query))
+             + MATCH(s.notes, s.layer_name) AGAINST (sqlc.arg(keyword_query))
+             + COALESCE(MATCH(lm.name) AGAINST (sqlc.arg(keyword_query)), 0)
+             + COALESCE(MATCH(mf.name) AGAINST (sqlc.arg(keyword_query)), 0)
+             + (mat.name LIKE CONCAT('%', sqlc.arg(keyword_query), '%'))) AS DECIMAL(12,6)) as relevance,
+       CAST(CASE sqlc.arg(sort_key)
+           WHEN 'relevance' THEN (MATCH(mat.name) AGAINST (sqlc.arg(keyword_query)) * 4
+                                  + COALESCE((SELECT MAX(MATCH(ma.alias) AGAINST (sqlc.arg(keyword_query)))
+                                              FROM material_aliases ma WHERE ma.material_id = s.material_id), 0) * 3
+                                  + MATCH(mc.name) AGAINST (sqlc.arg(keyword_query))
+                                  + MATCH(s.notes, s.layer_name) AGAINST (sqlc.arg(keyword_query))
+                                  + COALESCE(MATCH(lm.name) AGAINST (sqlc.arg(keyword_query)), 0)
+                                  + COALESCE(MATCH(mf.name) AGAINST (sqlc.arg(keyword_query)), 0)
+                                  + (mat.name LIKE CONCAT('%', sqlc.arg(keyword_query), '%')))
+           WHEN 'newest' THEN UNIX_TIMESTAMP(s.created_at)
+           WHEN 'speed' THEN s.speed
+           WHEN 'power' THEN s.max_power
+           WHEN 'effective_watts' THEN s.effective_watts
+           WHEN 'line_energy' THEN s.line_energy
+           WHEN 'fluence' THEN s.fluence
+           WHEN 'pulse_energy' THEN s.pulse_energy
+           WHEN 'confidence' THEN s.confidence
+           ELSE COALESCE(SUM(v.value), 0)
+       END AS DECIMAL(20,6)) as sort_value
 FROM settings s
 JOIN users u ON s.user_id = u.id
 JOIN materials mat ON s.material_id = mat.id
 JOIN material_categories mc ON mat.category_id = mc.id
+LEFT JOIN laser_models lm ON s.laser_model_id = lm.id
+LEFT JOIN laser_manufacturers mf ON lm.manufacturer_id = mf.id
 LEFT JOIN votes v ON v.setting_id = s.id
 WHERE (sqlc.narg(material_id) IS NULL OR s.material_id = sqlc.narg(material_id))
   AND (sqlc.narg(laser_type) IS NULL OR s.laser_type = sqlc.narg(laser_type))
   AND (sqlc.narg(wattage) IS NULL OR s.wattage = sqlc.narg(wattage))
+  AND (sqlc.narg(min_wattage) IS NULL OR s.wattage >= sqlc.narg(min_wattage))
+  AND (sqlc.narg(max_wattage) IS NULL OR s.wattage <= sqlc.narg(max_wattage))
   AND (sqlc.narg(operation_type) IS NULL OR s.operation_type = sqlc.narg(operation_type))
   AND (sqlc.narg(user_id) IS NULL OR s.user_id = sqlc.narg(user_id))
-  AND (sqlc.narg(keyword) IS NULL OR mat.name LIKE CONCAT('%', sqlc.narg(keyword), '%'))
+  AND (sqlc.narg(laser_model_id) IS NULL OR s.laser_model_id = sqlc.narg(laser_model_id))
+  AND (sqlc.narg(min_speed) IS NULL OR s.speed >= sqlc.narg(min_speed))
+  AND (sqlc.narg(max_speed) IS NULL OR s.speed <= sqlc.narg(max_speed))
+  AND (sqlc.narg(min_power) IS NULL OR s.max_power >= sqlc.narg(min_power))
+  AND (sqlc.narg(max_power) IS NULL OR s.max_power <= sqlc.narg(max_power))
+  AND (sqlc.narg(min_passes) IS NULL OR s.num_passes >= sqlc.narg(min_passes))
+  AND (sqlc.narg(max_passes) IS NULL OR s.num_passes <= sqlc.narg(max_passes))
+  AND (sqlc.narg(min_effective_watts) IS NULL OR s.effective_watts >= sqlc.narg(min_effective_watts))
+  AND (sqlc.narg(max_effective_watts) IS NULL OR s.effective_watts <= sqlc.narg(max_effective_watts))
+  AND (sqlc.narg(min_line_energy) IS NULL OR s.line_energy >= sqlc.narg(min_line_energy))
+  AND (sqlc.narg(max_line_energy) IS NULL OR s.line_energy <= sqlc.narg(max_line_energy))
+  AND (sqlc.narg(min_fluence) IS NULL OR s.fluence >= sqlc.narg(min_fluence))
+  AND (sqlc.narg(max_fluence) IS NULL OR s.fluence <= sqlc.narg(max_fluence))
+  AND (sqlc.narg(min_pulse_energy) IS NULL OR s.pulse_energy >= sqlc.narg(min_pulse_energy))
+  AND (sqlc.narg(max_pulse_energy) IS NULL OR s.pulse_energy <= sqlc.narg(max_pulse_energy))
+  AND (sqlc.narg(min_frequency) IS NULL OR s.frequency >= sqlc.narg(min_frequency))
+  AND (sqlc.narg(max_frequency) IS NULL OR s.frequency <= sqlc.narg(max_frequency))
+  AND (sqlc.narg(min_pulse_width) IS NULL OR s.pulse_width >= sqlc.narg(min_pulse_width))
+  AND (sqlc.narg(max_pulse_width) IS NULL OR s.pulse_width <= sqlc.narg(max_pulse_width))
+  AND (sqlc.narg(wobble) IS NULL OR COALESCE(s.wobble_enable, FALSE) = sqlc.narg(wobble))
+  AND (sqlc.narg(min_thickness) IS NULL OR s.thickness_mm >= sqlc.narg(min_thickness))
+  AND (sqlc.narg(max_thickness) IS NULL OR s.thickness_mm <= sqlc.narg(max_thickness))
+  AND (sqlc.narg(color) IS NULL OR s.color = sqlc.narg(color))
+  AND (sqlc.narg(finish) IS NULL OR s.finish = sqlc.narg(finish))
+  AND (sqlc.narg(grade) IS NULL OR s.grade = sqlc.narg(grade))
 GROUP BY s.id
-ORDER BY vote_score DESC, s.created_at DESC
-LIMIT 50;
+-- Keyset pagination on (sort_value * sort_sign, id), newest-first within ties.
+-- sort_sign is 1 for descending and -1 for ascending, so both directions
+-- page the same way. Rows without a sort value (e.g. fluence of a Cut) come
+-- last; cursor_value is NULL once the cursor is among them.
+HAVING (sqlc.narg(keyword) IS NULL OR relevance > 0)
+AND (sqlc.narg(cursor_id) IS NULL
+    OR (sqlc.narg(cursor_value) IS NULL AND sort_value IS NULL AND s.id < sqlc.narg(cursor_id))
+    OR (sqlc.narg(cursor_value) IS NOT NULL AND (
+           sort_value IS NULL
+        OR sort_value * sqlc.arg(sort_sign) < sqlc.narg(cursor_value)
+        OR (sort_value * sqlc.arg(sort_sign) = sqlc.narg(cursor_value) AND s.id < sqlc.narg(cursor_id)))))
+ORDER BY sort_value IS NULL, sort_value * sqlc.arg(sort_sign) DESC, s.id DESC
+LIMIT ?;
+
+-- name: CountSearchSettings :one
+SELECT COUNT(*)
+FROM settings s
+JOIN materials mat ON s.material_id = mat.id
+JOIN material_categories mc ON mat.category_id = mc.id
+LEFT JOIN laser_models lm ON s.laser_model_id = lm.id
+LEFT JOIN laser_manufacturers mf ON lm.manufacturer_id = mf.id
+WHERE (sqlc.narg(material_id) IS NULL OR s.material_id = sqlc.narg(material_id))
+  AND (sqlc.narg(laser_type) IS NULL OR s.laser_type = sqlc.narg(laser_type))
+  AND (sqlc.narg(wattage) IS NULL OR s.wattage = sqlc.narg(wattage))
+  AND (sqlc.narg(min_wattage) IS NULL OR s.wattage >= sqlc.narg(min_wattage))
+  AND (sqlc.narg(max_wattage) IS NULL OR s.wattage <= sqlc.narg(max_wattage))
+  AND (sqlc.narg(operation_type) IS NULL OR s.operation_type = sqlc.narg(operation_type))
+  AND (sqlc.narg(user_id) IS NULL OR s.user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(laser_model_id) IS NULL OR s.laser_model_id = sqlc.narg(laser_model_id))
+  AND (sqlc.narg(min_speed) IS NULL OR s.speed >= sqlc.narg(min_speed))
+  AND (sqlc.narg(max_speed) IS NULL OR s.speed <= sqlc.narg(max_speed))
+  AND (sqlc.narg(min_power) IS NULL OR s.max_power >= sqlc.narg(min_power))
+  AND (sqlc.narg(max_power) IS NULL OR s.max_power <= sqlc.narg(max_power))
+  AND (sqlc.narg(min_passes) IS NULL OR s.num_passes >= sqlc.narg(min_passes))
+  AND (sqlc.narg(max_passes) IS NULL OR s.num_passes <= sqlc.narg(max_passes))
+  AND (sqlc.narg(min_effective_watts) IS NULL OR s.effective_watts >= sqlc.narg(min_effective_watts))
+  AND (sqlc.narg(max_effective_watts) IS NULL OR s.effective_watts <= sqlc.narg(max_effective_watts))
+  AND (sqlc.narg(min_line_energy) IS NULL OR s.line_energy >= sqlc.narg(min_line_energy))
+  AND (sqlc.narg(max_line_energy) IS NULL OR s.line_energy <= sqlc.narg(max_line_energy))
+  AND (sqlc.narg(min_fluence) IS NULL OR s.fluence >= sqlc.narg(min_fluence))
+  AND (sqlc.narg(max_fluence) IS NULL OR s.fluence <= sqlc.narg(max_fluence))
+  AND (sqlc.narg(min_pulse_energy) IS NULL OR s.pulse_energy >= sqlc.narg(min_pulse_energy))
+  AND (sqlc.narg(max_pulse_energy) IS NULL OR s.pulse_energy <= sqlc.narg(max_pulse_energy))
+  AND (sqlc.narg(min_frequency) IS NULL OR s.frequency >= sqlc.narg(min_frequency))
+  AND (sqlc.narg(max_frequency) IS NULL OR s.frequency <= sqlc.narg(max_frequency))
+  AND (sqlc.narg(min_pulse_width) IS NULL OR s.pulse_width >= sqlc.narg(min_pulse_width))
+  AND (sqlc.narg(max_pulse_width) IS NULL OR s.pulse_width <= sqlc.narg(max_pulse_width))
+  AND (sqlc.narg(wobble) IS NULL OR COALESCE(s.wobble_enable, FALSE) = sqlc.narg(wobble))
+  AND (sqlc.narg(min_thickness) IS NULL OR s.thickness_mm >= sqlc.narg(min_thickness))
+  AND (sqlc.narg(max_thickness) IS NULL OR s.thickness_mm <= sqlc.narg(max_thickness))
+  AND (sqlc.narg(color) IS NULL OR s.color = sqlc.narg(color))
+  AND (sqlc.narg(finish) IS NULL OR s.finish = sqlc.narg(finish))
+  AND (sqlc.narg(grade) IS NULL OR s.grade = sqlc.narg(grade))
+  AND (sqlc.narg(keyword) IS NULL OR (MATCH(mat.name) AGAINST (sqlc.arg(keyword_query)) * 4
+                                    + COALESCE((SELECT MAX(MATCH(ma.alias) AGAINST (sqlc.arg(keyword_query)))
+                                                FROM material_aliases ma WHERE ma.material_id = s.material_id), 0) * 3
+                                    + MATCH(mc.name) AGAINST (sqlc.arg(keyword_query))
+                                    + MATCH(s.notes, s.layer_name) AGAINST (sqlc.arg(keyword_query))
+                                    + COALESCE(MATCH(lm.name) AGAINST (sqlc.arg(keyword_query)), 0)
+                                    + COALESCE(MATCH(mf.name) AGAINST (sqlc.arg(keyword_query)), 0)
+                                    + (mat.name LIKE CONCAT('%', sqlc.arg(keyword_query), '%'))) > 0);
 
 -- name: GetTopSettings :many
 SELECT s.id, s.user_id, s.material_id,
@@ -175,6 +421,8 @@ JOIN users u ON s.user_id = u.id
 JOIN materials mat ON s.material_id = mat.id
 JOIN material_categories mc ON mat.category_id = mc.id
 LEFT JOIN votes v ON v.setting_id = s.id
+WHERE (sqlc.narg(laser_type) IS NULL OR s.laser_type = sqlc.narg(laser_type))
+  AND (sqlc.narg(wattage) IS NULL OR s.wattage = sqlc.narg(wattage))
 GROUP BY s.id
 ORDER BY vote_score DESC
 LIMIT 20;
@@ -200,34 +448,78 @@ WHERE s.user_id = ?
 GROUP BY s.id
 ORDER BY s.created_at DESC;
 
+-- name: GetUserSettingsForImport :many
+SELECT s.id, s.material_id, s.operation_type,
+       s.max_power, s.min_power, s.speed,
+       s.num_passes, s.scan_interval, s.frequency,
+       s.image_mode, s.layer_name, s.layer_subname, s.thickness_mm,
+       mat.name as material_name
+FROM settings s
+JOIN materials mat ON s.material_id = mat.id
+WHERE s.user_id = ? AND s.laser_type = ? AND s.wattage = ?;
+
+-- name: GetSettingsByIDs :many
+SELECT s.id, s.user_id, s.material_id,
+       s.laser_type, s.wattage, s.laser_model_id, s.operation_type,
+       s.max_power, s.min_power, s.max_power2, s.min_power2, s.speed,
+       s.num_passes, s.z_offset, s.z_per_pass,
+       s.scan_interval, s.angle, s.angle_per_pass,
+       s.cross_hatch, s.bidir, s.scan_opt,
+       s.flood_fill, s.auto_rotate, s.overscan, s.overscan_percent,
+       s.frequency, s.wobble_enable, s.use_dot_correction,
+       s.pulse_width, s.wobble_diameter, s.wobble_step,
+       s.perforation_mode, s.enable_dot_width_adjust, s.dot_width,
+       s.image_mode, s.negative_image,
+       s.kerf, s.run_blower,
+       s.layer_name, s.layer_subname,
+       s.priority, s.tab_count, s.tab_count_max,
+       s.thickness_mm, s.color, s.finish, s.grade,
+       s.notes, s.created_at,
+       u.first_name, u.last_name, u.display_name,
+       mat.name as material_name, mc.name as category_name,
+       CAST(COALESCE(SUM(v.value), 0) AS SIGNED) as vote_score,
+       COUNT(v.id) as vote_count
+FROM settings s
+JOIN users u ON s.user_id = u.id
+JOIN materials mat ON s.material_id = mat.id
+JOIN material_categories mc ON mat.category_id = mc.id
+LEFT JOIN votes v ON v.setting_id = s.id
+WHERE s.id IN (sqlc.slice('ids'))
+GROUP BY s.id
+ORDER BY mat.name, s.thickness_mm IS NOT NULL, s.thickness_mm, s.id;
+
 -- name: CreateSetting :execresult
 INSERT INTO settings (
-    user_id, material_id, laser_type, wattage, operation_type,
+    user_id, material_id, import_id, forked_from_id, laser_type, wattage, laser_model_id, operation_type,
     max_power, min_power, max_power2, min_power2, speed,
     num_passes, z_offset, z_per_pass,
     scan_interval, angle, angle_per_pass,
     cross_hatch, bidir, scan_opt,
     flood_fill, auto_rotate, overscan, overscan_percent,
     frequency, wobble_enable, use_dot_correction,
+    pulse_width, wobble_diameter, wobble_step,
     perforation_mode, dot_width,
     image_mode, negative_image,
     kerf, run_blower,
     layer_name, layer_subname,
     priority, tab_count, tab_count_max,
+    thickness_mm, color, finish, grade,
     notes
 ) VALUES (
-    ?, ?, ?, ?, ?,
+    ?, ?, ?, ?, ?, ?, ?, ?,
     ?, ?, ?, ?, ?,
     ?, ?, ?,
     ?, ?, ?,
     ?, ?, ?,
     ?, ?, ?, ?,
     ?, ?, ?,
+    ?, ?, ?,
     ?, ?,
     ?, ?,
     ?, ?,
     ?, ?,
     ?, ?, ?,
+    ?, ?, ?, ?,
     ?
 );
 
@@ -239,11 +531,13 @@ UPDATE settings SET
     cross_hatch = ?, bidir = ?, scan_opt = ?,
     flood_fill = ?, auto_rotate = ?, overscan = ?, overscan_percent = ?,
     frequency = ?, wobble_enable = ?, use_dot_correction = ?,
+    pulse_width = ?, wobble_diameter = ?, wobble_step = ?,
     perforation_mode = ?, dot_width = ?,
     image_mode = ?, negative_image = ?,
     kerf = ?, run_blower = ?,
     layer_name = ?, layer_subname = ?,
     priority = ?, tab_count = ?, tab_count_max = ?,
+    thickness_mm = ?, color = ?, finish = ?, grade = ?,
     notes = ?
 WHERE id = ? AND user_id = ?;
 
@@ -251,21 +545,56 @@ WHERE id = ? AND user_id = ?;
 DELETE FROM settings
 WHERE id = ? AND user_id = ?;
 
+-- name: DeleteSettingsByImport :execrows
+DELETE FROM settings
+WHERE import_id = ? AND user_id = ?;
+
+-- =====================
+-- SETTING SUBLAYERS
+-- =====================
+
+-- name: GetSublayersBySetting :many
+SELECT id, setting_id, sublayer_index, sublayer_type, is_cleanup,
+       max_power, min_power, max_power2, min_power2, speed,
+       num_passes, frequency, scan_interval, subname
+FROM setting_sublayers
+WHERE setting_id = ?
+ORDER BY sublayer_index, id;
+
+-- name: GetSublayersBySettingIDs :many
+SELECT id, setting_id, sublayer_index, sublayer_type, is_cleanup,
+       max_power, min_power, max_power2, min_power2, speed,
+       num_passes, frequency, scan_interval, subname
+FROM setting_sublayers
+WHERE setting_id IN (sqlc.slice('setting_ids'))
+ORDER BY setting_id, sublayer_index, id;
+
+-- name: CreateSettingSublayer :exec
+INSERT INTO setting_sublayers (
+    setting_id, sublayer_index, sublayer_type, is_cleanup,
+    max_power, min_power, max_power2, min_power2, speed,
+    num_passes, frequency, scan_interval, subname
+) VALUES (
+    ?, ?, ?, ?,
+    ?, ?, ?, ?, ?,
+    ?, ?, ?, ?
+);
+
 -- =====================
 -- VOTES
 -- =====================
 
 -- name: GetUserVoteForSetting :one
-SELECT id, user_id, setting_id, value, created_at
+SELECT id, user_id, setting_id, value, revision, created_at
 FROM votes
 WHERE user_id = ? AND setting_id = ?;
 
 -- name: UpsertVote :exec
-INSERT INTO votes (user_id, setting_id, value)
-VALUES (?, ?, ?)
-ON DUPLICATE KEY UPDATE value = VALUES(value);
+INSERT INTO votes (user_id, setting_id, value, revision)
+VALUES (?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE value = VALUES(value), revision = VALUES(revision);
 
--- name: DeleteVote :exec
+-- name: DeleteVote :execrows
 DELETE FROM votes
 WHERE user_id = ? AND setting_id = ?;
 
@@ -274,6 +603,48 @@ SELECT COALESCE(SUM(value), 0) as score, COUNT(id) as total
 FROM votes
 WHERE setting_id = ?;
 
+-- =====================
+-- IMPORTS
+-- =====================
+
+-- name: CreateImport :execresult
+INSERT INTO imports (user_id, filename, content_hash, laser_make_model, laser_type, wattage)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: GetImportByHash :one
+SELECT id, user_id, filename, content_hash, laser_make_model, laser_type, wattage,
+       imported_count, skipped_count, conflict_count, failed_count, errors, created_at
+FROM imports
+WHERE user_id = ? AND content_hash = ? AND laser_make_model = ?
+  AND laser_type = ? AND wattage = ?
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: GetImportByID :one
+SELECT id, user_id, filename, content_hash, laser_make_model, laser_type, wattage,
+       imported_count, skipped_count, conflict_count, failed_count, errors, created_at
+FROM imports
+WHERE id = ? AND user_id = ?;
+
+-- name: GetUserImports :many
+SELECT i.id, i.user_id, i.filename, i.content_hash, i.laser_make_model, i.laser_type, i.wattage,
+       i.imported_count, i.skipped_count, i.conflict_count, i.failed_count, i.errors, i.created_at,
+       COUNT(s.id) as setting_count
+FROM imports i
+LEFT JOIN settings s ON s.import_id = i.id
+WHERE i.user_id = ?
+GROUP BY i.id
+ORDER BY i.created_at DESC;
+
+-- name: UpdateImportResult :exec
+UPDATE imports SET
+    imported_count = ?, skipped_count = ?, conflict_count = ?, failed_count = ?, errors = ?
+WHERE id = ?;
+
+-- name: DeleteImport :exec
+DELETE FROM imports
+WHERE id = ? AND user_id = ?;
+
 -- =====================
 -- ADMIN QUERIES
 -- =====================
@@ -346,3 +717,362 @@ FROM users u
 LEFT JOIN settings s ON s.user_id = u.id
 WHERE u.id = ?
 GROUP BY u.id;
+
+-- =====================
+-- MATERIAL TAXONOMY (ADMIN)
+-- =====================
+
+-- name: GetCategoriesAdmin :many
+SELECT c.id, c.name, COUNT(m.id) as material_count
+FROM material_categories c
+LEFT JOIN materials m ON m.category_id = c.id
+GROUP BY c.id
+ORDER BY c.name;
+
+-- name: GetCategoryByID :one
+SELECT id, name
+FROM material_categories
+WHERE id = ?;
+
+-- name: CreateCategory :execresult
+INSERT INTO material_categories (name)
+VALUES (?);
+
+-- name: RenameCategory :execrows
+UPDATE material_categories SET name = ?
+WHERE id = ?;
+
+-- name: DeleteCategory :execrows
+DELETE FROM material_categories
+WHERE id = ?;
+
+-- name: CountMaterialsInCategory :one
+SELECT COUNT(*) as total
+FROM materials
+WHERE category_id = ?;
+
+-- name: GetMaterialsAdmin :many
+SELECT m.id, m.category_id, m.name, m.slug,
+       c.name as category_name,
+       (SELECT COUNT(*) FROM settings s WHERE s.material_id = m.id) as setting_count,
+       (SELECT COUNT(*) FROM material_aliases ma WHERE ma.material_id = m.id) as alias_count
+FROM materials m
+JOIN material_categories c ON m.category_id = c.id
+WHERE (sqlc.narg(category_id) IS NULL OR m.category_id = sqlc.narg(category_id))
+  AND (sqlc.narg(search) IS NULL OR m.name LIKE CONCAT('%', sqlc.narg(search), '%'))
+ORDER BY c.name, m.name;
+
+-- name: UpdateMaterial :execrows
+UPDATE materials SET category_id = ?, name = ?, slug = ?
+WHERE id = ?;
+
+-- name: RecategorizeMaterials :execrows
+UPDATE materials SET category_id = sqlc.arg(category_id)
+WHERE id IN (sqlc.slice('ids'));
+
+-- name: DeleteMaterial :execrows
+DELETE FROM materials
+WHERE id = ?;
+
+-- name: CountMaterialSettings :one
+SELECT COUNT(*) as total
+FROM settings
+WHERE material_id = ?;
+
+-- name: CountMaterialVotes :one
+SELECT COUNT(*) as total
+FROM votes v
+JOIN settings s ON v.setting_id = s.id
+WHERE s.material_id = ?;
+
+-- name: MoveMaterialSettings :execrows
+UPDATE settings SET material_id = sqlc.arg(target_id)
+WHERE material_id = sqlc.arg(source_id);
+
+-- name: MoveMaterialAliases :execrows
+UPDATE material_aliases SET material_id = sqlc.arg(target_id)
+WHERE material_id = sqlc.arg(source_id);
+
+-- name: DeleteMaterialAlias :execrows
+DELETE FROM material_aliases
+WHERE id = ? AND material_id = ?;
+
+-- name: CreateTaxonomyAudit :exec
+INSERT INTO taxonomy_audit (admin_user_id, action, entity_type, entity_id, details)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: GetTaxonomyAudit :many
+SELECT t.id, t.admin_user_id, t.action, t.entity_type, t.entity_id, t.details, t.created_at,
+       u.email as admin_email
+FROM taxonomy_audit t
+LEFT JOIN users u ON t.admin_user_id = u.id
+ORDER BY t.created_at DESC, t.id DESC
+LIMIT ? OFFSET ?;
+
+-- name: GetTaxonomyAuditCount :one
+SELECT COUNT(*) as total
+FROM taxonomy_audit;
+
+-- =====================
+-- SETTING REVISIONS
+-- =====================
+
+-- name: GetSettingValues :one
+SELECT max_power, min_power, max_power2, min_power2, speed,
+       num_passes, z_offset, z_per_pass,
+       scan_interval, angle, angle_per_pass,
+       cross_hatch, bidir, scan_opt,
+       flood_fill, auto_rotate, overscan, overscan_percent,
+       frequency, wobble_enable, use_dot_correction,
+       pulse_width, wobble_diameter, wobble_step,
+       perforation_mode, dot_width,
+       image_mode, negative_image,
+       kerf, run_blower,
+       layer_name, layer_subname,
+       priority, tab_count, tab_count_max,
+       thickness_mm, color, finish, grade,
+       notes
+FROM settings
+WHERE id = ?;
+
+-- name: GetSettingRevisions :many
+SELECT r.id, r.setting_id, r.revision, r.user_id, r.action, r.reverted_from, r.snapshot, r.created_at,
+       u.display_name, u.first_name, u.last_name
+FROM setting_revisions r
+LEFT JOIN users u ON r.user_id = u.id
+WHERE r.setting_id = ?
+ORDER BY r.revision DESC;
+
+-- name: GetSettingRevision :one
+SELECT id, setting_id, revision, user_id, action, reverted_from, snapshot, created_at
+FROM setting_revisions
+WHERE setting_id = ? AND revision = ?;
+
+-- name: GetLatestSettingRevision :one
+SELECT CAST(COALESCE(MAX(revision), 0) AS SIGNED) as revision
+FROM setting_revisions
+WHERE setting_id = ?;
+
+-- name: CreateBaselineSettingRevision :exec
+INSERT INTO setting_revisions (setting_id, revision, user_id, action, snapshot, created_at)
+SELECT id, 1, user_id, 'create', sqlc.arg(snapshot), updated_at
+FROM settings
+WHERE id = sqlc.arg(id);
+
+-- name: CreateSettingRevision :exec
+INSERT INTO setting_revisions (setting_id, revision, user_id, action, reverted_from, snapshot)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: GetVotesByRevision :many
+SELECT revision,
+       CAST(COALESCE(SUM(value = 1), 0) AS SIGNED) as upvotes,
+       CAST(COALESCE(SUM(value = -1), 0) AS SIGNED) as downvotes
+FROM votes
+WHERE setting_id = ?
+GROUP BY revision;
+
+-- =====================
+-- SETTING FORKS
+-- =====================
+
+-- name: GetSettingParent :one
+SELECT s.id, s.user_id, u.first_name, u.last_name, u.display_name
+FROM settings s
+JOIN users u ON s.user_id = u.id
+WHERE s.id = ?;
+
+-- name: GetSettingForks :many
+SELECT s.id, s.user_id, s.laser_type, s.wattage, u.first_name, u.last_name, u.display_name
+FROM settings s
+JOIN users u ON s.user_id = u.id
+WHERE s.forked_from_id = ?
+ORDER BY s.created_at, s.id;
+
+-- =====================
+-- TEST REPORTS
+-- =====================
+
+-- name: UpsertTestReport :exec
+INSERT INTO test_reports (
+    setting_id, user_id, revision, laser_model_id, make_model, laser_type, wattage,
+    material_batch, outcome, adjusted_values, notes
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    revision = VALUES(revision), laser_model_id = VALUES(laser_model_id),
+    make_model = VALUES(make_model), laser_type = VALUES(laser_type), wattage = VALUES(wattage),
+    material_batch = VALUES(material_batch), outcome = VALUES(outcome),
+    adjusted_values = VALUES(adjusted_values), notes = VALUES(notes);
+
+-- name: GetTestReports :many
+SELECT t.id, t.setting_id, t.user_id, t.revision, t.laser_model_id, t.make_model, t.laser_type, t.wattage,
+       t.material_batch, t.outcome, t.adjusted_values, t.notes, t.created_at, t.updated_at,
+       u.first_name, u.last_name, u.display_name
+FROM test_reports t
+JOIN users u ON t.user_id = u.id
+WHERE t.setting_id = ?
+ORDER BY t.updated_at DESC, t.id DESC;
+
+-- name: GetTestReportSummary :one
+SELECT CAST(COALESCE(SUM(outcome = 'worked'), 0) AS SIGNED) as worked,
+       CAST(COALESCE(SUM(outcome = 'adjusted'), 0) AS SIGNED) as adjusted,
+       CAST(COALESCE(SUM(outcome = 'failed'), 0) AS SIGNED) as failed
+FROM test_reports
+WHERE setting_id = ?;
+
+-- name: DeleteTestReport :execrows
+DELETE FROM test_reports
+WHERE user_id = ? AND setting_id = ?;
+
+-- name: UpdateSettingConfidence :exec
+UPDATE settings SET test_count = ?, confidence = ?, updated_at = updated_at
+WHERE id = ?;
+
+-- =====================
+-- VOTE INTEGRITY (ADMIN)
+-- =====================
+
+-- name: GetUpvoteEdges :many
+SELECT v.user_id as voter_id, s.user_id as owner_id, COUNT(*) as upvotes
+FROM votes v
+JOIN settings s ON v.setting_id = s.id
+WHERE v.value = 1 AND v.user_id <> s.user_id
+GROUP BY v.user_id, s.user_id;
+
+-- name: GetVoterTotals :many
+SELECT u.id, u.email, u.first_name, u.last_name, u.display_name, u.email_verified, u.created_at,
+       COUNT(v.id) as vote_count,
+       CAST(COALESCE(SUM(v.value = 1), 0) AS SIGNED) as upvote_count
+FROM users u
+JOIN votes v ON v.user_id = u.id
+GROUP BY u.id;
+
+-- =====================
+-- RECIPE SETS
+-- =====================
+
+-- name: CreateRecipeSet :execresult
+INSERT INTO recipe_sets (user_id, material_id, laser_type, wattage, laser_model_id, name, description)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: UpdateRecipeSet :exec
+UPDATE recipe_sets SET
+    material_id = ?, laser_type = ?, wattage = ?, laser_model_id = ?, name = ?, description = ?
+WHERE id = ? AND user_id = ?;
+
+-- name: SetRecipeSetPublished :execrows
+UPDATE recipe_sets SET published_at = ?
+WHERE id = ? AND user_id = ?;
+
+-- name: DeleteRecipeSet :execrows
+DELETE FROM recipe_sets
+WHERE id = ? AND user_id = ?;
+
+-- name: GetRecipeSet :one
+SELECT r.id, r.user_id, r.material_id, r.laser_type, r.wattage, r.laser_model_id,
+       r.name, r.description, r.published_at, r.created_at, r.updated_at,
+       u.first_name, u.last_name, u.display_name,
+       mat.name as material_name,
+       lm.name as laser_model_name, mf.name as manufacturer_name,
+       CAST(COALESCE(SUM(v.value), 0) AS SIGNED) as vote_score,
+       COUNT(v.id) as vote_count
+FROM recipe_sets r
+JOIN users u ON r.user_id = u.id
+JOIN materials mat ON r.material_id = mat.id
+LEFT JOIN laser_models lm ON r.laser_model_id = lm.id
+LEFT JOIN laser_manufacturers mf ON lm.manufacturer_id = mf.id
+LEFT JOIN recipe_set_votes v ON v.recipe_set_id = r.id
+WHERE r.id = ?
+GROUP BY r.id;
+
+-- name: ListRecipeSets :many
+SELECT r.id, r.user_id, r.material_id, r.laser_type, r.wattage, r.laser_model_id,
+       r.name, r.description, r.published_at, r.created_at, r.updated_at,
+       u.first_name, u.last_name, u.display_name,
+       mat.name as material_name,
+       lm.name as laser_model_name, mf.name as manufacturer_name,
+       CAST(COALESCE(SUM(v.value), 0) AS SIGNED) as vote_score,
+       COUNT(v.id) as vote_count
+FROM recipe_sets r
+JOIN users u ON r.user_id = u.id
+JOIN materials mat ON r.material_id = mat.id
+LEFT JOIN laser_models lm ON r.laser_model_id = lm.id
+LEFT JOIN laser_manufacturers mf ON lm.manufacturer_id = mf.id
+LEFT JOIN recipe_set_votes v ON v.recipe_set_id = r.id
+WHERE r.published_at IS NOT NULL
+  AND (sqlc.narg(material_id) IS NULL OR r.material_id = sqlc.narg(material_id))
+  AND (sqlc.narg(laser_type) IS NULL OR r.laser_type = sqlc.narg(laser_type))
+  AND (sqlc.narg(wattage) IS NULL OR r.wattage = sqlc.narg(wattage))
+  AND (sqlc.narg(laser_model_id) IS NULL OR r.laser_model_id = sqlc.narg(laser_model_id))
+GROUP BY r.id
+ORDER BY CASE sqlc.arg(sort_key)
+             WHEN 'newest' THEN UNIX_TIMESTAMP(r.published_at)
+             ELSE COALESCE(SUM(v.value), 0)
+         END DESC, r.published_at DESC, r.id DESC
+LIMIT ? OFFSET ?;
+
+-- name: CountRecipeSets :one
+SELECT COUNT(*)
+FROM recipe_sets r
+WHERE r.published_at IS NOT NULL
+  AND (sqlc.narg(material_id) IS NULL OR r.material_id = sqlc.narg(material_id))
+  AND (sqlc.narg(laser_type) IS NULL OR r.laser_type = sqlc.narg(laser_type))
+  AND (sqlc.narg(wattage) IS NULL OR r.wattage = sqlc.narg(wattage))
+  AND (sqlc.narg(laser_model_id) IS NULL OR r.laser_model_id = sqlc.narg(laser_model_id));
+
+-- name: GetUserRecipeSets :many
+SELECT r.id, r.user_id, r.material_id, r.laser_type, r.wattage, r.laser_model_id,
+       r.name, r.description, r.published_at, r.created_at, r.updated_at,
+       u.first_name, u.last_name, u.display_name,
+       mat.name as material_name,
+       lm.name as laser_model_name, mf.name as manufacturer_name,
+       CAST(COALESCE(SUM(v.value), 0) AS SIGNED) as vote_score,
+       COUNT(v.id) as vote_count
+FROM recipe_sets r
+JOIN users u ON r.user_id = u.id
+JOIN materials mat ON r.material_id = mat.id
+LEFT JOIN laser_models lm ON r.laser_model_id = lm.id
+LEFT JOIN laser_manufacturers mf ON lm.manufacturer_id = mf.id
+LEFT JOIN recipe_set_votes v ON v.recipe_set_id = r.id
+WHERE r.user_id = ?
+GROUP BY r.id
+ORDER BY r.updated_at DESC, r.id DESC;
+
+-- name: GetRecipeSetColors :many
+SELECT c.recipe_set_id, c.setting_id, c.position, c.color_name, c.hex,
+       s.operation_type, s.max_power, s.speed, s.num_passes,
+       s.scan_interval, s.frequency, s.pulse_width
+FROM recipe_set_colors c
+JOIN settings s ON c.setting_id = s.id
+WHERE c.recipe_set_id IN (sqlc.slice('ids'))
+ORDER BY c.recipe_set_id, c.position;
+
+-- name: DeleteRecipeSetColors :exec
+DELETE FROM recipe_set_colors
+WHERE recipe_set_id = ?;
+
+-- name: CreateRecipeSetColor :exec
+INSERT INTO recipe_set_colors (recipe_set_id, setting_id, position, color_name, hex)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: UpsertRecipeSetVote :exec
+INSERT INTO recipe_set_votes (user_id, recipe_set_id, value)
+VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE value = VALUES(value);
+
+-- name: DeleteRecipeSetVote :execrows
+DELETE FROM recipe_set_votes
+WHERE user_id = ? AND recipe_set_id = ?;
+
+-- name: GetRecipeSetVoteScore :one
+SELECT CAST(COALESCE(SUM(value), 0) AS SIGNED) as score, COUNT(id) as total
+FROM recipe_set_votes
+WHERE recipe_set_id = ?;
+
+-- name: CountMaterialRecipeSets :one
+SELECT COUNT(*) as total
+FROM recipe_sets
+WHERE material_id = ?;
+
+-- name: MoveMaterialRecipeSets :execrows
+UPDATE recipe_sets SET material_id = sqlc.arg(target_id)
+WHERE material_id = sqlc.arg(source_id);
@@ -8,8 +8,376 @@ package db
 import (
 	"context"
 	"database/sql"
+	"strings"
 )
 
+const clearDefaultUserMachine = `-- name: ClearDefaultUserMachine :exec
+UPDATE user_machines SET is_default = FALSE
+WHERE user_id = ?
+`
+
+func (q *Queries) ClearDefaultUserMachine(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, clearDefaultUserMachine, userID)
+	return err
+}
+
+const countMaterialRecipeSets = `-- name: CountMaterialRecipeSets :one
+SELECT COUNT(*) as total
+FROM recipe_sets
+WHERE material_id = ?
+`
+
+func (q *Queries) CountMaterialRecipeSets(ctx context.Context, materialID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countMaterialRecipeSets, materialID)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const countMaterialSettings = `-- name: CountMaterialSettings :one
+SELECT COUNT(*) as total
+FROM settings
+WHERE material_id = ?
+`
+
+func (q *Queries) CountMaterialSettings(ctx context.Context, materialID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countMaterialSettings, materialID)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const countMaterialVotes = `-- name: CountMaterialVotes :one
+SELECT COUNT(*) as total
+FROM votes v
+JOIN settings s ON v.setting_id = s.id
+WHERE s.material_id = ?
+`
+
+func (q *Queries) CountMaterialVotes(ctx context.Context, materialID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countMaterialVotes, materialID)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const countMaterialsInCategory = `-- name: CountMaterialsInCategory :one
+SELECT COUNT(*) as total
+FROM materials
+WHERE category_id = ?
+`
+
+func (q *Queries) CountMaterialsInCategory(ctx context.Context, categoryID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countMaterialsInCategory, categoryID)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const countRecipeSets = `-- name: CountRecipeSets :one
+SELECT COUNT(*)
+FROM recipe_sets r
+WHERE r.published_at IS NOT NULL
+  AND (? IS NULL OR r.material_id = ?)
+  AND (? IS NULL OR r.laser_type = ?)
+  AND (? IS NULL OR r.wattage = ?)
+  AND (? IS NULL OR r.laser_model_id = ?)
+`
+
+type CountRecipeSetsParams struct {
+	MaterialID   sql.NullInt32
+	LaserType    NullRecipeSetsLaserType
+	Wattage      sql.NullInt32
+	LaserModelID sql.NullInt32
+}
+
+func (q *Queries) CountRecipeSets(ctx context.Context, arg CountRecipeSetsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRecipeSets,
+		arg.MaterialID,
+		arg.MaterialID,
+		arg.LaserType,
+		arg.LaserType,
+		arg.Wattage,
+		arg.Wattage,
+		arg.LaserModelID,
+		arg.LaserModelID,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSearchSettings = `-- name: CountSearchSettings :one
+SELECT COUNT(*)
+FROM settings s
+JOIN materials mat ON s.material_id = mat.id
+JOIN material_categories mc ON mat.category_id = mc.id
+LEFT JOIN laser_models lm ON s.laser_model_id = lm.id
+LEFT JOIN laser_manufacturers mf ON lm.manufacturer_id = mf.id
+WHERE (? IS NULL OR s.material_id = ?)
+  AND (? IS NULL OR s.laser_type = ?)
+  AND (? IS NULL OR s.wattage = ?)
+  AND (? IS NULL OR s.wattage >= ?)
+  AND (? IS NULL OR s.wattage <= ?)
+  AND (? IS NULL OR s.operation_type = ?)
+  AND (? IS NULL OR s.user_id = ?)
+  AND (? IS NULL OR s.laser_model_id = ?)
+  AND (? IS NULL OR s.speed >= ?)
+  AND (? IS NULL OR s.speed <= ?)
+  AND (? IS NULL OR s.max_power >= ?)
+  AND (? IS NULL OR s.max_power <= ?)
+  AND (? IS NULL OR s.num_passes >= ?)
+  AND (? IS NULL OR s.num_passes <= ?)
+  AND (? IS NULL OR s.effective_watts >= ?)
+  AND (? IS NULL OR s.effective_watts <= ?)
+  AND (? IS NULL OR s.line_energy >= ?)
+  AND (? IS NULL OR s.line_energy <= ?)
+  AND (? IS NULL OR s.fluence >= ?)
+  AND (? IS NULL OR s.fluence <= ?)
+  AND (? IS NULL OR s.pulse_energy >= ?)
+  AND (? IS NULL OR s.pulse_energy <= ?)
+  AND (? IS NULL OR s.frequency >= ?)
+  AND (? IS NULL OR s.frequency <= ?)
+  AND (? IS NULL OR s.pulse_width >= ?)
+  AND (? IS NULL OR s.pulse_width <= ?)
+  AND (? IS NULL OR COALESCE(s.wobble_enable, FALSE) = ?)
+  AND (? IS NULL OR s.thickness_mm >= ?)
+  AND (? IS NULL OR s.thickness_mm <= ?)
+  AND (? IS NULL OR s.color = ?)
+  AND (? IS NULL OR s.finish = ?)
+  AND (? IS NULL OR s.grade = ?)
+  AND (? IS NULL OR (MATCH(mat.name) AGAINST (?) * 4
+                                    + COALESCE((SELECT MAX(MATCH(ma.alias) AGAINST (?))
+                                                FROM material_aliases ma WHERE ma.material_id = s.material_id), 0) * 3
+                                    + MATCH(mc.name) AGAINST (?)
+                                    + MATCH(s.notes, s.layer_name) AGAINST (?)
+                                    + COALESCE(MATCH(lm.name) AGAINST (?), 0)
+                                    + COALESCE(MATCH(mf.name) AGAINST (?), 0)
+                                    + (mat.name LIKE CONCAT('%', ?, '%'))) > 0)
+`
+
+type CountSearchSettingsParams struct {
+	MaterialID        sql.NullInt32
+	LaserType         NullSettingsLaserType
+	Wattage           sql.NullInt32
+	MinWattage        sql.NullInt32
+	MaxWattage        sql.NullInt32
+	OperationType     NullSettingsOperationType
+	UserID            sql.NullInt32
+	LaserModelID      sql.NullInt32
+	MinSpeed          sql.NullString
+	MaxSpeed          sql.NullString
+	MinPower          sql.NullString
+	MaxPower          sql.NullString
+	MinPasses         sql.NullInt32
+	MaxPasses         sql.NullInt32
+	MinEffectiveWatts sql.NullString
+	MaxEffectiveWatts sql.NullString
+	MinLineEnergy     sql.NullString
+	MaxLineEnergy     sql.NullString
+	MinFluence        sql.NullString
+	MaxFluence        sql.NullString
+	MinPulseEnergy    sql.NullString
+	MaxPulseEnergy    sql.NullString
+	MinFrequency      sql.NullString
+	MaxFrequency      sql.NullString
+	MinPulseWidth     sql.NullString
+	MaxPulseWidth     sql.NullString
+	Wobble            sql.NullBool
+	MinThickness      sql.NullString
+	MaxThickness      sql.NullString
+	Color             sql.NullString
+	Finish            sql.NullString
+	Grade             sql.NullString
+	Keyword           sql.NullString
+	KeywordQuery      string
+}
+
+func (q *Queries) CountSearchSettings(ctx context.Context, arg CountSearchSettingsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSearchSettings,
+		arg.MaterialID,
+		arg.MaterialID,
+		arg.LaserType,
+		arg.LaserType,
+		arg.Wattage,
+		arg.Wattage,
+		arg.MinWattage,
+		arg.MinWattage,
+		arg.MaxWattage,
+		arg.MaxWattage,
+		arg.OperationType,
+		arg.OperationType,
+		arg.UserID,
+		arg.UserID,
+		arg.LaserModelID,
+		arg.LaserModelID,
+		arg.MinSpeed,
+		arg.MinSpeed,
+		arg.MaxSpeed,
+		arg.MaxSpeed,
+		arg.MinPower,
+		arg.MinPower,
+		arg.MaxPower,
+		arg.MaxPower,
+		arg.MinPasses,
+		arg.MinPasses,
+		arg.MaxPasses,
+		arg.MaxPasses,
+		arg.MinEffectiveWatts,
+		arg.MinEffectiveWatts,
+		arg.MaxEffectiveWatts,
+		arg.MaxEffectiveWatts,
+		arg.MinLineEnergy,
+		arg.MinLineEnergy,
+		arg.MaxLineEnergy,
+		arg.MaxLineEnergy,
+		arg.MinFluence,
+		arg.MinFluence,
+		arg.MaxFluence,
+		arg.MaxFluence,
+		arg.MinPulseEnergy,
+		arg.MinPulseEnergy,
+		arg.MaxPulseEnergy,
+		arg.MaxPulseEnergy,
+		arg.MinFrequency,
+		arg.MinFrequency,
+		arg.MaxFrequency,
+		arg.MaxFrequency,
+		arg.MinPulseWidth,
+		arg.MinPulseWidth,
+		arg.MaxPulseWidth,
+		arg.MaxPulseWidth,
+		arg.Wobble,
+		arg.Wobble,
+		arg.MinThickness,
+		arg.MinThickness,
+		arg.MaxThickness,
+		arg.MaxThickness,
+		arg.Color,
+		arg.Color,
+		arg.Finish,
+		arg.Finish,
+		arg.Grade,
+		arg.Grade,
+		arg.Keyword,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createBaselineSettingRevision = `-- name: CreateBaselineSettingRevision :exec
+INSERT INTO setting_revisions (setting_id, revision, user_id, action, snapshot, created_at)
+SELECT id, 1, user_id, 'create', ?, updated_at
+FROM settings
+WHERE id = ?
+`
+
+type CreateBaselineSettingRevisionParams struct {
+	Snapshot string
+	ID       int32
+}
+
+func (q *Queries) CreateBaselineSettingRevision(ctx context.Context, arg CreateBaselineSettingRevisionParams) error {
+	_, err := q.db.ExecContext(ctx, createBaselineSettingRevision, arg.Snapshot, arg.ID)
+	return err
+}
+
+const createCategory = `-- name: CreateCategory :execresult
+INSERT INTO material_categories (name)
+VALUES (?)
+`
+
+func (q *Queries) CreateCategory(ctx context.Context, name string) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createCategory, name)
+}
+
+const createImport = `-- name: CreateImport :execresult
+
+INSERT INTO imports (user_id, filename, content_hash, laser_make_model, laser_type, wattage)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateImportParams struct {
+	UserID         int32
+	Filename       string
+	ContentHash    string
+	LaserMakeModel string
+	LaserType      ImportsLaserType
+	Wattage        int32
+}
+
+// =====================
+// IMPORTS
+// =====================
+func (q *Queries) CreateImport(ctx context.Context, arg CreateImportParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createImport,
+		arg.UserID,
+		arg.Filename,
+		arg.ContentHash,
+		arg.LaserMakeModel,
+		arg.LaserType,
+		arg.Wattage,
+	)
+}
+
+const createLaserManufacturer = `-- name: CreateLaserManufacturer :execresult
+INSERT INTO laser_manufacturers (name, slug)
+VALUES (?, ?)
+`
+
+type CreateLaserManufacturerParams struct {
+	Name string
+	Slug string
+}
+
+func (q *Queries) CreateLaserManufacturer(ctx context.Context, arg CreateLaserManufacturerParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createLaserManufacturer, arg.Name, arg.Slug)
+}
+
+const createLaserModel = `-- name: CreateLaserModel :execresult
+INSERT INTO laser_models (
+    manufacturer_id, name, slug, laser_type, wattage,
+    source, lens_mm, field_size_mm, motion_system
+) VALUES (
+    ?, ?, ?, ?, ?,
+    ?, ?, ?, ?
+)
+`
+
+type CreateLaserModelParams struct {
+	ManufacturerID int32
+	Name           string
+	Slug           string
+	LaserType      LaserModelsLaserType
+	Wattage        int32
+	Source         sql.NullString
+	LensMm         sql.NullString
+	FieldSizeMm    sql.NullString
+	MotionSystem   LaserModelsMotionSystem
+}
+
+func (q *Queries) CreateLaserModel(ctx context.Context, arg CreateLaserModelParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createLaserModel,
+		arg.ManufacturerID,
+		arg.Name,
+		arg.Slug,
+		arg.LaserType,
+		arg.Wattage,
+		arg.Source,
+		arg.LensMm,
+		arg.FieldSizeMm,
+		arg.MotionSystem,
+	)
+}
+
 const createMaterial = `-- name: CreateMaterial :execresult
 INSERT INTO materials (category_id, name, slug)
 VALUES (?, ?, ?)
@@ -25,34 +393,107 @@ func (q *Queries) CreateMaterial(ctx context.Context, arg CreateMaterialParams)
 	return q.db.ExecContext(ctx, createMaterial, arg.CategoryID, arg.Name, arg.Slug)
 }
 
+const createMaterialAlias = `-- name: CreateMaterialAlias :execresult
+INSERT INTO material_aliases (material_id, alias)
+VALUES (?, ?)
+`
+
+type CreateMaterialAliasParams struct {
+	MaterialID int32
+	Alias      string
+}
+
+func (q *Queries) CreateMaterialAlias(ctx context.Context, arg CreateMaterialAliasParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createMaterialAlias, arg.MaterialID, arg.Alias)
+}
+
+const createRecipeSet = `-- name: CreateRecipeSet :execresult
+
+INSERT INTO recipe_sets (user_id, material_id, laser_type, wattage, laser_model_id, name, description)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateRecipeSetParams struct {
+	UserID       int32
+	MaterialID   int32
+	LaserType    RecipeSetsLaserType
+	Wattage      int32
+	LaserModelID sql.NullInt32
+	Name         string
+	Description  sql.NullString
+}
+
+// =====================
+// RECIPE SETS
+// =====================
+func (q *Queries) CreateRecipeSet(ctx context.Context, arg CreateRecipeSetParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createRecipeSet,
+		arg.UserID,
+		arg.MaterialID,
+		arg.LaserType,
+		arg.Wattage,
+		arg.LaserModelID,
+		arg.Name,
+		arg.Description,
+	)
+}
+
+const createRecipeSetColor = `-- name: CreateRecipeSetColor :exec
+INSERT INTO recipe_set_colors (recipe_set_id, setting_id, position, color_name, hex)
+VALUES (?, ?, ?, ?, ?)
+`
+
+type CreateRecipeSetColorParams struct {
+	RecipeSetID int32
+	SettingID   int32
+	Position    int32
+	ColorName   sql.NullString
+	Hex         string
+}
+
+func (q *Queries) CreateRecipeSetColor(ctx context.Context, arg CreateRecipeSetColorParams) error {
+	_, err := q.db.ExecContext(ctx, createRecipeSetColor,
+		arg.RecipeSetID,
+		arg.SettingID,
+		arg.Position,
+		arg.ColorName,
+		arg.Hex,
+	)
+	return err
+}
+
 const createSetting = `-- name: CreateSetting :execresult
 INSERT INTO settings (
-    user_id, material_id, laser_type, wattage, operation_type,
+    user_id, material_id, import_id, forked_from_id, laser_type, wattage, laser_model_id, operation_type,
     max_power, min_power, max_power2, min_power2, speed,
     num_passes, z_offset, z_per_pass,
     scan_interval, angle, angle_per_pass,
     cross_hatch, bidir, scan_opt,
     flood_fill, auto_rotate, overscan, overscan_percent,
     frequency, wobble_enable, use_dot_correction,
+    pulse_width, wobble_diameter, wobble_step,
     perforation_mode, dot_width,
     image_mode, negative_image,
     kerf, run_blower,
     layer_name, layer_subname,
     priority, tab_count, tab_count_max,
+    thickness_mm, color, finish, grade,
     notes
 ) VALUES (
-    ?, ?, ?, ?, ?,
+    ?, ?, ?, ?, ?, ?, ?, ?,
     ?, ?, ?, ?, ?,
     ?, ?, ?,
     ?, ?, ?,
     ?, ?, ?,
     ?, ?, ?, ?,
     ?, ?, ?,
+    ?, ?, ?,
     ?, ?,
     ?, ?,
     ?, ?,
     ?, ?,
     ?, ?, ?,
+    ?, ?, ?, ?,
     ?
 )
 `
@@ -60,8 +501,11 @@ INSERT INTO settings (
 type CreateSettingParams struct {
 	UserID           int32
 	MaterialID       int32
+	ImportID         sql.NullInt32
+	ForkedFromID     sql.NullInt32
 	LaserType        SettingsLaserType
 	Wattage          int32
+	LaserModelID     sql.NullInt32
 	OperationType    SettingsOperationType
 	MaxPower         string
 	MinPower         string
@@ -84,6 +528,9 @@ type CreateSettingParams struct {
 	Frequency        sql.NullString
 	WobbleEnable     sql.NullBool
 	UseDotCorrection sql.NullBool
+	PulseWidth       sql.NullString
+	WobbleDiameter   sql.NullString
+	WobbleStep       sql.NullString
 	PerforationMode  bool
 	DotWidth         sql.NullString
 	ImageMode        sql.NullString
@@ -95,6 +542,10 @@ type CreateSettingParams struct {
 	Priority         sql.NullInt32
 	TabCount         sql.NullInt32
 	TabCountMax      sql.NullInt32
+	ThicknessMm      sql.NullString
+	Color            sql.NullString
+	Finish           sql.NullString
+	Grade            sql.NullString
 	Notes            sql.NullString
 }
 
@@ -102,8 +553,11 @@ func (q *Queries) CreateSetting(ctx context.Context, arg CreateSettingParams) (s
 	return q.db.ExecContext(ctx, createSetting,
 		arg.UserID,
 		arg.MaterialID,
+		arg.ImportID,
+		arg.ForkedFromID,
 		arg.LaserType,
 		arg.Wattage,
+		arg.LaserModelID,
 		arg.OperationType,
 		arg.MaxPower,
 		arg.MinPower,
@@ -126,6 +580,9 @@ func (q *Queries) CreateSetting(ctx context.Context, arg CreateSettingParams) (s
 		arg.Frequency,
 		arg.WobbleEnable,
 		arg.UseDotCorrection,
+		arg.PulseWidth,
+		arg.WobbleDiameter,
+		arg.WobbleStep,
 		arg.PerforationMode,
 		arg.DotWidth,
 		arg.ImageMode,
@@ -137,10 +594,111 @@ func (q *Queries) CreateSetting(ctx context.Context, arg CreateSettingParams) (s
 		arg.Priority,
 		arg.TabCount,
 		arg.TabCountMax,
+		arg.ThicknessMm,
+		arg.Color,
+		arg.Finish,
+		arg.Grade,
 		arg.Notes,
 	)
 }
 
+const createSettingRevision = `-- name: CreateSettingRevision :exec
+INSERT INTO setting_revisions (setting_id, revision, user_id, action, reverted_from, snapshot)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateSettingRevisionParams struct {
+	SettingID    int32
+	Revision     int32
+	UserID       sql.NullInt32
+	Action       SettingRevisionsAction
+	RevertedFrom sql.NullInt32
+	Snapshot     string
+}
+
+func (q *Queries) CreateSettingRevision(ctx context.Context, arg CreateSettingRevisionParams) error {
+	_, err := q.db.ExecContext(ctx, createSettingRevision,
+		arg.SettingID,
+		arg.Revision,
+		arg.UserID,
+		arg.Action,
+		arg.RevertedFrom,
+		arg.Snapshot,
+	)
+	return err
+}
+
+const createSettingSublayer = `-- name: CreateSettingSublayer :exec
+INSERT INTO setting_sublayers (
+    setting_id, sublayer_index, sublayer_type, is_cleanup,
+    max_power, min_power, max_power2, min_power2, speed,
+    num_passes, frequency, scan_interval, subname
+) VALUES (
+    ?, ?, ?, ?,
+    ?, ?, ?, ?, ?,
+    ?, ?, ?, ?
+)
+`
+
+type CreateSettingSublayerParams struct {
+	SettingID     int32
+	SublayerIndex int32
+	SublayerType  string
+	IsCleanup     bool
+	MaxPower      string
+	MinPower      string
+	MaxPower2     sql.NullString
+	MinPower2     sql.NullString
+	Speed         string
+	NumPasses     int32
+	Frequency     sql.NullString
+	ScanInterval  sql.NullString
+	Subname       sql.NullString
+}
+
+func (q *Queries) CreateSettingSublayer(ctx context.Context, arg CreateSettingSublayerParams) error {
+	_, err := q.db.ExecContext(ctx, createSettingSublayer,
+		arg.SettingID,
+		arg.SublayerIndex,
+		arg.SublayerType,
+		arg.IsCleanup,
+		arg.MaxPower,
+		arg.MinPower,
+		arg.MaxPower2,
+		arg.MinPower2,
+		arg.Speed,
+		arg.NumPasses,
+		arg.Frequency,
+		arg.ScanInterval,
+		arg.Subname,
+	)
+	return err
+}
+
+const createTaxonomyAudit = `-- name: CreateTaxonomyAudit :exec
+INSERT INTO taxonomy_audit (admin_user_id, action, entity_type, entity_id, details)
+VALUES (?, ?, ?, ?, ?)
+`
+
+type CreateTaxonomyAuditParams struct {
+	AdminUserID sql.NullInt32
+	Action      string
+	EntityType  TaxonomyAuditEntityType
+	EntityID    int32
+	Details     sql.NullString
+}
+
+func (q *Queries) CreateTaxonomyAudit(ctx context.Context, arg CreateTaxonomyAuditParams) error {
+	_, err := q.db.ExecContext(ctx, createTaxonomyAudit,
+		arg.AdminUserID,
+		arg.Action,
+		arg.EntityType,
+		arg.EntityID,
+		arg.Details,
+	)
+	return err
+}
+
 const createUser = `-- name: CreateUser :execresult
 INSERT INTO users (first_name, last_name, email, password_hash, display_name)
 VALUES (?, ?, ?, ?, ?)
@@ -164,53 +722,249 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (sql.Res
 	)
 }
 
-const deleteSetting = `-- name: DeleteSetting :exec
-DELETE FROM settings
+const createUserMachine = `-- name: CreateUserMachine :execresult
+INSERT INTO user_machines (
+    user_id, laser_model_id, make_model, laser_type, wattage,
+    lens_mm, notes, is_default
+) VALUES (
+    ?, ?, ?, ?, ?,
+    ?, ?, ?
+)
+`
+
+type CreateUserMachineParams struct {
+	UserID       int32
+	LaserModelID sql.NullInt32
+	MakeModel    sql.NullString
+	LaserType    UserMachinesLaserType
+	Wattage      int32
+	LensMm       sql.NullString
+	Notes        sql.NullString
+	IsDefault    bool
+}
+
+func (q *Queries) CreateUserMachine(ctx context.Context, arg CreateUserMachineParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createUserMachine,
+		arg.UserID,
+		arg.LaserModelID,
+		arg.MakeModel,
+		arg.LaserType,
+		arg.Wattage,
+		arg.LensMm,
+		arg.Notes,
+		arg.IsDefault,
+	)
+}
+
+const deleteCategory = `-- name: DeleteCategory :execrows
+DELETE FROM material_categories
+WHERE id = ?
+`
+
+func (q *Queries) DeleteCategory(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCategory, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteImport = `-- name: DeleteImport :exec
+DELETE FROM imports
 WHERE id = ? AND user_id = ?
 `
 
-type DeleteSettingParams struct {
+type DeleteImportParams struct {
 	ID     int32
 	UserID int32
 }
 
-func (q *Queries) DeleteSetting(ctx context.Context, arg DeleteSettingParams) error {
-	_, err := q.db.ExecContext(ctx, deleteSetting, arg.ID, arg.UserID)
+func (q *Queries) DeleteImport(ctx context.Context, arg DeleteImportParams) error {
+	_, err := q.db.ExecContext(ctx, deleteImport, arg.ID, arg.UserID)
 	return err
 }
 
-const deleteVote = `-- name: DeleteVote :exec
-DELETE FROM votes
-WHERE user_id = ? AND setting_id = ?
+const deleteMaterial = `-- name: DeleteMaterial :execrows
+DELETE FROM materials
+WHERE id = ?
 `
 
-type DeleteVoteParams struct {
-	UserID    int32
-	SettingID int32
+func (q *Queries) DeleteMaterial(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteMaterial, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-func (q *Queries) DeleteVote(ctx context.Context, arg DeleteVoteParams) error {
-	_, err := q.db.ExecContext(ctx, deleteVote, arg.UserID, arg.SettingID)
-	return err
+const deleteMaterialAlias = `-- name: DeleteMaterialAlias :execrows
+DELETE FROM material_aliases
+WHERE id = ? AND material_id = ?
+`
+
+type DeleteMaterialAliasParams struct {
+	ID         int32
+	MaterialID int32
 }
 
-const getAdminStats = `-- name: GetAdminStats :one
+func (q *Queries) DeleteMaterialAlias(ctx context.Context, arg DeleteMaterialAliasParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteMaterialAlias, arg.ID, arg.MaterialID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
 
-SELECT
-    (SELECT COUNT(*) FROM users) as total_users,
-    (SELECT COUNT(*) FROM users WHERE email_verified = TRUE) as verified_users,
-    (SELECT COUNT(*) FROM users WHERE created_at >= DATE_SUB(NOW(), INTERVAL 7 DAY)) as users_this_week,
-    (SELECT COUNT(*) FROM settings) as total_settings,
-    (SELECT COUNT(*) FROM settings WHERE created_at >= DATE_SUB(NOW(), INTERVAL 7 DAY)) as settings_this_week,
-    (SELECT COUNT(*) FROM votes) as total_votes,
-    (SELECT COUNT(*) FROM votes WHERE created_at >= DATE_SUB(NOW(), INTERVAL 7 DAY)) as votes_this_week
+const deleteRecipeSet = `-- name: DeleteRecipeSet :execrows
+DELETE FROM recipe_sets
+WHERE id = ? AND user_id = ?
 `
 
-type GetAdminStatsRow struct {
-	TotalUsers       int64
-	VerifiedUsers    int64
-	UsersThisWeek    int64
-	TotalSettings    int64
+type DeleteRecipeSetParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) DeleteRecipeSet(ctx context.Context, arg DeleteRecipeSetParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRecipeSet, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRecipeSetColors = `-- name: DeleteRecipeSetColors :exec
+DELETE FROM recipe_set_colors
+WHERE recipe_set_id = ?
+`
+
+func (q *Queries) DeleteRecipeSetColors(ctx context.Context, recipeSetID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteRecipeSetColors, recipeSetID)
+	return err
+}
+
+const deleteRecipeSetVote = `-- name: DeleteRecipeSetVote :execrows
+DELETE FROM recipe_set_votes
+WHERE user_id = ? AND recipe_set_id = ?
+`
+
+type DeleteRecipeSetVoteParams struct {
+	UserID      int32
+	RecipeSetID int32
+}
+
+func (q *Queries) DeleteRecipeSetVote(ctx context.Context, arg DeleteRecipeSetVoteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRecipeSetVote, arg.UserID, arg.RecipeSetID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSetting = `-- name: DeleteSetting :exec
+DELETE FROM settings
+WHERE id = ? AND user_id = ?
+`
+
+type DeleteSettingParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) DeleteSetting(ctx context.Context, arg DeleteSettingParams) error {
+	_, err := q.db.ExecContext(ctx, deleteSetting, arg.ID, arg.UserID)
+	return err
+}
+
+const deleteSettingsByImport = `-- name: DeleteSettingsByImport :execrows
+DELETE FROM settings
+WHERE import_id = ? AND user_id = ?
+`
+
+type DeleteSettingsByImportParams struct {
+	ImportID sql.NullInt32
+	UserID   int32
+}
+
+func (q *Queries) DeleteSettingsByImport(ctx context.Context, arg DeleteSettingsByImportParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSettingsByImport, arg.ImportID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteTestReport = `-- name: DeleteTestReport :execrows
+DELETE FROM test_reports
+WHERE user_id = ? AND setting_id = ?
+`
+
+type DeleteTestReportParams struct {
+	UserID    int32
+	SettingID int32
+}
+
+func (q *Queries) DeleteTestReport(ctx context.Context, arg DeleteTestReportParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteTestReport, arg.UserID, arg.SettingID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserMachine = `-- name: DeleteUserMachine :execrows
+DELETE FROM user_machines
+WHERE id = ? AND user_id = ?
+`
+
+type DeleteUserMachineParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) DeleteUserMachine(ctx context.Context, arg DeleteUserMachineParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserMachine, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteVote = `-- name: DeleteVote :execrows
+DELETE FROM votes
+WHERE user_id = ? AND setting_id = ?
+`
+
+type DeleteVoteParams struct {
+	UserID    int32
+	SettingID int32
+}
+
+func (q *Queries) DeleteVote(ctx context.Context, arg DeleteVoteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteVote, arg.UserID, arg.SettingID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAdminStats = `-- name: GetAdminStats :one
+
+SELECT
+    (SELECT COUNT(*) FROM users) as total_users,
+    (SELECT COUNT(*) FROM users WHERE email_verified = TRUE) as verified_users,
+    (SELECT COUNT(*) FROM users WHERE created_at >= DATE_SUB(NOW(), INTERVAL 7 DAY)) as users_this_week,
+    (SELECT COUNT(*) FROM settings) as total_settings,
+    (SELECT COUNT(*) FROM settings WHERE created_at >= DATE_SUB(NOW(), INTERVAL 7 DAY)) as settings_this_week,
+    (SELECT COUNT(*) FROM votes) as total_votes,
+    (SELECT COUNT(*) FROM votes WHERE created_at >= DATE_SUB(NOW(), INTERVAL 7 DAY)) as votes_this_week
+`
+
+type GetAdminStatsRow struct {
+	TotalUsers       int64
+	VerifiedUsers    int64
+	UsersThisWeek    int64
+	TotalSettings    int64
 	SettingsThisWeek int64
 	TotalVotes       int64
 	VotesThisWeek    int64
@@ -297,6 +1051,35 @@ func (q *Queries) GetAllCategories(ctx context.Context) ([]MaterialCategory, err
 	return items, nil
 }
 
+const getAllMaterialAliases = `-- name: GetAllMaterialAliases :many
+SELECT id, material_id, alias
+FROM material_aliases
+ORDER BY material_id, alias
+`
+
+func (q *Queries) GetAllMaterialAliases(ctx context.Context) ([]MaterialAlias, error) {
+	rows, err := q.db.QueryContext(ctx, getAllMaterialAliases)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MaterialAlias
+	for rows.Next() {
+		var i MaterialAlias
+		if err := rows.Scan(&i.ID, &i.MaterialID, &i.Alias); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAllMaterials = `-- name: GetAllMaterials :many
 
 SELECT m.id, m.category_id, m.name, m.slug,
@@ -499,75 +1282,207 @@ func (q *Queries) GetAllUsersAdmin(ctx context.Context, arg GetAllUsersAdminPara
 	return items, nil
 }
 
-const getMaterialByID = `-- name: GetMaterialByID :one
-SELECT m.id, m.category_id, m.name, m.slug,
-       c.name as category_name
-FROM materials m
-JOIN material_categories c ON m.category_id = c.id
-WHERE m.id = ?
+const getCategoriesAdmin = `-- name: GetCategoriesAdmin :many
+
+SELECT c.id, c.name, COUNT(m.id) as material_count
+FROM material_categories c
+LEFT JOIN materials m ON m.category_id = c.id
+GROUP BY c.id
+ORDER BY c.name
 `
 
-type GetMaterialByIDRow struct {
-	ID           int32
-	CategoryID   int32
-	Name         string
-	Slug         string
-	CategoryName string
+type GetCategoriesAdminRow struct {
+	ID            int32
+	Name          string
+	MaterialCount int64
 }
 
-func (q *Queries) GetMaterialByID(ctx context.Context, id int32) (GetMaterialByIDRow, error) {
-	row := q.db.QueryRowContext(ctx, getMaterialByID, id)
-	var i GetMaterialByIDRow
+// =====================
+// MATERIAL TAXONOMY (ADMIN)
+// =====================
+func (q *Queries) GetCategoriesAdmin(ctx context.Context) ([]GetCategoriesAdminRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCategoriesAdmin)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCategoriesAdminRow
+	for rows.Next() {
+		var i GetCategoriesAdminRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.MaterialCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCategoryByID = `-- name: GetCategoryByID :one
+SELECT id, name
+FROM material_categories
+WHERE id = ?
+`
+
+func (q *Queries) GetCategoryByID(ctx context.Context, id int32) (MaterialCategory, error) {
+	row := q.db.QueryRowContext(ctx, getCategoryByID, id)
+	var i MaterialCategory
+	err := row.Scan(&i.ID, &i.Name)
+	return i, err
+}
+
+const getDefaultUserMachine = `-- name: GetDefaultUserMachine :one
+SELECT id, user_id, laser_model_id, make_model, laser_type, wattage,
+       lens_mm, notes, is_default, created_at, updated_at
+FROM user_machines
+WHERE user_id = ? AND is_default = TRUE
+LIMIT 1
+`
+
+func (q *Queries) GetDefaultUserMachine(ctx context.Context, userID int32) (UserMachine, error) {
+	row := q.db.QueryRowContext(ctx, getDefaultUserMachine, userID)
+	var i UserMachine
 	err := row.Scan(
 		&i.ID,
-		&i.CategoryID,
-		&i.Name,
-		&i.Slug,
-		&i.CategoryName,
+		&i.UserID,
+		&i.LaserModelID,
+		&i.MakeModel,
+		&i.LaserType,
+		&i.Wattage,
+		&i.LensMm,
+		&i.Notes,
+		&i.IsDefault,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getMaterialByName = `-- name: GetMaterialByName :one
-SELECT id, category_id, name, slug
-FROM materials
-WHERE name = ?
+const getImportByHash = `-- name: GetImportByHash :one
+SELECT id, user_id, filename, content_hash, laser_make_model, laser_type, wattage,
+       imported_count, skipped_count, conflict_count, failed_count, errors, created_at
+FROM imports
+WHERE user_id = ? AND content_hash = ? AND laser_make_model = ?
+  AND laser_type = ? AND wattage = ?
+ORDER BY created_at DESC
 LIMIT 1
 `
 
-func (q *Queries) GetMaterialByName(ctx context.Context, name string) (Material, error) {
-	row := q.db.QueryRowContext(ctx, getMaterialByName, name)
-	var i Material
+type GetImportByHashParams struct {
+	UserID         int32
+	ContentHash    string
+	LaserMakeModel string
+	LaserType      ImportsLaserType
+	Wattage        int32
+}
+
+func (q *Queries) GetImportByHash(ctx context.Context, arg GetImportByHashParams) (Import, error) {
+	row := q.db.QueryRowContext(ctx, getImportByHash,
+		arg.UserID,
+		arg.ContentHash,
+		arg.LaserMakeModel,
+		arg.LaserType,
+		arg.Wattage,
+	)
+	var i Import
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Filename,
+		&i.ContentHash,
+		&i.LaserMakeModel,
+		&i.LaserType,
+		&i.Wattage,
+		&i.ImportedCount,
+		&i.SkippedCount,
+		&i.ConflictCount,
+		&i.FailedCount,
+		&i.Errors,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getImportByID = `-- name: GetImportByID :one
+SELECT id, user_id, filename, content_hash, laser_make_model, laser_type, wattage,
+       imported_count, skipped_count, conflict_count, failed_count, errors, created_at
+FROM imports
+WHERE id = ? AND user_id = ?
+`
+
+type GetImportByIDParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) GetImportByID(ctx context.Context, arg GetImportByIDParams) (Import, error) {
+	row := q.db.QueryRowContext(ctx, getImportByID, arg.ID, arg.UserID)
+	var i Import
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Filename,
+		&i.ContentHash,
+		&i.LaserMakeModel,
+		&i.LaserType,
+		&i.Wattage,
+		&i.ImportedCount,
+		&i.SkippedCount,
+		&i.ConflictCount,
+		&i.FailedCount,
+		&i.Errors,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLaserManufacturerBySlug = `-- name: GetLaserManufacturerBySlug :one
+SELECT id, name, slug, created_at
+FROM laser_manufacturers
+WHERE slug = ?
+`
+
+func (q *Queries) GetLaserManufacturerBySlug(ctx context.Context, slug string) (LaserManufacturer, error) {
+	row := q.db.QueryRowContext(ctx, getLaserManufacturerBySlug, slug)
+	var i LaserManufacturer
 	err := row.Scan(
 		&i.ID,
-		&i.CategoryID,
 		&i.Name,
 		&i.Slug,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getMaterialsByCategory = `-- name: GetMaterialsByCategory :many
-SELECT id, category_id, name, slug
-FROM materials
-WHERE category_id = ?
+const getLaserManufacturers = `-- name: GetLaserManufacturers :many
+
+SELECT id, name, slug, created_at
+FROM laser_manufacturers
 ORDER BY name
 `
 
-func (q *Queries) GetMaterialsByCategory(ctx context.Context, categoryID int32) ([]Material, error) {
-	rows, err := q.db.QueryContext(ctx, getMaterialsByCategory, categoryID)
+// =====================
+// LASER MACHINES
+// =====================
+func (q *Queries) GetLaserManufacturers(ctx context.Context) ([]LaserManufacturer, error) {
+	rows, err := q.db.QueryContext(ctx, getLaserManufacturers)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Material
+	var items []LaserManufacturer
 	for rows.Next() {
-		var i Material
+		var i LaserManufacturer
 		if err := rows.Scan(
 			&i.ID,
-			&i.CategoryID,
 			&i.Name,
 			&i.Slug,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -582,25 +1497,423 @@ func (q *Queries) GetMaterialsByCategory(ctx context.Context, categoryID int32)
 	return items, nil
 }
 
-const getSettingByID = `-- name: GetSettingByID :one
+const getLaserModelByID = `-- name: GetLaserModelByID :one
+SELECT lm.id, lm.manufacturer_id, lm.name, lm.slug, lm.laser_type, lm.wattage,
+       lm.source, lm.lens_mm, lm.field_size_mm, lm.motion_system,
+       mf.name as manufacturer_name
+FROM laser_models lm
+JOIN laser_manufacturers mf ON lm.manufacturer_id = mf.id
+WHERE lm.id = ?
+`
 
-SELECT s.id, s.user_id, s.material_id,
-       s.laser_type, s.wattage, s.operation_type,
-       s.max_power, s.min_power, s.max_power2, s.min_power2, s.speed,
-       s.num_passes, s.z_offset, s.z_per_pass,
-       s.scan_interval, s.angle, s.angle_per_pass,
-       s.cross_hatch, s.bidir, s.scan_opt,
-       s.flood_fill, s.auto_rotate, s.overscan, s.overscan_percent,
-       s.frequency, s.wobble_enable, s.use_dot_correction,
-       s.kerf, s.run_blower,
-       s.layer_name, s.layer_subname,
-       s.priority, s.tab_count, s.tab_count_max,
-       s.notes, s.created_at, s.updated_at,
-       u.first_name, u.last_name, u.display_name,
-       mat.name as material_name, mc.name as category_name,
-       CAST(COALESCE(SUM(v.value), 0) AS SIGNED) as vote_score,
-       COUNT(v.id) as vote_count
-FROM settings s
+type GetLaserModelByIDRow struct {
+	ID               int32
+	ManufacturerID   int32
+	Name             string
+	Slug             string
+	LaserType        LaserModelsLaserType
+	Wattage          int32
+	Source           sql.NullString
+	LensMm           sql.NullString
+	FieldSizeMm      sql.NullString
+	MotionSystem     LaserModelsMotionSystem
+	ManufacturerName string
+}
+
+func (q *Queries) GetLaserModelByID(ctx context.Context, id int32) (GetLaserModelByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getLaserModelByID, id)
+	var i GetLaserModelByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.ManufacturerID,
+		&i.Name,
+		&i.Slug,
+		&i.LaserType,
+		&i.Wattage,
+		&i.Source,
+		&i.LensMm,
+		&i.FieldSizeMm,
+		&i.MotionSystem,
+		&i.ManufacturerName,
+	)
+	return i, err
+}
+
+const getLaserModelBySlug = `-- name: GetLaserModelBySlug :one
+SELECT id, manufacturer_id, name, slug, laser_type, wattage,
+       source, lens_mm, field_size_mm, motion_system, created_at
+FROM laser_models
+WHERE manufacturer_id = ? AND slug = ? AND laser_type = ? AND wattage = ?
+`
+
+type GetLaserModelBySlugParams struct {
+	ManufacturerID int32
+	Slug           string
+	LaserType      LaserModelsLaserType
+	Wattage        int32
+}
+
+func (q *Queries) GetLaserModelBySlug(ctx context.Context, arg GetLaserModelBySlugParams) (LaserModel, error) {
+	row := q.db.QueryRowContext(ctx, getLaserModelBySlug,
+		arg.ManufacturerID,
+		arg.Slug,
+		arg.LaserType,
+		arg.Wattage,
+	)
+	var i LaserModel
+	err := row.Scan(
+		&i.ID,
+		&i.ManufacturerID,
+		&i.Name,
+		&i.Slug,
+		&i.LaserType,
+		&i.Wattage,
+		&i.Source,
+		&i.LensMm,
+		&i.FieldSizeMm,
+		&i.MotionSystem,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLatestSettingRevision = `-- name: GetLatestSettingRevision :one
+SELECT CAST(COALESCE(MAX(revision), 0) AS SIGNED) as revision
+FROM setting_revisions
+WHERE setting_id = ?
+`
+
+func (q *Queries) GetLatestSettingRevision(ctx context.Context, settingID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getLatestSettingRevision, settingID)
+	var revision int64
+	err := row.Scan(&revision)
+	return revision, err
+}
+
+const getMaterialByID = `-- name: GetMaterialByID :one
+SELECT m.id, m.category_id, m.name, m.slug,
+       c.name as category_name
+FROM materials m
+JOIN material_categories c ON m.category_id = c.id
+WHERE m.id = ?
+`
+
+type GetMaterialByIDRow struct {
+	ID           int32
+	CategoryID   int32
+	Name         string
+	Slug         string
+	CategoryName string
+}
+
+func (q *Queries) GetMaterialByID(ctx context.Context, id int32) (GetMaterialByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getMaterialByID, id)
+	var i GetMaterialByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.CategoryID,
+		&i.Name,
+		&i.Slug,
+		&i.CategoryName,
+	)
+	return i, err
+}
+
+const getMaterialByName = `-- name: GetMaterialByName :one
+SELECT id, category_id, name, slug
+FROM materials
+WHERE name = ?
+LIMIT 1
+`
+
+func (q *Queries) GetMaterialByName(ctx context.Context, name string) (Material, error) {
+	row := q.db.QueryRowContext(ctx, getMaterialByName, name)
+	var i Material
+	err := row.Scan(
+		&i.ID,
+		&i.CategoryID,
+		&i.Name,
+		&i.Slug,
+	)
+	return i, err
+}
+
+const getMaterialsAdmin = `-- name: GetMaterialsAdmin :many
+SELECT m.id, m.category_id, m.name, m.slug,
+       c.name as category_name,
+       (SELECT COUNT(*) FROM settings s WHERE s.material_id = m.id) as setting_count,
+       (SELECT COUNT(*) FROM material_aliases ma WHERE ma.material_id = m.id) as alias_count
+FROM materials m
+JOIN material_categories c ON m.category_id = c.id
+WHERE (? IS NULL OR m.category_id = ?)
+  AND (? IS NULL OR m.name LIKE CONCAT('%', ?, '%'))
+ORDER BY c.name, m.name
+`
+
+type GetMaterialsAdminParams struct {
+	CategoryID sql.NullInt32
+	Search     sql.NullString
+}
+
+type GetMaterialsAdminRow struct {
+	ID           int32
+	CategoryID   int32
+	Name         string
+	Slug         string
+	CategoryName string
+	SettingCount int64
+	AliasCount   int64
+}
+
+func (q *Queries) GetMaterialsAdmin(ctx context.Context, arg GetMaterialsAdminParams) ([]GetMaterialsAdminRow, error) {
+	rows, err := q.db.QueryContext(ctx, getMaterialsAdmin,
+		arg.CategoryID,
+		arg.CategoryID,
+		arg.Search,
+		arg.Search,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMaterialsAdminRow
+	for rows.Next() {
+		var i GetMaterialsAdminRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CategoryID,
+			&i.Name,
+			&i.Slug,
+			&i.CategoryName,
+			&i.SettingCount,
+			&i.AliasCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMaterialsByCategory = `-- name: GetMaterialsByCategory :many
+SELECT id, category_id, name, slug
+FROM materials
+WHERE category_id = ?
+ORDER BY name
+`
+
+func (q *Queries) GetMaterialsByCategory(ctx context.Context, categoryID int32) ([]Material, error) {
+	rows, err := q.db.QueryContext(ctx, getMaterialsByCategory, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Material
+	for rows.Next() {
+		var i Material
+		if err := rows.Scan(
+			&i.ID,
+			&i.CategoryID,
+			&i.Name,
+			&i.Slug,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecipeSet = `-- name: GetRecipeSet :one
+SELECT r.id, r.user_id, r.material_id, r.laser_type, r.wattage, r.laser_model_id,
+       r.name, r.description, r.published_at, r.created_at, r.updated_at,
+       u.first_name, u.last_name, u.display_name,
+       mat.name as material_name,
+       lm.name as laser_model_name, mf.name as manufacturer_name,
+       CAST(COALESCE(SUM(v.value), 0) AS SIGNED) as vote_score,
+       COUNT(v.id) as vote_count
+FROM recipe_sets r
+JOIN users u ON r.user_id = u.id
+JOIN materials mat ON r.material_id = mat.id
+LEFT JOIN laser_models lm ON r.laser_model_id = lm.id
+LEFT JOIN laser_manufacturers mf ON lm.manufacturer_id = mf.id
+LEFT JOIN recipe_set_votes v ON v.recipe_set_id = r.id
+WHERE r.id = ?
+GROUP BY r.id
+`
+
+type GetRecipeSetRow struct {
+	ID               int32
+	UserID           int32
+	MaterialID       int32
+	LaserType        RecipeSetsLaserType
+	Wattage          int32
+	LaserModelID     sql.NullInt32
+	Name             string
+	Description      sql.NullString
+	PublishedAt      sql.NullTime
+	CreatedAt        sql.NullTime
+	UpdatedAt        sql.NullTime
+	FirstName        string
+	LastName         string
+	DisplayName      sql.NullString
+	MaterialName     string
+	LaserModelName   sql.NullString
+	ManufacturerName sql.NullString
+	VoteScore        int64
+	VoteCount        int64
+}
+
+func (q *Queries) GetRecipeSet(ctx context.Context, id int32) (GetRecipeSetRow, error) {
+	row := q.db.QueryRowContext(ctx, getRecipeSet, id)
+	var i GetRecipeSetRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.MaterialID,
+		&i.LaserType,
+		&i.Wattage,
+		&i.LaserModelID,
+		&i.Name,
+		&i.Description,
+		&i.PublishedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FirstName,
+		&i.LastName,
+		&i.DisplayName,
+		&i.MaterialName,
+		&i.LaserModelName,
+		&i.ManufacturerName,
+		&i.VoteScore,
+		&i.VoteCount,
+	)
+	return i, err
+}
+
+const getRecipeSetColors = `-- name: GetRecipeSetColors :many
+SELECT c.recipe_set_id, c.setting_id, c.position, c.color_name, c.hex,
+       s.operation_type, s.max_power, s.speed, s.num_passes,
+       s.scan_interval, s.frequency, s.pulse_width
+FROM recipe_set_colors c
+JOIN settings s ON c.setting_id = s.id
+WHERE c.recipe_set_id IN (/*SLICE:ids*/?)
+ORDER BY c.recipe_set_id, c.position
+`
+
+type GetRecipeSetColorsRow struct {
+	RecipeSetID   int32
+	SettingID     int32
+	Position      int32
+	ColorName     sql.NullString
+	Hex           string
+	OperationType SettingsOperationType
+	MaxPower      string
+	Speed         string
+	NumPasses     int32
+	ScanInterval  sql.NullString
+	Frequency     sql.NullString
+	PulseWidth    sql.NullString
+}
+
+func (q *Queries) GetRecipeSetColors(ctx context.Context, ids []int32) ([]GetRecipeSetColorsRow, error) {
+	query := getRecipeSetColors
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRecipeSetColorsRow
+	for rows.Next() {
+		var i GetRecipeSetColorsRow
+		if err := rows.Scan(
+			&i.RecipeSetID,
+			&i.SettingID,
+			&i.Position,
+			&i.ColorName,
+			&i.Hex,
+			&i.OperationType,
+			&i.MaxPower,
+			&i.Speed,
+			&i.NumPasses,
+			&i.ScanInterval,
+			&i.Frequency,
+			&i.PulseWidth,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecipeSetVoteScore = `-- name: GetRecipeSetVoteScore :one
+SELECT CAST(COALESCE(SUM(value), 0) AS SIGNED) as score, COUNT(id) as total
+FROM recipe_set_votes
+WHERE recipe_set_id = ?
+`
+
+type GetRecipeSetVoteScoreRow struct {
+	Score int64
+	Total int64
+}
+
+func (q *Queries) GetRecipeSetVoteScore(ctx context.Context, recipeSetID int32) (GetRecipeSetVoteScoreRow, error) {
+	row := q.db.QueryRowContext(ctx, getRecipeSetVoteScore, recipeSetID)
+	var i GetRecipeSetVoteScoreRow
+	err := row.Scan(&i.Score, &i.Total)
+	return i, err
+}
+
+const getSettingByID = `-- name: GetSettingByID :one
+SELECT s.id, s.user_id, s.material_id, s.forked_from_id,
+       s.laser_type, s.wattage, s.laser_model_id, s.operation_type,
+       s.max_power, s.min_power, s.max_power2, s.min_power2, s.speed,
+       s.num_passes, s.z_offset, s.z_per_pass,
+       s.scan_interval, s.angle, s.angle_per_pass,
+       s.cross_hatch, s.bidir, s.scan_opt,
+       s.flood_fill, s.auto_rotate, s.overscan, s.overscan_percent,
+       s.frequency, s.wobble_enable, s.use_dot_correction,
+       s.pulse_width, s.wobble_diameter, s.wobble_step,
+       s.kerf, s.run_blower,
+       s.layer_name, s.layer_subname,
+       s.priority, s.tab_count, s.tab_count_max,
+       s.thickness_mm, s.color, s.finish, s.grade,
+       s.test_count, s.confidence,
+       s.effective_watts, s.line_energy, s.fluence, s.pulse_energy,
+       s.notes, s.created_at, s.updated_at,
+       u.first_name, u.last_name, u.display_name,
+       mat.name as material_name, mc.name as category_name,
+       CAST(COALESCE(SUM(v.value), 0) AS SIGNED) as vote_score,
+       COUNT(v.id) as vote_count
+FROM settings s
 JOIN users u ON s.user_id = u.id
 JOIN materials mat ON s.material_id = mat.id
 JOIN material_categories mc ON mat.category_id = mc.id
@@ -609,13 +1922,322 @@ WHERE s.id = ?
 GROUP BY s.id
 `
 
-type GetSettingByIDRow struct {
-	ID               int32
-	UserID           int32
-	MaterialID       int32
-	LaserType        SettingsLaserType
-	Wattage          int32
-	OperationType    SettingsOperationType
+type GetSettingByIDRow struct {
+	ID               int32
+	UserID           int32
+	MaterialID       int32
+	ForkedFromID     sql.NullInt32
+	LaserType        SettingsLaserType
+	Wattage          int32
+	LaserModelID     sql.NullInt32
+	OperationType    SettingsOperationType
+	MaxPower         string
+	MinPower         string
+	MaxPower2        sql.NullString
+	MinPower2        sql.NullString
+	Speed            string
+	NumPasses        int32
+	ZOffset          sql.NullString
+	ZPerPass         sql.NullString
+	ScanInterval     sql.NullString
+	Angle            sql.NullString
+	AnglePerPass     sql.NullString
+	CrossHatch       bool
+	Bidir            bool
+	ScanOpt          sql.NullString
+	FloodFill        bool
+	AutoRotate       bool
+	Overscan         sql.NullString
+	OverscanPercent  sql.NullString
+	Frequency        sql.NullString
+	WobbleEnable     sql.NullBool
+	UseDotCorrection sql.NullBool
+	PulseWidth       sql.NullString
+	WobbleDiameter   sql.NullString
+	WobbleStep       sql.NullString
+	Kerf             sql.NullString
+	RunBlower        sql.NullBool
+	LayerName        sql.NullString
+	LayerSubname     sql.NullString
+	Priority         sql.NullInt32
+	TabCount         sql.NullInt32
+	TabCountMax      sql.NullInt32
+	ThicknessMm      sql.NullString
+	Color            sql.NullString
+	Finish           sql.NullString
+	Grade            sql.NullString
+	TestCount        int32
+	Confidence       sql.NullString
+	EffectiveWatts   sql.NullString
+	LineEnergy       sql.NullString
+	Fluence          sql.NullString
+	PulseEnergy      sql.NullString
+	Notes            sql.NullString
+	CreatedAt        sql.NullTime
+	UpdatedAt        sql.NullTime
+	FirstName        string
+	LastName         string
+	DisplayName      sql.NullString
+	MaterialName     string
+	CategoryName     string
+	VoteScore        int64
+	VoteCount        int64
+}
+
+func (q *Queries) GetSettingByID(ctx context.Context, id int32) (GetSettingByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getSettingByID, id)
+	var i GetSettingByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.MaterialID,
+		&i.ForkedFromID,
+		&i.LaserType,
+		&i.Wattage,
+		&i.LaserModelID,
+		&i.OperationType,
+		&i.MaxPower,
+		&i.MinPower,
+		&i.MaxPower2,
+		&i.MinPower2,
+		&i.Speed,
+		&i.NumPasses,
+		&i.ZOffset,
+		&i.ZPerPass,
+		&i.ScanInterval,
+		&i.Angle,
+		&i.AnglePerPass,
+		&i.CrossHatch,
+		&i.Bidir,
+		&i.ScanOpt,
+		&i.FloodFill,
+		&i.AutoRotate,
+		&i.Overscan,
+		&i.OverscanPercent,
+		&i.Frequency,
+		&i.WobbleEnable,
+		&i.UseDotCorrection,
+		&i.PulseWidth,
+		&i.WobbleDiameter,
+		&i.WobbleStep,
+		&i.Kerf,
+		&i.RunBlower,
+		&i.LayerName,
+		&i.LayerSubname,
+		&i.Priority,
+		&i.TabCount,
+		&i.TabCountMax,
+		&i.ThicknessMm,
+		&i.Color,
+		&i.Finish,
+		&i.Grade,
+		&i.TestCount,
+		&i.Confidence,
+		&i.EffectiveWatts,
+		&i.LineEnergy,
+		&i.Fluence,
+		&i.PulseEnergy,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FirstName,
+		&i.LastName,
+		&i.DisplayName,
+		&i.MaterialName,
+		&i.CategoryName,
+		&i.VoteScore,
+		&i.VoteCount,
+	)
+	return i, err
+}
+
+const getSettingForks = `-- name: GetSettingForks :many
+SELECT s.id, s.user_id, s.laser_type, s.wattage, u.first_name, u.last_name, u.display_name
+FROM settings s
+JOIN users u ON s.user_id = u.id
+WHERE s.forked_from_id = ?
+ORDER BY s.created_at, s.id
+`
+
+type GetSettingForksRow struct {
+	ID          int32
+	UserID      int32
+	LaserType   SettingsLaserType
+	Wattage     int32
+	FirstName   string
+	LastName    string
+	DisplayName sql.NullString
+}
+
+func (q *Queries) GetSettingForks(ctx context.Context, forkedFromID sql.NullInt32) ([]GetSettingForksRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSettingForks, forkedFromID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSettingForksRow
+	for rows.Next() {
+		var i GetSettingForksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.LaserType,
+			&i.Wattage,
+			&i.FirstName,
+			&i.LastName,
+			&i.DisplayName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSettingParent = `-- name: GetSettingParent :one
+
+SELECT s.id, s.user_id, u.first_name, u.last_name, u.display_name
+FROM settings s
+JOIN users u ON s.user_id = u.id
+WHERE s.id = ?
+`
+
+type GetSettingParentRow struct {
+	ID          int32
+	UserID      int32
+	FirstName   string
+	LastName    string
+	DisplayName sql.NullString
+}
+
+// =====================
+// SETTING FORKS
+// =====================
+func (q *Queries) GetSettingParent(ctx context.Context, id int32) (GetSettingParentRow, error) {
+	row := q.db.QueryRowContext(ctx, getSettingParent, id)
+	var i GetSettingParentRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.FirstName,
+		&i.LastName,
+		&i.DisplayName,
+	)
+	return i, err
+}
+
+const getSettingRevision = `-- name: GetSettingRevision :one
+SELECT id, setting_id, revision, user_id, action, reverted_from, snapshot, created_at
+FROM setting_revisions
+WHERE setting_id = ? AND revision = ?
+`
+
+type GetSettingRevisionParams struct {
+	SettingID int32
+	Revision  int32
+}
+
+func (q *Queries) GetSettingRevision(ctx context.Context, arg GetSettingRevisionParams) (SettingRevision, error) {
+	row := q.db.QueryRowContext(ctx, getSettingRevision, arg.SettingID, arg.Revision)
+	var i SettingRevision
+	err := row.Scan(
+		&i.ID,
+		&i.SettingID,
+		&i.Revision,
+		&i.UserID,
+		&i.Action,
+		&i.RevertedFrom,
+		&i.Snapshot,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getSettingRevisions = `-- name: GetSettingRevisions :many
+SELECT r.id, r.setting_id, r.revision, r.user_id, r.action, r.reverted_from, r.snapshot, r.created_at,
+       u.display_name, u.first_name, u.last_name
+FROM setting_revisions r
+LEFT JOIN users u ON r.user_id = u.id
+WHERE r.setting_id = ?
+ORDER BY r.revision DESC
+`
+
+type GetSettingRevisionsRow struct {
+	ID           int32
+	SettingID    int32
+	Revision     int32
+	UserID       sql.NullInt32
+	Action       SettingRevisionsAction
+	RevertedFrom sql.NullInt32
+	Snapshot     string
+	CreatedAt    sql.NullTime
+	DisplayName  sql.NullString
+	FirstName    sql.NullString
+	LastName     sql.NullString
+}
+
+func (q *Queries) GetSettingRevisions(ctx context.Context, settingID int32) ([]GetSettingRevisionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSettingRevisions, settingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSettingRevisionsRow
+	for rows.Next() {
+		var i GetSettingRevisionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SettingID,
+			&i.Revision,
+			&i.UserID,
+			&i.Action,
+			&i.RevertedFrom,
+			&i.Snapshot,
+			&i.CreatedAt,
+			&i.DisplayName,
+			&i.FirstName,
+			&i.LastName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSettingValues = `-- name: GetSettingValues :one
+
+SELECT max_power, min_power, max_power2, min_power2, speed,
+       num_passes, z_offset, z_per_pass,
+       scan_interval, angle, angle_per_pass,
+       cross_hatch, bidir, scan_opt,
+       flood_fill, auto_rotate, overscan, overscan_percent,
+       frequency, wobble_enable, use_dot_correction,
+       pulse_width, wobble_diameter, wobble_step,
+       perforation_mode, dot_width,
+       image_mode, negative_image,
+       kerf, run_blower,
+       layer_name, layer_subname,
+       priority, tab_count, tab_count_max,
+       thickness_mm, color, finish, grade,
+       notes
+FROM settings
+WHERE id = ?
+`
+
+type GetSettingValuesRow struct {
 	MaxPower         string
 	MinPower         string
 	MaxPower2        sql.NullString
@@ -637,6 +2259,13 @@ type GetSettingByIDRow struct {
 	Frequency        sql.NullString
 	WobbleEnable     sql.NullBool
 	UseDotCorrection sql.NullBool
+	PulseWidth       sql.NullString
+	WobbleDiameter   sql.NullString
+	WobbleStep       sql.NullString
+	PerforationMode  bool
+	DotWidth         sql.NullString
+	ImageMode        sql.NullString
+	NegativeImage    bool
 	Kerf             sql.NullString
 	RunBlower        sql.NullBool
 	LayerName        sql.NullString
@@ -644,31 +2273,20 @@ type GetSettingByIDRow struct {
 	Priority         sql.NullInt32
 	TabCount         sql.NullInt32
 	TabCountMax      sql.NullInt32
+	ThicknessMm      sql.NullString
+	Color            sql.NullString
+	Finish           sql.NullString
+	Grade            sql.NullString
 	Notes            sql.NullString
-	CreatedAt        sql.NullTime
-	UpdatedAt        sql.NullTime
-	FirstName        string
-	LastName         string
-	DisplayName      sql.NullString
-	MaterialName     string
-	CategoryName     string
-	VoteScore        int64
-	VoteCount        int64
 }
 
 // =====================
-// SETTINGS
+// SETTING REVISIONS
 // =====================
-func (q *Queries) GetSettingByID(ctx context.Context, id int32) (GetSettingByIDRow, error) {
-	row := q.db.QueryRowContext(ctx, getSettingByID, id)
-	var i GetSettingByIDRow
+func (q *Queries) GetSettingValues(ctx context.Context, id int32) (GetSettingValuesRow, error) {
+	row := q.db.QueryRowContext(ctx, getSettingValues, id)
+	var i GetSettingValuesRow
 	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.MaterialID,
-		&i.LaserType,
-		&i.Wattage,
-		&i.OperationType,
 		&i.MaxPower,
 		&i.MinPower,
 		&i.MaxPower2,
@@ -690,6 +2308,13 @@ func (q *Queries) GetSettingByID(ctx context.Context, id int32) (GetSettingByIDR
 		&i.Frequency,
 		&i.WobbleEnable,
 		&i.UseDotCorrection,
+		&i.PulseWidth,
+		&i.WobbleDiameter,
+		&i.WobbleStep,
+		&i.PerforationMode,
+		&i.DotWidth,
+		&i.ImageMode,
+		&i.NegativeImage,
 		&i.Kerf,
 		&i.RunBlower,
 		&i.LayerName,
@@ -697,42 +2322,406 @@ func (q *Queries) GetSettingByID(ctx context.Context, id int32) (GetSettingByIDR
 		&i.Priority,
 		&i.TabCount,
 		&i.TabCountMax,
+		&i.ThicknessMm,
+		&i.Color,
+		&i.Finish,
+		&i.Grade,
 		&i.Notes,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.FirstName,
-		&i.LastName,
-		&i.DisplayName,
-		&i.MaterialName,
-		&i.CategoryName,
-		&i.VoteScore,
-		&i.VoteCount,
 	)
 	return i, err
 }
 
-const getSettingsByLaserType = `-- name: GetSettingsByLaserType :many
-SELECT laser_type, COUNT(*) as count
-FROM settings
-GROUP BY laser_type
-ORDER BY count DESC
+const getSettingsByIDs = `-- name: GetSettingsByIDs :many
+SELECT s.id, s.user_id, s.material_id,
+       s.laser_type, s.wattage, s.laser_model_id, s.operation_type,
+       s.max_power, s.min_power, s.max_power2, s.min_power2, s.speed,
+       s.num_passes, s.z_offset, s.z_per_pass,
+       s.scan_interval, s.angle, s.angle_per_pass,
+       s.cross_hatch, s.bidir, s.scan_opt,
+       s.flood_fill, s.auto_rotate, s.overscan, s.overscan_percent,
+       s.frequency, s.wobble_enable, s.use_dot_correction,
+       s.pulse_width, s.wobble_diameter, s.wobble_step,
+       s.perforation_mode, s.enable_dot_width_adjust, s.dot_width,
+       s.image_mode, s.negative_image,
+       s.kerf, s.run_blower,
+       s.layer_name, s.layer_subname,
+       s.priority, s.tab_count, s.tab_count_max,
+       s.thickness_mm, s.color, s.finish, s.grade,
+       s.notes, s.created_at,
+       u.first_name, u.last_name, u.display_name,
+       mat.name as material_name, mc.name as category_name,
+       CAST(COALESCE(SUM(v.value), 0) AS SIGNED) as vote_score,
+       COUNT(v.id) as vote_count
+FROM settings s
+JOIN users u ON s.user_id = u.id
+JOIN materials mat ON s.material_id = mat.id
+JOIN material_categories mc ON mat.category_id = mc.id
+LEFT JOIN votes v ON v.setting_id = s.id
+WHERE s.id IN (/*SLICE:ids*/?)
+GROUP BY s.id
+ORDER BY mat.name, s.thickness_mm IS NOT NULL, s.thickness_mm, s.id
+`
+
+type GetSettingsByIDsRow struct {
+	ID                   int32
+	UserID               int32
+	MaterialID           int32
+	LaserType            SettingsLaserType
+	Wattage              int32
+	LaserModelID         sql.NullInt32
+	OperationType        SettingsOperationType
+	MaxPower             string
+	MinPower             string
+	MaxPower2            sql.NullString
+	MinPower2            sql.NullString
+	Speed                string
+	NumPasses            int32
+	ZOffset              sql.NullString
+	ZPerPass             sql.NullString
+	ScanInterval         sql.NullString
+	Angle                sql.NullString
+	AnglePerPass         sql.NullString
+	CrossHatch           bool
+	Bidir                bool
+	ScanOpt              sql.NullString
+	FloodFill            bool
+	AutoRotate           bool
+	Overscan             sql.NullString
+	OverscanPercent      sql.NullString
+	Frequency            sql.NullString
+	WobbleEnable         sql.NullBool
+	UseDotCorrection     sql.NullBool
+	PulseWidth           sql.NullString
+	WobbleDiameter       sql.NullString
+	WobbleStep           sql.NullString
+	PerforationMode      bool
+	EnableDotWidthAdjust bool
+	DotWidth             sql.NullString
+	ImageMode            sql.NullString
+	NegativeImage        bool
+	Kerf                 sql.NullString
+	RunBlower            sql.NullBool
+	LayerName            sql.NullString
+	LayerSubname         sql.NullString
+	Priority             sql.NullInt32
+	TabCount             sql.NullInt32
+	TabCountMax          sql.NullInt32
+	ThicknessMm          sql.NullString
+	Color                sql.NullString
+	Finish               sql.NullString
+	Grade                sql.NullString
+	Notes                sql.NullString
+	CreatedAt            sql.NullTime
+	FirstName            string
+	LastName             string
+	DisplayName          sql.NullString
+	MaterialName         string
+	CategoryName         string
+	VoteScore            int64
+	VoteCount            int64
+}
+
+func (q *Queries) GetSettingsByIDs(ctx context.Context, ids []int32) ([]GetSettingsByIDsRow, error) {
+	query := getSettingsByIDs
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSettingsByIDsRow
+	for rows.Next() {
+		var i GetSettingsByIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.MaterialID,
+			&i.LaserType,
+			&i.Wattage,
+			&i.LaserModelID,
+			&i.OperationType,
+			&i.MaxPower,
+			&i.MinPower,
+			&i.MaxPower2,
+			&i.MinPower2,
+			&i.Speed,
+			&i.NumPasses,
+			&i.ZOffset,
+			&i.ZPerPass,
+			&i.ScanInterval,
+			&i.Angle,
+			&i.AnglePerPass,
+			&i.CrossHatch,
+			&i.Bidir,
+			&i.ScanOpt,
+			&i.FloodFill,
+			&i.AutoRotate,
+			&i.Overscan,
+			&i.OverscanPercent,
+			&i.Frequency,
+			&i.WobbleEnable,
+			&i.UseDotCorrection,
+			&i.PulseWidth,
+			&i.WobbleDiameter,
+			&i.WobbleStep,
+			&i.PerforationMode,
+			&i.EnableDotWidthAdjust,
+			&i.DotWidth,
+			&i.ImageMode,
+			&i.NegativeImage,
+			&i.Kerf,
+			&i.RunBlower,
+			&i.LayerName,
+			&i.LayerSubname,
+			&i.Priority,
+			&i.TabCount,
+			&i.TabCountMax,
+			&i.ThicknessMm,
+			&i.Color,
+			&i.Finish,
+			&i.Grade,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.FirstName,
+			&i.LastName,
+			&i.DisplayName,
+			&i.MaterialName,
+			&i.CategoryName,
+			&i.VoteScore,
+			&i.VoteCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSettingsByLaserType = `-- name: GetSettingsByLaserType :many
+SELECT laser_type, COUNT(*) as count
+FROM settings
+GROUP BY laser_type
+ORDER BY count DESC
+`
+
+type GetSettingsByLaserTypeRow struct {
+	LaserType SettingsLaserType
+	Count     int64
+}
+
+func (q *Queries) GetSettingsByLaserType(ctx context.Context) ([]GetSettingsByLaserTypeRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSettingsByLaserType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSettingsByLaserTypeRow
+	for rows.Next() {
+		var i GetSettingsByLaserTypeRow
+		if err := rows.Scan(&i.LaserType, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSettingsCountAdmin = `-- name: GetSettingsCountAdmin :one
+SELECT COUNT(*) as total
+FROM settings s
+WHERE (? IS NULL OR s.material_id = ?)
+  AND (? IS NULL OR s.laser_type = ?)
+`
+
+type GetSettingsCountAdminParams struct {
+	MaterialID sql.NullInt32
+	LaserType  NullSettingsLaserType
+}
+
+func (q *Queries) GetSettingsCountAdmin(ctx context.Context, arg GetSettingsCountAdminParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getSettingsCountAdmin,
+		arg.MaterialID,
+		arg.MaterialID,
+		arg.LaserType,
+		arg.LaserType,
+	)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const getSublayersBySetting = `-- name: GetSublayersBySetting :many
+
+SELECT id, setting_id, sublayer_index, sublayer_type, is_cleanup,
+       max_power, min_power, max_power2, min_power2, speed,
+       num_passes, frequency, scan_interval, subname
+FROM setting_sublayers
+WHERE setting_id = ?
+ORDER BY sublayer_index, id
+`
+
+// =====================
+// SETTING SUBLAYERS
+// =====================
+func (q *Queries) GetSublayersBySetting(ctx context.Context, settingID int32) ([]SettingSublayer, error) {
+	rows, err := q.db.QueryContext(ctx, getSublayersBySetting, settingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SettingSublayer
+	for rows.Next() {
+		var i SettingSublayer
+		if err := rows.Scan(
+			&i.ID,
+			&i.SettingID,
+			&i.SublayerIndex,
+			&i.SublayerType,
+			&i.IsCleanup,
+			&i.MaxPower,
+			&i.MinPower,
+			&i.MaxPower2,
+			&i.MinPower2,
+			&i.Speed,
+			&i.NumPasses,
+			&i.Frequency,
+			&i.ScanInterval,
+			&i.Subname,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSublayersBySettingIDs = `-- name: GetSublayersBySettingIDs :many
+SELECT id, setting_id, sublayer_index, sublayer_type, is_cleanup,
+       max_power, min_power, max_power2, min_power2, speed,
+       num_passes, frequency, scan_interval, subname
+FROM setting_sublayers
+WHERE setting_id IN (/*SLICE:setting_ids*/?)
+ORDER BY setting_id, sublayer_index, id
 `
 
-type GetSettingsByLaserTypeRow struct {
-	LaserType SettingsLaserType
-	Count     int64
+func (q *Queries) GetSublayersBySettingIDs(ctx context.Context, settingIds []int32) ([]SettingSublayer, error) {
+	query := getSublayersBySettingIDs
+	var queryParams []interface{}
+	if len(settingIds) > 0 {
+		for _, v := range settingIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:setting_ids*/?", strings.Repeat(",?", len(settingIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:setting_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SettingSublayer
+	for rows.Next() {
+		var i SettingSublayer
+		if err := rows.Scan(
+			&i.ID,
+			&i.SettingID,
+			&i.SublayerIndex,
+			&i.SublayerType,
+			&i.IsCleanup,
+			&i.MaxPower,
+			&i.MinPower,
+			&i.MaxPower2,
+			&i.MinPower2,
+			&i.Speed,
+			&i.NumPasses,
+			&i.Frequency,
+			&i.ScanInterval,
+			&i.Subname,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) GetSettingsByLaserType(ctx context.Context) ([]GetSettingsByLaserTypeRow, error) {
-	rows, err := q.db.QueryContext(ctx, getSettingsByLaserType)
+const getTaxonomyAudit = `-- name: GetTaxonomyAudit :many
+SELECT t.id, t.admin_user_id, t.action, t.entity_type, t.entity_id, t.details, t.created_at,
+       u.email as admin_email
+FROM taxonomy_audit t
+LEFT JOIN users u ON t.admin_user_id = u.id
+ORDER BY t.created_at DESC, t.id DESC
+LIMIT ? OFFSET ?
+`
+
+type GetTaxonomyAuditParams struct {
+	Limit  int32
+	Offset int32
+}
+
+type GetTaxonomyAuditRow struct {
+	ID          int32
+	AdminUserID sql.NullInt32
+	Action      string
+	EntityType  TaxonomyAuditEntityType
+	EntityID    int32
+	Details     sql.NullString
+	CreatedAt   sql.NullTime
+	AdminEmail  sql.NullString
+}
+
+func (q *Queries) GetTaxonomyAudit(ctx context.Context, arg GetTaxonomyAuditParams) ([]GetTaxonomyAuditRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTaxonomyAudit, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetSettingsByLaserTypeRow
+	var items []GetTaxonomyAuditRow
 	for rows.Next() {
-		var i GetSettingsByLaserTypeRow
-		if err := rows.Scan(&i.LaserType, &i.Count); err != nil {
+		var i GetTaxonomyAuditRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AdminUserID,
+			&i.Action,
+			&i.EntityType,
+			&i.EntityID,
+			&i.Details,
+			&i.CreatedAt,
+			&i.AdminEmail,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -746,30 +2735,110 @@ func (q *Queries) GetSettingsByLaserType(ctx context.Context) ([]GetSettingsByLa
 	return items, nil
 }
 
-const getSettingsCountAdmin = `-- name: GetSettingsCountAdmin :one
+const getTaxonomyAuditCount = `-- name: GetTaxonomyAuditCount :one
 SELECT COUNT(*) as total
-FROM settings s
-WHERE (? IS NULL OR s.material_id = ?)
-  AND (? IS NULL OR s.laser_type = ?)
+FROM taxonomy_audit
 `
 
-type GetSettingsCountAdminParams struct {
-	MaterialID sql.NullInt32
-	LaserType  NullSettingsLaserType
-}
-
-func (q *Queries) GetSettingsCountAdmin(ctx context.Context, arg GetSettingsCountAdminParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getSettingsCountAdmin,
-		arg.MaterialID,
-		arg.MaterialID,
-		arg.LaserType,
-		arg.LaserType,
-	)
+func (q *Queries) GetTaxonomyAuditCount(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getTaxonomyAuditCount)
 	var total int64
 	err := row.Scan(&total)
 	return total, err
 }
 
+const getTestReportSummary = `-- name: GetTestReportSummary :one
+SELECT CAST(COALESCE(SUM(outcome = 'worked'), 0) AS SIGNED) as worked,
+       CAST(COALESCE(SUM(outcome = 'adjusted'), 0) AS SIGNED) as adjusted,
+       CAST(COALESCE(SUM(outcome = 'failed'), 0) AS SIGNED) as failed
+FROM test_reports
+WHERE setting_id = ?
+`
+
+type GetTestReportSummaryRow struct {
+	Worked   int64
+	Adjusted int64
+	Failed   int64
+}
+
+func (q *Queries) GetTestReportSummary(ctx context.Context, settingID int32) (GetTestReportSummaryRow, error) {
+	row := q.db.QueryRowContext(ctx, getTestReportSummary, settingID)
+	var i GetTestReportSummaryRow
+	err := row.Scan(&i.Worked, &i.Adjusted, &i.Failed)
+	return i, err
+}
+
+const getTestReports = `-- name: GetTestReports :many
+SELECT t.id, t.setting_id, t.user_id, t.revision, t.laser_model_id, t.make_model, t.laser_type, t.wattage,
+       t.material_batch, t.outcome, t.adjusted_values, t.notes, t.created_at, t.updated_at,
+       u.first_name, u.last_name, u.display_name
+FROM test_reports t
+JOIN users u ON t.user_id = u.id
+WHERE t.setting_id = ?
+ORDER BY t.updated_at DESC, t.id DESC
+`
+
+type GetTestReportsRow struct {
+	ID             int32
+	SettingID      int32
+	UserID         int32
+	Revision       int32
+	LaserModelID   sql.NullInt32
+	MakeModel      sql.NullString
+	LaserType      TestReportsLaserType
+	Wattage        int32
+	MaterialBatch  sql.NullString
+	Outcome        TestReportsOutcome
+	AdjustedValues sql.NullString
+	Notes          sql.NullString
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+	FirstName      string
+	LastName       string
+	DisplayName    sql.NullString
+}
+
+func (q *Queries) GetTestReports(ctx context.Context, settingID int32) ([]GetTestReportsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTestReports, settingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTestReportsRow
+	for rows.Next() {
+		var i GetTestReportsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SettingID,
+			&i.UserID,
+			&i.Revision,
+			&i.LaserModelID,
+			&i.MakeModel,
+			&i.LaserType,
+			&i.Wattage,
+			&i.MaterialBatch,
+			&i.Outcome,
+			&i.AdjustedValues,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FirstName,
+			&i.LastName,
+			&i.DisplayName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTopMaterialsBySettings = `-- name: GetTopMaterialsBySettings :many
 SELECT m.name as material_name, COUNT(s.id) as setting_count
 FROM materials m
@@ -825,11 +2894,18 @@ JOIN users u ON s.user_id = u.id
 JOIN materials mat ON s.material_id = mat.id
 JOIN material_categories mc ON mat.category_id = mc.id
 LEFT JOIN votes v ON v.setting_id = s.id
+WHERE (? IS NULL OR s.laser_type = ?)
+  AND (? IS NULL OR s.wattage = ?)
 GROUP BY s.id
 ORDER BY vote_score DESC
 LIMIT 20
 `
 
+type GetTopSettingsParams struct {
+	LaserType NullSettingsLaserType
+	Wattage   sql.NullInt32
+}
+
 type GetTopSettingsRow struct {
 	ID               int32
 	UserID           int32
@@ -862,8 +2938,13 @@ type GetTopSettingsRow struct {
 	VoteCount        int64
 }
 
-func (q *Queries) GetTopSettings(ctx context.Context) ([]GetTopSettingsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getTopSettings)
+func (q *Queries) GetTopSettings(ctx context.Context, arg GetTopSettingsParams) ([]GetTopSettingsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTopSettings,
+		arg.LaserType,
+		arg.LaserType,
+		arg.Wattage,
+		arg.Wattage,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -915,6 +2996,47 @@ func (q *Queries) GetTopSettings(ctx context.Context) ([]GetTopSettingsRow, erro
 	return items, nil
 }
 
+const getUpvoteEdges = `-- name: GetUpvoteEdges :many
+
+SELECT v.user_id as voter_id, s.user_id as owner_id, COUNT(*) as upvotes
+FROM votes v
+JOIN settings s ON v.setting_id = s.id
+WHERE v.value = 1 AND v.user_id <> s.user_id
+GROUP BY v.user_id, s.user_id
+`
+
+type GetUpvoteEdgesRow struct {
+	VoterID int32
+	OwnerID int32
+	Upvotes int64
+}
+
+// =====================
+// VOTE INTEGRITY (ADMIN)
+// =====================
+func (q *Queries) GetUpvoteEdges(ctx context.Context) ([]GetUpvoteEdgesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUpvoteEdges)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUpvoteEdgesRow
+	for rows.Next() {
+		var i GetUpvoteEdgesRow
+		if err := rows.Scan(&i.VoterID, &i.OwnerID, &i.Upvotes); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
 SELECT id, first_name, last_name, email, password_hash, display_name, email_verified, is_admin, created_at
 FROM users
@@ -1066,11 +3188,239 @@ type GetUserCountAdminParams struct {
 	Search interface{}
 }
 
-func (q *Queries) GetUserCountAdmin(ctx context.Context, arg GetUserCountAdminParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getUserCountAdmin, arg.Search, arg.Search)
-	var total int64
-	err := row.Scan(&total)
-	return total, err
+func (q *Queries) GetUserCountAdmin(ctx context.Context, arg GetUserCountAdminParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getUserCountAdmin, arg.Search, arg.Search)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const getUserImports = `-- name: GetUserImports :many
+SELECT i.id, i.user_id, i.filename, i.content_hash, i.laser_make_model, i.laser_type, i.wattage,
+       i.imported_count, i.skipped_count, i.conflict_count, i.failed_count, i.errors, i.created_at,
+       COUNT(s.id) as setting_count
+FROM imports i
+LEFT JOIN settings s ON s.import_id = i.id
+WHERE i.user_id = ?
+GROUP BY i.id
+ORDER BY i.created_at DESC
+`
+
+type GetUserImportsRow struct {
+	ID             int32
+	UserID         int32
+	Filename       string
+	ContentHash    string
+	LaserMakeModel string
+	LaserType      ImportsLaserType
+	Wattage        int32
+	ImportedCount  int32
+	SkippedCount   int32
+	ConflictCount  int32
+	FailedCount    int32
+	Errors         sql.NullString
+	CreatedAt      sql.NullTime
+	SettingCount   int64
+}
+
+func (q *Queries) GetUserImports(ctx context.Context, userID int32) ([]GetUserImportsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserImports, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserImportsRow
+	for rows.Next() {
+		var i GetUserImportsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Filename,
+			&i.ContentHash,
+			&i.LaserMakeModel,
+			&i.LaserType,
+			&i.Wattage,
+			&i.ImportedCount,
+			&i.SkippedCount,
+			&i.ConflictCount,
+			&i.FailedCount,
+			&i.Errors,
+			&i.CreatedAt,
+			&i.SettingCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserMachineByID = `-- name: GetUserMachineByID :one
+SELECT id, user_id, laser_model_id, make_model, laser_type, wattage,
+       lens_mm, notes, is_default, created_at, updated_at
+FROM user_machines
+WHERE id = ? AND user_id = ?
+`
+
+type GetUserMachineByIDParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) GetUserMachineByID(ctx context.Context, arg GetUserMachineByIDParams) (UserMachine, error) {
+	row := q.db.QueryRowContext(ctx, getUserMachineByID, arg.ID, arg.UserID)
+	var i UserMachine
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.LaserModelID,
+		&i.MakeModel,
+		&i.LaserType,
+		&i.Wattage,
+		&i.LensMm,
+		&i.Notes,
+		&i.IsDefault,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getUserMachines = `-- name: GetUserMachines :many
+
+SELECT id, user_id, laser_model_id, make_model, laser_type, wattage,
+       lens_mm, notes, is_default, created_at, updated_at
+FROM user_machines
+WHERE user_id = ?
+ORDER BY is_default DESC, created_at
+`
+
+// =====================
+// USER MACHINES
+// =====================
+func (q *Queries) GetUserMachines(ctx context.Context, userID int32) ([]UserMachine, error) {
+	rows, err := q.db.QueryContext(ctx, getUserMachines, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserMachine
+	for rows.Next() {
+		var i UserMachine
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.LaserModelID,
+			&i.MakeModel,
+			&i.LaserType,
+			&i.Wattage,
+			&i.LensMm,
+			&i.Notes,
+			&i.IsDefault,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserRecipeSets = `-- name: GetUserRecipeSets :many
+SELECT r.id, r.user_id, r.material_id, r.laser_type, r.wattage, r.laser_model_id,
+       r.name, r.description, r.published_at, r.created_at, r.updated_at,
+       u.first_name, u.last_name, u.display_name,
+       mat.name as material_name,
+       lm.name as laser_model_name, mf.name as manufacturer_name,
+       CAST(COALESCE(SUM(v.value), 0) AS SIGNED) as vote_score,
+       COUNT(v.id) as vote_count
+FROM recipe_sets r
+JOIN users u ON r.user_id = u.id
+JOIN materials mat ON r.material_id = mat.id
+LEFT JOIN laser_models lm ON r.laser_model_id = lm.id
+LEFT JOIN laser_manufacturers mf ON lm.manufacturer_id = mf.id
+LEFT JOIN recipe_set_votes v ON v.recipe_set_id = r.id
+WHERE r.user_id = ?
+GROUP BY r.id
+ORDER BY r.updated_at DESC, r.id DESC
+`
+
+type GetUserRecipeSetsRow struct {
+	ID               int32
+	UserID           int32
+	MaterialID       int32
+	LaserType        RecipeSetsLaserType
+	Wattage          int32
+	LaserModelID     sql.NullInt32
+	Name             string
+	Description      sql.NullString
+	PublishedAt      sql.NullTime
+	CreatedAt        sql.NullTime
+	UpdatedAt        sql.NullTime
+	FirstName        string
+	LastName         string
+	DisplayName      sql.NullString
+	MaterialName     string
+	LaserModelName   sql.NullString
+	ManufacturerName sql.NullString
+	VoteScore        int64
+	VoteCount        int64
+}
+
+func (q *Queries) GetUserRecipeSets(ctx context.Context, userID int32) ([]GetUserRecipeSetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserRecipeSets, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserRecipeSetsRow
+	for rows.Next() {
+		var i GetUserRecipeSetsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.MaterialID,
+			&i.LaserType,
+			&i.Wattage,
+			&i.LaserModelID,
+			&i.Name,
+			&i.Description,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FirstName,
+			&i.LastName,
+			&i.DisplayName,
+			&i.MaterialName,
+			&i.LaserModelName,
+			&i.ManufacturerName,
+			&i.VoteScore,
+			&i.VoteCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getUserSettings = `-- name: GetUserSettings :many
@@ -1167,7 +3517,350 @@ func (q *Queries) GetUserSettings(ctx context.Context, userID int32) ([]GetUserS
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.MaterialName,
-			&i.CategoryName,
+			&i.CategoryName,
+			&i.VoteScore,
+			&i.VoteCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserSettingsForImport = `-- name: GetUserSettingsForImport :many
+SELECT s.id, s.material_id, s.operation_type,
+       s.max_power, s.min_power, s.speed,
+       s.num_passes, s.scan_interval, s.frequency,
+       s.image_mode, s.layer_name, s.layer_subname, s.thickness_mm,
+       mat.name as material_name
+FROM settings s
+JOIN materials mat ON s.material_id = mat.id
+WHERE s.user_id = ? AND s.laser_type = ? AND s.wattage = ?
+`
+
+type GetUserSettingsForImportParams struct {
+	UserID    int32
+	LaserType SettingsLaserType
+	Wattage   int32
+}
+
+type GetUserSettingsForImportRow struct {
+	ID            int32
+	MaterialID    int32
+	OperationType SettingsOperationType
+	MaxPower      string
+	MinPower      string
+	Speed         string
+	NumPasses     int32
+	ScanInterval  sql.NullString
+	Frequency     sql.NullString
+	ImageMode     sql.NullString
+	LayerName     sql.NullString
+	LayerSubname  sql.NullString
+	ThicknessMm   sql.NullString
+	MaterialName  string
+}
+
+func (q *Queries) GetUserSettingsForImport(ctx context.Context, arg GetUserSettingsForImportParams) ([]GetUserSettingsForImportRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserSettingsForImport, arg.UserID, arg.LaserType, arg.Wattage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserSettingsForImportRow
+	for rows.Next() {
+		var i GetUserSettingsForImportRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.MaterialID,
+			&i.OperationType,
+			&i.MaxPower,
+			&i.MinPower,
+			&i.Speed,
+			&i.NumPasses,
+			&i.ScanInterval,
+			&i.Frequency,
+			&i.ImageMode,
+			&i.LayerName,
+			&i.LayerSubname,
+			&i.ThicknessMm,
+			&i.MaterialName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserUnits = `-- name: GetUserUnits :one
+SELECT speed_unit, interval_unit
+FROM users
+WHERE id = ?
+`
+
+type GetUserUnitsRow struct {
+	SpeedUnit    string
+	IntervalUnit string
+}
+
+func (q *Queries) GetUserUnits(ctx context.Context, id int32) (GetUserUnitsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserUnits, id)
+	var i GetUserUnitsRow
+	err := row.Scan(&i.SpeedUnit, &i.IntervalUnit)
+	return i, err
+}
+
+const getUserVoteForSetting = `-- name: GetUserVoteForSetting :one
+
+SELECT id, user_id, setting_id, value, revision, created_at
+FROM votes
+WHERE user_id = ? AND setting_id = ?
+`
+
+type GetUserVoteForSettingParams struct {
+	UserID    int32
+	SettingID int32
+}
+
+// =====================
+// VOTES
+// =====================
+func (q *Queries) GetUserVoteForSetting(ctx context.Context, arg GetUserVoteForSettingParams) (Vote, error) {
+	row := q.db.QueryRowContext(ctx, getUserVoteForSetting, arg.UserID, arg.SettingID)
+	var i Vote
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.SettingID,
+		&i.Value,
+		&i.Revision,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getVoteScore = `-- name: GetVoteScore :one
+SELECT COALESCE(SUM(value), 0) as score, COUNT(id) as total
+FROM votes
+WHERE setting_id = ?
+`
+
+type GetVoteScoreRow struct {
+	Score interface{}
+	Total int64
+}
+
+func (q *Queries) GetVoteScore(ctx context.Context, settingID int32) (GetVoteScoreRow, error) {
+	row := q.db.QueryRowContext(ctx, getVoteScore, settingID)
+	var i GetVoteScoreRow
+	err := row.Scan(&i.Score, &i.Total)
+	return i, err
+}
+
+const getVoterTotals = `-- name: GetVoterTotals :many
+SELECT u.id, u.email, u.first_name, u.last_name, u.display_name, u.email_verified, u.created_at,
+       COUNT(v.id) as vote_count,
+       CAST(COALESCE(SUM(v.value = 1), 0) AS SIGNED) as upvote_count
+FROM users u
+JOIN votes v ON v.user_id = u.id
+GROUP BY u.id
+`
+
+type GetVoterTotalsRow struct {
+	ID            int32
+	Email         string
+	FirstName     string
+	LastName      string
+	DisplayName   sql.NullString
+	EmailVerified bool
+	CreatedAt     sql.NullTime
+	VoteCount     int64
+	UpvoteCount   int64
+}
+
+func (q *Queries) GetVoterTotals(ctx context.Context) ([]GetVoterTotalsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getVoterTotals)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetVoterTotalsRow
+	for rows.Next() {
+		var i GetVoterTotalsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.FirstName,
+			&i.LastName,
+			&i.DisplayName,
+			&i.EmailVerified,
+			&i.CreatedAt,
+			&i.VoteCount,
+			&i.UpvoteCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVotesByRevision = `-- name: GetVotesByRevision :many
+SELECT revision,
+       CAST(COALESCE(SUM(value = 1), 0) AS SIGNED) as upvotes,
+       CAST(COALESCE(SUM(value = -1), 0) AS SIGNED) as downvotes
+FROM votes
+WHERE setting_id = ?
+GROUP BY revision
+`
+
+type GetVotesByRevisionRow struct {
+	Revision  sql.NullInt32
+	Upvotes   int64
+	Downvotes int64
+}
+
+func (q *Queries) GetVotesByRevision(ctx context.Context, settingID int32) ([]GetVotesByRevisionRow, error) {
+	rows, err := q.db.QueryContext(ctx, getVotesByRevision, settingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetVotesByRevisionRow
+	for rows.Next() {
+		var i GetVotesByRevisionRow
+		if err := rows.Scan(&i.Revision, &i.Upvotes, &i.Downvotes); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecipeSets = `-- name: ListRecipeSets :many
+SELECT r.id, r.user_id, r.material_id, r.laser_type, r.wattage, r.laser_model_id,
+       r.name, r.description, r.published_at, r.created_at, r.updated_at,
+       u.first_name, u.last_name, u.display_name,
+       mat.name as material_name,
+       lm.name as laser_model_name, mf.name as manufacturer_name,
+       CAST(COALESCE(SUM(v.value), 0) AS SIGNED) as vote_score,
+       COUNT(v.id) as vote_count
+FROM recipe_sets r
+JOIN users u ON r.user_id = u.id
+JOIN materials mat ON r.material_id = mat.id
+LEFT JOIN laser_models lm ON r.laser_model_id = lm.id
+LEFT JOIN laser_manufacturers mf ON lm.manufacturer_id = mf.id
+LEFT JOIN recipe_set_votes v ON v.recipe_set_id = r.id
+WHERE r.published_at IS NOT NULL
+  AND (? IS NULL OR r.material_id = ?)
+  AND (? IS NULL OR r.laser_type = ?)
+  AND (? IS NULL OR r.wattage = ?)
+  AND (? IS NULL OR r.laser_model_id = ?)
+GROUP BY r.id
+ORDER BY CASE ?
+             WHEN 'newest' THEN UNIX_TIMESTAMP(r.published_at)
+             ELSE COALESCE(SUM(v.value), 0)
+         END DESC, r.published_at DESC, r.id DESC
+LIMIT ? OFFSET ?
+`
+
+type ListRecipeSetsParams struct {
+	MaterialID   sql.NullInt32
+	LaserType    NullRecipeSetsLaserType
+	Wattage      sql.NullInt32
+	LaserModelID sql.NullInt32
+	SortKey      interface{}
+	Limit        int32
+	Offset       int32
+}
+
+type ListRecipeSetsRow struct {
+	ID               int32
+	UserID           int32
+	MaterialID       int32
+	LaserType        RecipeSetsLaserType
+	Wattage          int32
+	LaserModelID     sql.NullInt32
+	Name             string
+	Description      sql.NullString
+	PublishedAt      sql.NullTime
+	CreatedAt        sql.NullTime
+	UpdatedAt        sql.NullTime
+	FirstName        string
+	LastName         string
+	DisplayName      sql.NullString
+	MaterialName     string
+	LaserModelName   sql.NullString
+	ManufacturerName sql.NullString
+	VoteScore        int64
+	VoteCount        int64
+}
+
+func (q *Queries) ListRecipeSets(ctx context.Context, arg ListRecipeSetsParams) ([]ListRecipeSetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRecipeSets,
+		arg.MaterialID,
+		arg.MaterialID,
+		arg.LaserType,
+		arg.LaserType,
+		arg.Wattage,
+		arg.Wattage,
+		arg.LaserModelID,
+		arg.LaserModelID,
+		arg.SortKey,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRecipeSetsRow
+	for rows.Next() {
+		var i ListRecipeSetsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.MaterialID,
+			&i.LaserType,
+			&i.Wattage,
+			&i.LaserModelID,
+			&i.Name,
+			&i.Description,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FirstName,
+			&i.LastName,
+			&i.DisplayName,
+			&i.MaterialName,
+			&i.LaserModelName,
+			&i.ManufacturerName,
 			&i.VoteScore,
 			&i.VoteCount,
 		); err != nil {
@@ -1184,50 +3877,183 @@ func (q *Queries) GetUserSettings(ctx context.Context, userID int32) ([]GetUserS
 	return items, nil
 }
 
-const getUserVoteForSetting = `-- name: GetUserVoteForSetting :one
+const moveMaterialAliases = `-- name: MoveMaterialAliases :execrows
+UPDATE material_aliases SET material_id = ?
+WHERE material_id = ?
+`
 
-SELECT id, user_id, setting_id, value, created_at
-FROM votes
-WHERE user_id = ? AND setting_id = ?
+type MoveMaterialAliasesParams struct {
+	TargetID int32
+	SourceID int32
+}
+
+func (q *Queries) MoveMaterialAliases(ctx context.Context, arg MoveMaterialAliasesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveMaterialAliases, arg.TargetID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const moveMaterialRecipeSets = `-- name: MoveMaterialRecipeSets :execrows
+UPDATE recipe_sets SET material_id = ?
+WHERE material_id = ?
 `
 
-type GetUserVoteForSettingParams struct {
-	UserID    int32
-	SettingID int32
+type MoveMaterialRecipeSetsParams struct {
+	TargetID int32
+	SourceID int32
 }
 
-// =====================
-// VOTES
-// =====================
-func (q *Queries) GetUserVoteForSetting(ctx context.Context, arg GetUserVoteForSettingParams) (Vote, error) {
-	row := q.db.QueryRowContext(ctx, getUserVoteForSetting, arg.UserID, arg.SettingID)
-	var i Vote
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.SettingID,
-		&i.Value,
-		&i.CreatedAt,
-	)
-	return i, err
+func (q *Queries) MoveMaterialRecipeSets(ctx context.Context, arg MoveMaterialRecipeSetsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveMaterialRecipeSets, arg.TargetID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const getVoteScore = `-- name: GetVoteScore :one
-SELECT COALESCE(SUM(value), 0) as score, COUNT(id) as total
-FROM votes
-WHERE setting_id = ?
+const moveMaterialSettings = `-- name: MoveMaterialSettings :execrows
+UPDATE settings SET material_id = ?
+WHERE material_id = ?
 `
 
-type GetVoteScoreRow struct {
-	Score interface{}
-	Total int64
+type MoveMaterialSettingsParams struct {
+	TargetID int32
+	SourceID int32
 }
 
-func (q *Queries) GetVoteScore(ctx context.Context, settingID int32) (GetVoteScoreRow, error) {
-	row := q.db.QueryRowContext(ctx, getVoteScore, settingID)
-	var i GetVoteScoreRow
-	err := row.Scan(&i.Score, &i.Total)
-	return i, err
+func (q *Queries) MoveMaterialSettings(ctx context.Context, arg MoveMaterialSettingsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveMaterialSettings, arg.TargetID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const recategorizeMaterials = `-- name: RecategorizeMaterials :execrows
+UPDATE materials SET category_id = ?
+WHERE id IN (/*SLICE:ids*/?)
+`
+
+type RecategorizeMaterialsParams struct {
+	CategoryID int32
+	Ids        []int32
+}
+
+func (q *Queries) RecategorizeMaterials(ctx context.Context, arg RecategorizeMaterialsParams) (int64, error) {
+	query := recategorizeMaterials
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.CategoryID)
+	if len(arg.Ids) > 0 {
+		for _, v := range arg.Ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(arg.Ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	result, err := q.db.ExecContext(ctx, query, queryParams...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const renameCategory = `-- name: RenameCategory :execrows
+UPDATE material_categories SET name = ?
+WHERE id = ?
+`
+
+type RenameCategoryParams struct {
+	Name string
+	ID   int32
+}
+
+func (q *Queries) RenameCategory(ctx context.Context, arg RenameCategoryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, renameCategory, arg.Name, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchLaserModels = `-- name: SearchLaserModels :many
+SELECT lm.id, lm.manufacturer_id, lm.name, lm.slug, lm.laser_type, lm.wattage,
+       lm.source, lm.lens_mm, lm.field_size_mm, lm.motion_system,
+       mf.name as manufacturer_name
+FROM laser_models lm
+JOIN laser_manufacturers mf ON lm.manufacturer_id = mf.id
+WHERE (? IS NULL
+       OR CONCAT(mf.name, ' ', lm.name) LIKE CONCAT('%', ?, '%')
+       OR lm.name LIKE CONCAT('%', ?, '%'))
+  AND (? IS NULL OR lm.laser_type = ?)
+  AND (? IS NULL OR lm.wattage = ?)
+ORDER BY mf.name, lm.name, lm.wattage
+LIMIT 20
+`
+
+type SearchLaserModelsParams struct {
+	Query     interface{}
+	LaserType NullLaserModelsLaserType
+	Wattage   sql.NullInt32
+}
+
+type SearchLaserModelsRow struct {
+	ID               int32
+	ManufacturerID   int32
+	Name             string
+	Slug             string
+	LaserType        LaserModelsLaserType
+	Wattage          int32
+	Source           sql.NullString
+	LensMm           sql.NullString
+	FieldSizeMm      sql.NullString
+	MotionSystem     LaserModelsMotionSystem
+	ManufacturerName string
+}
+
+func (q *Queries) SearchLaserModels(ctx context.Context, arg SearchLaserModelsParams) ([]SearchLaserModelsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchLaserModels,
+		arg.Query,
+		arg.Query,
+		arg.Query,
+		arg.LaserType,
+		arg.LaserType,
+		arg.Wattage,
+		arg.Wattage,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchLaserModelsRow
+	for rows.Next() {
+		var i SearchLaserModelsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ManufacturerID,
+			&i.Name,
+			&i.Slug,
+			&i.LaserType,
+			&i.Wattage,
+			&i.Source,
+			&i.LensMm,
+			&i.FieldSizeMm,
+			&i.MotionSystem,
+			&i.ManufacturerName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const searchMaterials = `-- name: SearchMaterials :many
@@ -1344,37 +4170,140 @@ SELECT s.id, s.user_id, s.material_id,
        s.laser_type, s.wattage, s.operation_type,
        s.max_power, s.min_power, s.speed,
        s.num_passes, s.scan_interval, s.frequency,
+       s.pulse_width, s.wobble_enable, s.wobble_diameter, s.wobble_step,
        s.cross_hatch, s.bidir, s.angle, s.angle_per_pass,
        s.image_mode, s.negative_image,
        s.use_dot_correction, s.dot_width,
+       s.thickness_mm, s.color, s.finish, s.grade,
+       s.test_count, s.confidence,
+       s.effective_watts, s.line_energy, s.fluence, s.pulse_energy,
        s.notes, s.created_at,
        u.first_name, u.last_name, u.display_name,
        mat.name as material_name, mc.name as category_name,
        CAST(COALESCE(SUM(v.value), 0) AS SIGNED) as vote_score,
-       COUNT(v.id) as vote_count
+       COUNT(v.id) as vote_count,
+       CAST((MATCH(mat.name) AGAINST (?) * 4
+             + COALESCE((SELECT MAX(MATCH(ma.alias) AGAINST (?))
+                         FROM material_aliases ma WHERE ma.material_id = s.material_id), 0) * 3
+             + MATCH(mc.name) AGAINST (?)
+             + MATCH(s.notes, s.layer_name) AGAINST (?)
+             + COALESCE(MATCH(lm.name) AGAINST (?), 0)
+             + COALESCE(MATCH(mf.name) AGAINST (?), 0)
+             + (mat.name LIKE CONCAT('%', ?, '%'))) AS DECIMAL(12,6)) as relevance,
+       CAST(CASE ?
+           WHEN 'relevance' THEN (MATCH(mat.name) AGAINST (?) * 4
+                                  + COALESCE((SELECT MAX(MATCH(ma.alias) AGAINST (?))
+                                              FROM material_aliases ma WHERE ma.material_id = s.material_id), 0) * 3
+                                  + MATCH(mc.name) AGAINST (?)
+                                  + MATCH(s.notes, s.layer_name) AGAINST (?)
+                                  + COALESCE(MATCH(lm.name) AGAINST (?), 0)
+                                  + COALESCE(MATCH(mf.name) AGAINST (?), 0)
+                                  + (mat.name LIKE CONCAT('%', ?, '%')))
+           WHEN 'newest' THEN UNIX_TIMESTAMP(s.created_at)
+           WHEN 'speed' THEN s.speed
+           WHEN 'power' THEN s.max_power
+           WHEN 'effective_watts' THEN s.effective_watts
+           WHEN 'line_energy' THEN s.line_energy
+           WHEN 'fluence' THEN s.fluence
+           WHEN 'pulse_energy' THEN s.pulse_energy
+           WHEN 'confidence' THEN s.confidence
+           ELSE COALESCE(SUM(v.value), 0)
+       END AS DECIMAL(20,6)) as sort_value
 FROM settings s
 JOIN users u ON s.user_id = u.id
 JOIN materials mat ON s.material_id = mat.id
 JOIN material_categories mc ON mat.category_id = mc.id
+LEFT JOIN laser_models lm ON s.laser_model_id = lm.id
+LEFT JOIN laser_manufacturers mf ON lm.manufacturer_id = mf.id
 LEFT JOIN votes v ON v.setting_id = s.id
 WHERE (? IS NULL OR s.material_id = ?)
   AND (? IS NULL OR s.laser_type = ?)
   AND (? IS NULL OR s.wattage = ?)
+  AND (? IS NULL OR s.wattage >= ?)
+  AND (? IS NULL OR s.wattage <= ?)
   AND (? IS NULL OR s.operation_type = ?)
   AND (? IS NULL OR s.user_id = ?)
-  AND (? IS NULL OR mat.name LIKE CONCAT('%', ?, '%'))
+  AND (? IS NULL OR s.laser_model_id = ?)
+  AND (? IS NULL OR s.speed >= ?)
+  AND (? IS NULL OR s.speed <= ?)
+  AND (? IS NULL OR s.max_power >= ?)
+  AND (? IS NULL OR s.max_power <= ?)
+  AND (? IS NULL OR s.num_passes >= ?)
+  AND (? IS NULL OR s.num_passes <= ?)
+  AND (? IS NULL OR s.effective_watts >= ?)
+  AND (? IS NULL OR s.effective_watts <= ?)
+  AND (? IS NULL OR s.line_energy >= ?)
+  AND (? IS NULL OR s.line_energy <= ?)
+  AND (? IS NULL OR s.fluence >= ?)
+  AND (? IS NULL OR s.fluence <= ?)
+  AND (? IS NULL OR s.pulse_energy >= ?)
+  AND (? IS NULL OR s.pulse_energy <= ?)
+  AND (? IS NULL OR s.frequency >= ?)
+  AND (? IS NULL OR s.frequency <= ?)
+  AND (? IS NULL OR s.pulse_width >= ?)
+  AND (? IS NULL OR s.pulse_width <= ?)
+  AND (? IS NULL OR COALESCE(s.wobble_enable, FALSE) = ?)
+  AND (? IS NULL OR s.thickness_mm >= ?)
+  AND (? IS NULL OR s.thickness_mm <= ?)
+  AND (? IS NULL OR s.color = ?)
+  AND (? IS NULL OR s.finish = ?)
+  AND (? IS NULL OR s.grade = ?)
 GROUP BY s.id
-ORDER BY vote_score DESC, s.created_at DESC
-LIMIT 50
+-- Keyset pagination on (sort_value * sort_sign, id), newest-first within ties.
+-- sort_sign is 1 for descending and -1 for ascending, so both directions
+-- page the same way. Rows without a sort value (e.g. fluence of a Cut) come
+-- last; cursor_value is NULL once the cursor is among them.
+HAVING (? IS NULL OR relevance > 0)
+AND (? IS NULL
+    OR (? IS NULL AND sort_value IS NULL AND s.id < ?)
+    OR (? IS NOT NULL AND (
+           sort_value IS NULL
+        OR sort_value * ? < ?
+        OR (sort_value * ? = ? AND s.id < ?))))
+ORDER BY sort_value IS NULL, sort_value * ? DESC, s.id DESC
+LIMIT ?
 `
 
 type SearchSettingsParams struct {
-	MaterialID    sql.NullInt32
-	LaserType     NullSettingsLaserType
-	Wattage       sql.NullInt32
-	OperationType NullSettingsOperationType
-	UserID        sql.NullInt32
-	Keyword       interface{}
+	KeywordQuery      string
+	SortKey           interface{}
+	MaterialID        sql.NullInt32
+	LaserType         NullSettingsLaserType
+	Wattage           sql.NullInt32
+	MinWattage        sql.NullInt32
+	MaxWattage        sql.NullInt32
+	OperationType     NullSettingsOperationType
+	UserID            sql.NullInt32
+	LaserModelID      sql.NullInt32
+	MinSpeed          sql.NullString
+	MaxSpeed          sql.NullString
+	MinPower          sql.NullString
+	MaxPower          sql.NullString
+	MinPasses         sql.NullInt32
+	MaxPasses         sql.NullInt32
+	MinEffectiveWatts sql.NullString
+	MaxEffectiveWatts sql.NullString
+	MinLineEnergy     sql.NullString
+	MaxLineEnergy     sql.NullString
+	MinFluence        sql.NullString
+	MaxFluence        sql.NullString
+	MinPulseEnergy    sql.NullString
+	MaxPulseEnergy    sql.NullString
+	MinFrequency      sql.NullString
+	MaxFrequency      sql.NullString
+	MinPulseWidth     sql.NullString
+	MaxPulseWidth     sql.NullString
+	Wobble            sql.NullBool
+	MinThickness      sql.NullString
+	MaxThickness      sql.NullString
+	Color             sql.NullString
+	Finish            sql.NullString
+	Grade             sql.NullString
+	Keyword           sql.NullString
+	CursorID          sql.NullInt32
+	CursorValue       sql.NullString
+	SortSign          interface{}
+	Limit             int32
 }
 
 type SearchSettingsRow struct {
@@ -1390,6 +4319,10 @@ type SearchSettingsRow struct {
 	NumPasses        int32
 	ScanInterval     sql.NullString
 	Frequency        sql.NullString
+	PulseWidth       sql.NullString
+	WobbleEnable     sql.NullBool
+	WobbleDiameter   sql.NullString
+	WobbleStep       sql.NullString
 	CrossHatch       bool
 	Bidir            bool
 	Angle            sql.NullString
@@ -1398,6 +4331,16 @@ type SearchSettingsRow struct {
 	NegativeImage    bool
 	UseDotCorrection sql.NullBool
 	DotWidth         sql.NullString
+	ThicknessMm      sql.NullString
+	Color            sql.NullString
+	Finish           sql.NullString
+	Grade            sql.NullString
+	TestCount        int32
+	Confidence       sql.NullString
+	EffectiveWatts   sql.NullString
+	LineEnergy       sql.NullString
+	Fluence          sql.NullString
+	PulseEnergy      sql.NullString
 	Notes            sql.NullString
 	CreatedAt        sql.NullTime
 	FirstName        string
@@ -1407,22 +4350,103 @@ type SearchSettingsRow struct {
 	CategoryName     string
 	VoteScore        int64
 	VoteCount        int64
+	Relevance        string
+	SortValue        sql.NullString
 }
 
 func (q *Queries) SearchSettings(ctx context.Context, arg SearchSettingsParams) ([]SearchSettingsRow, error) {
 	rows, err := q.db.QueryContext(ctx, searchSettings,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.SortKey,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
+		arg.KeywordQuery,
 		arg.MaterialID,
 		arg.MaterialID,
 		arg.LaserType,
 		arg.LaserType,
 		arg.Wattage,
 		arg.Wattage,
+		arg.MinWattage,
+		arg.MinWattage,
+		arg.MaxWattage,
+		arg.MaxWattage,
 		arg.OperationType,
 		arg.OperationType,
 		arg.UserID,
 		arg.UserID,
+		arg.LaserModelID,
+		arg.LaserModelID,
+		arg.MinSpeed,
+		arg.MinSpeed,
+		arg.MaxSpeed,
+		arg.MaxSpeed,
+		arg.MinPower,
+		arg.MinPower,
+		arg.MaxPower,
+		arg.MaxPower,
+		arg.MinPasses,
+		arg.MinPasses,
+		arg.MaxPasses,
+		arg.MaxPasses,
+		arg.MinEffectiveWatts,
+		arg.MinEffectiveWatts,
+		arg.MaxEffectiveWatts,
+		arg.MaxEffectiveWatts,
+		arg.MinLineEnergy,
+		arg.MinLineEnergy,
+		arg.MaxLineEnergy,
+		arg.MaxLineEnergy,
+		arg.MinFluence,
+		arg.MinFluence,
+		arg.MaxFluence,
+		arg.MaxFluence,
+		arg.MinPulseEnergy,
+		arg.MinPulseEnergy,
+		arg.MaxPulseEnergy,
+		arg.MaxPulseEnergy,
+		arg.MinFrequency,
+		arg.MinFrequency,
+		arg.MaxFrequency,
+		arg.MaxFrequency,
+		arg.MinPulseWidth,
+		arg.MinPulseWidth,
+		arg.MaxPulseWidth,
+		arg.MaxPulseWidth,
+		arg.Wobble,
+		arg.Wobble,
+		arg.MinThickness,
+		arg.MinThickness,
+		arg.MaxThickness,
+		arg.MaxThickness,
+		arg.Color,
+		arg.Color,
+		arg.Finish,
+		arg.Finish,
+		arg.Grade,
+		arg.Grade,
 		arg.Keyword,
-		arg.Keyword,
+		arg.CursorID,
+		arg.CursorValue,
+		arg.CursorID,
+		arg.CursorValue,
+		arg.SortSign,
+		arg.CursorValue,
+		arg.SortSign,
+		arg.CursorValue,
+		arg.CursorID,
+		arg.SortSign,
+		arg.Limit,
 	)
 	if err != nil {
 		return nil, err
@@ -1444,6 +4468,10 @@ func (q *Queries) SearchSettings(ctx context.Context, arg SearchSettingsParams)
 			&i.NumPasses,
 			&i.ScanInterval,
 			&i.Frequency,
+			&i.PulseWidth,
+			&i.WobbleEnable,
+			&i.WobbleDiameter,
+			&i.WobbleStep,
 			&i.CrossHatch,
 			&i.Bidir,
 			&i.Angle,
@@ -1452,6 +4480,16 @@ func (q *Queries) SearchSettings(ctx context.Context, arg SearchSettingsParams)
 			&i.NegativeImage,
 			&i.UseDotCorrection,
 			&i.DotWidth,
+			&i.ThicknessMm,
+			&i.Color,
+			&i.Finish,
+			&i.Grade,
+			&i.TestCount,
+			&i.Confidence,
+			&i.EffectiveWatts,
+			&i.LineEnergy,
+			&i.Fluence,
+			&i.PulseEnergy,
 			&i.Notes,
 			&i.CreatedAt,
 			&i.FirstName,
@@ -1461,6 +4499,8 @@ func (q *Queries) SearchSettings(ctx context.Context, arg SearchSettingsParams)
 			&i.CategoryName,
 			&i.VoteScore,
 			&i.VoteCount,
+			&i.Relevance,
+			&i.SortValue,
 		); err != nil {
 			return nil, err
 		}
@@ -1475,6 +4515,43 @@ func (q *Queries) SearchSettings(ctx context.Context, arg SearchSettingsParams)
 	return items, nil
 }
 
+const setDefaultUserMachine = `-- name: SetDefaultUserMachine :execrows
+UPDATE user_machines SET is_default = TRUE
+WHERE id = ? AND user_id = ?
+`
+
+type SetDefaultUserMachineParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) SetDefaultUserMachine(ctx context.Context, arg SetDefaultUserMachineParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setDefaultUserMachine, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setRecipeSetPublished = `-- name: SetRecipeSetPublished :execrows
+UPDATE recipe_sets SET published_at = ?
+WHERE id = ? AND user_id = ?
+`
+
+type SetRecipeSetPublishedParams struct {
+	PublishedAt sql.NullTime
+	ID          int32
+	UserID      int32
+}
+
+func (q *Queries) SetRecipeSetPublished(ctx context.Context, arg SetRecipeSetPublishedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setRecipeSetPublished, arg.PublishedAt, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const setVerificationToken = `-- name: SetVerificationToken :exec
 UPDATE users SET verification_token = ?, verification_expires = ?
 WHERE id = ?
@@ -1491,6 +4568,89 @@ func (q *Queries) SetVerificationToken(ctx context.Context, arg SetVerificationT
 	return err
 }
 
+const updateImportResult = `-- name: UpdateImportResult :exec
+UPDATE imports SET
+    imported_count = ?, skipped_count = ?, conflict_count = ?, failed_count = ?, errors = ?
+WHERE id = ?
+`
+
+type UpdateImportResultParams struct {
+	ImportedCount int32
+	SkippedCount  int32
+	ConflictCount int32
+	FailedCount   int32
+	Errors        sql.NullString
+	ID            int32
+}
+
+func (q *Queries) UpdateImportResult(ctx context.Context, arg UpdateImportResultParams) error {
+	_, err := q.db.ExecContext(ctx, updateImportResult,
+		arg.ImportedCount,
+		arg.SkippedCount,
+		arg.ConflictCount,
+		arg.FailedCount,
+		arg.Errors,
+		arg.ID,
+	)
+	return err
+}
+
+const updateMaterial = `-- name: UpdateMaterial :execrows
+UPDATE materials SET category_id = ?, name = ?, slug = ?
+WHERE id = ?
+`
+
+type UpdateMaterialParams struct {
+	CategoryID int32
+	Name       string
+	Slug       string
+	ID         int32
+}
+
+func (q *Queries) UpdateMaterial(ctx context.Context, arg UpdateMaterialParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateMaterial,
+		arg.CategoryID,
+		arg.Name,
+		arg.Slug,
+		arg.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateRecipeSet = `-- name: UpdateRecipeSet :exec
+UPDATE recipe_sets SET
+    material_id = ?, laser_type = ?, wattage = ?, laser_model_id = ?, name = ?, description = ?
+WHERE id = ? AND user_id = ?
+`
+
+type UpdateRecipeSetParams struct {
+	MaterialID   int32
+	LaserType    RecipeSetsLaserType
+	Wattage      int32
+	LaserModelID sql.NullInt32
+	Name         string
+	Description  sql.NullString
+	ID           int32
+	UserID       int32
+}
+
+func (q *Queries) UpdateRecipeSet(ctx context.Context, arg UpdateRecipeSetParams) error {
+	_, err := q.db.ExecContext(ctx, updateRecipeSet,
+		arg.MaterialID,
+		arg.LaserType,
+		arg.Wattage,
+		arg.LaserModelID,
+		arg.Name,
+		arg.Description,
+		arg.ID,
+		arg.UserID,
+	)
+	return err
+}
+
 const updateSetting = `-- name: UpdateSetting :exec
 UPDATE settings SET
     max_power = ?, min_power = ?, max_power2 = ?, min_power2 = ?, speed = ?,
@@ -1499,11 +4659,13 @@ UPDATE settings SET
     cross_hatch = ?, bidir = ?, scan_opt = ?,
     flood_fill = ?, auto_rotate = ?, overscan = ?, overscan_percent = ?,
     frequency = ?, wobble_enable = ?, use_dot_correction = ?,
+    pulse_width = ?, wobble_diameter = ?, wobble_step = ?,
     perforation_mode = ?, dot_width = ?,
     image_mode = ?, negative_image = ?,
     kerf = ?, run_blower = ?,
     layer_name = ?, layer_subname = ?,
     priority = ?, tab_count = ?, tab_count_max = ?,
+    thickness_mm = ?, color = ?, finish = ?, grade = ?,
     notes = ?
 WHERE id = ? AND user_id = ?
 `
@@ -1530,6 +4692,9 @@ type UpdateSettingParams struct {
 	Frequency        sql.NullString
 	WobbleEnable     sql.NullBool
 	UseDotCorrection sql.NullBool
+	PulseWidth       sql.NullString
+	WobbleDiameter   sql.NullString
+	WobbleStep       sql.NullString
 	PerforationMode  bool
 	DotWidth         sql.NullString
 	ImageMode        sql.NullString
@@ -1541,6 +4706,10 @@ type UpdateSettingParams struct {
 	Priority         sql.NullInt32
 	TabCount         sql.NullInt32
 	TabCountMax      sql.NullInt32
+	ThicknessMm      sql.NullString
+	Color            sql.NullString
+	Finish           sql.NullString
+	Grade            sql.NullString
 	Notes            sql.NullString
 	ID               int32
 	UserID           int32
@@ -1569,6 +4738,9 @@ func (q *Queries) UpdateSetting(ctx context.Context, arg UpdateSettingParams) er
 		arg.Frequency,
 		arg.WobbleEnable,
 		arg.UseDotCorrection,
+		arg.PulseWidth,
+		arg.WobbleDiameter,
+		arg.WobbleStep,
 		arg.PerforationMode,
 		arg.DotWidth,
 		arg.ImageMode,
@@ -1580,6 +4752,10 @@ func (q *Queries) UpdateSetting(ctx context.Context, arg UpdateSettingParams) er
 		arg.Priority,
 		arg.TabCount,
 		arg.TabCountMax,
+		arg.ThicknessMm,
+		arg.Color,
+		arg.Finish,
+		arg.Grade,
 		arg.Notes,
 		arg.ID,
 		arg.UserID,
@@ -1587,20 +4763,154 @@ func (q *Queries) UpdateSetting(ctx context.Context, arg UpdateSettingParams) er
 	return err
 }
 
-const upsertVote = `-- name: UpsertVote :exec
-INSERT INTO votes (user_id, setting_id, value)
+const updateSettingConfidence = `-- name: UpdateSettingConfidence :exec
+UPDATE settings SET test_count = ?, confidence = ?, updated_at = updated_at
+WHERE id = ?
+`
+
+type UpdateSettingConfidenceParams struct {
+	TestCount  int32
+	Confidence sql.NullString
+	ID         int32
+}
+
+func (q *Queries) UpdateSettingConfidence(ctx context.Context, arg UpdateSettingConfidenceParams) error {
+	_, err := q.db.ExecContext(ctx, updateSettingConfidence, arg.TestCount, arg.Confidence, arg.ID)
+	return err
+}
+
+const updateUserMachine = `-- name: UpdateUserMachine :exec
+UPDATE user_machines SET
+    laser_model_id = ?, make_model = ?, laser_type = ?, wattage = ?,
+    lens_mm = ?, notes = ?
+WHERE id = ? AND user_id = ?
+`
+
+type UpdateUserMachineParams struct {
+	LaserModelID sql.NullInt32
+	MakeModel    sql.NullString
+	LaserType    UserMachinesLaserType
+	Wattage      int32
+	LensMm       sql.NullString
+	Notes        sql.NullString
+	ID           int32
+	UserID       int32
+}
+
+func (q *Queries) UpdateUserMachine(ctx context.Context, arg UpdateUserMachineParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserMachine,
+		arg.LaserModelID,
+		arg.MakeModel,
+		arg.LaserType,
+		arg.Wattage,
+		arg.LensMm,
+		arg.Notes,
+		arg.ID,
+		arg.UserID,
+	)
+	return err
+}
+
+const updateUserUnits = `-- name: UpdateUserUnits :exec
+UPDATE users SET speed_unit = ?, interval_unit = ?
+WHERE id = ?
+`
+
+type UpdateUserUnitsParams struct {
+	SpeedUnit    string
+	IntervalUnit string
+	ID           int32
+}
+
+func (q *Queries) UpdateUserUnits(ctx context.Context, arg UpdateUserUnitsParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserUnits, arg.SpeedUnit, arg.IntervalUnit, arg.ID)
+	return err
+}
+
+const upsertRecipeSetVote = `-- name: UpsertRecipeSetVote :exec
+INSERT INTO recipe_set_votes (user_id, recipe_set_id, value)
 VALUES (?, ?, ?)
 ON DUPLICATE KEY UPDATE value = VALUES(value)
 `
 
+type UpsertRecipeSetVoteParams struct {
+	UserID      int32
+	RecipeSetID int32
+	Value       int8
+}
+
+func (q *Queries) UpsertRecipeSetVote(ctx context.Context, arg UpsertRecipeSetVoteParams) error {
+	_, err := q.db.ExecContext(ctx, upsertRecipeSetVote, arg.UserID, arg.RecipeSetID, arg.Value)
+	return err
+}
+
+const upsertTestReport = `-- name: UpsertTestReport :exec
+
+INSERT INTO test_reports (
+    setting_id, user_id, revision, laser_model_id, make_model, laser_type, wattage,
+    material_batch, outcome, adjusted_values, notes
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    revision = VALUES(revision), laser_model_id = VALUES(laser_model_id),
+    make_model = VALUES(make_model), laser_type = VALUES(laser_type), wattage = VALUES(wattage),
+    material_batch = VALUES(material_batch), outcome = VALUES(outcome),
+    adjusted_values = VALUES(adjusted_values), notes = VALUES(notes)
+`
+
+type UpsertTestReportParams struct {
+	SettingID      int32
+	UserID         int32
+	Revision       int32
+	LaserModelID   sql.NullInt32
+	MakeModel      sql.NullString
+	LaserType      TestReportsLaserType
+	Wattage        int32
+	MaterialBatch  sql.NullString
+	Outcome        TestReportsOutcome
+	AdjustedValues sql.NullString
+	Notes          sql.NullString
+}
+
+// =====================
+// TEST REPORTS
+// =====================
+func (q *Queries) UpsertTestReport(ctx context.Context, arg UpsertTestReportParams) error {
+	_, err := q.db.ExecContext(ctx, upsertTestReport,
+		arg.SettingID,
+		arg.UserID,
+		arg.Revision,
+		arg.LaserModelID,
+		arg.MakeModel,
+		arg.LaserType,
+		arg.Wattage,
+		arg.MaterialBatch,
+		arg.Outcome,
+		arg.AdjustedValues,
+		arg.Notes,
+	)
+	return err
+}
+
+const upsertVote = `-- name: UpsertVote :exec
+INSERT INTO votes (user_id, setting_id, value, revision)
+VALUES (?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE value = VALUES(value), revision = VALUES(revision)
+`
+
 type UpsertVoteParams struct {
 	UserID    int32
 	SettingID int32
 	Value     int8
+	Revision  sql.NullInt32
 }
 
 func (q *Queries) UpsertVote(ctx context.Context, arg UpsertVoteParams) error {
-	_, err := q.db.ExecContext(ctx, upsertVote, arg.UserID, arg.SettingID, arg.Value)
+	_, err := q.db.ExecContext(ctx, upsertVote,
+		arg.UserID,
+		arg.SettingID,
+		arg.Value,
+		arg.Revision,
+	)
 	return err
 }
 
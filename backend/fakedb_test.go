@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"laserscribe/backend/db"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDB is an in-memory stand-in for MySQL in handler tests. It answers
+// the sqlc queries a test registers by name and puts the test's tables back
+// when a transaction or savepoint is rolled back, so a handler's
+// transaction handling can be checked without a server.
+type fakeDB struct {
+	mu      sync.Mutex
+	queries map[string]fakeQuery
+	// snapshot copies the test's tables; restore puts such a copy back
+	snapshot   func() interface{}
+	restore    func(interface{})
+	savepoints map[string]interface{}
+	// statements lists the queries run, by name, in order
+	statements []string
+}
+
+// fakeQuery answers one query. Queries return rows as structs with fields
+// in column order; execs return the id of an inserted row, which is also
+// reported as the number of rows affected.
+type fakeQuery func(args []driver.Value) (rows []interface{}, n int64, err error)
+
+// useFakeDB points the handlers at f until the test ends
+func useFakeDB(t *testing.T, f *fakeDB) {
+	prevConn, prevQueries := dbConn, queries
+	dbConn = sql.OpenDB(fakeConnector{f})
+	queries = db.New(dbConn)
+	invalidateMaterialCatalog()
+	t.Cleanup(func() {
+		dbConn.Close()
+		dbConn, queries = prevConn, prevQueries
+		invalidateMaterialCatalog()
+	})
+}
+
+// ran reports how many times the named query was run
+func (f *fakeDB) ran(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, s := range f.statements {
+		if s == name {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeDB) run(query string, args []driver.Value) ([]interface{}, int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := strings.TrimSpace(query)
+	if strings.HasPrefix(name, "-- name: ") {
+		name = strings.Fields(name)[2]
+	}
+	f.statements = append(f.statements, name)
+
+	fields := strings.Fields(name)
+	switch {
+	case fields[0] == "SAVEPOINT":
+		f.savepoints[fields[1]] = f.snapshot()
+		return nil, 0, nil
+	case fields[0] == "RELEASE":
+		delete(f.savepoints, fields[2])
+		return nil, 0, nil
+	case fields[0] == "ROLLBACK":
+		saved, ok := f.savepoints[fields[3]]
+		if !ok {
+			return nil, 0, fmt.Errorf("fakedb: no savepoint %s", fields[3])
+		}
+		f.restore(saved)
+		// The copy stays the savepoint, so it must not be shared
+		f.savepoints[fields[3]] = f.snapshot()
+		return nil, 0, nil
+	}
+
+	q, ok := f.queries[name]
+	if !ok {
+		return nil, 0, fmt.Errorf("fakedb: no query %s", name)
+	}
+	return q(args)
+}
+
+type fakeConnector struct{ db *fakeDB }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: c.db}, nil }
+func (c fakeConnector) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, fmt.Errorf("fakedb: open through a connector")
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fakedb: prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.savepoints = make(map[string]interface{})
+	return &fakeTx{db: c.db, saved: c.db.snapshot()}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	_, n, err := c.db.run(query, namedValues(args))
+	if err != nil {
+		return nil, err
+	}
+	return fakeResult(n), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, _, err := c.db.run(query, namedValues(args))
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeTx struct {
+	db    *fakeDB
+	saved interface{}
+}
+
+func (tx *fakeTx) Commit() error { return nil }
+
+func (tx *fakeTx) Rollback() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	tx.db.restore(tx.saved)
+	return nil
+}
+
+// fakeRows returns struct rows one field per column
+type fakeRows struct {
+	rows []interface{}
+	next int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	t := reflect.TypeOf(r.rows[0])
+	cols := make([]string, t.NumField())
+	for i := range cols {
+		cols[i] = t.Field(i).Name
+	}
+	return cols
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next == len(r.rows) {
+		return io.EOF
+	}
+	v := reflect.ValueOf(r.rows[r.next])
+	r.next++
+	for i := range dest {
+		value, err := driverValue(v.Field(i).Interface())
+		if err != nil {
+			return err
+		}
+		dest[i] = value
+	}
+	return nil
+}
+
+// fakeResult is both the LastInsertId and the RowsAffected of an exec
+type fakeResult int64
+
+func (r fakeResult) LastInsertId() (int64, error) { return int64(r), nil }
+func (r fakeResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+// driverValue converts a field of a row struct to a column value
+func driverValue(v interface{}) (driver.Value, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		return valuer.Value()
+	}
+	if t, ok := v.(time.Time); ok {
+		return t, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	}
+	return nil, fmt.Errorf("fakedb: can't return a %T", v)
+}
+
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	return values
+}
+
+// bindArgs fills the fields of the struct dst points to from a query's
+// arguments, which sqlc passes in field order
+func bindArgs(args []driver.Value, dst interface{}) {
+	v := reflect.ValueOf(dst).Elem()
+	for i := 0; i < v.NumField() && i < len(args); i++ {
+		field := v.Field(i)
+		if scanner, ok := field.Addr().Interface().(sql.Scanner); ok {
+			if err := scanner.Scan(args[i]); err != nil {
+				panic(err)
+			}
+			continue
+		}
+		switch a := args[i].(type) {
+		case int64:
+			field.SetInt(a)
+		case string:
+			field.SetString(a)
+		case bool:
+			field.SetBool(a)
+		case nil:
+		default:
+			panic(fmt.Sprintf("fakedb: can't bind a %T", a))
+		}
+	}
+}
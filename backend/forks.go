@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"laserscribe/backend/db"
+	"laserscribe/backend/units"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// SETTING FORKS
+// =====================
+
+// SettingLink identifies a setting related to another by forking
+type SettingLink struct {
+	ID        int32                `json:"id"`
+	UserID    int32                `json:"userId"`
+	Author    string               `json:"author"`
+	LaserType db.SettingsLaserType `json:"laserType,omitempty"`
+	Wattage   int32                `json:"wattage,omitempty"`
+}
+
+// settingLineage finds the setting this one was forked from, if it still
+// exists, and the settings forked from this one
+func settingLineage(ctx context.Context, setting db.GetSettingByIDRow) (*SettingLink, []SettingLink, error) {
+	var parent *SettingLink
+	if setting.ForkedFromID.Valid {
+		p, err := queries.GetSettingParent(ctx, setting.ForkedFromID.Int32)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, nil, err
+		}
+		if err == nil {
+			parent = &SettingLink{ID: p.ID, UserID: p.UserID, Author: authorName(p.DisplayName, p.FirstName, p.LastName)}
+		}
+	}
+
+	rows, err := queries.GetSettingForks(ctx, sql.NullInt32{Int32: setting.ID, Valid: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	forks := make([]SettingLink, 0, len(rows))
+	for _, f := range rows {
+		forks = append(forks, SettingLink{
+			ID:        f.ID,
+			UserID:    f.UserID,
+			Author:    authorName(f.DisplayName, f.FirstName, f.LastName),
+			LaserType: f.LaserType,
+			Wattage:   f.Wattage,
+		})
+	}
+	return parent, forks, nil
+}
+
+// ForkSettingRequest changes a copy of a setting. Overrides are keyed like
+// the fields of UpdateSettingRequest (speed, maxPower, notes, ...); anything
+// not overridden is copied from the original. Overridden speeds, intervals
+// and frequencies are in the request's units.
+type ForkSettingRequest struct {
+	Wattage      *int32          `json:"wattage"`
+	LaserModelID *int32          `json:"laserModelId"`
+	Overrides    settingSnapshot `json:"overrides"`
+	InputUnits
+}
+
+// forkSettingHandler copies a setting, with its sublayers, to the current
+// user's account as a new setting linked to the original
+func forkSettingHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid setting id"})
+		return
+	}
+	parent, err := queries.GetSettingByID(ctx, int32(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "setting not found"})
+		return
+	}
+
+	// The body is optional: without one the fork is an exact copy
+	var req ForkSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	inputUnits, verr := req.resolve()
+	if verr != nil {
+		validationFailed(c, verr)
+		return
+	}
+
+	values, err := snapshotSetting(ctx, queries, parent.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for key, value := range req.Overrides {
+		if _, ok := values[key]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown field in overrides: " + key})
+			return
+		}
+		values[key] = value
+	}
+	params, err := values.updateParams(0, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid overrides: " + err.Error()})
+		return
+	}
+	// Only overridden values are in the request's units; copied ones are
+	// already stored in mm/s, mm and kHz
+	if _, ok := req.Overrides["speed"]; ok {
+		params.Speed = units.ConvertSpeed(params.Speed, inputUnits.SpeedUnit)
+	}
+	if _, ok := req.Overrides["scanInterval"]; ok {
+		params.ScanInterval = canonicalInterval(params.ScanInterval, inputUnits.IntervalUnit)
+	}
+	if _, ok := req.Overrides["frequency"]; ok {
+		params.Frequency = canonicalFrequency(params.Frequency, inputUnits.FrequencyUnit)
+	}
+	if params.ThicknessMm.Valid {
+		if _, err := (MaterialAttributes{ThicknessMm: &params.ThicknessMm.String}).thickness(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// The catalog machine has the original's wattage, so a fork for another
+	// wattage drops the link unless a matching machine is given
+	wattage := parent.Wattage
+	laserModelID := parent.LaserModelID
+	if req.Wattage != nil && *req.Wattage != parent.Wattage {
+		if *req.Wattage <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "wattage must be positive"})
+			return
+		}
+		wattage = *req.Wattage
+		laserModelID = sql.NullInt32{}
+	}
+	if req.LaserModelID != nil {
+		machine, err := queries.GetLaserModelByID(ctx, *req.LaserModelID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown laser model"})
+			return
+		}
+		if machine.LaserType != db.LaserModelsLaserType(parent.LaserType) || machine.Wattage != wattage {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "laser model does not match laserType and wattage"})
+			return
+		}
+		laserModelID = sql.NullInt32{Int32: machine.ID, Valid: true}
+		if _, ok := req.Overrides["layerName"]; !ok {
+			params.LayerName = sql.NullString{String: machineDisplayName(machine), Valid: true}
+		}
+	}
+
+	if verr := validateSetting(parent.LaserType, wattage, parent.OperationType, updateValues(params)); verr != nil {
+		validationFailed(c, verr)
+		return
+	}
+
+	subLayers, err := queries.GetSublayersBySetting(ctx, parent.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	result, err := qtx.CreateSetting(ctx, db.CreateSettingParams{
+		UserID:           userID,
+		MaterialID:       parent.MaterialID,
+		ForkedFromID:     sql.NullInt32{Int32: parent.ID, Valid: true},
+		LaserType:        parent.LaserType,
+		Wattage:          wattage,
+		LaserModelID:     laserModelID,
+		OperationType:    parent.OperationType,
+		MaxPower:         params.MaxPower,
+		MinPower:         params.MinPower,
+		MaxPower2:        params.MaxPower2,
+		MinPower2:        params.MinPower2,
+		Speed:            params.Speed,
+		NumPasses:        params.NumPasses,
+		ZOffset:          params.ZOffset,
+		ZPerPass:         params.ZPerPass,
+		ScanInterval:     params.ScanInterval,
+		Angle:            params.Angle,
+		AnglePerPass:     params.AnglePerPass,
+		CrossHatch:       params.CrossHatch,
+		Bidir:            params.Bidir,
+		ScanOpt:          params.ScanOpt,
+		FloodFill:        params.FloodFill,
+		AutoRotate:       params.AutoRotate,
+		Overscan:         params.Overscan,
+		OverscanPercent:  params.OverscanPercent,
+		Frequency:        params.Frequency,
+		WobbleEnable:     params.WobbleEnable,
+		UseDotCorrection: params.UseDotCorrection,
+		PulseWidth:       params.PulseWidth,
+		WobbleDiameter:   params.WobbleDiameter,
+		WobbleStep:       params.WobbleStep,
+		PerforationMode:  params.PerforationMode,
+		DotWidth:         params.DotWidth,
+		ImageMode:        params.ImageMode,
+		NegativeImage:    params.NegativeImage,
+		Kerf:             params.Kerf,
+		RunBlower:        params.RunBlower,
+		LayerName:        params.LayerName,
+		LayerSubname:     params.LayerSubname,
+		Priority:         params.Priority,
+		TabCount:         params.TabCount,
+		TabCountMax:      params.TabCountMax,
+		ThicknessMm:      params.ThicknessMm,
+		Color:            params.Color,
+		Finish:           params.Finish,
+		Grade:            params.Grade,
+		Notes:            params.Notes,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	forkID, _ := result.LastInsertId()
+
+	for _, sl := range subLayers {
+		err := qtx.CreateSettingSublayer(ctx, db.CreateSettingSublayerParams{
+			SettingID:     int32(forkID),
+			SublayerIndex: sl.SublayerIndex,
+			SublayerType:  sl.SublayerType,
+			IsCleanup:     sl.IsCleanup,
+			MaxPower:      sl.MaxPower,
+			MinPower:      sl.MinPower,
+			MaxPower2:     sl.MaxPower2,
+			MinPower2:     sl.MinPower2,
+			Speed:         sl.Speed,
+			NumPasses:     sl.NumPasses,
+			Frequency:     sl.Frequency,
+			ScanInterval:  sl.ScanInterval,
+			Subname:       sl.Subname,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": forkID, "forkedFrom": parent.ID})
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"laserscribe/backend/db"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// forkTables are the rows the fork handler writes
+type forkTables struct {
+	settings  []importedSetting
+	sublayers []db.CreateSettingSublayerParams
+}
+
+// newForkDB serves setting 5, a 20W fiber fill on catalog machine 7, and
+// records the forks written to tables
+func newForkDB(tables *forkTables) *fakeDB {
+	values := db.GetSettingValuesRow{
+		MaxPower: "80", MinPower: "10", Speed: "1000", NumPasses: 1, Bidir: true,
+		ScanInterval: validString("0.05"), Frequency: validString("30"), LayerName: validString("Gweike G2"),
+	}
+	var parent db.GetSettingByIDRow
+	copyFields(&parent, values)
+	parent.ID, parent.UserID, parent.MaterialID = 5, 2, 1
+	parent.LaserType, parent.Wattage, parent.OperationType = db.SettingsLaserTypeFiber, 20, db.SettingsOperationTypeScan
+	parent.LaserModelID = sql.NullInt32{Int32: 7, Valid: true}
+
+	machines := map[int64]db.GetLaserModelByIDRow{
+		7: {ID: 7, Name: "G2", LaserType: db.LaserModelsLaserTypeFiber, Wattage: 20, ManufacturerName: "Gweike"},
+		8: {ID: 8, Name: "G2 Pro", LaserType: db.LaserModelsLaserTypeFiber, Wattage: 30, ManufacturerName: "Gweike"},
+	}
+	setting := func(rows ...interface{}) fakeQuery {
+		return func(args []driver.Value) ([]interface{}, int64, error) {
+			if args[0] != int64(5) {
+				return nil, 0, nil
+			}
+			return rows, 0, nil
+		}
+	}
+	t := tables
+	return &fakeDB{
+		snapshot: func() interface{} { c := *t; return &c },
+		restore:  func(saved interface{}) { *t = *saved.(*forkTables) },
+		queries: map[string]fakeQuery{
+			"GetSettingByID":   setting(parent),
+			"GetSettingValues": setting(values),
+			"GetSublayersBySetting": setting(db.SettingSublayer{ID: 9, SettingID: 5, SublayerIndex: 1, SublayerType: "Scan",
+				IsCleanup: true, MaxPower: "40", MinPower: "0", Speed: "2000", NumPasses: 1}),
+			"GetLaserModelByID": func(args []driver.Value) ([]interface{}, int64, error) {
+				if m, ok := machines[args[0].(int64)]; ok {
+					return []interface{}{m}, 0, nil
+				}
+				return nil, 0, nil
+			},
+			"CreateSetting": func(args []driver.Value) ([]interface{}, int64, error) {
+				s := importedSetting{ID: int32(100 + len(t.settings))}
+				bindArgs(args, &s.CreateSettingParams)
+				t.settings = append(t.settings, s)
+				return nil, int64(s.ID), nil
+			},
+			"CreateSettingSublayer": func(args []driver.Value) ([]interface{}, int64, error) {
+				var p db.CreateSettingSublayerParams
+				bindArgs(args, &p)
+				t.sublayers = append(t.sublayers, p)
+				return nil, 1, nil
+			},
+		},
+	}
+}
+
+func TestForkSetting(t *testing.T) {
+	cases := []struct {
+		name   string
+		body   string
+		status int
+		check  func(t *testing.T, fork db.CreateSettingParams)
+	}{
+		{"exact copy", "", http.StatusCreated, func(t *testing.T, fork db.CreateSettingParams) {
+			if fork.UserID != 1 || fork.ForkedFromID.Int32 != 5 || fork.Speed != "1000" || fork.LaserModelID.Int32 != 7 {
+				t.Errorf("fork = %+v", fork)
+			}
+		}},
+		{"unknown override", `{"overrides": {"speeed": "10"}}`, http.StatusBadRequest, nil},
+		{"invalid override", `{"overrides": {"numPasses": "two"}}`, http.StatusBadRequest, nil},
+		{"overridden values are out of range", `{"overrides": {"maxPower": 120}}`, http.StatusBadRequest, nil},
+		// Only the overridden speed is in the request's units; the copied
+		// interval and frequency are stored values already
+		{"overridden speed", `{"overrides": {"speed": "6000"}, "speedUnit": "mm/min", "intervalUnit": "lpi", "frequencyUnit": "Hz"}`,
+			http.StatusCreated, func(t *testing.T, fork db.CreateSettingParams) {
+				if fork.Speed != "100" || fork.ScanInterval.String != "0.05" || fork.Frequency.String != "30" {
+					t.Errorf("speed %s, interval %s and frequency %s, want 100, 0.05 and 30",
+						fork.Speed, fork.ScanInterval.String, fork.Frequency.String)
+				}
+			}},
+		{"overridden interval and frequency", `{"overrides": {"scanInterval": 254, "frequency": "45000"}, "intervalUnit": "lpi", "frequencyUnit": "Hz"}`,
+			http.StatusCreated, func(t *testing.T, fork db.CreateSettingParams) {
+				if fork.Speed != "1000" || fork.ScanInterval.String != "0.1" || fork.Frequency.String != "45" {
+					t.Errorf("speed %s, interval %s and frequency %s, want 1000, 0.1 and 45",
+						fork.Speed, fork.ScanInterval.String, fork.Frequency.String)
+				}
+			}},
+		{"other wattage", `{"wattage": 30}`, http.StatusCreated, func(t *testing.T, fork db.CreateSettingParams) {
+			if fork.Wattage != 30 || fork.LaserModelID.Valid {
+				t.Errorf("%dW fork on machine %+v, want 30W and no machine", fork.Wattage, fork.LaserModelID)
+			}
+		}},
+		{"same wattage", `{"wattage": 20}`, http.StatusCreated, func(t *testing.T, fork db.CreateSettingParams) {
+			if fork.LaserModelID.Int32 != 7 {
+				t.Errorf("fork on machine %+v, want 7", fork.LaserModelID)
+			}
+		}},
+		{"other wattage and machine", `{"wattage": 30, "laserModelId": 8}`, http.StatusCreated, func(t *testing.T, fork db.CreateSettingParams) {
+			if fork.LaserModelID.Int32 != 8 || fork.LayerName.String != "Gweike G2 Pro" {
+				t.Errorf("fork on machine %+v named %q, want 8", fork.LaserModelID, fork.LayerName.String)
+			}
+		}},
+		{"machine of another wattage", `{"wattage": 30, "laserModelId": 7}`, http.StatusBadRequest, nil},
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/settings/:id/fork", func(c *gin.Context) { c.Set("user_id", int32(1)) }, forkSettingHandler)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tables := &forkTables{}
+			useFakeDB(t, newForkDB(tables))
+			req := httptest.NewRequest(http.MethodPost, "/api/settings/5/fork", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			code, resp := serve(t, r, req)
+			if code != tc.status {
+				t.Fatalf("status %d, want %d: %v", code, tc.status, resp)
+			}
+			if tc.check == nil {
+				if len(tables.settings) != 0 {
+					t.Errorf("refused fork was written")
+				}
+				return
+			}
+			if len(tables.settings) != 1 || len(tables.sublayers) != 1 {
+				t.Fatalf("%d settings and %d sublayers written, want 1 of each", len(tables.settings), len(tables.sublayers))
+			}
+			if sl := tables.sublayers[0]; sl.SettingID != tables.settings[0].ID || !sl.IsCleanup || sl.Speed != "2000" {
+				t.Errorf("sublayer copied as %+v", sl)
+			}
+			tc.check(t, tables.settings[0].CreateSettingParams)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/settings/6/fork", nil)
+	useFakeDB(t, newForkDB(&forkTables{}))
+	if code, _ := serve(t, r, req); code != http.StatusNotFound {
+		t.Errorf("forking a missing setting: %d", code)
+	}
+}
@@ -0,0 +1,946 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"laserscribe/backend/clb"
+	"laserscribe/backend/db"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// CLB IMPORT HANDLER
+// =====================
+
+type ImportCLBRequest struct {
+	UserMachineID  int32  `form:"userMachineId"`
+	LaserModelID   int32  `form:"laserModelId"`
+	LaserMakeModel string `form:"laserMakeModel"`
+	LaserType      string `form:"laserType"`
+	Wattage        int32  `form:"wattage"`
+	DryRun         bool   `form:"dryRun"`
+	AllOrNothing   bool   `form:"allOrNothing"`
+	// MaterialDecisions is a JSON array of materialDecision choosing what
+	// each material name in the file maps to
+	MaterialDecisions string `form:"materialDecisions"`
+	// InputUnits declares the units the file's speeds and intervals are in.
+	// LightBurn writes mm/s and mm, the default, but hand-made libraries
+	// sometimes hold mm/min.
+	InputUnits
+}
+
+// Status of a parsed setting relative to the uploader's existing settings
+const (
+	importStatusNew       = "new"
+	importStatusDuplicate = "duplicate"
+	importStatusConflict  = "conflict"
+	importStatusInvalid   = "invalid"
+)
+
+// importItem is one setting parsed from an uploaded library, before it is
+// written to the database
+type importItem struct {
+	MaterialName string
+	Desc         string
+	Params       db.CreateSettingParams
+	SubLayers    []clb.SubLayer
+	Status       string
+	ExistingID   int32
+	NewMaterial  bool
+	Material     *materialResolution
+	// Invalid holds the values that failed validation; such items are
+	// never written
+	Invalid *ValidationError
+}
+
+// ImportPreviewItem is how a parsed setting is reported by a dry run
+type ImportPreviewItem struct {
+	Material      string `json:"material"`
+	Desc          string `json:"desc"`
+	SubLayers     int    `json:"subLayers"`
+	OperationType string `json:"operationType"`
+	MaxPower      string `json:"maxPower"`
+	MinPower      string `json:"minPower"`
+	Speed         string `json:"speed"`
+	NumPasses     int32  `json:"numPasses"`
+	Frequency     string `json:"frequency,omitempty"`
+	ScanInterval  string `json:"scanInterval,omitempty"`
+	ImageMode     string `json:"imageMode,omitempty"`
+	ThicknessMm   string `json:"thicknessMm,omitempty"`
+	Status        string `json:"status"`
+	ExistingID    int32  `json:"existingId,omitempty"`
+	NewMaterial   bool   `json:"newMaterial"`
+	// CanonicalMaterial is the existing material the name resolved to
+	CanonicalMaterial string `json:"canonicalMaterial,omitempty"`
+	// Errors lists the values that make an invalid setting unimportable
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+func importCLBHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	ctx := c.Request.Context()
+
+	// Parse form data
+	var req ImportCLBRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	inputUnits, verr := req.resolve()
+	if verr != nil {
+		validationFailed(c, verr)
+		return
+	}
+
+	// One of the user's own machines supplies the laser details it records
+	if req.UserMachineID != 0 {
+		owned, err := queries.GetUserMachineByID(ctx, db.GetUserMachineByIDParams{
+			ID:     req.UserMachineID,
+			UserID: userID,
+		})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown machine"})
+			return
+		}
+		if owned.LaserModelID.Valid {
+			req.LaserModelID = owned.LaserModelID.Int32
+		}
+		req.LaserMakeModel = owned.MakeModel.String
+		req.LaserType = string(owned.LaserType)
+		req.Wattage = owned.Wattage
+	}
+
+	// A catalog machine supplies the laser details; otherwise they are required
+	var laserModelID sql.NullInt32
+	if req.LaserModelID != 0 {
+		machine, err := queries.GetLaserModelByID(ctx, req.LaserModelID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown laser model"})
+			return
+		}
+		laserModelID = sql.NullInt32{Int32: machine.ID, Valid: true}
+		req.LaserMakeModel = machineDisplayName(machine)
+		req.LaserType = string(machine.LaserType)
+		req.Wattage = machine.Wattage
+	}
+	if req.LaserMakeModel == "" || req.LaserType == "" || req.Wattage == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "laserMakeModel, laserType and wattage are required unless laserModelId is given"})
+		return
+	}
+	laserType, ok := stringToLaserType(req.LaserType)
+	if !ok {
+		verr := &ValidationError{}
+		verr.add("laserType", "must be one of %s", laserTypeNames)
+		validationFailed(c, verr)
+		return
+	}
+
+	// Get uploaded file
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	// Check file size (10MB limit)
+	if file.Size > 10*1024*1024 {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file too large (max 10MB)"})
+		return
+	}
+
+	// Open file
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open file"})
+		return
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read file"})
+		return
+	}
+	sum := sha256.Sum256(data)
+	contentHash := hex.EncodeToString(sum[:])
+
+	// Parse XML
+	library, err := clb.Decode(bytes.NewReader(data))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid CLB file format: " + err.Error()})
+		return
+	}
+
+	var decisions []materialDecision
+	if req.MaterialDecisions != "" {
+		if err := json.Unmarshal([]byte(req.MaterialDecisions), &decisions); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid materialDecisions: " + err.Error()})
+			return
+		}
+	}
+
+	// Bring the file's values into the stored units before anything, the
+	// duplicate check included, compares them. Frequencies are always
+	// looked at, since files written in Hz don't say so.
+	for m := range library.Materials {
+		entries := library.Materials[m].Entries
+		for e := range entries {
+			cs := &entries[e].CutSetting
+			convertCLBUnits(&cs.Params, inputUnits)
+			for i := range cs.SubLayers {
+				convertCLBUnits(&cs.SubLayers[i].Params, inputUnits)
+			}
+		}
+	}
+
+	items := importItemsFromLibrary(library, req.LaserMakeModel, laserType, req.Wattage, userID)
+	for i := range items {
+		items[i].Params.LaserModelID = laserModelID
+	}
+
+	// Map each material name in the file onto the catalog
+	normalizer, err := catalogNormalizer(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.MaterialName
+	}
+	resolutions, err := resolveMaterialNames(ctx, normalizer, names, decisions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range items {
+		items[i].Material = resolutions[strings.ToLower(strings.TrimSpace(items[i].MaterialName))]
+	}
+
+	if err := classifyImportItems(ctx, items, userID, laserType, req.Wattage); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Detect a re-upload of the same file for the same laser
+	previous, err := queries.GetImportByHash(ctx, db.GetImportByHashParams{
+		UserID:         userID,
+		ContentHash:    contentHash,
+		LaserMakeModel: req.LaserMakeModel,
+		LaserType:      db.ImportsLaserType(laserType),
+		Wattage:        req.Wattage,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	alreadyImported := err == nil
+
+	if req.DryRun {
+		preview := make([]ImportPreviewItem, len(items))
+		counts := map[string]int{
+			importStatusNew:       0,
+			importStatusDuplicate: 0,
+			importStatusConflict:  0,
+			importStatusInvalid:   0,
+		}
+		materials := []MaterialMatch{}
+		seen := make(map[*materialResolution]bool)
+		for i, item := range items {
+			preview[i] = importPreviewItem(item)
+			counts[item.Status]++
+			if !seen[item.Material] {
+				seen[item.Material] = true
+				match := item.Material.Match
+				match.Action = item.Material.Action
+				materials = append(materials, match)
+			}
+		}
+
+		response := gin.H{
+			"dryRun":          true,
+			"contentHash":     contentHash,
+			"alreadyImported": alreadyImported,
+			"new":             counts[importStatusNew],
+			"duplicates":      counts[importStatusDuplicate],
+			"conflicts":       counts[importStatusConflict],
+			"invalid":         counts[importStatusInvalid],
+			"settings":        preview,
+			"materials":       materials,
+		}
+		if alreadyImported {
+			response["previousImportId"] = previous.ID
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	if alreadyImported {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":            "this file has already been imported for this laser",
+			"previousImportId": previous.ID,
+		})
+		return
+	}
+
+	// Write everything in one transaction. Duplicates of settings the user
+	// already has are skipped, so re-running an import never double-inserts.
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	// Record the batch first so every setting can point back to it
+	result, err := qtx.CreateImport(ctx, db.CreateImportParams{
+		UserID:         userID,
+		Filename:       filepath.Base(file.Filename),
+		ContentHash:    contentHash,
+		LaserMakeModel: req.LaserMakeModel,
+		LaserType:      db.ImportsLaserType(laserType),
+		Wattage:        req.Wattage,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	importID, err := result.LastInsertId()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	imported := 0
+	skipped := 0
+	conflicts := 0
+	failed := 0
+	var errors []string
+
+	for _, item := range items {
+		label := item.MaterialName + "/" + item.Desc
+
+		if item.Status == importStatusDuplicate {
+			skipped++
+			continue
+		}
+
+		// Invalid values fail like a rejected insert would
+		var err error
+		if item.Invalid != nil {
+			err = item.Invalid
+		} else {
+			// Each item gets a savepoint, so one that fails halfway (say on a
+			// sublayer) leaves no setting or material behind when the rest
+			// of the batch is committed
+			resolution := *item.Material
+			if _, spErr := tx.ExecContext(ctx, "SAVEPOINT import_item"); spErr != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": spErr.Error()})
+				return
+			}
+			err = createImportedSetting(ctx, qtx, item, int32(importID))
+			savepoint := "RELEASE SAVEPOINT import_item"
+			if err != nil {
+				// A material created for this item is rolled back with it,
+				// so later items with the same name create it again
+				*item.Material = resolution
+				savepoint = "ROLLBACK TO SAVEPOINT import_item"
+			}
+			if _, spErr := tx.ExecContext(ctx, savepoint); spErr != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": spErr.Error()})
+				return
+			}
+		}
+		if err != nil {
+			if req.AllOrNothing {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{
+					"error":  "import aborted, no settings were saved",
+					"failed": fmt.Sprintf("%s: %v", label, err),
+				})
+				return
+			}
+			failed++
+			errors = append(errors, fmt.Sprintf("%s: %v", label, err))
+			continue
+		}
+
+		imported++
+		if item.Status == importStatusConflict {
+			conflicts++
+		}
+	}
+
+	errorsJSON := sql.NullString{}
+	if len(errors) > 0 {
+		data, _ := json.Marshal(errors)
+		errorsJSON = sql.NullString{String: string(data), Valid: true}
+	}
+	err = qtx.UpdateImportResult(ctx, db.UpdateImportResultParams{
+		ImportedCount: int32(imported),
+		SkippedCount:  int32(skipped),
+		ConflictCount: int32(conflicts),
+		FailedCount:   int32(failed),
+		Errors:        errorsJSON,
+		ID:            int32(importID),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to commit import"})
+		return
+	}
+	// The import may have added materials and aliases
+	invalidateMaterialCatalog()
+
+	response := gin.H{
+		"message":   "CLB import completed",
+		"importId":  importID,
+		"imported":  imported,
+		"skipped":   skipped,
+		"conflicts": conflicts,
+		"failed":    failed,
+	}
+	if len(errors) > 0 {
+		response["errors"] = errors
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// importItemsFromLibrary flattens a parsed library into settings to import
+func importItemsFromLibrary(library *clb.Library, laserMakeModel string, laserType db.SettingsLaserType, wattage int32, userID int32) []importItem {
+	var items []importItem
+
+	for _, material := range library.Materials {
+		// Extract base material name (remove laser make/model if present)
+		baseMaterialName := material.Name
+		if idx := strings.LastIndex(baseMaterialName, " ("); idx > 0 {
+			baseMaterialName = baseMaterialName[:idx]
+		}
+
+		for _, entry := range material.Entries {
+			params := cutSettingParams(entry.CutSetting, laserMakeModel, laserType, wattage, userID)
+			params.ThicknessMm = importThickness(entry.Thickness, material.Name)
+			items = append(items, importItem{
+				MaterialName: baseMaterialName,
+				Desc:         entry.Desc,
+				Params:       params,
+				SubLayers:    entry.CutSetting.SubLayers,
+				Invalid:      validateImportItem(laserType, wattage, params, entry.CutSetting.SubLayers),
+			})
+		}
+	}
+
+	return items
+}
+
+// validateImportItem checks a parsed setting and each of its sublayers,
+// whose errors are reported as subLayers[i].<field>
+func validateImportItem(laserType db.SettingsLaserType, wattage int32, params db.CreateSettingParams, subLayers []clb.SubLayer) *ValidationError {
+	errs := validateSetting(laserType, wattage, params.OperationType, createValues(params))
+	if errs == nil {
+		errs = &ValidationError{}
+	}
+	for i, sl := range subLayers {
+		validateSublayer(errs, fmt.Sprintf("subLayers[%d].", i), laserType, sublayerParams(0, i, sl))
+	}
+	if len(errs.Fields) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// classifyImportItems marks each item as new, a duplicate of a setting the
+// user already has (same material, operation and values), or a conflict
+// (same material and operation but different values). Items whose values
+// failed validation are marked invalid and not compared.
+func classifyImportItems(ctx context.Context, items []importItem, userID int32, laserType db.SettingsLaserType, wattage int32) error {
+	existing, err := queries.GetUserSettingsForImport(ctx, db.GetUserSettingsForImportParams{
+		UserID:    userID,
+		LaserType: laserType,
+		Wattage:   wattage,
+	})
+	if err != nil {
+		return err
+	}
+
+	type match struct {
+		id          int32
+		fingerprint string
+	}
+	byKey := make(map[string][]match)
+	for _, row := range existing {
+		key := importKey(row.MaterialName, row.OperationType, row.LayerName, row.LayerSubname, row.ImageMode, row.ThicknessMm)
+		byKey[key] = append(byKey[key], match{
+			id: row.ID,
+			fingerprint: importFingerprint(row.MaxPower, row.MinPower, row.Speed, row.NumPasses,
+				row.ScanInterval, row.Frequency),
+		})
+	}
+
+	for i := range items {
+		item := &items[i]
+		p := item.Params
+
+		// Compare against the user's settings under the canonical material
+		item.NewMaterial = item.Material.Action == materialActionCreate
+		if item.Invalid != nil {
+			item.Status = importStatusInvalid
+			continue
+		}
+		key := importKey(item.Material.Name, p.OperationType, p.LayerName, p.LayerSubname, p.ImageMode, p.ThicknessMm)
+		fingerprint := importFingerprint(p.MaxPower, p.MinPower, p.Speed, p.NumPasses, p.ScanInterval, p.Frequency)
+
+		item.Status = importStatusNew
+		for _, m := range byKey[key] {
+			if m.fingerprint == fingerprint {
+				item.Status = importStatusDuplicate
+				item.ExistingID = m.id
+				break
+			}
+			item.Status = importStatusConflict
+			item.ExistingID = m.id
+		}
+
+		// Later copies of the same setting within this file are duplicates too
+		if item.Status != importStatusDuplicate {
+			byKey[key] = append(byKey[key], match{id: item.ExistingID, fingerprint: fingerprint})
+		}
+	}
+
+	return nil
+}
+
+// importKey identifies "the same setting" for duplicate detection; the
+// same operation on 3mm and 6mm stock are different settings
+func importKey(materialName string, operationType db.SettingsOperationType, layerName, layerSubname, imageMode, thicknessMm sql.NullString) string {
+	return strings.Join([]string{
+		strings.ToLower(materialName),
+		string(operationType),
+		strings.ToLower(layerName.String),
+		strings.ToLower(layerSubname.String),
+		strings.ToLower(imageMode.String),
+		normalizeDecimal(thicknessMm.String),
+	}, "|")
+}
+
+// importFingerprint summarizes the values that make two settings identical,
+// normalizing decimals so "70.000" from MySQL matches "70" from a .clb
+func importFingerprint(maxPower, minPower, speed string, numPasses int32, scanInterval, frequency sql.NullString) string {
+	return strings.Join([]string{
+		normalizeDecimal(maxPower),
+		normalizeDecimal(minPower),
+		normalizeDecimal(speed),
+		strconv.Itoa(int(numPasses)),
+		normalizeDecimal(scanInterval.String),
+		normalizeDecimal(frequency.String),
+	}, "|")
+}
+
+func normalizeDecimal(s string) string {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return s
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func importPreviewItem(item importItem) ImportPreviewItem {
+	p := item.Params
+	preview := ImportPreviewItem{
+		Material:      item.MaterialName,
+		Desc:          item.Desc,
+		SubLayers:     len(item.SubLayers),
+		OperationType: string(p.OperationType),
+		MaxPower:      p.MaxPower,
+		MinPower:      p.MinPower,
+		Speed:         p.Speed,
+		NumPasses:     p.NumPasses,
+		Frequency:     p.Frequency.String,
+		ScanInterval:  p.ScanInterval.String,
+		ImageMode:     p.ImageMode.String,
+		ThicknessMm:   p.ThicknessMm.String,
+		Status:        item.Status,
+		ExistingID:    item.ExistingID,
+		NewMaterial:   item.NewMaterial,
+	}
+	if item.Invalid != nil {
+		preview.Errors = item.Invalid.Fields
+	}
+	if !item.NewMaterial && !strings.EqualFold(item.Material.Name, item.MaterialName) {
+		preview.CanonicalMaterial = item.Material.Name
+	}
+	return preview
+}
+
+// createImportedSetting writes a single parsed setting and its sublayers
+// using q, creating its material or alias if needed, and links it to its
+// import batch
+func createImportedSetting(ctx context.Context, q *db.Queries, item importItem, importID int32) error {
+	materialID, err := item.Material.apply(ctx, q)
+	if err != nil {
+		return fmt.Errorf("failed to get/create material: %w", err)
+	}
+
+	params := item.Params
+	params.MaterialID = materialID
+	params.ImportID = sql.NullInt32{Int32: importID, Valid: true}
+	result, err := q.CreateSetting(ctx, params)
+	if err != nil {
+		return err
+	}
+	settingID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for i, sl := range item.SubLayers {
+		if err := q.CreateSettingSublayer(ctx, sublayerParams(int32(settingID), i, sl)); err != nil {
+			return fmt.Errorf("failed to save sublayer %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Helper function to get or create material by name
+func getOrCreateMaterial(ctx context.Context, q *db.Queries, materialName string, categoryID int32) (int32, error) {
+	// Try to find existing material
+	material, err := q.GetMaterialByName(ctx, materialName)
+	if err == nil {
+		return material.ID, nil
+	}
+
+	// Material doesn't exist, create it
+	result, err := q.CreateMaterial(ctx, db.CreateMaterialParams{
+		CategoryID: categoryID,
+		Name:       materialName,
+		Slug:       materialSlug(materialName),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(id), nil
+}
+
+// cutSettingParams maps a CutSetting to insert params; MaterialID is filled
+// in when the setting is written
+func cutSettingParams(cs clb.CutSetting, laserMakeModel string, laserType db.SettingsLaserType, wattage int32, userID int32) db.CreateSettingParams {
+	// Parse required fields
+	maxPower := cs.MaxPower.String()
+	if maxPower == "" {
+		maxPower = "0"
+	}
+	minPower := cs.MinPower.String()
+	if minPower == "" {
+		minPower = "0"
+	}
+	speed := cs.Speed.String()
+	if speed == "" {
+		speed = "0"
+	}
+
+	// Parse optional numeric fields
+	numPasses := parseInt32(cs.NumPasses.String(), 1)
+
+	// Parse booleans
+	bidir := true // default
+	if cs.Bidir.IsSet() {
+		bidir = parseBool(cs.Bidir.String())
+	}
+
+	operationType := operationFromCLBType(cs.Type)
+
+	return db.CreateSettingParams{
+		UserID:           userID,
+		LaserType:        laserType,
+		Wattage:          wattage,
+		OperationType:    operationType,
+		MaxPower:         maxPower,
+		MinPower:         minPower,
+		MaxPower2:        nullValue(cs.MaxPower2),
+		MinPower2:        nullValue(cs.MinPower2),
+		Speed:            speed,
+		NumPasses:        numPasses,
+		ZOffset:          nullValue(cs.ZOffset),
+		ZPerPass:         nullValue(cs.ZPerPass),
+		ScanInterval:     nullValue(cs.Interval),
+		Angle:            nullValue(cs.Angle),
+		AnglePerPass:     nullValue(cs.AnglePerPass),
+		CrossHatch:       parseBool(cs.CrossHatch.String()),
+		Bidir:            bidir,
+		ScanOpt:          nullValue(cs.ScanOpt),
+		FloodFill:        parseBool(cs.FloodFill.String()),
+		AutoRotate:       parseBool(cs.AutoRotate.String()),
+		Overscan:         nullValue(cs.Overscan),
+		OverscanPercent:  nullValue(cs.OverscanPercent),
+		Frequency:        nullValue(cs.Frequency),
+		WobbleEnable:     nullBoolValue(cs.WobbleEnable),
+		UseDotCorrection: parseBoolOrFallback(cs.UseDotCorrection.String(), cs.EnableDotWidthAdjust.String()),
+		PulseWidth:       nullValue(cs.QPulseWidth),
+		WobbleDiameter:   nullValue(cs.WobbleSize),
+		WobbleStep:       nullValue(cs.WobbleStep),
+		PerforationMode:  parseBool(cs.PerforationMode.String()),
+		ImageMode:        imageMode(cs),
+		NegativeImage:    parseBool(cs.NegativeImage.String()),
+		DotWidth:         nullValue(cs.DotWidth),
+		Kerf:             nullValue(cs.Kerf),
+		RunBlower:        nullBoolValue(cs.RunBlower),
+		LayerName:        sql.NullString{String: laserMakeModel, Valid: true},
+		LayerSubname:     nullValue(cs.Subname),
+		Priority:         nullInt32Value(cs.Priority),
+		TabCount:         nullInt32Value(cs.TabCount),
+		TabCountMax:      nullInt32Value(cs.TabCountMax),
+		Notes:            sql.NullString{String: "", Valid: false},
+	}
+}
+
+// sublayerParams maps a SubLayer nested in a CutSetting to a child row of
+// settingID. position is used when the SubLayer has no index attribute.
+func sublayerParams(settingID int32, position int, sl clb.SubLayer) db.CreateSettingSublayerParams {
+	maxPower := sl.MaxPower.String()
+	if maxPower == "" {
+		maxPower = "0"
+	}
+	minPower := sl.MinPower.String()
+	if minPower == "" {
+		minPower = "0"
+	}
+	speed := sl.Speed.String()
+	if speed == "" {
+		speed = "0"
+	}
+
+	sublayerType := sl.Type
+	if sublayerType == "" {
+		sublayerType = clb.TypeScan
+	}
+
+	return db.CreateSettingSublayerParams{
+		SettingID:     settingID,
+		SublayerIndex: parseInt32(sl.Index, int32(position+1)),
+		SublayerType:  sublayerType,
+		IsCleanup:     parseBool(sl.IsCleanup.String()),
+		MaxPower:      maxPower,
+		MinPower:      minPower,
+		MaxPower2:     nullValue(sl.MaxPower2),
+		MinPower2:     nullValue(sl.MinPower2),
+		Speed:         speed,
+		NumPasses:     parseInt32(sl.NumPasses.String(), 1),
+		Frequency:     nullValue(sl.Frequency),
+		ScanInterval:  nullValue(sl.Interval),
+		Subname:       nullValue(sl.Subname),
+	}
+}
+
+// Parse helpers
+func parseInt32(s string, defaultVal int32) int32 {
+	if s == "" {
+		return defaultVal
+	}
+	val, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return defaultVal
+	}
+	return int32(val)
+}
+
+func parseBool(s string) bool {
+	return s == "1" || strings.ToLower(s) == "true"
+}
+
+// parseBoolOrFallback tries primary first, falls back to secondary for backward compatibility
+func parseBoolOrFallback(primary, secondary string) sql.NullBool {
+	if primary != "" {
+		return sql.NullBool{Bool: parseBool(primary), Valid: true}
+	}
+	if secondary != "" {
+		return sql.NullBool{Bool: parseBool(secondary), Valid: true}
+	}
+	return sql.NullBool{Valid: false}
+}
+
+// imageMode extracts the image mode from a CutSetting, preferring ditherMode
+func imageMode(cs clb.CutSetting) sql.NullString {
+	// Prefer ditherMode as that's what LightBurn uses for Image mode
+	if cs.DitherMode.IsSet() {
+		return sql.NullString{String: cs.DitherMode.String(), Valid: true}
+	}
+	// Fall back to imageMode if present
+	if cs.ImageMode.IsSet() {
+		return sql.NullString{String: cs.ImageMode.String(), Valid: true}
+	}
+	return sql.NullString{Valid: false}
+}
+
+func nullValue(v *clb.Value) sql.NullString {
+	if !v.IsSet() {
+		return sql.NullString{Valid: false}
+	}
+	return sql.NullString{String: v.String(), Valid: true}
+}
+
+func nullBoolValue(v *clb.Value) sql.NullBool {
+	if !v.IsSet() {
+		return sql.NullBool{Valid: false}
+	}
+	return sql.NullBool{Bool: parseBool(v.String()), Valid: true}
+}
+
+func nullInt32Value(v *clb.Value) sql.NullInt32 {
+	if !v.IsSet() {
+		return sql.NullInt32{Valid: false}
+	}
+	val := parseInt32(v.String(), 0)
+	return sql.NullInt32{Int32: val, Valid: true}
+}
+
+// laserTypeNames lists the laser types stringToLaserType accepts, for
+// error messages
+const laserTypeNames = "CO2, Fiber, Diode, UV or Infrared"
+
+// stringToLaserType parses a laser type case-insensitively, reporting false
+// for anything that isn't one
+func stringToLaserType(s string) (db.SettingsLaserType, bool) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "CO2":
+		return db.SettingsLaserTypeCO2, true
+	case "FIBER":
+		return db.SettingsLaserTypeFiber, true
+	case "DIODE":
+		return db.SettingsLaserTypeDiode, true
+	case "UV":
+		return db.SettingsLaserTypeUV, true
+	case "INFRARED":
+		return db.SettingsLaserTypeInfrared, true
+	default:
+		return "", false
+	}
+}
+
+// =====================
+// IMPORT HISTORY HANDLERS
+// =====================
+
+func getUserImportsHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+
+	imports, err := queries.GetUserImports(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Transform imports to flatten sql.Null types
+	importsResponse := make([]map[string]interface{}, len(imports))
+	for i, imp := range imports {
+		errors := []string{}
+		if imp.Errors.Valid {
+			json.Unmarshal([]byte(imp.Errors.String), &errors)
+		}
+		createdAt := ""
+		if imp.CreatedAt.Valid {
+			createdAt = imp.CreatedAt.Time.Format(time.RFC3339)
+		}
+		importsResponse[i] = map[string]interface{}{
+			"id":             imp.ID,
+			"filename":       imp.Filename,
+			"laserMakeModel": imp.LaserMakeModel,
+			"laserType":      imp.LaserType,
+			"wattage":        imp.Wattage,
+			"imported":       imp.ImportedCount,
+			"skipped":        imp.SkippedCount,
+			"conflicts":      imp.ConflictCount,
+			"failed":         imp.FailedCount,
+			"errors":         errors,
+			"settingCount":   imp.SettingCount,
+			"createdAt":      createdAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, importsResponse)
+}
+
+// rollbackImportHandler removes every setting created by an import batch,
+// then the batch itself so the same file can be imported again
+func rollbackImportHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	ctx := c.Request.Context()
+
+	importID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid import id"})
+		return
+	}
+
+	// Verify ownership
+	_, err = queries.GetImportByID(ctx, db.GetImportByIDParams{
+		ID:     int32(importID),
+		UserID: userID,
+	})
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "import not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	deleted, err := qtx.DeleteSettingsByImport(ctx, db.DeleteSettingsByImportParams{
+		ImportID: sql.NullInt32{Int32: int32(importID), Valid: true},
+		UserID:   userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = qtx.DeleteImport(ctx, db.DeleteImportParams{
+		ID:     int32(importID),
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to roll back import"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "import rolled back", "deleted": deleted})
+}
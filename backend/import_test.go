@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"laserscribe/backend/clb"
+	"laserscribe/backend/db"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// importTables are the rows the import and rollback handlers read and write
+type importTables struct {
+	materials []db.GetAllMaterialsRow
+	settings  []importedSetting
+	sublayers []db.CreateSettingSublayerParams
+	imports   []db.Import
+	nextID    int32
+}
+
+type importedSetting struct {
+	ID int32
+	db.CreateSettingParams
+}
+
+func (t *importTables) clone() *importTables {
+	c := *t
+	c.materials = append([]db.GetAllMaterialsRow(nil), t.materials...)
+	c.settings = append([]importedSetting(nil), t.settings...)
+	c.sublayers = append([]db.CreateSettingSublayerParams(nil), t.sublayers...)
+	c.imports = append([]db.Import(nil), t.imports...)
+	return &c
+}
+
+func (t *importTables) id() int32 {
+	t.nextID++
+	return t.nextID
+}
+
+func (t *importTables) materialName(id int32) string {
+	for _, m := range t.materials {
+		if m.ID == id {
+			return m.Name
+		}
+	}
+	return ""
+}
+
+// newImportDB answers the import queries from tables. Saving a sublayer
+// named "fail" fails, like a rejected insert.
+func newImportDB(tables *importTables) *fakeDB {
+	t := tables
+	return &fakeDB{
+		snapshot: func() interface{} { return t.clone() },
+		restore:  func(saved interface{}) { *t = *saved.(*importTables).clone() },
+		queries: map[string]fakeQuery{
+			"GetAllMaterials": func([]driver.Value) ([]interface{}, int64, error) {
+				var rows []interface{}
+				for _, m := range t.materials {
+					rows = append(rows, m)
+				}
+				return rows, 0, nil
+			},
+			"GetAllMaterialAliases": func([]driver.Value) ([]interface{}, int64, error) {
+				return nil, 0, nil
+			},
+			"GetMaterialByName": func(args []driver.Value) ([]interface{}, int64, error) {
+				for _, m := range t.materials {
+					if strings.EqualFold(m.Name, args[0].(string)) {
+						return []interface{}{db.Material{ID: m.ID, CategoryID: m.CategoryID, Name: m.Name, Slug: m.Slug}}, 0, nil
+					}
+				}
+				return nil, 0, nil
+			},
+			"CreateMaterial": func(args []driver.Value) ([]interface{}, int64, error) {
+				var p db.CreateMaterialParams
+				bindArgs(args, &p)
+				id := t.id()
+				t.materials = append(t.materials, db.GetAllMaterialsRow{ID: id, CategoryID: p.CategoryID, Name: p.Name, Slug: p.Slug})
+				return nil, int64(id), nil
+			},
+			"GetUserSettingsForImport": func(args []driver.Value) ([]interface{}, int64, error) {
+				var p db.GetUserSettingsForImportParams
+				bindArgs(args, &p)
+				var rows []interface{}
+				for _, s := range t.settings {
+					if s.UserID != p.UserID || s.LaserType != p.LaserType || s.Wattage != p.Wattage {
+						continue
+					}
+					rows = append(rows, db.GetUserSettingsForImportRow{
+						ID: s.ID, MaterialID: s.MaterialID, OperationType: s.OperationType,
+						MaxPower: s.MaxPower, MinPower: s.MinPower, Speed: s.Speed, NumPasses: s.NumPasses,
+						ScanInterval: s.ScanInterval, Frequency: s.Frequency, ImageMode: s.ImageMode,
+						LayerName: s.LayerName, LayerSubname: s.LayerSubname, ThicknessMm: s.ThicknessMm,
+						MaterialName: t.materialName(s.MaterialID),
+					})
+				}
+				return rows, 0, nil
+			},
+			"GetImportByHash": func(args []driver.Value) ([]interface{}, int64, error) {
+				var p db.GetImportByHashParams
+				bindArgs(args, &p)
+				for _, imp := range t.imports {
+					if imp.UserID == p.UserID && imp.ContentHash == p.ContentHash && imp.LaserMakeModel == p.LaserMakeModel &&
+						imp.LaserType == p.LaserType && imp.Wattage == p.Wattage {
+						return []interface{}{imp}, 0, nil
+					}
+				}
+				return nil, 0, nil
+			},
+			"CreateImport": func(args []driver.Value) ([]interface{}, int64, error) {
+				var p db.CreateImportParams
+				bindArgs(args, &p)
+				imp := db.Import{ID: t.id(), UserID: p.UserID, Filename: p.Filename, ContentHash: p.ContentHash,
+					LaserMakeModel: p.LaserMakeModel, LaserType: p.LaserType, Wattage: p.Wattage}
+				t.imports = append(t.imports, imp)
+				return nil, int64(imp.ID), nil
+			},
+			"CreateSetting": func(args []driver.Value) ([]interface{}, int64, error) {
+				s := importedSetting{ID: t.id()}
+				bindArgs(args, &s.CreateSettingParams)
+				t.settings = append(t.settings, s)
+				return nil, int64(s.ID), nil
+			},
+			"CreateSettingSublayer": func(args []driver.Value) ([]interface{}, int64, error) {
+				var p db.CreateSettingSublayerParams
+				bindArgs(args, &p)
+				if p.Subname.String == "fail" {
+					return nil, 0, fmt.Errorf("sublayer rejected")
+				}
+				t.sublayers = append(t.sublayers, p)
+				return nil, 1, nil
+			},
+			"UpdateImportResult": func(args []driver.Value) ([]interface{}, int64, error) {
+				var p db.UpdateImportResultParams
+				bindArgs(args, &p)
+				for i := range t.imports {
+					if t.imports[i].ID == p.ID {
+						imp := &t.imports[i]
+						imp.ImportedCount, imp.SkippedCount = p.ImportedCount, p.SkippedCount
+						imp.ConflictCount, imp.FailedCount, imp.Errors = p.ConflictCount, p.FailedCount, p.Errors
+					}
+				}
+				return nil, 1, nil
+			},
+			"GetImportByID": func(args []driver.Value) ([]interface{}, int64, error) {
+				var p db.GetImportByIDParams
+				bindArgs(args, &p)
+				for _, imp := range t.imports {
+					if imp.ID == p.ID && imp.UserID == p.UserID {
+						return []interface{}{imp}, 0, nil
+					}
+				}
+				return nil, 0, nil
+			},
+			"DeleteSettingsByImport": func(args []driver.Value) ([]interface{}, int64, error) {
+				var p db.DeleteSettingsByImportParams
+				bindArgs(args, &p)
+				deleted := make(map[int32]bool)
+				var kept []importedSetting
+				for _, s := range t.settings {
+					if s.ImportID == p.ImportID && s.UserID == p.UserID {
+						deleted[s.ID] = true
+					} else {
+						kept = append(kept, s)
+					}
+				}
+				t.settings = kept
+				var sublayers []db.CreateSettingSublayerParams
+				for _, sl := range t.sublayers {
+					if !deleted[sl.SettingID] {
+						sublayers = append(sublayers, sl)
+					}
+				}
+				t.sublayers = sublayers
+				return nil, int64(len(deleted)), nil
+			},
+			"DeleteImport": func(args []driver.Value) ([]interface{}, int64, error) {
+				var p db.DeleteImportParams
+				bindArgs(args, &p)
+				var kept []db.Import
+				for _, imp := range t.imports {
+					if imp.ID != p.ID || imp.UserID != p.UserID {
+						kept = append(kept, imp)
+					}
+				}
+				t.imports = kept
+				return nil, 1, nil
+			},
+		},
+	}
+}
+
+// catalogTables has the one material the test libraries mostly use
+func catalogTables() *importTables {
+	return &importTables{
+		materials: []db.GetAllMaterialsRow{{ID: 1, CategoryID: 1, Name: "Stainless Steel", Slug: "stainless-steel", CategoryName: "Metal"}},
+		nextID:    100,
+	}
+}
+
+func importRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	asUser := func(c *gin.Context) {
+		c.Set("user_id", int32(1))
+	}
+	r.POST("/api/settings/import", asUser, importCLBHandler)
+	r.DELETE("/api/profile/imports/:id", asUser, rollbackImportHandler)
+	return r
+}
+
+// testEntry is a library entry with the values import checks
+func testEntry(desc, cutType, maxPower, speed, interval string, subLayers ...clb.SubLayer) clb.Entry {
+	cs := clb.CutSetting{Type: cutType}
+	cs.MaxPower, cs.Speed, cs.Interval = clb.V(maxPower), clb.V(speed), clb.V(interval)
+	cs.SubLayers = subLayers
+	return clb.Entry{Thickness: clb.NoThickness, Desc: desc, NoThickTitle: desc, CutSetting: cs}
+}
+
+func testSubLayer(subname string) clb.SubLayer {
+	sl := clb.SubLayer{Type: clb.TypeScan}
+	sl.MaxPower, sl.Speed, sl.Interval, sl.Subname = clb.V("40"), clb.V("2000"), clb.V("0.05"), clb.V(subname)
+	return sl
+}
+
+func testLibrary(t *testing.T, materials ...clb.Material) []byte {
+	data, err := clb.Marshal(&clb.Library{DisplayName: clb.DisplayName, Materials: materials})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return data
+}
+
+// postImport uploads library for a 20W fiber with the extra form fields
+func postImport(t *testing.T, r *gin.Engine, library []byte, fields map[string]string) (int, map[string]interface{}) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	form := map[string]string{"laserMakeModel": "Gweike G2", "laserType": "Fiber", "wattage": "20"}
+	for k, v := range fields {
+		form[k] = v
+	}
+	for k, v := range form {
+		w.WriteField(k, v)
+	}
+	part, _ := w.CreateFormFile("file", "library.clb")
+	part.Write(library)
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/settings/import", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return serve(t, r, req)
+}
+
+func serve(t *testing.T, r *gin.Engine, req *http.Request) (int, map[string]interface{}) {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s %s: %v: %s", req.Method, req.URL, err, rec.Body)
+	}
+	return rec.Code, resp
+}
+
+func TestClassifyImportItems(t *testing.T) {
+	tables := catalogTables()
+	existing := db.CreateSettingParams{
+		UserID: 1, MaterialID: 1, LaserType: db.SettingsLaserTypeFiber, Wattage: 20,
+		OperationType: db.SettingsOperationTypeScan, MaxPower: "70.000", MinPower: "0.000", Speed: "1000.000",
+		NumPasses: 1, ScanInterval: validString("0.0300"),
+	}
+	tables.settings = []importedSetting{{ID: 7, CreateSettingParams: existing}}
+	useFakeDB(t, newImportDB(tables))
+
+	stainless := &materialResolution{Action: materialActionAccept, MaterialID: 1, Name: "Stainless Steel"}
+	item := func(op db.SettingsOperationType, maxPower string, invalid bool) importItem {
+		p := db.CreateSettingParams{OperationType: op, MaxPower: maxPower, MinPower: "0", Speed: "1000",
+			NumPasses: 1, ScanInterval: validString("0.03")}
+		it := importItem{MaterialName: "stainless steel", Params: p, Material: stainless}
+		if invalid {
+			it.Invalid = &ValidationError{Fields: []FieldError{{Field: "maxPower", Message: "must be between 0 and 100"}}}
+		}
+		return it
+	}
+	items := []importItem{
+		item(db.SettingsOperationTypeScan, "70", false),
+		item(db.SettingsOperationTypeScan, "75", false),
+		item(db.SettingsOperationTypeCut, "70", false),
+		item(db.SettingsOperationTypeCut, "70", false),
+		item(db.SettingsOperationTypeCut, "170", true),
+	}
+	if err := classifyImportItems(context.Background(), items, 1, db.SettingsLaserTypeFiber, 20); err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		status     string
+		existingID int32
+	}{
+		// Same values as setting 7, once stored decimals are normalized
+		{importStatusDuplicate, 7},
+		{importStatusConflict, 7},
+		{importStatusNew, 0},
+		// A second copy in the same file is a duplicate of the first
+		{importStatusDuplicate, 0},
+		{importStatusInvalid, 0},
+	}
+	for i, w := range want {
+		if items[i].Status != w.status || items[i].ExistingID != w.existingID {
+			t.Errorf("item %d: %s of %d, want %s of %d", i, items[i].Status, items[i].ExistingID, w.status, w.existingID)
+		}
+	}
+}
+
+func TestImportRollsBackFailedItems(t *testing.T) {
+	library := testLibrary(t,
+		clb.Material{Name: "Walnut", Entries: []clb.Entry{
+			// Fails on its sublayer after the setting and material are written
+			testEntry("Fill", clb.TypeScan, "60", "1500", "0.05", testSubLayer("Cleanup"), testSubLayer("fail")),
+			testEntry("Line", clb.TypeCut, "80", "500", ""),
+		}},
+		clb.Material{Name: "Stainless Steel", Entries: []clb.Entry{
+			testEntry("Fill", clb.TypeScan, "75", "1000", "0.03", testSubLayer("Cleanup")),
+			// Invalid values fail without being written
+			testEntry("Line", clb.TypeCut, "150", "1000", ""),
+		}},
+	)
+
+	t.Run("per item", func(t *testing.T) {
+		tables := catalogTables()
+		useFakeDB(t, newImportDB(tables))
+		code, resp := postImport(t, importRouter(), library, nil)
+		if code != http.StatusCreated {
+			t.Fatalf("status %d: %v", code, resp)
+		}
+		if resp["imported"] != 2.0 || resp["failed"] != 2.0 {
+			t.Errorf("imported %v and failed %v, want 2 and 2", resp["imported"], resp["failed"])
+		}
+		if len(tables.settings) != 2 || len(tables.sublayers) != 1 {
+			t.Fatalf("%d settings and %d sublayers written, want 2 and 1", len(tables.settings), len(tables.sublayers))
+		}
+		// Walnut was rolled back with the failed item and created again for
+		// the next one
+		walnut := tables.settings[0]
+		if tables.materialName(walnut.MaterialID) != "Walnut" || walnut.OperationType != db.SettingsOperationTypeCut {
+			t.Errorf("first setting is a %s of %q", walnut.OperationType, tables.materialName(walnut.MaterialID))
+		}
+		if n := len(tables.materials); n != 2 {
+			t.Errorf("%d materials, want 2", n)
+		}
+		if sl := tables.sublayers[0]; sl.SettingID != tables.settings[1].ID {
+			t.Errorf("sublayer belongs to setting %d, want %d", sl.SettingID, tables.settings[1].ID)
+		}
+		if len(tables.imports) != 1 || tables.imports[0].FailedCount != 2 {
+			t.Errorf("import history %+v", tables.imports)
+		}
+	})
+
+	t.Run("all or nothing", func(t *testing.T) {
+		tables := catalogTables()
+		f := newImportDB(tables)
+		useFakeDB(t, f)
+		code, resp := postImport(t, importRouter(), library, map[string]string{"allOrNothing": "true"})
+		if code != http.StatusUnprocessableEntity {
+			t.Fatalf("status %d: %v", code, resp)
+		}
+		if !strings.HasPrefix(resp["failed"].(string), "Walnut/Fill:") {
+			t.Errorf("failed = %v, want the first Walnut item", resp["failed"])
+		}
+		if len(tables.settings) != 0 || len(tables.sublayers) != 0 || len(tables.imports) != 0 || len(tables.materials) != 1 {
+			t.Errorf("aborted import left %d settings, %d sublayers, %d imports and %d materials",
+				len(tables.settings), len(tables.sublayers), len(tables.imports), len(tables.materials))
+		}
+		if n := f.ran("CreateSetting"); n != 1 {
+			t.Errorf("import went on after the first failure: %v", f.statements)
+		}
+	})
+}
+
+func TestReimportAfterRollback(t *testing.T) {
+	tables := catalogTables()
+	useFakeDB(t, newImportDB(tables))
+	r := importRouter()
+	library := testLibrary(t, clb.Material{Name: "Stainless Steel", Entries: []clb.Entry{
+		testEntry("Fill", clb.TypeScan, "75", "1000", "0.03", testSubLayer("Cleanup")),
+		testEntry("Line", clb.TypeCut, "70", "1000", ""),
+	}})
+	dryRun := map[string]string{"dryRun": "true"}
+
+	code, resp := postImport(t, r, library, nil)
+	if code != http.StatusCreated || resp["imported"] != 2.0 {
+		t.Fatalf("import: %d %v", code, resp)
+	}
+	importID := resp["importId"].(float64)
+
+	// The same file again is refused, and a dry run says why
+	code, resp = postImport(t, r, library, nil)
+	if code != http.StatusConflict || resp["previousImportId"] != importID {
+		t.Errorf("re-upload: %d %v", code, resp)
+	}
+	code, resp = postImport(t, r, library, dryRun)
+	if code != http.StatusOK || resp["alreadyImported"] != true || resp["duplicates"] != 2.0 || resp["new"] != 0.0 {
+		t.Errorf("dry run after import: %d %v", code, resp)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/profile/imports/%d", int(importID)), nil)
+	code, resp = serve(t, r, req)
+	if code != http.StatusOK || resp["deleted"] != 2.0 {
+		t.Fatalf("rollback: %d %v", code, resp)
+	}
+	if len(tables.settings) != 0 || len(tables.sublayers) != 0 || len(tables.imports) != 0 {
+		t.Errorf("rollback left %d settings, %d sublayers and %d imports", len(tables.settings), len(tables.sublayers), len(tables.imports))
+	}
+
+	// Once rolled back the file is new again
+	code, resp = postImport(t, r, library, dryRun)
+	if code != http.StatusOK || resp["alreadyImported"] != false || resp["new"] != 2.0 || resp["duplicates"] != 0.0 {
+		t.Errorf("dry run after rollback: %d %v", code, resp)
+	}
+	code, resp = postImport(t, r, library, nil)
+	if code != http.StatusCreated || resp["imported"] != 2.0 {
+		t.Errorf("import after rollback: %d %v", code, resp)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/profile/imports/9999", nil)
+	if code, _ := serve(t, r, req); code != http.StatusNotFound {
+		t.Errorf("rolling back an unknown import: %d", code)
+	}
+}
@@ -0,0 +1,122 @@
+// Package lbrn writes LightBurn project (.lbrn2) files.
+//
+// A project holds the same <CutSetting> elements as a .clb library, one per
+// layer, followed by the shapes drawn on those layers. Only what Laserscribe
+// generates is modelled: rectangles and single-line text. See
+// docs/lightburn-clb-format.md for the layout.
+package lbrn
+
+import (
+	"encoding/xml"
+	"fmt"
+	"laserscribe/backend/clb"
+	"strconv"
+)
+
+// AppVersion is the LightBurn version generated projects claim to be from
+const AppVersion = "1.4.05"
+
+// MaxLayers is the number of color layers (C00 to C29) a project can use
+const MaxLayers = 30
+
+// DefaultFont is the font text shapes are set in: Arial at normal weight
+const DefaultFont = "Arial,-1,100,5,50,0,0,0,0,0"
+
+// Project is the <LightBurnProject> root element
+type Project struct {
+	XMLName        xml.Name         `xml:"LightBurnProject"`
+	AppVersion     string           `xml:"AppVersion,attr"`
+	FormatVersion  string           `xml:"FormatVersion,attr"`
+	MaterialHeight string           `xml:"MaterialHeight,attr"`
+	MirrorX        string           `xml:"MirrorX,attr"`
+	MirrorY        string           `xml:"MirrorY,attr"`
+	CutSettings    []clb.CutSetting `xml:"CutSetting"`
+	Shapes         []Shape          `xml:"Shape"`
+	Notes          Notes            `xml:"Notes"`
+}
+
+// Shape is a drawn object assigned to the layer with index CutIndex. Its
+// geometry is centred on the origin and placed by XForm.
+type Shape struct {
+	Type     string `xml:"Type,attr"`
+	CutIndex int    `xml:"CutIndex,attr"`
+	// Rectangle size and corner radius
+	W  string `xml:"W,attr,omitempty"`
+	H  string `xml:"H,attr,omitempty"`
+	Cr string `xml:"Cr,attr,omitempty"`
+	// Text font, content and alignment
+	Font  string `xml:"Font,attr,omitempty"`
+	Str   string `xml:"Str,attr,omitempty"`
+	Ah    string `xml:"Ah,attr,omitempty"`
+	Av    string `xml:"Av,attr,omitempty"`
+	XForm string `xml:"XForm"`
+}
+
+// Notes are the project notes, shown when the file is opened if
+// ShowOnLoad is 1
+type Notes struct {
+	ShowOnLoad string `xml:"ShowOnLoad,attr"`
+	Notes      string `xml:"Notes,attr"`
+}
+
+// New returns an empty project in millimetres
+func New() *Project {
+	return &Project{
+		AppVersion:     AppVersion,
+		FormatVersion:  "1",
+		MaterialHeight: "0",
+		MirrorX:        "False",
+		MirrorY:        "False",
+		Notes:          Notes{ShowOnLoad: "0"},
+	}
+}
+
+// Layer returns a CutSetting for the layer at index, named name
+func Layer(index int, cutType, name string) clb.CutSetting {
+	return clb.CutSetting{
+		Type:  cutType,
+		Index: clb.Int(index),
+		Name:  clb.V(name),
+	}
+}
+
+// Rect returns a w by h rectangle centred on x, y. Coordinates are in mm
+// with y increasing upwards.
+func Rect(layer int, x, y, w, h float64) Shape {
+	return Shape{
+		Type:     "Rect",
+		CutIndex: layer,
+		W:        mm(w),
+		H:        mm(h),
+		Cr:       "0",
+		XForm:    translate(x, y),
+	}
+}
+
+// Text returns a line of text of height h centred on x, y
+func Text(layer int, x, y, h float64, s string) Shape {
+	return Shape{
+		Type:     "Text",
+		CutIndex: layer,
+		H:        mm(h),
+		Font:     DefaultFont,
+		Str:      s,
+		Ah:       "1",
+		Av:       "1",
+		XForm:    translate(x, y),
+	}
+}
+
+// Marshal encodes p in LightBurn's layout
+func Marshal(p *Project) ([]byte, error) {
+	return clb.MarshalDocument(p)
+}
+
+func translate(x, y float64) string {
+	return fmt.Sprintf("1 0 0 1 %s %s", mm(x), mm(y))
+}
+
+// mm renders a length to a tenth of a micron, without trailing zeros
+func mm(v float64) string {
+	return clb.TrimDecimal(strconv.FormatFloat(v, 'f', 4, 64))
+}
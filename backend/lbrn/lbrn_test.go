@@ -0,0 +1,67 @@
+package lbrn
+
+import (
+	"encoding/xml"
+	"laserscribe/backend/clb"
+	"reflect"
+	"testing"
+)
+
+const wantProject = `<?xml version="1.0" encoding="UTF-8"?>
+<LightBurnProject AppVersion="1.4.05" FormatVersion="1" MaterialHeight="0" MirrorX="False" MirrorY="False">
+    <CutSetting type="Scan">
+        <index Value="0"/>
+        <name Value="40%, 800 mm/s"/>
+    </CutSetting>
+    <Shape Type="Rect" CutIndex="0" W="10" H="10" Cr="0">
+        <XForm>1 0 0 1 25 5</XForm>
+    </Shape>
+    <Shape Type="Text" CutIndex="1" H="3" Font="Arial,-1,100,5,50,0,0,0,0,0" Str="A &amp; B" Ah="1" Av="1">
+        <XForm>1 0 0 1 10 5.5</XForm>
+    </Shape>
+    <Notes ShowOnLoad="0" Notes=""/>
+</LightBurnProject>
+`
+
+func testProject() *Project {
+	p := New()
+	p.CutSettings = append(p.CutSettings, Layer(0, clb.TypeScan, "40%, 800 mm/s"))
+	p.Shapes = append(p.Shapes, Rect(0, 25, 5, 10, 10), Text(1, 10, 5.5, 3, "A & B"))
+	return p
+}
+
+func TestMarshal(t *testing.T) {
+	out, err := Marshal(testProject())
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(out) != wantProject {
+		t.Errorf("project differs\n--- got ---\n%s", out)
+	}
+
+	var back Project
+	if err := xml.Unmarshal(out, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(back.Shapes, testProject().Shapes) {
+		t.Errorf("shapes changed after round trip: %+v", back.Shapes)
+	}
+	if got := back.CutSettings[0].Name.String(); got != "40%, 800 mm/s" {
+		t.Errorf("layer name = %q", got)
+	}
+}
+
+func TestMM(t *testing.T) {
+	cases := map[float64]string{
+		10:        "10",
+		2.5:       "2.5",
+		0.123456:  "0.1235",
+		-3.17500:  "-3.175",
+		1.0000001: "1",
+	}
+	for in, want := range cases {
+		if got := mm(in); got != want {
+			t.Errorf("mm(%v) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"laserscribe/backend/db"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// MACHINE HANDLERS
+// =====================
+
+// wattageToken matches a wattage typed into a machine search, e.g. "20w"
+var wattageToken = regexp.MustCompile(`(?i)^(\d+)\s*w$`)
+
+// searchMachinesHandler powers make/model autocomplete. A wattage typed into
+// q ("gweike g2 20w") is used as a wattage filter rather than matched as text.
+func searchMachinesHandler(c *gin.Context) {
+	params := db.SearchLaserModelsParams{}
+
+	var words []string
+	for _, word := range strings.Fields(c.Query("q")) {
+		if m := wattageToken.FindStringSubmatch(word); m != nil && c.Query("wattage") == "" {
+			w, _ := strconv.Atoi(m[1])
+			params.Wattage = sql.NullInt32{Int32: int32(w), Valid: true}
+			continue
+		}
+		words = append(words, word)
+	}
+	if len(words) > 0 {
+		params.Query = sql.NullString{String: strings.Join(words, " "), Valid: true}
+	}
+	if v := c.Query("laser_type"); v != "" {
+		lt, ok := stringToLaserType(v)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "laser_type must be one of " + laserTypeNames})
+			return
+		}
+		params.LaserType = db.NullLaserModelsLaserType{
+			LaserModelsLaserType: db.LaserModelsLaserType(lt),
+			Valid:                true,
+		}
+	}
+	if v := c.Query("wattage"); v != "" {
+		w, _ := strconv.Atoi(v)
+		params.Wattage = sql.NullInt32{Int32: int32(w), Valid: true}
+	}
+
+	machines, err := queries.SearchLaserModels(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, machines)
+}
+
+func getMachineHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid machine id"})
+		return
+	}
+	machine, err := queries.GetLaserModelByID(c.Request.Context(), int32(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "machine not found"})
+		return
+	}
+	c.JSON(http.StatusOK, machine)
+}
+
+func getManufacturersHandler(c *gin.Context) {
+	manufacturers, err := queries.GetLaserManufacturers(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, manufacturers)
+}
+
+type CreateMachineRequest struct {
+	Manufacturer string  `json:"manufacturer" binding:"required"`
+	Model        string  `json:"model" binding:"required"`
+	LaserType    string  `json:"laserType" binding:"required"`
+	Wattage      int32   `json:"wattage" binding:"required"`
+	Source       *string `json:"source"`
+	LensMm       *string `json:"lensMm"`
+	FieldSizeMm  *string `json:"fieldSizeMm"`
+	MotionSystem string  `json:"motionSystem"`
+}
+
+// createMachineHandler adds a machine to the catalog, returning the existing
+// entry when the same make/model/type/wattage is already known
+func createMachineHandler(c *gin.Context) {
+	var req CreateMachineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	manufacturerSlug := slugify(req.Manufacturer)
+	modelSlug := slugify(req.Model)
+	if manufacturerSlug == "" || modelSlug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "manufacturer and model are required"})
+		return
+	}
+	lt, ok := stringToLaserType(req.LaserType)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "laserType must be one of " + laserTypeNames})
+		return
+	}
+
+	// Galvo is the norm for fiber and UV sources, gantry for everything else
+	motion := db.LaserModelsMotionSystemGantry
+	switch strings.ToLower(req.MotionSystem) {
+	case "galvo":
+		motion = db.LaserModelsMotionSystemGalvo
+	case "gantry":
+	case "":
+		if lt == db.SettingsLaserTypeFiber || lt == db.SettingsLaserTypeUV {
+			motion = db.LaserModelsMotionSystemGalvo
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "motionSystem must be Galvo or Gantry"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	manufacturerID, err := getOrCreateManufacturer(ctx, strings.TrimSpace(req.Manufacturer), manufacturerSlug)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	laserType := db.LaserModelsLaserType(lt)
+	existing, err := queries.GetLaserModelBySlug(ctx, db.GetLaserModelBySlugParams{
+		ManufacturerID: manufacturerID,
+		Slug:           modelSlug,
+		LaserType:      laserType,
+		Wattage:        req.Wattage,
+	})
+	if err == nil {
+		machine, err := queries.GetLaserModelByID(ctx, existing.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, machine)
+		return
+	}
+	if err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := queries.CreateLaserModel(ctx, db.CreateLaserModelParams{
+		ManufacturerID: manufacturerID,
+		Name:           strings.TrimSpace(req.Model),
+		Slug:           modelSlug,
+		LaserType:      laserType,
+		Wattage:        req.Wattage,
+		Source:         nullString(req.Source),
+		LensMm:         nullString(req.LensMm),
+		FieldSizeMm:    nullString(req.FieldSizeMm),
+		MotionSystem:   motion,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	machine, err := queries.GetLaserModelByID(ctx, int32(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, machine)
+}
+
+func getOrCreateManufacturer(ctx context.Context, name, slug string) (int32, error) {
+	manufacturer, err := queries.GetLaserManufacturerBySlug(ctx, slug)
+	if err == nil {
+		return manufacturer.ID, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	result, err := queries.CreateLaserManufacturer(ctx, db.CreateLaserManufacturerParams{
+		Name: name,
+		Slug: slug,
+	})
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int32(id), nil
+}
+
+// machineDisplayName is the make/model string stored in settings.layer_name
+// and appended to material names on export
+func machineDisplayName(machine db.GetLaserModelByIDRow) string {
+	return machine.ManufacturerName + " " + machine.Name
+}
+
+// nonSlugChars matches runs of characters that are not allowed in a slug
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify lowercases s and joins its alphanumeric runs with hyphens, so
+// "Gweike G2", "gweike  g2" and "GWEIKE-G2" all become "gweike-g2"
+func slugify(s string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+// =====================
+// USER MACHINE HANDLERS
+// =====================
+
+type UserMachineRequest struct {
+	LaserModelID *int32  `json:"laserModelId"`
+	MakeModel    *string `json:"makeModel"`
+	LaserType    string  `json:"laserType"`
+	Wattage      int32   `json:"wattage"`
+	LensMm       *string `json:"lensMm"`
+	Notes        *string `json:"notes"`
+	IsDefault    bool    `json:"isDefault"`
+}
+
+// resolve fills the request's laser details from its catalog model, if any,
+// and checks that a machine without one still names a laser type and wattage
+func (req *UserMachineRequest) resolve(ctx context.Context) error {
+	if req.LaserModelID != nil {
+		machine, err := queries.GetLaserModelByID(ctx, *req.LaserModelID)
+		if err != nil {
+			return fmt.Errorf("unknown laser model")
+		}
+		if lt, _ := stringToLaserType(req.LaserType); req.LaserType != "" && lt != db.SettingsLaserType(machine.LaserType) {
+			return fmt.Errorf("laserType does not match the selected machine")
+		}
+		if req.Wattage != 0 && req.Wattage != machine.Wattage {
+			return fmt.Errorf("wattage does not match the selected machine")
+		}
+		req.LaserType = string(machine.LaserType)
+		req.Wattage = machine.Wattage
+		if req.MakeModel == nil || *req.MakeModel == "" {
+			name := machineDisplayName(machine)
+			req.MakeModel = &name
+		}
+		if req.LensMm == nil && machine.LensMm.Valid {
+			req.LensMm = &machine.LensMm.String
+		}
+	}
+	if req.LaserType == "" || req.Wattage <= 0 {
+		return fmt.Errorf("laserType and wattage are required unless laserModelId is given")
+	}
+	lt, ok := stringToLaserType(req.LaserType)
+	if !ok {
+		return fmt.Errorf("laserType must be one of %s", laserTypeNames)
+	}
+	req.LaserType = string(lt)
+	return nil
+}
+
+func userMachineResponse(m db.UserMachine) map[string]interface{} {
+	createdAt := ""
+	if m.CreatedAt.Valid {
+		createdAt = m.CreatedAt.Time.Format(time.RFC3339)
+	}
+	var laserModelID interface{}
+	if m.LaserModelID.Valid {
+		laserModelID = m.LaserModelID.Int32
+	}
+	return map[string]interface{}{
+		"id":           m.ID,
+		"laserModelId": laserModelID,
+		"makeModel":    m.MakeModel.String,
+		"laserType":    m.LaserType,
+		"wattage":      m.Wattage,
+		"lensMm":       m.LensMm.String,
+		"notes":        m.Notes.String,
+		"isDefault":    m.IsDefault,
+		"createdAt":    createdAt,
+	}
+}
+
+func getUserMachinesHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+
+	machines, err := queries.GetUserMachines(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	machinesResponse := make([]map[string]interface{}, len(machines))
+	for i, m := range machines {
+		machinesResponse[i] = userMachineResponse(m)
+	}
+	c.JSON(http.StatusOK, machinesResponse)
+}
+
+// createUserMachineHandler registers a machine the user owns. The first
+// machine registered becomes the default.
+func createUserMachineHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	ctx := c.Request.Context()
+
+	var req UserMachineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.resolve(ctx); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	existing, err := queries.GetUserMachines(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	isDefault := req.IsDefault || len(existing) == 0
+
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	if isDefault {
+		if err := qtx.ClearDefaultUserMachine(ctx, userID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := qtx.CreateUserMachine(ctx, db.CreateUserMachineParams{
+		UserID:       userID,
+		LaserModelID: nullInt32(req.LaserModelID),
+		MakeModel:    nullString(req.MakeModel),
+		LaserType:    db.UserMachinesLaserType(req.LaserType),
+		Wattage:      req.Wattage,
+		LensMm:       nullString(req.LensMm),
+		Notes:        nullString(req.Notes),
+		IsDefault:    isDefault,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save machine"})
+		return
+	}
+
+	machine, err := queries.GetUserMachineByID(ctx, db.GetUserMachineByIDParams{
+		ID:     int32(id),
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, userMachineResponse(machine))
+}
+
+func updateUserMachineHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid machine id"})
+		return
+	}
+
+	var req UserMachineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.resolve(ctx); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify ownership
+	_, err = queries.GetUserMachineByID(ctx, db.GetUserMachineByIDParams{
+		ID:     int32(id),
+		UserID: userID,
+	})
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "machine not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = queries.UpdateUserMachine(ctx, db.UpdateUserMachineParams{
+		LaserModelID: nullInt32(req.LaserModelID),
+		MakeModel:    nullString(req.MakeModel),
+		LaserType:    db.UserMachinesLaserType(req.LaserType),
+		Wattage:      req.Wattage,
+		LensMm:       nullString(req.LensMm),
+		Notes:        nullString(req.Notes),
+		ID:           int32(id),
+		UserID:       userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	machine, err := queries.GetUserMachineByID(ctx, db.GetUserMachineByIDParams{
+		ID:     int32(id),
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, userMachineResponse(machine))
+}
+
+func deleteUserMachineHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid machine id"})
+		return
+	}
+
+	deleted, err := queries.DeleteUserMachine(c.Request.Context(), db.DeleteUserMachineParams{
+		ID:     int32(id),
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "machine not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "machine deleted"})
+}
+
+func setDefaultUserMachineHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid machine id"})
+		return
+	}
+
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	if err := qtx.ClearDefaultUserMachine(ctx, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	updated, err := qtx.SetDefaultUserMachine(ctx, db.SetDefaultUserMachineParams{
+		ID:     int32(id),
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if updated == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "machine not found"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set default machine"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "default machine updated"})
+}
+
+// searchMachine picks the user machine whose laser type and wattage scope a
+// settings search: the one named by ?machine=, otherwise the default machine
+// when the request sets no laser filters of its own. ?machine=none opts out,
+// as do anonymous requests. A nil machine means the search is unscoped.
+func searchMachine(c *gin.Context) (*db.UserMachine, error) {
+	userIDVal, ok := c.Get("user_id")
+	if !ok || c.Query("machine") == "none" {
+		return nil, nil
+	}
+	userID := userIDVal.(int32)
+	ctx := c.Request.Context()
+
+	if v := c.Query("machine"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, sql.ErrNoRows
+		}
+		machine, err := queries.GetUserMachineByID(ctx, db.GetUserMachineByIDParams{
+			ID:     int32(id),
+			UserID: userID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &machine, nil
+	}
+
+	for _, filter := range []string{"laser_type", "wattage", "min_wattage", "max_wattage", "laser_model_id"} {
+		if c.Query(filter) != "" {
+			return nil, nil
+		}
+	}
+	machine, err := queries.GetDefaultUserMachine(ctx, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &machine, nil
+}
@@ -2,15 +2,15 @@ package main
 
 import (
 	"bytes"
-	"context"
 	"crypto/rand"
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
-	"encoding/xml"
 	"fmt"
 	"io"
+	"laserscribe/backend/clb"
 	"laserscribe/backend/db"
+	"laserscribe/backend/units"
 	"log"
 	"net/http"
 	"os"
@@ -86,13 +86,22 @@ func main() {
 
 	// Materials
 	r.GET("/api/materials", getMaterialsHandler)
+	r.GET("/api/materials/match", matchMaterialHandler)
 	r.GET("/api/materials/:id/aliases", getAliasesHandler)
 	r.GET("/api/categories", getCategoriesHandler)
 
+	// Machines
+	r.GET("/api/machines", searchMachinesHandler)
+	r.GET("/api/machines/manufacturers", getManufacturersHandler)
+	r.GET("/api/machines/:id", getMachineHandler)
+	r.POST("/api/machines", authMiddleware(), emailVerifiedMiddleware(), createMachineHandler)
+
 	// Settings (public)
-	r.GET("/api/settings", searchSettingsHandler)
-	r.GET("/api/settings/top", getTopSettingsHandler)
+	r.GET("/api/settings", optionalAuthMiddleware(), searchSettingsHandler)
+	r.GET("/api/settings/top", optionalAuthMiddleware(), getTopSettingsHandler)
 	r.GET("/api/settings/:id", getSettingHandler)
+	r.GET("/api/settings/:id/convert", convertSettingHandler)
+	r.POST("/api/settings/convert", convertBulkSettingsHandler)
 
 	// Settings (require authentication + email verification)
 	r.POST("/api/settings", authMiddleware(), emailVerifiedMiddleware(), createSettingHandler)
@@ -100,10 +109,42 @@ func main() {
 	r.GET("/api/settings/export", authMiddleware(), exportCLBHandler)
 	r.PUT("/api/settings/:id", authMiddleware(), emailVerifiedMiddleware(), updateSettingHandler)
 	r.DELETE("/api/settings/:id", authMiddleware(), emailVerifiedMiddleware(), deleteSettingHandler)
-	r.POST("/api/settings/:id/vote", authMiddleware(), voteHandler)
+	r.POST("/api/settings/:id/vote", authMiddleware(), emailVerifiedMiddleware(), voteRateLimit(), voteHandler)
+	r.DELETE("/api/settings/:id/vote", authMiddleware(), emailVerifiedMiddleware(), voteRateLimit(), deleteVoteHandler)
+	r.GET("/api/settings/:id/history", getSettingHistoryHandler)
+	r.POST("/api/settings/:id/revert", authMiddleware(), emailVerifiedMiddleware(), revertSettingHandler)
+	r.POST("/api/settings/:id/fork", authMiddleware(), emailVerifiedMiddleware(), forkSettingHandler)
+	r.GET("/api/settings/:id/tests", getTestReportsHandler)
+	r.POST("/api/settings/:id/tests", authMiddleware(), emailVerifiedMiddleware(), reportTestHandler)
+	r.DELETE("/api/settings/:id/tests", authMiddleware(), deleteTestReportHandler)
+
+	// Tools
+	r.POST("/api/tools/test-grid", authMiddleware(), testGridHandler)
+
+	// Recipe sets
+	r.GET("/api/recipe-sets", listRecipeSetsHandler)
+	r.GET("/api/recipe-sets/:id", optionalAuthMiddleware(), getRecipeSetHandler)
+	r.GET("/api/recipe-sets/:id/export", authMiddleware(), exportRecipeSetHandler)
+	r.POST("/api/recipe-sets", authMiddleware(), emailVerifiedMiddleware(), createRecipeSetHandler)
+	r.PUT("/api/recipe-sets/:id", authMiddleware(), emailVerifiedMiddleware(), updateRecipeSetHandler)
+	r.DELETE("/api/recipe-sets/:id", authMiddleware(), emailVerifiedMiddleware(), deleteRecipeSetHandler)
+	r.POST("/api/recipe-sets/:id/publish", authMiddleware(), emailVerifiedMiddleware(), publishRecipeSetHandler)
+	r.DELETE("/api/recipe-sets/:id/publish", authMiddleware(), emailVerifiedMiddleware(), unpublishRecipeSetHandler)
+	r.POST("/api/recipe-sets/:id/vote", authMiddleware(), emailVerifiedMiddleware(), voteRateLimit(), voteRecipeSetHandler)
+	r.DELETE("/api/recipe-sets/:id/vote", authMiddleware(), emailVerifiedMiddleware(), voteRateLimit(), deleteRecipeSetVoteHandler)
 
 	// User profile
 	r.GET("/api/profile/settings", authMiddleware(), getUserSettingsHandler)
+	r.GET("/api/profile/recipe-sets", authMiddleware(), getUserRecipeSetsHandler)
+	r.GET("/api/profile/imports", authMiddleware(), getUserImportsHandler)
+	r.DELETE("/api/profile/imports/:id", authMiddleware(), emailVerifiedMiddleware(), rollbackImportHandler)
+	r.GET("/api/profile/preferences", authMiddleware(), getPreferencesHandler)
+	r.PUT("/api/profile/preferences", authMiddleware(), updatePreferencesHandler)
+	r.GET("/api/profile/machines", authMiddleware(), getUserMachinesHandler)
+	r.POST("/api/profile/machines", authMiddleware(), createUserMachineHandler)
+	r.PUT("/api/profile/machines/:id", authMiddleware(), updateUserMachineHandler)
+	r.DELETE("/api/profile/machines/:id", authMiddleware(), deleteUserMachineHandler)
+	r.POST("/api/profile/machines/:id/default", authMiddleware(), setDefaultUserMachineHandler)
 
 	// Admin routes
 	r.GET("/api/admin/stats", authMiddleware(), adminMiddleware(), adminStatsHandler)
@@ -111,6 +152,22 @@ func main() {
 	r.GET("/api/admin/users/:id", authMiddleware(), adminMiddleware(), adminUserDetailHandler)
 	r.POST("/api/admin/users/:id/set-admin", authMiddleware(), adminMiddleware(), setUserAdminHandler)
 	r.GET("/api/admin/settings", authMiddleware(), adminMiddleware(), adminSettingsHandler)
+	r.GET("/api/admin/votes/rings", authMiddleware(), adminMiddleware(), adminVoteRingsHandler)
+
+	// Admin material taxonomy
+	r.GET("/api/admin/categories", authMiddleware(), adminMiddleware(), adminCategoriesHandler)
+	r.POST("/api/admin/categories", authMiddleware(), adminMiddleware(), adminCreateCategoryHandler)
+	r.PUT("/api/admin/categories/:id", authMiddleware(), adminMiddleware(), adminRenameCategoryHandler)
+	r.DELETE("/api/admin/categories/:id", authMiddleware(), adminMiddleware(), adminDeleteCategoryHandler)
+	r.GET("/api/admin/materials", authMiddleware(), adminMiddleware(), adminMaterialsHandler)
+	r.POST("/api/admin/materials", authMiddleware(), adminMiddleware(), adminCreateMaterialHandler)
+	r.POST("/api/admin/materials/recategorize", authMiddleware(), adminMiddleware(), adminRecategorizeMaterialsHandler)
+	r.PUT("/api/admin/materials/:id", authMiddleware(), adminMiddleware(), adminUpdateMaterialHandler)
+	r.DELETE("/api/admin/materials/:id", authMiddleware(), adminMiddleware(), adminDeleteMaterialHandler)
+	r.POST("/api/admin/materials/:id/merge", authMiddleware(), adminMiddleware(), adminMergeMaterialHandler)
+	r.POST("/api/admin/materials/:id/aliases", authMiddleware(), adminMiddleware(), adminAddAliasHandler)
+	r.DELETE("/api/admin/materials/:id/aliases/:aliasId", authMiddleware(), adminMiddleware(), adminDeleteAliasHandler)
+	r.GET("/api/admin/taxonomy/audit", authMiddleware(), adminMiddleware(), adminTaxonomyAuditHandler)
 
 	log.Println("Laserscribe API running on :8080")
 	r.Run(":8080")
@@ -188,6 +245,19 @@ func authMiddleware() gin.HandlerFunc {
 	}
 }
 
+// optionalAuthMiddleware sets user_id when a valid auth cookie is present but
+// lets anonymous requests through, for public routes that personalize results
+func optionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tokenString, err := c.Cookie(cookieName); err == nil {
+			if userID, err := validateToken(tokenString); err == nil {
+				c.Set("user_id", userID)
+			}
+		}
+		c.Next()
+	}
+}
+
 func emailVerifiedMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDVal, exists := c.Get("user_id")
@@ -328,13 +398,21 @@ func loginHandler(c *gin.Context) {
 		displayName = user.DisplayName.String
 	}
 
+	prefs, err := queries.GetUserUnits(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"id":          user.ID,
-		"firstName":   user.FirstName,
-		"lastName":    user.LastName,
-		"email":       user.Email,
-		"displayName": displayName,
-		"isAdmin":     user.IsAdmin,
+		"id":           user.ID,
+		"firstName":    user.FirstName,
+		"lastName":     user.LastName,
+		"email":        user.Email,
+		"displayName":  displayName,
+		"isAdmin":      user.IsAdmin,
+		"speedUnit":    prefs.SpeedUnit,
+		"intervalUnit": prefs.IntervalUnit,
 	})
 }
 
@@ -356,13 +434,21 @@ func meHandler(c *gin.Context) {
 		displayName = user.DisplayName.String
 	}
 
+	prefs, err := queries.GetUserUnits(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"id":          user.ID,
-		"firstName":   user.FirstName,
-		"lastName":    user.LastName,
-		"email":       user.Email,
-		"displayName": displayName,
-		"isAdmin":     user.IsAdmin,
+		"id":           user.ID,
+		"firstName":    user.FirstName,
+		"lastName":     user.LastName,
+		"email":        user.Email,
+		"displayName":  displayName,
+		"isAdmin":      user.IsAdmin,
+		"speedUnit":    prefs.SpeedUnit,
+		"intervalUnit": prefs.IntervalUnit,
 	})
 }
 
@@ -511,10 +597,10 @@ func sendVerificationEmail(c *gin.Context, userID int32, email string) error {
 
 	// Use SMTP2GO HTTP API
 	payload := map[string]interface{}{
-		"api_key": apiKey,
-		"to":      []string{email},
-		"sender":  smtpFrom,
-		"subject": subject,
+		"api_key":   apiKey,
+		"to":        []string{email},
+		"sender":    smtpFrom,
+		"subject":   subject,
 		"text_body": textBody,
 	}
 
@@ -634,8 +720,13 @@ func searchSettingsHandler(c *gin.Context) {
 		params.Wattage = sql.NullInt32{Int32: int32(w), Valid: true}
 	}
 	if v := c.Query("operation_type"); v != "" {
+		op, ok := parseOperationType(v)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid operation_type: " + v})
+			return
+		}
 		params.OperationType = db.NullSettingsOperationType{
-			SettingsOperationType: db.SettingsOperationType(v),
+			SettingsOperationType: op,
 			Valid:                 true,
 		}
 	}
@@ -643,20 +734,111 @@ func searchSettingsHandler(c *gin.Context) {
 		id, _ := strconv.Atoi(v)
 		params.UserID = sql.NullInt32{Int32: int32(id), Valid: true}
 	}
+	if v := c.Query("laser_model_id"); v != "" {
+		id, _ := strconv.Atoi(v)
+		params.LaserModelID = sql.NullInt32{Int32: int32(id), Valid: true}
+	}
 	if v := c.Query("keyword"); v != "" {
 		params.Keyword = sql.NullString{String: v, Valid: true}
+		params.KeywordQuery = v
+	}
+
+	if err := applySearchRanges(c, &params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applyMetricFilters(c, &params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applyAttributeFilters(c, &params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applySearchPage(c, &params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Signed-in users see settings for their own laser unless they filter
+	machine, err := searchMachine(c)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "machine not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if machine != nil {
+		params.LaserType = db.NullSettingsLaserType{
+			SettingsLaserType: db.SettingsLaserType(machine.LaserType),
+			Valid:             true,
+		}
+		params.Wattage = sql.NullInt32{Int32: machine.Wattage, Valid: true}
 	}
 
+	// Fetch one extra row to learn whether another page follows
+	limit := params.Limit
+	params.Limit = limit + 1
 	settings, err := queries.SearchSettings(c.Request.Context(), params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, settings)
+	total, err := queries.CountSearchSettings(c.Request.Context(), countSearchParams(params))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := SearchSettingsResponse{Settings: settings, Total: total}
+	if response.Settings == nil {
+		response.Settings = []db.SearchSettingsRow{}
+	}
+	if len(settings) > int(limit) {
+		response.Settings = settings[:limit]
+		order := "desc"
+		if params.SortSign == -1 {
+			order = "asc"
+		}
+		response.NextCursor = encodeSearchCursor(settings[limit-1], params.SortKey.(string), order)
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 func getTopSettingsHandler(c *gin.Context) {
-	settings, err := queries.GetTopSettings(c.Request.Context())
+	params := db.GetTopSettingsParams{}
+
+	if v := c.Query("laser_type"); v != "" {
+		params.LaserType = db.NullSettingsLaserType{
+			SettingsLaserType: db.SettingsLaserType(v),
+			Valid:             true,
+		}
+	}
+	if v := c.Query("wattage"); v != "" {
+		w, _ := strconv.Atoi(v)
+		params.Wattage = sql.NullInt32{Int32: int32(w), Valid: true}
+	}
+
+	machine, err := searchMachine(c)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "machine not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if machine != nil {
+		params.LaserType = db.NullSettingsLaserType{
+			SettingsLaserType: db.SettingsLaserType(machine.LaserType),
+			Valid:             true,
+		}
+		params.Wattage = sql.NullInt32{Int32: machine.Wattage, Valid: true}
+	}
+
+	settings, err := queries.GetTopSettings(c.Request.Context(), params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -675,13 +857,59 @@ func getSettingHandler(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "setting not found"})
 		return
 	}
-	c.JSON(http.StatusOK, setting)
+	subLayers, err := queries.GetSublayersBySetting(c.Request.Context(), setting.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if subLayers == nil {
+		subLayers = []db.SettingSublayer{}
+	}
+	revision, err := currentRevision(c.Request.Context(), queries, setting.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	forkedFrom, forks, err := settingLineage(c.Request.Context(), setting)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tests, err := queries.GetTestReportSummary(c.Request.Context(), setting.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, SettingDetail{
+		GetSettingByIDRow: setting,
+		SubLayers:         subLayers,
+		Revision:          revision,
+		ForkedFrom:        forkedFrom,
+		Forks:             forks,
+		Tests:             testSummaryJSON(tests, setting.Confidence),
+	})
+}
+
+// SettingDetail is a setting with its LightBurn sublayers nested under it,
+// the revision its values are at, its lineage (the setting it was forked
+// from and the forks made of it) and its test report summary
+type SettingDetail struct {
+	db.GetSettingByIDRow
+	SubLayers  []db.SettingSublayer `json:"subLayers"`
+	Revision   int32                `json:"revision"`
+	ForkedFrom *SettingLink         `json:"forkedFrom"`
+	Forks      []SettingLink        `json:"forks"`
+	Tests      gin.H                `json:"tests"`
 }
 
 type CreateSettingRequest struct {
-	MaterialID       int32   `json:"materialId" binding:"required"`
+	// The material is given by materialId, or by a materialName matched
+	// against the catalog
+	MaterialID       int32   `json:"materialId"`
+	MaterialName     string  `json:"materialName"`
 	LaserType        string  `json:"laserType" binding:"required"`
 	Wattage          int32   `json:"wattage" binding:"required"`
+	LaserModelID     *int32  `json:"laserModelId"`
 	OperationType    string  `json:"operationType" binding:"required"`
 	MaxPower         string  `json:"maxPower" binding:"required"`
 	MinPower         string  `json:"minPower"`
@@ -704,18 +932,23 @@ type CreateSettingRequest struct {
 	Frequency        *string `json:"frequency"`
 	WobbleEnable     *bool   `json:"wobbleEnable"`
 	UseDotCorrection *bool   `json:"useDotCorrection"`
+	PulseWidth       *string `json:"pulseWidth"`
+	WobbleDiameter   *string `json:"wobbleDiameter"`
+	WobbleStep       *string `json:"wobbleStep"`
 	PerforationMode  bool    `json:"perforationMode"`
 	ImageMode        *string `json:"imageMode"`
 	NegativeImage    bool    `json:"negativeImage"`
 	DotWidth         *string `json:"dotWidth"`
-	Kerf               *string `json:"kerf"`
-	RunBlower          *bool   `json:"runBlower"`
-	LayerName          *string `json:"layerName"`
-	LayerSubname       *string `json:"layerSubname"`
-	Priority           *int32  `json:"priority"`
-	TabCount           *int32  `json:"tabCount"`
-	TabCountMax        *int32  `json:"tabCountMax"`
-	Notes              string  `json:"notes"`
+	Kerf             *string `json:"kerf"`
+	RunBlower        *bool   `json:"runBlower"`
+	LayerName        *string `json:"layerName"`
+	LayerSubname     *string `json:"layerSubname"`
+	Priority         *int32  `json:"priority"`
+	TabCount         *int32  `json:"tabCount"`
+	TabCountMax      *int32  `json:"tabCountMax"`
+	Notes            string  `json:"notes"`
+	MaterialAttributes
+	InputUnits
 }
 
 func createSettingHandler(c *gin.Context) {
@@ -727,6 +960,42 @@ func createSettingHandler(c *gin.Context) {
 		return
 	}
 
+	operationType, ok := parseOperationType(req.OperationType)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid operationType: " + req.OperationType})
+		return
+	}
+	inputUnits, verr := req.resolve()
+	if verr != nil {
+		validationFailed(c, verr)
+		return
+	}
+
+	// Link the setting to a catalog machine, which must match the laser
+	// details and names the layer when none was given
+	laserModelID := nullInt32(req.LaserModelID)
+	layerName := nullString(req.LayerName)
+	if laserModelID.Valid {
+		machine, err := queries.GetLaserModelByID(c.Request.Context(), laserModelID.Int32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown laser model"})
+			return
+		}
+		if string(machine.LaserType) != req.LaserType || machine.Wattage != req.Wattage {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "laser model does not match laserType and wattage"})
+			return
+		}
+		if !layerName.Valid || layerName.String == "" {
+			layerName = sql.NullString{String: machineDisplayName(machine), Valid: true}
+		}
+	}
+
+	thickness, err := req.thickness()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	numPasses := req.NumPasses
 	if numPasses == 0 {
 		numPasses = 1
@@ -742,21 +1011,21 @@ func createSettingHandler(c *gin.Context) {
 		minPower = "0"
 	}
 
-	result, err := queries.CreateSetting(c.Request.Context(), db.CreateSettingParams{
+	params := db.CreateSettingParams{
 		UserID:           userID,
-		MaterialID:       req.MaterialID,
 		LaserType:        db.SettingsLaserType(req.LaserType),
 		Wattage:          req.Wattage,
-		OperationType:    db.SettingsOperationType(req.OperationType),
+		LaserModelID:     laserModelID,
+		OperationType:    operationType,
 		MaxPower:         req.MaxPower,
 		MinPower:         minPower,
 		MaxPower2:        nullString(req.MaxPower2),
 		MinPower2:        nullString(req.MinPower2),
-		Speed:            req.Speed,
+		Speed:            units.ConvertSpeed(req.Speed, inputUnits.SpeedUnit),
 		NumPasses:        numPasses,
 		ZOffset:          nullString(req.ZOffset),
 		ZPerPass:         nullString(req.ZPerPass),
-		ScanInterval:     nullString(req.ScanInterval),
+		ScanInterval:     canonicalInterval(nullString(req.ScanInterval), inputUnits.IntervalUnit),
 		Angle:            nullString(req.Angle),
 		AnglePerPass:     nullString(req.AnglePerPass),
 		CrossHatch:       req.CrossHatch,
@@ -766,22 +1035,50 @@ func createSettingHandler(c *gin.Context) {
 		AutoRotate:       req.AutoRotate,
 		Overscan:         nullString(req.Overscan),
 		OverscanPercent:  nullString(req.OverscanPercent),
-		Frequency:            nullString(req.Frequency),
+		Frequency:        canonicalFrequency(nullString(req.Frequency), inputUnits.FrequencyUnit),
 		WobbleEnable:     nullBool(req.WobbleEnable),
 		UseDotCorrection: nullBool(req.UseDotCorrection),
+		PulseWidth:       nullString(req.PulseWidth),
+		WobbleDiameter:   nullString(req.WobbleDiameter),
+		WobbleStep:       nullString(req.WobbleStep),
 		PerforationMode:  req.PerforationMode,
 		ImageMode:        nullString(req.ImageMode),
 		NegativeImage:    req.NegativeImage,
 		DotWidth:         nullString(req.DotWidth),
 		Kerf:             nullString(req.Kerf),
-		RunBlower:            nullBool(req.RunBlower),
-		LayerName:            nullString(req.LayerName),
-		LayerSubname:         nullString(req.LayerSubname),
-		Priority:             nullInt32(req.Priority),
-		TabCount:             nullInt32(req.TabCount),
-		TabCountMax:          nullInt32(req.TabCountMax),
-		Notes:                sql.NullString{String: req.Notes, Valid: req.Notes != ""},
-	})
+		RunBlower:        nullBool(req.RunBlower),
+		LayerName:        layerName,
+		LayerSubname:     nullString(req.LayerSubname),
+		Priority:         nullInt32(req.Priority),
+		TabCount:         nullInt32(req.TabCount),
+		TabCountMax:      nullInt32(req.TabCountMax),
+		ThicknessMm:      thickness,
+		Color:            attributeValue(req.Color),
+		Finish:           attributeValue(req.Finish),
+		Grade:            attributeValue(req.Grade),
+		Notes:            sql.NullString{String: req.Notes, Valid: req.Notes != ""},
+	}
+	if verr = validateSetting(params.LaserType, params.Wattage, params.OperationType, createValues(params)); verr != nil {
+		validationFailed(c, verr)
+		return
+	}
+
+	materialID := req.MaterialID
+	if materialID == 0 {
+		if strings.TrimSpace(req.MaterialName) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "materialId or materialName is required"})
+			return
+		}
+		id, err := resolveContributedMaterial(c.Request.Context(), req.MaterialName)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		materialID = id
+	}
+	params.MaterialID = materialID
+
+	result, err := queries.CreateSetting(c.Request.Context(), params)
 	if err != nil {
 		if strings.Contains(err.Error(), "Duplicate") {
 			c.JSON(http.StatusConflict, gin.H{"error": "you already have a setting for this material/operation/laser combination"})
@@ -817,6 +1114,9 @@ type UpdateSettingRequest struct {
 	Frequency        *string `json:"frequency"`
 	WobbleEnable     *bool   `json:"wobbleEnable"`
 	UseDotCorrection *bool   `json:"useDotCorrection"`
+	PulseWidth       *string `json:"pulseWidth"`
+	WobbleDiameter   *string `json:"wobbleDiameter"`
+	WobbleStep       *string `json:"wobbleStep"`
 	PerforationMode  bool    `json:"perforationMode"`
 	ImageMode        *string `json:"imageMode"`
 	NegativeImage    bool    `json:"negativeImage"`
@@ -829,6 +1129,8 @@ type UpdateSettingRequest struct {
 	TabCount         *int32  `json:"tabCount"`
 	TabCountMax      *int32  `json:"tabCountMax"`
 	Notes            string  `json:"notes"`
+	MaterialAttributes
+	InputUnits
 }
 
 func updateSettingHandler(c *gin.Context) {
@@ -856,6 +1158,16 @@ func updateSettingHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	inputUnits, verr := req.resolve()
+	if verr != nil {
+		validationFailed(c, verr)
+		return
+	}
+	thickness, err := req.thickness()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	numPasses := req.NumPasses
 	if numPasses == 0 {
@@ -872,16 +1184,16 @@ func updateSettingHandler(c *gin.Context) {
 		minPower = "0"
 	}
 
-	err = queries.UpdateSetting(c.Request.Context(), db.UpdateSettingParams{
+	params := db.UpdateSettingParams{
 		MaxPower:         req.MaxPower,
 		MinPower:         minPower,
 		MaxPower2:        nullString(req.MaxPower2),
 		MinPower2:        nullString(req.MinPower2),
-		Speed:            req.Speed,
+		Speed:            units.ConvertSpeed(req.Speed, inputUnits.SpeedUnit),
 		NumPasses:        numPasses,
 		ZOffset:          nullString(req.ZOffset),
 		ZPerPass:         nullString(req.ZPerPass),
-		ScanInterval:     nullString(req.ScanInterval),
+		ScanInterval:     canonicalInterval(nullString(req.ScanInterval), inputUnits.IntervalUnit),
 		Angle:            nullString(req.Angle),
 		AnglePerPass:     nullString(req.AnglePerPass),
 		CrossHatch:       req.CrossHatch,
@@ -891,30 +1203,45 @@ func updateSettingHandler(c *gin.Context) {
 		AutoRotate:       req.AutoRotate,
 		Overscan:         nullString(req.Overscan),
 		OverscanPercent:  nullString(req.OverscanPercent),
-		Frequency:            nullString(req.Frequency),
+		Frequency:        canonicalFrequency(nullString(req.Frequency), inputUnits.FrequencyUnit),
 		WobbleEnable:     nullBool(req.WobbleEnable),
 		UseDotCorrection: nullBool(req.UseDotCorrection),
+		PulseWidth:       nullString(req.PulseWidth),
+		WobbleDiameter:   nullString(req.WobbleDiameter),
+		WobbleStep:       nullString(req.WobbleStep),
 		PerforationMode:  req.PerforationMode,
 		ImageMode:        nullString(req.ImageMode),
 		NegativeImage:    req.NegativeImage,
 		DotWidth:         nullString(req.DotWidth),
 		Kerf:             nullString(req.Kerf),
-		RunBlower:            nullBool(req.RunBlower),
-		LayerName:            nullString(req.LayerName),
-		LayerSubname:         nullString(req.LayerSubname),
-		Priority:             nullInt32(req.Priority),
-		TabCount:             nullInt32(req.TabCount),
-		TabCountMax:          nullInt32(req.TabCountMax),
-		Notes:                sql.NullString{String: req.Notes, Valid: req.Notes != ""},
-		ID:                   int32(settingID),
-		UserID:               int32(userID),
-	})
+		RunBlower:        nullBool(req.RunBlower),
+		LayerName:        nullString(req.LayerName),
+		LayerSubname:     nullString(req.LayerSubname),
+		Priority:         nullInt32(req.Priority),
+		TabCount:         nullInt32(req.TabCount),
+		TabCountMax:      nullInt32(req.TabCountMax),
+		ThicknessMm:      thickness,
+		Color:            attributeValue(req.Color),
+		Finish:           attributeValue(req.Finish),
+		Grade:            attributeValue(req.Grade),
+		Notes:            sql.NullString{String: req.Notes, Valid: req.Notes != ""},
+		ID:               int32(settingID),
+		UserID:           int32(userID),
+	}
+	if verr = validateSetting(setting.LaserType, setting.Wattage, setting.OperationType, updateValues(params)); verr != nil {
+		validationFailed(c, verr)
+		return
+	}
+
+	// Every change is kept as a revision, so votes cast on earlier values
+	// stay attached to them
+	revision, _, err := saveSettingRevision(c.Request.Context(), userID, params, db.SettingRevisionsActionUpdate, sql.NullInt32{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		settingWriteError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+	c.JSON(http.StatusOK, gin.H{"message": "updated", "revision": revision})
 }
 
 func deleteSettingHandler(c *gin.Context) {
@@ -949,470 +1276,6 @@ func deleteSettingHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
 
-// =====================
-// CLB XML STRUCTURES
-// =====================
-
-type LightBurnLibrary struct {
-	XMLName     xml.Name   `xml:"LightBurnLibrary"`
-	DisplayName string     `xml:"DisplayName,attr"`
-	Materials   []Material `xml:"Material"`
-}
-
-type Material struct {
-	Name    string  `xml:"name,attr"`
-	Entries []Entry `xml:"Entry"`
-}
-
-type Entry struct {
-	Thickness    string      `xml:"Thickness,attr"`
-	Desc         string      `xml:"Desc,attr"`
-	NoThickTitle string      `xml:"NoThickTitle,attr"`
-	CutSetting   CutSetting  `xml:"CutSetting"`
-}
-
-type CutSetting struct {
-	Type             string        `xml:"type,attr"`
-	Index            ValueElement  `xml:"index"`
-	Name             ValueElement  `xml:"name"`
-	LinkPath         ValueElement  `xml:"LinkPath"`
-	MaxPower         ValueElement  `xml:"maxPower"`
-	MaxPower2        ValueElement  `xml:"maxPower2"`
-	MinPower         ValueElement  `xml:"minPower"`
-	MinPower2        ValueElement  `xml:"minPower2"`
-	Speed            ValueElement  `xml:"speed"`
-	Frequency        ValueElement  `xml:"frequency"`
-	NumPasses        ValueElement  `xml:"numPasses"`
-	ZOffset          ValueElement  `xml:"zOffset"`
-	ZPerPass         ValueElement  `xml:"zPerPass"`
-	ScanInterval     ValueElement  `xml:"interval"`
-	Angle            ValueElement  `xml:"angle"`
-	AnglePerPass     ValueElement  `xml:"anglePerPass"`
-	CrossHatch       ValueElement  `xml:"crossHatch"`
-	Bidir            ValueElement  `xml:"bidir"`
-	ScanOpt          ValueElement  `xml:"scanOpt"`
-	FloodFill        ValueElement  `xml:"floodFill"`
-	AutoRotate       ValueElement  `xml:"autoRotate"`
-	Overscan         ValueElement  `xml:"overscan"`
-	OverscanPercent  ValueElement  `xml:"overscanPercent"`
-	WobbleEnable         ValueElement  `xml:"wobbleEnable"`
-	UseDotCorrection     ValueElement  `xml:"useDotCorrection"`
-	PerforationMode      ValueElement  `xml:"perforationMode"`
-	ImageMode            ValueElement  `xml:"imageMode"`
-	DitherMode           ValueElement  `xml:"ditherMode"`
-	NegativeImage        ValueElement  `xml:"negativeImage"`
-	EnableDotWidthAdjust ValueElement  `xml:"enableDotWidthAdjust"`
-	DotWidth             ValueElement  `xml:"dotWidth"`
-	Kerf                 ValueElement  `xml:"kerf"`
-	RunBlower        ValueElement  `xml:"runBlower"`
-	Subname          ValueElement  `xml:"subname"`
-	Priority         ValueElement  `xml:"priority"`
-	TabCount         ValueElement  `xml:"tabCount"`
-	TabCountMax      ValueElement  `xml:"tabCountMax"`
-	SubLayers        []SubLayer    `xml:"SubLayer"`
-}
-
-type ValueElement struct {
-	Value string `xml:"Value,attr"`
-}
-
-type SubLayer struct {
-	Type         string       `xml:"type,attr"`
-	Index        string       `xml:"index,attr"`
-	MaxPower     ValueElement `xml:"maxPower"`
-	MaxPower2    ValueElement `xml:"maxPower2"`
-	MinPower     ValueElement `xml:"minPower"`
-	MinPower2    ValueElement `xml:"minPower2"`
-	Speed        ValueElement `xml:"speed"`
-	Frequency    ValueElement `xml:"frequency"`
-	NumPasses    ValueElement `xml:"numPasses"`
-	IsCleanup    ValueElement `xml:"isCleanup"`
-	ScanInterval ValueElement `xml:"interval"`
-	Subname      ValueElement `xml:"subname"`
-}
-
-// =====================
-// CLB IMPORT HANDLER
-// =====================
-
-type ImportCLBRequest struct {
-	LaserMakeModel string `form:"laserMakeModel" binding:"required"`
-	LaserType      string `form:"laserType" binding:"required"`
-	Wattage        int32  `form:"wattage" binding:"required"`
-}
-
-func importCLBHandler(c *gin.Context) {
-	userIDVal, _ := c.Get("user_id")
-	userID := userIDVal.(int32)
-
-	// Parse form data
-	var req ImportCLBRequest
-	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Get uploaded file
-	file, err := c.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
-		return
-	}
-
-	// Check file size (10MB limit)
-	if file.Size > 10*1024*1024 {
-		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file too large (max 10MB)"})
-		return
-	}
-
-	// Open file
-	src, err := file.Open()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open file"})
-		return
-	}
-	defer src.Close()
-
-	// Parse XML
-	var library LightBurnLibrary
-	decoder := xml.NewDecoder(src)
-	if err := decoder.Decode(&library); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid CLB file format: " + err.Error()})
-		return
-	}
-
-	// Track results
-	imported := 0
-	failed := 0
-	var errors []string
-
-	// Process each material
-	for _, material := range library.Materials {
-		// Extract base material name (remove laser make/model if present)
-		baseMaterialName := material.Name
-		if idx := strings.LastIndex(baseMaterialName, " ("); idx > 0 {
-			baseMaterialName = baseMaterialName[:idx]
-		}
-
-		for _, entry := range material.Entries {
-			// Use base material name without modification
-			materialName := baseMaterialName
-
-			// Create setting from main CutSetting
-			if err := createSettingFromCutSetting(
-				entry.CutSetting,
-				materialName,
-				entry.Desc,
-				req.LaserMakeModel,
-				req.LaserType,
-				req.Wattage,
-				userID,
-			); err != nil {
-				failed++
-				errors = append(errors, fmt.Sprintf("%s/%s: %v", materialName, entry.Desc, err))
-			} else {
-				imported++
-			}
-
-			// Process SubLayers
-			for _, subLayer := range entry.CutSetting.SubLayers {
-				subMaterialName := materialName
-				subDesc := entry.Desc
-				if subLayer.Subname.Value != "" {
-					subMaterialName = materialName + " - " + subLayer.Subname.Value
-				}
-
-				if err := createSettingFromSubLayer(
-					subLayer,
-					subMaterialName,
-					subDesc,
-					req.LaserMakeModel,
-					req.LaserType,
-					req.Wattage,
-					userID,
-				); err != nil {
-					failed++
-					errors = append(errors, fmt.Sprintf("%s/%s (SubLayer): %v", subMaterialName, subDesc, err))
-				} else {
-					imported++
-				}
-			}
-		}
-	}
-
-	response := gin.H{
-		"message":  "CLB import completed",
-		"imported": imported,
-		"failed":   failed,
-	}
-	if len(errors) > 0 {
-		response["errors"] = errors
-	}
-
-	c.JSON(http.StatusCreated, response)
-}
-
-// Helper function to get or create material by name
-func getOrCreateMaterial(materialName string) (int32, error) {
-	// Try to find existing material
-	material, err := queries.GetMaterialByName(context.Background(), materialName)
-	if err == nil {
-		return material.ID, nil
-	}
-
-	// Material doesn't exist, create it
-	// Default to category_id = 1 (you might want to make this smarter)
-	slug := strings.ToLower(strings.ReplaceAll(materialName, " ", "-"))
-	slug = strings.ReplaceAll(slug, "/", "-")
-
-	result, err := queries.CreateMaterial(context.Background(), db.CreateMaterialParams{
-		CategoryID: 1, // Default category
-		Name:       materialName,
-		Slug:       slug,
-	})
-	if err != nil {
-		return 0, err
-	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, err
-	}
-
-	return int32(id), nil
-}
-
-// Helper functions for CLB import
-func createSettingFromCutSetting(cs CutSetting, materialName, operation, laserMakeModel, laserType string, wattage int32, userID int32) error {
-	// Get or create material
-	materialID, err := getOrCreateMaterial(materialName)
-	if err != nil {
-		return fmt.Errorf("failed to get/create material: %w", err)
-	}
-
-	// Parse required fields
-	maxPower := cs.MaxPower.Value
-	if maxPower == "" {
-		maxPower = "0"
-	}
-	minPower := cs.MinPower.Value
-	if minPower == "" {
-		minPower = "0"
-	}
-	speed := cs.Speed.Value
-	if speed == "" {
-		speed = "0"
-	}
-
-	// Parse optional numeric fields
-	numPasses := parseInt32(cs.NumPasses.Value, 1)
-
-	// Parse booleans
-	bidir := true // default
-	if cs.Bidir.Value != "" {
-		bidir = parseBool(cs.Bidir.Value)
-	}
-
-	// Map operation type
-	operationType := db.SettingsOperationTypeCut
-	if cs.Type == "Scan" {
-		operationType = db.SettingsOperationTypeScan
-	} else if cs.Type == "Image" {
-		// Image mode should be treated as Scan type with imageMode set
-		operationType = db.SettingsOperationTypeScan
-	}
-
-	// Convert laser type string to enum
-	laserTypeEnum := stringToLaserType(laserType)
-
-	// Create setting
-	_, err = queries.CreateSetting(context.Background(), db.CreateSettingParams{
-		UserID:           userID,
-		MaterialID:       materialID,
-		LaserType:        laserTypeEnum,
-		Wattage:          wattage,
-		OperationType:    operationType,
-		MaxPower:         maxPower,
-		MinPower:         minPower,
-		MaxPower2:        nullString(&cs.MaxPower2.Value),
-		MinPower2:        nullString(&cs.MinPower2.Value),
-		Speed:            speed,
-		NumPasses:        numPasses,
-		ZOffset:          nullString(&cs.ZOffset.Value),
-		ZPerPass:         nullString(&cs.ZPerPass.Value),
-		ScanInterval:     nullString(&cs.ScanInterval.Value),
-		Angle:            nullString(&cs.Angle.Value),
-		AnglePerPass:     nullString(&cs.AnglePerPass.Value),
-		CrossHatch:       parseBool(cs.CrossHatch.Value),
-		Bidir:            bidir,
-		ScanOpt:          nullString(&cs.ScanOpt.Value),
-		FloodFill:        parseBool(cs.FloodFill.Value),
-		AutoRotate:       parseBool(cs.AutoRotate.Value),
-		Overscan:         nullString(&cs.Overscan.Value),
-		OverscanPercent:  nullString(&cs.OverscanPercent.Value),
-		Frequency:        nullString(&cs.Frequency.Value),
-		WobbleEnable:     nullBoolFromString(&cs.WobbleEnable.Value),
-		UseDotCorrection: parseBoolOrFallback(cs.UseDotCorrection.Value, cs.EnableDotWidthAdjust.Value),
-		PerforationMode:  parseBool(cs.PerforationMode.Value),
-		ImageMode:        imageMode(cs),
-		NegativeImage:    parseBool(cs.NegativeImage.Value),
-		DotWidth:         nullString(&cs.DotWidth.Value),
-		Kerf:             nullString(&cs.Kerf.Value),
-		RunBlower:        nullBoolFromString(&cs.RunBlower.Value),
-		LayerName:        sql.NullString{String: laserMakeModel, Valid: true},
-		LayerSubname:     nullString(&cs.Subname.Value),
-		Priority:         nullInt32FromString(&cs.Priority.Value),
-		TabCount:         nullInt32FromString(&cs.TabCount.Value),
-		TabCountMax:      nullInt32FromString(&cs.TabCountMax.Value),
-		Notes:            sql.NullString{String: "", Valid: false},
-	})
-
-	return err
-}
-
-func createSettingFromSubLayer(sl SubLayer, materialName, operation, laserMakeModel, laserType string, wattage int32, userID int32) error {
-	// Get or create material
-	materialID, err := getOrCreateMaterial(materialName)
-	if err != nil {
-		return fmt.Errorf("failed to get/create material: %w", err)
-	}
-
-	maxPower := sl.MaxPower.Value
-	if maxPower == "" {
-		maxPower = "0"
-	}
-	minPower := sl.MinPower.Value
-	if minPower == "" {
-		minPower = "0"
-	}
-	speed := sl.Speed.Value
-	if speed == "" {
-		speed = "0"
-	}
-
-	numPasses := parseInt32(sl.NumPasses.Value, 1)
-
-	// Map operation type
-	operationType := db.SettingsOperationTypeCut
-	if sl.Type == "Scan" {
-		operationType = db.SettingsOperationTypeScan
-	}
-
-	// Convert laser type string to enum
-	laserTypeEnum := stringToLaserType(laserType)
-
-	_, err = queries.CreateSetting(context.Background(), db.CreateSettingParams{
-		UserID:        userID,
-		MaterialID:    materialID,
-		LaserType:     laserTypeEnum,
-		Wattage:       wattage,
-		OperationType: operationType,
-		MaxPower:         maxPower,
-		MinPower:         minPower,
-		MaxPower2:        nullString(&sl.MaxPower2.Value),
-		MinPower2:        nullString(&sl.MinPower2.Value),
-		Speed:            speed,
-		NumPasses:        numPasses,
-		ZOffset:          sql.NullString{Valid: false},
-		ZPerPass:         sql.NullString{Valid: false},
-		ScanInterval:     nullString(&sl.ScanInterval.Value),
-		Angle:            sql.NullString{Valid: false},
-		AnglePerPass:     sql.NullString{Valid: false},
-		CrossHatch:       false,
-		Bidir:            true,
-		ScanOpt:          sql.NullString{Valid: false},
-		FloodFill:        false,
-		AutoRotate:       false,
-		Overscan:         sql.NullString{Valid: false},
-		OverscanPercent:  sql.NullString{Valid: false},
-		Frequency:        nullString(&sl.Frequency.Value),
-		WobbleEnable:     sql.NullBool{Valid: false},
-		UseDotCorrection: sql.NullBool{Valid: false},
-		Kerf:             sql.NullString{Valid: false},
-		RunBlower:        sql.NullBool{Valid: false},
-		LayerName:        sql.NullString{String: laserMakeModel, Valid: true},
-		LayerSubname:     nullString(&sl.Subname.Value),
-		Priority:         sql.NullInt32{Valid: false},
-		TabCount:         sql.NullInt32{Valid: false},
-		TabCountMax:      sql.NullInt32{Valid: false},
-		Notes:            sql.NullString{Valid: false},
-	})
-
-	return err
-}
-
-// Parse helpers
-func parseInt32(s string, defaultVal int32) int32 {
-	if s == "" {
-		return defaultVal
-	}
-	val, err := strconv.ParseInt(s, 10, 32)
-	if err != nil {
-		return defaultVal
-	}
-	return int32(val)
-}
-
-func parseBool(s string) bool {
-	return s == "1" || strings.ToLower(s) == "true"
-}
-
-// parseBoolOrFallback tries primary first, falls back to secondary for backward compatibility
-func parseBoolOrFallback(primary, secondary string) sql.NullBool {
-	if primary != "" {
-		return sql.NullBool{Bool: parseBool(primary), Valid: true}
-	}
-	if secondary != "" {
-		return sql.NullBool{Bool: parseBool(secondary), Valid: true}
-	}
-	return sql.NullBool{Valid: false}
-}
-
-// imageMode extracts the image mode from a CutSetting, preferring ditherMode
-func imageMode(cs CutSetting) sql.NullString {
-	// Prefer ditherMode as that's what LightBurn uses for Image mode
-	if cs.DitherMode.Value != "" {
-		return sql.NullString{String: cs.DitherMode.Value, Valid: true}
-	}
-	// Fall back to imageMode if present
-	if cs.ImageMode.Value != "" {
-		return sql.NullString{String: cs.ImageMode.Value, Valid: true}
-	}
-	return sql.NullString{Valid: false}
-}
-
-func nullBoolFromString(s *string) sql.NullBool {
-	if s == nil || *s == "" {
-		return sql.NullBool{Valid: false}
-	}
-	return sql.NullBool{Bool: parseBool(*s), Valid: true}
-}
-
-func nullInt32FromString(s *string) sql.NullInt32 {
-	if s == nil || *s == "" {
-		return sql.NullInt32{Valid: false}
-	}
-	val := parseInt32(*s, 0)
-	return sql.NullInt32{Int32: val, Valid: true}
-}
-
-func stringToLaserType(s string) db.SettingsLaserType {
-	switch strings.ToUpper(s) {
-	case "CO2":
-		return db.SettingsLaserTypeCO2
-	case "FIBER":
-		return db.SettingsLaserTypeFiber
-	case "DIODE":
-		return db.SettingsLaserTypeDiode
-	case "UV":
-		return db.SettingsLaserTypeUV
-	case "INFRARED":
-		return db.SettingsLaserTypeInfrared
-	default:
-		return db.SettingsLaserTypeCO2 // Default fallback
-	}
-}
-
 // =====================
 // VOTE HANDLER
 // =====================
@@ -1441,10 +1304,29 @@ func voteHandler(c *gin.Context) {
 		return
 	}
 
+	setting, err := queries.GetSettingByID(c.Request.Context(), int32(settingID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "setting not found"})
+		return
+	}
+	if setting.UserID == userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you cannot vote on your own setting"})
+		return
+	}
+
+	// A vote is on the values the setting has now; voting again after an
+	// edit moves it to the new revision
+	revision, err := currentRevision(c.Request.Context(), queries, int32(settingID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	err = queries.UpsertVote(c.Request.Context(), db.UpsertVoteParams{
 		UserID:    int32(userID),
 		SettingID: int32(settingID),
 		Value:     int8(req.Value),
+		Revision:  sql.NullInt32{Int32: revision, Valid: true},
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -1457,7 +1339,7 @@ func voteHandler(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"score": score.Score, "total": score.Total})
+	c.JSON(http.StatusOK, gin.H{"score": score.Score, "total": score.Total, "revision": revision})
 }
 
 // =====================
@@ -1483,214 +1365,320 @@ func exportCLBHandler(c *gin.Context) {
 	userIDVal, _ := c.Get("user_id")
 	userID := userIDVal.(int32)
 
-	// Get user's settings
-	allSettings, err := queries.GetUserSettings(c.Request.Context(), userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve settings"})
-		return
-	}
-
-	// Filter by specific IDs if provided
+	// Resolve which settings to export. An explicit ids list may reference any
+	// public setting; without one, export everything the caller contributed.
+	var ids []int32
 	idsParam := c.Query("ids")
-	var settings []db.GetUserSettingsRow
 
 	if idsParam != "" {
-		// Parse comma-separated IDs
-		idStrings := strings.Split(idsParam, ",")
-		idMap := make(map[int32]bool)
-		for _, idStr := range idStrings {
-			if id, err := strconv.Atoi(strings.TrimSpace(idStr)); err == nil {
-				idMap[int32(id)] = true
+		seen := make(map[int32]bool)
+		for _, idStr := range strings.Split(idsParam, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(idStr))
+			if err != nil || id <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid setting id: " + idStr})
+				return
 			}
-		}
-
-		// Filter settings by requested IDs
-		for _, setting := range allSettings {
-			if idMap[setting.ID] {
-				settings = append(settings, setting)
+			if !seen[int32(id)] {
+				seen[int32(id)] = true
+				ids = append(ids, int32(id))
 			}
 		}
 	} else {
-		settings = allSettings
+		ownSettings, err := queries.GetUserSettings(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve settings"})
+			return
+		}
+		for _, setting := range ownSettings {
+			ids = append(ids, setting.ID)
+		}
 	}
 
-	if len(settings) == 0 {
+	if len(ids) == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No settings found to export"})
 		return
 	}
 
-	// Group settings by material
-	type MaterialSettings struct {
-		MaterialName string
-		Settings     []db.GetUserSettingsRow
+	settings, err := queries.GetSettingsByIDs(c.Request.Context(), ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve settings"})
+		return
 	}
-	materialsMap := make(map[string]*MaterialSettings)
 
+	// Refuse to produce a partial library: report every requested ID that
+	// no longer exists so the client can fix its cart.
+	found := make(map[int32]bool, len(settings))
 	for _, setting := range settings {
-		materialName := setting.MaterialName
-		if materialsMap[materialName] == nil {
-			materialsMap[materialName] = &MaterialSettings{
-				MaterialName: materialName,
-				Settings:     []db.GetUserSettingsRow{},
-			}
+		found[setting.ID] = true
+	}
+	missing := []int32{}
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
 		}
-		materialsMap[materialName].Settings = append(materialsMap[materialName].Settings, setting)
-	}
-
-	// Generate CLB XML
-	var xmlBuilder strings.Builder
-	xmlBuilder.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
-	xmlBuilder.WriteString(`<LightBurnLibrary DisplayName="Laserscribe Export">` + "\n")
-
-	for _, materialData := range materialsMap {
-		xmlBuilder.WriteString(fmt.Sprintf(`  <Material name="%s">`, materialData.MaterialName) + "\n")
-
-		for idx, setting := range materialData.Settings {
-			// Map operation type to CutSetting type
-			// If imageMode is set, use type="Image"
-			cutType := "Cut"
-			noThickTitle := "Line Settings"
-			desc := "Line"
-
-			if setting.ImageMode.Valid && setting.ImageMode.String != "" {
-				cutType = "Image"
-				// Convert dither mode from database format to display format
-				imageMode := setting.ImageMode.String
-				imageModeDisplay := imageMode
-				imageModeShort := imageMode
-
-				// Reverse mapping: database format → display format
-				switch strings.ToLower(imageMode) {
-				case "jarvis":
-					imageModeDisplay = "Jarvis"
-					imageModeShort = "Jarvis"
-				case "3dslice":
-					imageModeDisplay = "3D Sliced"
-					imageModeShort = "3D"
-				case "stucki":
-					imageModeDisplay = "Stucki"
-					imageModeShort = "Stucki"
-				case "atkinson":
-					imageModeDisplay = "Atkinson"
-					imageModeShort = "Atkinson"
-				default:
-					// Capitalize first letter
-					if len(imageMode) > 0 {
-						imageModeDisplay = strings.ToUpper(imageMode[:1]) + imageMode[1:]
-						imageModeShort = imageModeDisplay
-					}
-				}
-
-				noThickTitle = "Image-" + imageModeShort
-				desc = imageModeDisplay + " Optimized"
-			} else if setting.OperationType == "Scan" {
-				cutType = "Scan"
-				noThickTitle = "Fill Settings"
-				desc = "Fill"
-			} else if setting.OperationType == "ScanCut" {
-				cutType = "Scan"
-				noThickTitle = "Fill+Line Settings"
-				desc = "Fill+Line"
-			}
-
-			xmlBuilder.WriteString(fmt.Sprintf(`    <Entry Thickness="-1.0000" Desc="%s" NoThickTitle="%s">`, desc, noThickTitle) + "\n")
-			xmlBuilder.WriteString(fmt.Sprintf(`      <CutSetting type="%s">`, cutType) + "\n")
-
-			// Write fields in minimal format - only include non-empty values
-			xmlBuilder.WriteString(fmt.Sprintf(`        <index Value="%d"/>`, idx) + "\n")
-			xmlBuilder.WriteString(`        <name Value=""/>` + "\n")
-			linkPath := fmt.Sprintf("%s/%s/%s", materialData.MaterialName, noThickTitle, desc)
-			xmlBuilder.WriteString(fmt.Sprintf(`        <LinkPath Value="%s"/>`, linkPath) + "\n")
-			xmlBuilder.WriteString(fmt.Sprintf(`        <minPower Value="%s"/>`, setting.MinPower) + "\n")
-			xmlBuilder.WriteString(fmt.Sprintf(`        <maxPower Value="%s"/>`, setting.MaxPower) + "\n")
-			xmlBuilder.WriteString(`        <maxPower2 Value="20"/>` + "\n")
-			xmlBuilder.WriteString(fmt.Sprintf(`        <speed Value="%s"/>`, setting.Speed) + "\n")
-
-			// Only include frequency if set
-			if setting.Frequency.Valid && setting.Frequency.String != "" && setting.Frequency.String != "0" {
-				xmlBuilder.WriteString(fmt.Sprintf(`        <frequency Value="%s"/>`, setting.Frequency.String) + "\n")
-			}
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "some requested settings were not found",
+			"missing": missing,
+		})
+		return
+	}
 
-			// Only include numPasses if > 1
-			if setting.NumPasses > 1 {
-				xmlBuilder.WriteString(fmt.Sprintf(`        <numPasses Value="%d"/>`, setting.NumPasses) + "\n")
-			}
+	subLayers, err := queries.GetSublayersBySettingIDs(c.Request.Context(), ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve settings"})
+		return
+	}
+	subLayersBySetting := make(map[int32][]db.SettingSublayer)
+	for _, sl := range subLayers {
+		subLayersBySetting[sl.SettingID] = append(subLayersBySetting[sl.SettingID], sl)
+	}
 
-			// Only include anglePerPass if set
-			if setting.AnglePerPass.Valid && setting.AnglePerPass.String != "" && setting.AnglePerPass.String != "0" {
-				xmlBuilder.WriteString(fmt.Sprintf(`        <anglePerPass Value="%s"/>`, setting.AnglePerPass.String) + "\n")
-			}
+	// ?wattage= exports the cart rescaled for the caller's machine
+	conversions := make(map[int32]ConversionInfo)
+	if v := c.Query("wattage"); v != "" {
+		wattage, err := parseTargetWattage(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		mode, ok := parseConvertMode(c.Query("mode"))
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be auto, power or speed"})
+			return
+		}
+		laserType, errs := conversionLaserType(settings, c.Query("laser_type"))
+		if len(errs) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "settings use different laser types", "details": errs})
+			return
+		}
+		ec := energyConversion{LaserType: laserType, To: wattage, Mode: mode}
+		for i := range settings {
+			conversions[settings[i].ID] = ec.convertLibrarySetting(&settings[i], subLayersBySetting[settings[i].ID])
+		}
+	}
 
-			// crossHatch and bidir
-			if setting.CrossHatch {
-				xmlBuilder.WriteString(`        <crossHatch Value="1"/>` + "\n")
-			}
-			if setting.Bidir {
-				xmlBuilder.WriteString(`        <bidir Value="1"/>` + "\n")
-			} else {
-				xmlBuilder.WriteString(`        <bidir Value="0"/>` + "\n")
-			}
+	data, err := clb.Marshal(clbLibraryFromSettings(settings, subLayersBySetting, conversions))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate CLB file"})
+		return
+	}
 
-			// Only include interval if set
-			if setting.ScanInterval.Valid && setting.ScanInterval.String != "" && setting.ScanInterval.String != "0" {
-				xmlBuilder.WriteString(fmt.Sprintf(`        <interval Value="%s"/>`, setting.ScanInterval.String) + "\n")
-			}
+	// Set headers for file download
+	filename := "LASERSCRIBED.CLB"
+	c.Header("Content-Description", "File Transfer")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Type", "application/xml")
+	c.Data(http.StatusOK, "application/xml", data)
+}
 
-			// Only include angle if set
-			if setting.Angle.Valid && setting.Angle.String != "" && setting.Angle.String != "0" {
-				xmlBuilder.WriteString(fmt.Sprintf(`        <angle Value="%s"/>`, setting.Angle.String) + "\n")
-			}
+// clbLibraryFromSettings groups settings by material, keeping the query's
+// alphabetical order; within a material, entries follow the query's
+// thickness order. Laser make/model is appended in parentheses per the .clb
+// convention.
+func clbLibraryFromSettings(settings []db.GetSettingsByIDsRow, subLayersBySetting map[int32][]db.SettingSublayer, conversions map[int32]ConversionInfo) *clb.Library {
+	library := &clb.Library{DisplayName: clb.DisplayName}
+	materialIndex := make(map[string]int)
 
-			// Always include priority
-			xmlBuilder.WriteString(`        <priority Value="0"/>` + "\n")
+	for _, setting := range settings {
+		materialName := setting.MaterialName
+		if setting.LayerName.Valid && setting.LayerName.String != "" {
+			materialName = fmt.Sprintf("%s (%s)", materialName, setting.LayerName.String)
+		}
+		idx, ok := materialIndex[materialName]
+		if !ok {
+			idx = len(library.Materials)
+			materialIndex[materialName] = idx
+			library.Materials = append(library.Materials, clb.Material{Name: materialName})
+		}
+		library.Materials[idx].Entries = append(library.Materials[idx].Entries, clbEntryFromSetting(materialName, setting, subLayersBySetting[setting.ID], conversions[setting.ID]))
+	}
+	return library
+}
 
-			// Optional fields
-			if setting.WobbleEnable.Valid && setting.WobbleEnable.Bool {
-				xmlBuilder.WriteString(`        <wobbleEnable Value="1"/>` + "\n")
-			}
-			if setting.PerforationMode {
-				xmlBuilder.WriteString(`        <perforationMode Value="1"/>` + "\n")
-			}
-			// ditherMode for Image mode (convert to lowercase LightBurn format)
-			if setting.ImageMode.Valid && setting.ImageMode.String != "" {
-				ditherMode := strings.ToLower(strings.ReplaceAll(setting.ImageMode.String, " ", ""))
-				// Fix known mappings
-				if ditherMode == "3dsliced" {
-					ditherMode = "3dslice"
-				}
-				xmlBuilder.WriteString(fmt.Sprintf(`        <ditherMode Value="%s"/>`, ditherMode) + "\n")
-			}
-			if setting.NegativeImage {
-				xmlBuilder.WriteString(`        <negativeImage Value="1"/>` + "\n")
-			}
-			if setting.UseDotCorrection.Valid && setting.UseDotCorrection.Bool {
-				xmlBuilder.WriteString(`        <useDotCorrection Value="1"/>` + "\n")
-			}
-			if setting.DotWidth.Valid && setting.DotWidth.String != "" && setting.DotWidth.String != "0" {
-				xmlBuilder.WriteString(fmt.Sprintf(`        <dotWidth Value="%s"/>`, setting.DotWidth.String) + "\n")
-			}
-			if setting.FloodFill {
-				xmlBuilder.WriteString(`        <floodFill Value="1"/>` + "\n")
-			}
-			if setting.AutoRotate {
-				xmlBuilder.WriteString(`        <autoRotate Value="1"/>` + "\n")
+// clbEntryFromSetting converts a stored setting and its sublayers into a
+// library entry, writing only the fields LightBurn would keep (non-default
+// values).
+//
+// Importing a library and exporting it again reproduces every value of its
+// entries except the labels, which are rebuilt from the stored setting:
+// Desc becomes the operation (or dither mode) label followed by the
+// contributor attribution, NoThickTitle and LinkPath follow from it, and
+// index is 0. The material name gains the " (layer name)" suffix in
+// clbLibraryFromSettings. bidir, on unless a file says otherwise, is only
+// written when off.
+func clbEntryFromSetting(materialName string, setting db.GetSettingsByIDsRow, subLayers []db.SettingSublayer, conversion ConversionInfo) clb.Entry {
+	// Map operation type to CutSetting type and LightBurn's mode naming
+	cutType := clbTypeFromOperation(setting.OperationType)
+	desc := operationLabel(setting.OperationType)
+	noThickTitle := desc + " Settings"
+
+	if setting.OperationType == db.SettingsOperationTypeImage && setting.ImageMode.Valid && setting.ImageMode.String != "" {
+		// Convert dither mode from database format to display format
+		imageMode := setting.ImageMode.String
+		imageModeDisplay := imageMode
+		imageModeShort := imageMode
+
+		// Reverse mapping: database format → display format
+		switch strings.ToLower(imageMode) {
+		case "jarvis":
+			imageModeDisplay = "Jarvis"
+			imageModeShort = "Jarvis"
+		case "3dslice":
+			imageModeDisplay = "3D Sliced"
+			imageModeShort = "3D"
+		case "stucki":
+			imageModeDisplay = "Stucki"
+			imageModeShort = "Stucki"
+		case "atkinson":
+			imageModeDisplay = "Atkinson"
+			imageModeShort = "Atkinson"
+		default:
+			// Capitalize first letter
+			if len(imageMode) > 0 {
+				imageModeDisplay = strings.ToUpper(imageMode[:1]) + imageMode[1:]
+				imageModeShort = imageModeDisplay
 			}
+		}
 
-			xmlBuilder.WriteString(`      </CutSetting>` + "\n")
-			xmlBuilder.WriteString(`    </Entry>` + "\n")
+		noThickTitle = "Image-" + imageModeShort
+		desc = imageModeDisplay + " Optimized"
+	}
+
+	// Attribute each entry to its contributor so libraries assembled
+	// from community settings keep track of where they came from.
+	desc = fmt.Sprintf("%s #%d by %s", desc, setting.ID,
+		authorName(setting.DisplayName, setting.FirstName, setting.LastName))
+	if conversion.Derived {
+		desc = fmt.Sprintf("%s, scaled from %dW (untested)", desc, conversion.SourceWattage)
+	}
+
+	cs := clb.CutSetting{
+		Type:     cutType,
+		Index:    clb.Int(0),
+		Name:     clb.V(""),
+		LinkPath: clb.V(clb.LinkPath(materialName, noThickTitle, desc)),
+	}
+	cs.MinPower = nonZeroNum(sql.NullString{String: setting.MinPower, Valid: true})
+	cs.MaxPower = clb.Num(setting.MaxPower)
+	cs.MinPower2 = optionalNum(setting.MinPower2)
+	cs.MaxPower2 = optionalNum(setting.MaxPower2)
+	cs.Speed = clb.Num(setting.Speed)
+	cs.Frequency = nonZeroNum(setting.Frequency)
+	cs.QPulseWidth = nonZeroNum(setting.PulseWidth)
+	if setting.WobbleEnable.Valid && setting.WobbleEnable.Bool {
+		cs.WobbleEnable = clb.Bool(true)
+		cs.WobbleStep = nonZeroNum(setting.WobbleStep)
+		cs.WobbleSize = nonZeroNum(setting.WobbleDiameter)
+	}
+	cs.NumPasses = clb.Int(int(setting.NumPasses))
+	cs.ZOffset = nonZeroNum(setting.ZOffset)
+	cs.ZPerPass = nonZeroNum(setting.ZPerPass)
+	cs.AnglePerPass = nonZeroNum(setting.AnglePerPass)
+	if setting.ScanOpt.Valid && setting.ScanOpt.String != "" {
+		cs.ScanOpt = clb.V(setting.ScanOpt.String)
+	}
+	if !setting.Bidir {
+		cs.Bidir = clb.Bool(false)
+	}
+	if setting.CrossHatch {
+		cs.CrossHatch = clb.Bool(true)
+	}
+	cs.Overscan = nonZeroNum(setting.Overscan)
+	cs.OverscanPercent = optionalNum(setting.OverscanPercent)
+	if setting.UseDotCorrection.Valid && setting.UseDotCorrection.Bool {
+		cs.UseDotCorrection = clb.Bool(true)
+	}
+	cs.DotWidth = nonZeroNum(setting.DotWidth)
+	if setting.FloodFill {
+		cs.FloodFill = clb.Bool(true)
+	}
+	if setting.PerforationMode {
+		cs.PerforationMode = clb.Bool(true)
+	}
+	cs.Interval = nonZeroNum(setting.ScanInterval)
+	cs.Angle = nonZeroNum(setting.Angle)
+	if setting.AutoRotate {
+		cs.AutoRotate = clb.Bool(true)
+	}
+	// ditherMode for Image mode (convert to lowercase LightBurn format)
+	if setting.ImageMode.Valid && setting.ImageMode.String != "" {
+		ditherMode := strings.ToLower(strings.ReplaceAll(setting.ImageMode.String, " ", ""))
+		// Fix known mappings
+		if ditherMode == "3dsliced" {
+			ditherMode = "3dslice"
 		}
+		cs.DitherMode = clb.V(ditherMode)
+	}
+	if setting.NegativeImage {
+		cs.NegativeImage = clb.Bool(true)
+	}
+	cs.Kerf = nonZeroNum(setting.Kerf)
+	if setting.RunBlower.Valid {
+		cs.RunBlower = clb.Bool(setting.RunBlower.Bool)
+	}
+	if setting.LayerSubname.Valid && setting.LayerSubname.String != "" {
+		cs.Subname = clb.V(setting.LayerSubname.String)
+	}
+	cs.Priority = clb.Int(int(setting.Priority.Int32))
+	if setting.TabCount.Valid {
+		cs.TabCount = clb.Int(int(setting.TabCount.Int32))
+	}
+	if setting.TabCountMax.Valid {
+		cs.TabCountMax = clb.Int(int(setting.TabCountMax.Int32))
+	}
+	for _, sl := range subLayers {
+		cs.SubLayers = append(cs.SubLayers, clbSubLayer(sl))
+	}
 
-		xmlBuilder.WriteString(`  </Material>` + "\n")
+	return clb.Entry{
+		Thickness:    entryThickness(setting.ThicknessMm),
+		Desc:         desc,
+		NoThickTitle: noThickTitle,
+		CutSetting:   cs,
 	}
+}
 
-	xmlBuilder.WriteString(`</LightBurnLibrary>` + "\n")
+// clbSubLayer converts a stored sublayer back into a <SubLayer> element
+func clbSubLayer(sl db.SettingSublayer) clb.SubLayer {
+	out := clb.SubLayer{
+		Type:  sl.SublayerType,
+		Index: strconv.Itoa(int(sl.SublayerIndex)),
+	}
+	out.MinPower = nonZeroNum(sql.NullString{String: sl.MinPower, Valid: true})
+	out.MaxPower = clb.Num(sl.MaxPower)
+	out.MinPower2 = optionalNum(sl.MinPower2)
+	out.MaxPower2 = optionalNum(sl.MaxPower2)
+	out.Speed = clb.Num(sl.Speed)
+	out.Frequency = nonZeroNum(sl.Frequency)
+	out.NumPasses = clb.Int(int(sl.NumPasses))
+	out.Interval = nonZeroNum(sl.ScanInterval)
+	if sl.IsCleanup {
+		out.IsCleanup = clb.Bool(true)
+	}
+	if sl.Subname.Valid && sl.Subname.String != "" {
+		out.Subname = clb.V(sl.Subname.String)
+	}
+	return out
+}
 
-	// Set headers for file download
-	filename := "LASERSCRIBED.CLB"
-	c.Header("Content-Description", "File Transfer")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-	c.Header("Content-Type", "application/xml")
-	c.Data(http.StatusOK, "application/xml", []byte(xmlBuilder.String()))
+// optionalNum returns a Value for a nullable decimal column, or nil if NULL
+func optionalNum(ns sql.NullString) *clb.Value {
+	if !ns.Valid {
+		return nil
+	}
+	return clb.Num(ns.String)
+}
+
+// nonZeroNum is like optionalNum but also omits zero, LightBurn's default
+// for most numeric fields
+func nonZeroNum(ns sql.NullString) *clb.Value {
+	v := optionalNum(ns)
+	if v == nil || v.Value == "0" || v.Value == "-0" {
+		return nil
+	}
+	return v
 }
 
 // =====================
@@ -1718,6 +1706,14 @@ func nullInt32(v *int32) sql.NullInt32 {
 	return sql.NullInt32{Int32: *v, Valid: true}
 }
 
+// authorName returns the name a contributor is credited with publicly
+func authorName(displayName sql.NullString, firstName, lastName string) string {
+	if displayName.Valid && displayName.String != "" {
+		return displayName.String
+	}
+	return strings.TrimSpace(firstName + " " + lastName)
+}
+
 // =====================
 // ADMIN HANDLERS
 // =====================
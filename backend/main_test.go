@@ -0,0 +1,84 @@
+package main
+
+import (
+	"laserscribe/backend/clb"
+	"laserscribe/backend/db"
+	"os"
+	"reflect"
+	"testing"
+)
+
+const goldenLibrary = "../Gweike_20W_Fiber.clb"
+
+// copyFields sets each field of dst to the field of src with the same name
+// and type
+func copyFields(dst, src interface{}) {
+	d := reflect.ValueOf(dst).Elem()
+	s := reflect.ValueOf(src)
+	for i := 0; i < s.NumField(); i++ {
+		f := d.FieldByName(s.Type().Field(i).Name)
+		if f.IsValid() && f.Type() == s.Field(i).Type() {
+			f.Set(s.Field(i))
+		}
+	}
+}
+
+func TestImportExportGolden(t *testing.T) {
+	f, err := os.Open(goldenLibrary)
+	if err != nil {
+		t.Fatalf("open golden: %v", err)
+	}
+	defer f.Close()
+	original, err := clb.Decode(f)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	// Store each entry the way the import handler would, then load it back
+	// the way the export handler does
+	items := importItemsFromLibrary(original, "Gweike G2", db.SettingsLaserTypeFiber, 20, 1)
+	var settings []db.GetSettingsByIDsRow
+	subLayers := make(map[int32][]db.SettingSublayer)
+	for i, item := range items {
+		if item.Invalid != nil {
+			t.Errorf("%s/%s: %v", item.MaterialName, item.Desc, item.Invalid)
+		}
+		var row db.GetSettingsByIDsRow
+		copyFields(&row, item.Params)
+		row.ID = int32(i + 1)
+		row.MaterialName = item.MaterialName
+		row.FirstName, row.LastName = "Ada", "Lovelace"
+		settings = append(settings, row)
+		for j, sl := range item.SubLayers {
+			var stored db.SettingSublayer
+			copyFields(&stored, sublayerParams(row.ID, j, sl))
+			subLayers[row.ID] = append(subLayers[row.ID], stored)
+		}
+	}
+	exported := clbLibraryFromSettings(settings, subLayers, nil)
+
+	// Only the fields clbEntryFromSetting documents as rebuilt may differ
+	if len(exported.Materials) != len(original.Materials) {
+		t.Fatalf("exported %d materials, want %d", len(exported.Materials), len(original.Materials))
+	}
+	for m, want := range original.Materials {
+		got := exported.Materials[m]
+		if got.Name != want.Name+" (Gweike G2)" {
+			t.Errorf("material %d is %q, want %q with the layer name", m, got.Name, want.Name)
+		}
+		if len(got.Entries) != len(want.Entries) {
+			t.Errorf("%s: exported %d entries, want %d", want.Name, len(got.Entries), len(want.Entries))
+			continue
+		}
+		for e := range want.Entries {
+			g, w := got.Entries[e], want.Entries[e]
+			for _, entry := range []*clb.Entry{&g, &w} {
+				entry.Desc, entry.NoThickTitle = "", ""
+				entry.CutSetting.Index, entry.CutSetting.LinkPath = nil, nil
+			}
+			if !reflect.DeepEqual(g, w) {
+				t.Errorf("%s entry %d changed on import and export\n got: %+v\nwant: %+v", want.Name, e, g.CutSetting.Params, w.CutSetting.Params)
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"laserscribe/backend/db"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// DERIVED METRICS
+// =====================
+
+// The metrics are generated columns on settings (see schema.sql for formulas
+// and units), so search filters, sorts and detail pages all read the same
+// values.
+
+// applyMetricFilters reads min_<metric>/max_<metric> range filters from the
+// query string
+func applyMetricFilters(c *gin.Context, params *db.SearchSettingsParams) error {
+	ranges := []struct {
+		name  string
+		field *sql.NullString
+	}{
+		{"min_effective_watts", &params.MinEffectiveWatts},
+		{"max_effective_watts", &params.MaxEffectiveWatts},
+		{"min_line_energy", &params.MinLineEnergy},
+		{"max_line_energy", &params.MaxLineEnergy},
+		{"min_fluence", &params.MinFluence},
+		{"max_fluence", &params.MaxFluence},
+		{"min_pulse_energy", &params.MinPulseEnergy},
+		{"max_pulse_energy", &params.MaxPulseEnergy},
+	}
+	for _, r := range ranges {
+		v := c.Query(r.name)
+		if v == "" {
+			continue
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return fmt.Errorf("%s must be a number", r.name)
+		}
+		*r.field = sql.NullString{String: v, Valid: true}
+	}
+
+	return nil
+}
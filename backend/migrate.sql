@@ -67,4 +67,400 @@ PREPARE stmt FROM @query;
 EXECUTE stmt;
 DEALLOCATE PREPARE stmt;
 
+-- =============================================================================
+-- CLB import history: one row per upload, keyed by content hash
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS imports (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    content_hash CHAR(64) NOT NULL,
+    laser_make_model VARCHAR(200) NOT NULL,
+    laser_type ENUM('CO2', 'Fiber', 'Diode', 'UV', 'Infrared') NOT NULL,
+    wattage INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_imports_user_hash (user_id, content_hash)
+);
+
+-- =============================================================================
+-- Import history: filename, result counts and settings linked to their batch
+-- =============================================================================
+ALTER TABLE imports
+    ADD COLUMN IF NOT EXISTS filename VARCHAR(255) NOT NULL DEFAULT '' AFTER user_id,
+    ADD COLUMN IF NOT EXISTS imported_count INT NOT NULL DEFAULT 0 AFTER wattage,
+    ADD COLUMN IF NOT EXISTS skipped_count INT NOT NULL DEFAULT 0 AFTER imported_count,
+    ADD COLUMN IF NOT EXISTS conflict_count INT NOT NULL DEFAULT 0 AFTER skipped_count,
+    ADD COLUMN IF NOT EXISTS failed_count INT NOT NULL DEFAULT 0 AFTER conflict_count,
+    ADD COLUMN IF NOT EXISTS errors TEXT AFTER failed_count;
+
+ALTER TABLE settings
+    ADD COLUMN IF NOT EXISTS import_id INT AFTER material_id;
+
+SET @fk_exists := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+    WHERE TABLE_SCHEMA = 'laserscribe' AND TABLE_NAME = 'settings'
+      AND COLUMN_NAME = 'import_id' AND REFERENCED_TABLE_NAME = 'imports');
+
+SET @query = IF(@fk_exists = 0,
+    'ALTER TABLE settings
+        ADD INDEX idx_settings_import (import_id),
+        ADD FOREIGN KEY (import_id) REFERENCES imports(id) ON DELETE SET NULL',
+    'SELECT "Import foreign key already exists" AS status');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+-- =============================================================================
+-- Setting sublayers: LightBurn <SubLayer> passes stored under their setting
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS setting_sublayers (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    setting_id INT NOT NULL,
+    sublayer_index INT NOT NULL DEFAULT 0,
+    sublayer_type VARCHAR(20) NOT NULL,
+    is_cleanup BOOLEAN NOT NULL DEFAULT FALSE,
+    max_power DECIMAL(7,3) NOT NULL,
+    min_power DECIMAL(7,3) NOT NULL DEFAULT 0,
+    max_power2 DECIMAL(7,3),
+    min_power2 DECIMAL(7,3),
+    speed DECIMAL(10,3) NOT NULL,
+    num_passes INT NOT NULL DEFAULT 1,
+    frequency DECIMAL(12,3),
+    scan_interval DECIMAL(8,4),
+    subname VARCHAR(200),
+    FOREIGN KEY (setting_id) REFERENCES settings(id) ON DELETE CASCADE,
+    INDEX idx_sublayers_setting (setting_id, sublayer_index)
+);
+
+-- Libraries imported before this table existed were flattened: each named
+-- <SubLayer> became a setting of its own under an invented
+-- "<Material> - <subname>" material, saved right after its parent and with
+-- none of the file-level fields (scan_opt, priority, tab_count) set. Move
+-- those rows under the nearest earlier setting of the parent material and
+-- drop the invented materials. The flattening did not keep isCleanup, so
+-- moved sublayers are not marked as cleanup passes.
+CREATE TEMPORARY TABLE legacy_sublayers AS
+SELECT s.id, s.material_id, (
+        SELECT MAX(p.id) FROM settings p
+        JOIN materials pm ON pm.id = p.material_id
+        WHERE p.user_id = s.user_id AND p.id < s.id
+          AND p.laser_type = s.laser_type AND p.wattage = s.wattage
+          AND p.layer_name <=> s.layer_name
+          AND m.name = CONCAT(pm.name, ' - ', s.layer_subname)
+    ) AS parent_id
+FROM settings s
+JOIN materials m ON m.id = s.material_id
+WHERE s.import_id IS NULL
+  AND s.layer_subname IS NOT NULL AND s.layer_subname <> ''
+  AND s.scan_opt IS NULL AND s.priority IS NULL AND s.tab_count IS NULL;
+
+DELETE FROM legacy_sublayers WHERE parent_id IS NULL;
+
+INSERT INTO setting_sublayers (setting_id, sublayer_index, sublayer_type, is_cleanup,
+    max_power, min_power, max_power2, min_power2, speed, num_passes, frequency, scan_interval, subname)
+SELECT l.parent_id,
+       ROW_NUMBER() OVER (PARTITION BY l.parent_id ORDER BY s.id),
+       IF(s.operation_type = 'Scan', 'Scan', 'Cut'),
+       FALSE,
+       s.max_power, s.min_power, s.max_power2, s.min_power2, s.speed, s.num_passes,
+       s.frequency, s.scan_interval, s.layer_subname
+FROM legacy_sublayers l
+JOIN settings s ON s.id = l.id;
+
+DELETE s FROM settings s
+JOIN legacy_sublayers l ON l.id = s.id;
+
+DELETE m FROM materials m
+JOIN (SELECT DISTINCT material_id FROM legacy_sublayers) l ON l.material_id = m.id
+LEFT JOIN settings s ON s.material_id = m.id
+WHERE s.id IS NULL;
+
+DROP TEMPORARY TABLE legacy_sublayers;
+
+-- =============================================================================
+-- Operation types: Image and Offset Fill become first-class values.
+-- Image settings were previously stored as Scan with image_mode set.
+-- =============================================================================
+ALTER TABLE settings
+    MODIFY operation_type ENUM('Cut', 'Scan', 'ScanCut', 'Image', 'OffsetFill') NOT NULL;
+
+UPDATE settings
+SET operation_type = 'Image'
+WHERE operation_type = 'Scan' AND image_mode IS NOT NULL AND image_mode <> '';
+
+-- =============================================================================
+-- Laser machine catalog: manufacturers, models and settings.laser_model_id.
+-- Existing settings keep their free-text layer_name and are left unlinked.
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS laser_manufacturers (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS laser_models (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    manufacturer_id INT NOT NULL,
+    name VARCHAR(150) NOT NULL,
+    slug VARCHAR(150) NOT NULL,
+    laser_type ENUM('CO2', 'Fiber', 'Diode', 'UV', 'Infrared') NOT NULL,
+    wattage INT NOT NULL,
+    source VARCHAR(100),
+    lens_mm DECIMAL(7,2),
+    field_size_mm DECIMAL(7,2),
+    motion_system ENUM('Galvo', 'Gantry') NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (manufacturer_id) REFERENCES laser_manufacturers(id) ON DELETE CASCADE,
+    UNIQUE KEY uq_laser_model (manufacturer_id, slug, laser_type, wattage),
+    INDEX idx_laser_models_type_watt (laser_type, wattage)
+);
+
+ALTER TABLE settings
+    ADD COLUMN IF NOT EXISTS laser_model_id INT AFTER wattage;
+
+SET @fk_exists := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+    WHERE TABLE_SCHEMA = 'laserscribe' AND TABLE_NAME = 'settings'
+      AND COLUMN_NAME = 'laser_model_id' AND REFERENCED_TABLE_NAME = 'laser_models');
+
+SET @query = IF(@fk_exists = 0,
+    'ALTER TABLE settings
+        ADD INDEX idx_settings_laser_model (laser_model_id),
+        ADD FOREIGN KEY (laser_model_id) REFERENCES laser_models(id) ON DELETE SET NULL',
+    'SELECT "Laser model foreign key already exists" AS status');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+-- =============================================================================
+-- User machines ("my machines" garage).
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS user_machines (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    laser_model_id INT,
+    make_model VARCHAR(255),
+    laser_type ENUM('CO2', 'Fiber', 'Diode', 'UV', 'Infrared') NOT NULL,
+    wattage INT NOT NULL,
+    lens_mm DECIMAL(7,2),
+    notes TEXT,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (laser_model_id) REFERENCES laser_models(id) ON DELETE SET NULL,
+    INDEX idx_user_machines_user (user_id, is_default)
+);
+
+-- =============================================================================
+-- Derived metrics: effective watts, line energy, fluence and pulse energy as
+-- generated columns on settings. Existing rows are computed on ALTER.
+-- =============================================================================
+ALTER TABLE settings
+    ADD COLUMN IF NOT EXISTS effective_watts DECIMAL(10,3) GENERATED ALWAYS AS (wattage * max_power / 100) STORED AFTER tab_count_max,
+    ADD COLUMN IF NOT EXISTS line_energy DECIMAL(14,6) GENERATED ALWAYS AS (wattage * max_power / 100 / NULLIF(speed, 0) * num_passes) STORED AFTER effective_watts,
+    ADD COLUMN IF NOT EXISTS fluence DECIMAL(14,6) GENERATED ALWAYS AS (
+        CASE WHEN operation_type <> 'Cut' AND scan_interval > 0
+             THEN wattage * max_power / NULLIF(speed, 0) / scan_interval * num_passes END
+    ) STORED AFTER line_energy,
+    ADD COLUMN IF NOT EXISTS pulse_energy DECIMAL(12,6) GENERATED ALWAYS AS (
+        CASE WHEN laser_type = 'Fiber' AND frequency > 0
+             THEN wattage * max_power * 10 / frequency END
+    ) STORED AFTER fluence;
+
+CREATE INDEX IF NOT EXISTS idx_settings_line_energy ON settings(line_energy);
+CREATE INDEX IF NOT EXISTS idx_settings_fluence ON settings(fluence);
+
+-- =============================================================================
+-- Keyword search: full-text indexes for categories, setting notes/make-model
+-- and the machine catalog, alongside ft_material_name and ft_alias.
+-- =============================================================================
+CREATE FULLTEXT INDEX IF NOT EXISTS ft_category_name ON material_categories(name);
+CREATE FULLTEXT INDEX IF NOT EXISTS ft_setting_text ON settings(notes, layer_name);
+CREATE FULLTEXT INDEX IF NOT EXISTS ft_laser_model_name ON laser_models(name);
+CREATE FULLTEXT INDEX IF NOT EXISTS ft_manufacturer_name ON laser_manufacturers(name);
+
+-- =============================================================================
+-- Taxonomy audit: log of admin changes to categories, materials and aliases
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS taxonomy_audit (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    admin_user_id INT,
+    action VARCHAR(50) NOT NULL,
+    entity_type ENUM('category', 'material', 'alias') NOT NULL,
+    entity_id INT NOT NULL,
+    details TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (admin_user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_taxonomy_audit_created ON taxonomy_audit(created_at DESC);
+
+-- =============================================================================
+-- Material attributes: thickness, color, finish/coating and grade per setting
+-- =============================================================================
+ALTER TABLE settings
+    ADD COLUMN IF NOT EXISTS thickness_mm DECIMAL(7,3) AFTER tab_count_max,
+    ADD COLUMN IF NOT EXISTS color VARCHAR(50) AFTER thickness_mm,
+    ADD COLUMN IF NOT EXISTS finish VARCHAR(100) AFTER color,
+    ADD COLUMN IF NOT EXISTS grade VARCHAR(50) AFTER finish;
+
+CREATE INDEX IF NOT EXISTS idx_settings_material_thickness ON settings(material_id, thickness_mm);
+
+-- =============================================================================
+-- Setting revisions: history of every change, with votes tied to a revision
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS setting_revisions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    setting_id INT NOT NULL,
+    revision INT NOT NULL,
+    user_id INT,
+    action ENUM('create', 'update', 'revert') NOT NULL,
+    reverted_from INT,
+    snapshot TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (setting_id) REFERENCES settings(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE KEY uq_setting_revision (setting_id, revision)
+);
+
+ALTER TABLE votes ADD COLUMN IF NOT EXISTS revision INT AFTER value;
+
+-- Votes cast since a setting was last edited were cast on its current
+-- values, which become revision 1 when history starts
+UPDATE votes v
+JOIN settings s ON v.setting_id = s.id
+SET v.revision = 1
+WHERE v.revision IS NULL AND v.created_at >= s.updated_at
+  AND NOT EXISTS (SELECT 1 FROM setting_revisions r WHERE r.setting_id = s.id);
+
+-- =============================================================================
+-- Setting forks: a copy of another user's setting keeps a link to its parent
+-- =============================================================================
+ALTER TABLE settings
+    ADD COLUMN IF NOT EXISTS forked_from_id INT AFTER import_id;
+
+SET @fk_exists := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+    WHERE TABLE_SCHEMA = 'laserscribe' AND TABLE_NAME = 'settings'
+      AND COLUMN_NAME = 'forked_from_id' AND REFERENCED_TABLE_NAME = 'settings');
+
+SET @query = IF(@fk_exists = 0,
+    'ALTER TABLE settings
+        ADD INDEX idx_settings_forked_from (forked_from_id),
+        ADD FOREIGN KEY (forked_from_id) REFERENCES settings(id) ON DELETE SET NULL',
+    'SELECT "Fork foreign key already exists" AS status');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+-- =============================================================================
+-- Test reports: "I tested this" confirmations and the confidence score
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS test_reports (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    setting_id INT NOT NULL,
+    user_id INT NOT NULL,
+    revision INT NOT NULL,
+    laser_model_id INT,
+    make_model VARCHAR(255),
+    laser_type ENUM('CO2', 'Fiber', 'Diode', 'UV', 'Infrared') NOT NULL,
+    wattage INT NOT NULL,
+    material_batch VARCHAR(200),
+    outcome ENUM('worked', 'adjusted', 'failed') NOT NULL,
+    adjusted_values TEXT,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (setting_id) REFERENCES settings(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (laser_model_id) REFERENCES laser_models(id) ON DELETE SET NULL,
+    UNIQUE KEY uq_user_setting_test (user_id, setting_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_test_reports_setting ON test_reports(setting_id, updated_at DESC);
+
+ALTER TABLE settings
+    ADD COLUMN IF NOT EXISTS test_count INT NOT NULL DEFAULT 0 AFTER grade,
+    ADD COLUMN IF NOT EXISTS confidence DECIMAL(5,4) AFTER test_count;
+
+CREATE INDEX IF NOT EXISTS idx_settings_confidence ON settings(confidence);
+
+-- =============================================================================
+-- Unit preferences: the units each user reads and enters speeds and intervals in
+-- =============================================================================
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS speed_unit VARCHAR(10) NOT NULL DEFAULT 'mm/s' AFTER verification_expires,
+    ADD COLUMN IF NOT EXISTS interval_unit VARCHAR(10) NOT NULL DEFAULT 'mm' AFTER speed_unit;
+
+-- =============================================================================
+-- Fiber frequency in kHz and MOPA fields. Frequencies stored in Hz (above
+-- 4000, the fastest pulsed source) are converted, and pulse energy is
+-- recomputed from kHz.
+-- =============================================================================
+ALTER TABLE settings
+    ADD COLUMN IF NOT EXISTS pulse_width DECIMAL(8,2) AFTER frequency,
+    ADD COLUMN IF NOT EXISTS wobble_diameter DECIMAL(8,4) AFTER wobble_enable,
+    ADD COLUMN IF NOT EXISTS wobble_step DECIMAL(8,4) AFTER wobble_diameter;
+
+UPDATE settings SET frequency = frequency / 1000, updated_at = updated_at WHERE frequency > 4000;
+UPDATE setting_sublayers SET frequency = frequency / 1000 WHERE frequency > 4000;
+UPDATE setting_revisions
+SET snapshot = JSON_SET(snapshot, '$.frequency', CAST(CAST(JSON_VALUE(snapshot, '$.frequency') AS DECIMAL(12,3)) / 1000 AS CHAR))
+WHERE CAST(JSON_VALUE(snapshot, '$.frequency') AS DECIMAL(12,3)) > 4000;
+
+ALTER TABLE settings
+    MODIFY COLUMN pulse_energy DECIMAL(12,6) GENERATED ALWAYS AS (
+        CASE WHEN laser_type = 'Fiber' AND frequency > 0
+             THEN wattage * max_power / 100 / frequency END
+    ) STORED;
+
+-- =============================================================================
+-- Recipe sets: palettes of settings with a target color each
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS recipe_sets (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    material_id INT NOT NULL,
+    laser_type ENUM('CO2', 'Fiber', 'Diode', 'UV', 'Infrared') NOT NULL,
+    wattage INT NOT NULL,
+    laser_model_id INT,
+    name VARCHAR(200) NOT NULL,
+    description TEXT,
+    published_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (material_id) REFERENCES materials(id) ON DELETE CASCADE,
+    FOREIGN KEY (laser_model_id) REFERENCES laser_models(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS recipe_set_colors (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    recipe_set_id INT NOT NULL,
+    setting_id INT NOT NULL,
+    position INT NOT NULL,
+    color_name VARCHAR(50),
+    hex CHAR(7) NOT NULL,
+    FOREIGN KEY (recipe_set_id) REFERENCES recipe_sets(id) ON DELETE CASCADE,
+    FOREIGN KEY (setting_id) REFERENCES settings(id) ON DELETE CASCADE,
+    UNIQUE KEY uq_recipe_set_setting (recipe_set_id, setting_id)
+);
+
+CREATE TABLE IF NOT EXISTS recipe_set_votes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    recipe_set_id INT NOT NULL,
+    value TINYINT NOT NULL CHECK (value IN (-1, 1)),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (recipe_set_id) REFERENCES recipe_sets(id) ON DELETE CASCADE,
+    UNIQUE KEY uq_user_recipe_set_vote (user_id, recipe_set_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recipe_sets_browse ON recipe_sets(laser_type, wattage, material_id, published_at);
+CREATE INDEX IF NOT EXISTS idx_recipe_sets_user ON recipe_sets(user_id, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_recipe_set_votes_set ON recipe_set_votes(recipe_set_id);
+
 SELECT 'Migration completed successfully!' AS status;
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"laserscribe/backend/db"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// MATERIAL NORMALIZATION
+// =====================
+
+// How an incoming material name is mapped onto the catalog
+const (
+	materialActionAccept = "accept" // use the matched material
+	materialActionAlias  = "alias"  // use the matched material and remember the name as an alias
+	materialActionCreate = "create" // add a new material
+)
+
+// What a candidate matched on
+const (
+	matchedOnName  = "name"
+	matchedOnAlias = "alias"
+	matchedOnFuzzy = "fuzzy"
+)
+
+const (
+	// autoMatchConfidence is the confidence at which a match is used without
+	// asking. Only exact and normalized name/alias matches reach it.
+	autoMatchConfidence = 0.9
+	// minMatchConfidence is the lowest confidence still proposed as a match
+	minMatchConfidence = 0.5
+	maxMatchCandidates = 5
+	// defaultMaterialCategoryID is where new materials go unless a category
+	// is chosen
+	defaultMaterialCategoryID = 1
+)
+
+// MaterialCandidate is an existing material an incoming name may refer to
+type MaterialCandidate struct {
+	MaterialID   int32   `json:"materialId"`
+	Name         string  `json:"name"`
+	CategoryName string  `json:"categoryName"`
+	MatchedOn    string  `json:"matchedOn"`
+	MatchedText  string  `json:"matchedText"`
+	Confidence   float64 `json:"confidence"`
+}
+
+// MaterialMatch is the normalization result for one incoming name. Match is
+// the proposed canonical material, if any, and Action what happens to the
+// name unless the user decides otherwise.
+type MaterialMatch struct {
+	Input       string              `json:"input"`
+	ThicknessMm *float64            `json:"thicknessMm,omitempty"`
+	Match       *MaterialCandidate  `json:"match,omitempty"`
+	Candidates  []MaterialCandidate `json:"candidates"`
+	Action      string              `json:"action"`
+}
+
+// parsedMaterialName is a name reduced to comparable words and a thickness
+type parsedMaterialName struct {
+	tokens       []string
+	key          string
+	thicknessMm  float64
+	hasThickness bool
+}
+
+// materialTerm is one name or alias an incoming name is compared against
+type materialTerm struct {
+	materialID int32
+	name       string
+	category   string
+	text       string
+	matchedOn  string
+	parsed     parsedMaterialName
+}
+
+// materialNormalizer holds the material catalog for matching many names,
+// so an import loads it once
+type materialNormalizer struct {
+	terms []materialTerm
+}
+
+func newMaterialNormalizer(ctx context.Context, q *db.Queries) (*materialNormalizer, error) {
+	materials, err := q.GetAllMaterials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := q.GetAllMaterialAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &materialNormalizer{}
+	byID := make(map[int32]db.GetAllMaterialsRow, len(materials))
+	for _, m := range materials {
+		byID[m.ID] = m
+		n.terms = append(n.terms, materialTerm{
+			materialID: m.ID,
+			name:       m.Name,
+			category:   m.CategoryName,
+			text:       m.Name,
+			matchedOn:  matchedOnName,
+			parsed:     parseMaterialName(m.Name),
+		})
+	}
+	for _, a := range aliases {
+		m, ok := byID[a.MaterialID]
+		if !ok {
+			continue
+		}
+		n.terms = append(n.terms, materialTerm{
+			materialID: m.ID,
+			name:       m.Name,
+			category:   m.CategoryName,
+			text:       a.Alias,
+			matchedOn:  matchedOnAlias,
+			parsed:     parseMaterialName(a.Alias),
+		})
+	}
+	return n, nil
+}
+
+// materialCatalog caches the normalizer between requests, so matching a
+// typed name doesn't load every material and alias. Whatever writes
+// materials, aliases or categories invalidates it once the change is
+// committed.
+var materialCatalog struct {
+	sync.Mutex
+	normalizer *materialNormalizer
+}
+
+// catalogNormalizer returns the cached normalizer, loading it if needed
+func catalogNormalizer(ctx context.Context) (*materialNormalizer, error) {
+	materialCatalog.Lock()
+	defer materialCatalog.Unlock()
+	if materialCatalog.normalizer == nil {
+		n, err := newMaterialNormalizer(ctx, queries)
+		if err != nil {
+			return nil, err
+		}
+		materialCatalog.normalizer = n
+	}
+	return materialCatalog.normalizer, nil
+}
+
+// invalidateMaterialCatalog drops the cached normalizer
+func invalidateMaterialCatalog() {
+	materialCatalog.Lock()
+	materialCatalog.normalizer = nil
+	materialCatalog.Unlock()
+}
+
+// match scores input against every material name and alias and returns the
+// best candidates, one per material
+func (n *materialNormalizer) match(input string) MaterialMatch {
+	input = strings.TrimSpace(input)
+	parsed := parseMaterialName(input)
+	result := MaterialMatch{Input: input, Candidates: []MaterialCandidate{}, Action: materialActionCreate}
+	if parsed.hasThickness {
+		thickness := parsed.thicknessMm
+		result.ThicknessMm = &thickness
+	}
+
+	best := make(map[int32]MaterialCandidate)
+	for _, t := range n.terms {
+		confidence, matchedOn := scoreMaterialTerm(input, parsed, t)
+		if confidence < minMatchConfidence {
+			continue
+		}
+		if prev, ok := best[t.materialID]; ok && prev.Confidence >= confidence {
+			continue
+		}
+		best[t.materialID] = MaterialCandidate{
+			MaterialID:   t.materialID,
+			Name:         t.name,
+			CategoryName: t.category,
+			MatchedOn:    matchedOn,
+			MatchedText:  t.text,
+			Confidence:   math.Round(confidence*100) / 100,
+		}
+	}
+
+	for _, c := range best {
+		result.Candidates = append(result.Candidates, c)
+	}
+	sort.Slice(result.Candidates, func(i, j int) bool {
+		a, b := result.Candidates[i], result.Candidates[j]
+		if a.Confidence != b.Confidence {
+			return a.Confidence > b.Confidence
+		}
+		return a.Name < b.Name
+	})
+	if len(result.Candidates) > maxMatchCandidates {
+		result.Candidates = result.Candidates[:maxMatchCandidates]
+	}
+
+	if len(result.Candidates) > 0 {
+		top := result.Candidates[0]
+		result.Match = &top
+		if top.Confidence >= autoMatchConfidence {
+			result.Action = materialActionAccept
+		}
+	}
+	return result
+}
+
+// scoreMaterialTerm rates how likely input names the same material as t.
+// Exact matches score 1 (name) or 0.98 (alias); names that only differ in
+// case, punctuation or thickness notation score 0.95; anything else is a
+// fuzzy word match scaled below autoMatchConfidence.
+func scoreMaterialTerm(input string, parsed parsedMaterialName, t materialTerm) (float64, string) {
+	if strings.EqualFold(input, t.text) {
+		if t.matchedOn == matchedOnAlias {
+			return 0.98, matchedOnAlias
+		}
+		return 1, matchedOnName
+	}
+	if parsed.key == "" || t.parsed.key == "" {
+		return 0, ""
+	}
+
+	thickness := thicknessAgreement(parsed, t.parsed)
+	if parsed.key == t.parsed.key && thickness >= 0 {
+		return 0.95, t.matchedOn
+	}
+
+	score := tokenSimilarity(parsed.tokens, t.parsed.tokens)
+	if keyScore := stringSimilarity(parsed.key, t.parsed.key); keyScore > score {
+		score = keyScore
+	}
+	switch {
+	case thickness > 0:
+		score = math.Min(1, score+0.05)
+	case thickness < 0:
+		score *= 0.7
+	}
+	return score * 0.85, matchedOnFuzzy
+}
+
+// thicknessAgreement is 1 when both names give the same thickness, -1 when
+// they give different ones and 0 when either gives none. Imperial sizes are
+// the same as their nominal metric sheet, so 1/8in (3.175mm) agrees with 3mm.
+func thicknessAgreement(a, b parsedMaterialName) int {
+	if !a.hasThickness || !b.hasThickness {
+		return 0
+	}
+	diff := math.Abs(a.thicknessMm - b.thicknessMm)
+	if diff <= thicknessTolerance(math.Max(a.thicknessMm, b.thicknessMm)) {
+		return 1
+	}
+	return -1
+}
+
+// tokenSimilarity is a Dice coefficient over words, where words also count
+// as shared when one is a prefix of the other or they differ by a typo,
+// averaged with how much of a's words are found in b
+func tokenSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	matched := 0.0
+	for _, x := range a {
+		bestWord := 0.0
+		for _, y := range b {
+			var s float64
+			switch {
+			case x == y:
+				s = 1
+			case len(x) >= 3 && len(y) >= 3 && (strings.HasPrefix(x, y) || strings.HasPrefix(y, x)):
+				s = 0.9
+			default:
+				if s = stringSimilarity(x, y); s < 0.75 {
+					s = 0
+				}
+			}
+			if s > bestWord {
+				bestWord = s
+			}
+		}
+		matched += bestWord
+	}
+	dice := 2 * matched / float64(len(a)+len(b))
+	coverage := matched / float64(len(a))
+	return (dice + coverage) / 2
+}
+
+// stringSimilarity is 1 minus the edit distance relative to the longer string
+func stringSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// thicknessPattern finds sizes such as 3mm, 0.5 cm, 1/8in, 1/8", 1 1/2 inch
+// or a bare fraction like 1/8, which is read as inches. Bare whole numbers
+// are left alone since they are usually grades ("SS 304").
+var thicknessPattern = regexp.MustCompile(`(?i)\b(\d+\s+\d+/\d+|\d+/\d+|\d+(?:\.\d+)?)\s*(mm|cm|inches|inch|in\b|"|”)?`)
+
+var materialNameSeparators = regexp.MustCompile(`[^\pL\pN]+`)
+
+// materialNoiseWords carry no meaning for matching
+var materialNoiseWords = map[string]bool{
+	"thick": true, "thickness": true, "sheet": true, "the": true, "and": true, "of": true,
+}
+
+// parseMaterialName lowercases a name, pulls out its thickness and splits
+// the rest into words
+func parseMaterialName(name string) parsedMaterialName {
+	var p parsedMaterialName
+	name = strings.ToLower(name)
+
+	name = thicknessPattern.ReplaceAllStringFunc(name, func(m string) string {
+		parts := thicknessPattern.FindStringSubmatch(m)
+		number, unit := parts[1], parts[2]
+		if unit == "" && !strings.Contains(number, "/") {
+			return m
+		}
+		mm, ok := thicknessToMm(number, unit)
+		if !ok {
+			return m
+		}
+		if !p.hasThickness {
+			p.thicknessMm = mm
+			p.hasThickness = true
+		}
+		return " "
+	})
+
+	for _, word := range materialNameSeparators.Split(name, -1) {
+		if word == "" || materialNoiseWords[word] {
+			continue
+		}
+		p.tokens = append(p.tokens, word)
+	}
+	p.key = strings.Join(p.tokens, " ")
+	return p
+}
+
+// thicknessToMm converts a size such as "1 1/2" with its unit to millimetres
+func thicknessToMm(number, unit string) (float64, bool) {
+	value := 0.0
+	for _, part := range strings.Fields(number) {
+		if num, den, ok := strings.Cut(part, "/"); ok {
+			n, err1 := strconv.ParseFloat(num, 64)
+			d, err2 := strconv.ParseFloat(den, 64)
+			if err1 != nil || err2 != nil || d == 0 {
+				return 0, false
+			}
+			value += n / d
+			continue
+		}
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, false
+		}
+		value += v
+	}
+
+	switch unit {
+	case "mm":
+		return value, true
+	case "cm":
+		return value * 10, true
+	default:
+		return value * 25.4, true
+	}
+}
+
+// materialDecision is the user's choice for one incoming material name,
+// sent with an import as JSON
+type materialDecision struct {
+	Name       string `json:"name"`
+	Action     string `json:"action"`
+	MaterialID int32  `json:"materialId"`
+	CategoryID int32  `json:"categoryId"`
+}
+
+// materialResolution is what an incoming name resolved to. MaterialID is
+// zero until a material to create has been written.
+type materialResolution struct {
+	Action     string
+	MaterialID int32
+	Name       string
+	CategoryID int32
+	Match      MaterialMatch
+}
+
+// resolveMaterialNames matches each distinct name (case-insensitively) and
+// applies the user's decisions over the default action
+func resolveMaterialNames(ctx context.Context, n *materialNormalizer, names []string, decisions []materialDecision) (map[string]*materialResolution, error) {
+	byName := make(map[string]materialDecision, len(decisions))
+	for _, d := range decisions {
+		byName[strings.ToLower(strings.TrimSpace(d.Name))] = d
+	}
+
+	resolutions := make(map[string]*materialResolution)
+	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, ok := resolutions[key]; ok {
+			continue
+		}
+		match := n.match(name)
+		r := &materialResolution{
+			Action:     match.Action,
+			Name:       match.Input,
+			CategoryID: defaultMaterialCategoryID,
+			Match:      match,
+		}
+		if match.Action == materialActionAccept {
+			r.MaterialID = match.Match.MaterialID
+			r.Name = match.Match.Name
+		}
+
+		if d, ok := byName[key]; ok {
+			switch d.Action {
+			case materialActionAccept, materialActionAlias:
+				material, err := queries.GetMaterialByID(ctx, d.MaterialID)
+				if err != nil {
+					return nil, fmt.Errorf("unknown material %d for %q", d.MaterialID, name)
+				}
+				r.Action = d.Action
+				r.MaterialID = material.ID
+				r.Name = material.Name
+				// Aliasing a name the material already answers to adds nothing
+				if d.Action == materialActionAlias && match.Match != nil &&
+					match.Match.MaterialID == material.ID && strings.EqualFold(match.Match.MatchedText, match.Input) {
+					r.Action = materialActionAccept
+				}
+			case materialActionCreate:
+				r.Action = materialActionCreate
+				r.MaterialID = 0
+				r.Name = match.Input
+				if d.CategoryID != 0 {
+					r.CategoryID = d.CategoryID
+				}
+			default:
+				return nil, fmt.Errorf("invalid material action %q for %q", d.Action, name)
+			}
+		}
+		resolutions[key] = r
+	}
+	return resolutions, nil
+}
+
+// apply writes what a resolution needs using q: the new material, or the
+// alias for the incoming name. It is safe to call more than once.
+func (r *materialResolution) apply(ctx context.Context, q *db.Queries) (int32, error) {
+	switch r.Action {
+	case materialActionCreate:
+		if r.MaterialID == 0 {
+			id, err := getOrCreateMaterial(ctx, q, r.Name, r.CategoryID)
+			if err != nil {
+				return 0, err
+			}
+			r.MaterialID = id
+		}
+	case materialActionAlias:
+		if _, err := q.CreateMaterialAlias(ctx, db.CreateMaterialAliasParams{
+			MaterialID: r.MaterialID,
+			Alias:      r.Match.Input,
+		}); err != nil {
+			return 0, err
+		}
+		r.Action = materialActionAccept
+	}
+	return r.MaterialID, nil
+}
+
+// resolveContributedMaterial maps a typed material name onto the catalog the
+// way an import does by default: a confident match is reused, anything else
+// becomes a new material
+func resolveContributedMaterial(ctx context.Context, name string) (int32, error) {
+	normalizer, err := catalogNormalizer(ctx)
+	if err != nil {
+		return 0, err
+	}
+	resolutions, err := resolveMaterialNames(ctx, normalizer, []string{name}, nil)
+	if err != nil {
+		return 0, err
+	}
+	r := resolutions[strings.ToLower(strings.TrimSpace(name))]
+	if r.Action == materialActionAccept {
+		return r.MaterialID, nil
+	}
+	id, err := r.apply(ctx, queries)
+	invalidateMaterialCatalog()
+	return id, err
+}
+
+// matchMaterialHandler proposes the canonical material for a typed name,
+// e.g. GET /api/materials/match?name=SS%20304
+func matchMaterialHandler(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	normalizer, err := catalogNormalizer(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, normalizer.match(name))
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+// testNormalizer builds a normalizer over names and aliases without a
+// database, as newMaterialNormalizer would
+func testNormalizer(materials map[int32]string, aliases map[string]int32) *materialNormalizer {
+	n := &materialNormalizer{}
+	for id, name := range materials {
+		n.terms = append(n.terms, materialTerm{
+			materialID: id, name: name, text: name, matchedOn: matchedOnName, parsed: parseMaterialName(name),
+		})
+	}
+	for alias, id := range aliases {
+		n.terms = append(n.terms, materialTerm{
+			materialID: id, name: materials[id], text: alias, matchedOn: matchedOnAlias, parsed: parseMaterialName(alias),
+		})
+	}
+	return n
+}
+
+func TestParseMaterialName(t *testing.T) {
+	cases := []struct {
+		in        string
+		key       string
+		thickness float64 // 0 for none
+	}{
+		{"Birch Plywood 3mm", "birch plywood", 3},
+		{"3 mm birch plywood", "birch plywood", 3},
+		{"Acrylic 0.5 cm", "acrylic", 5},
+		{`Walnut 1/8"`, "walnut", 3.175},
+		{"MDF 1 1/2 inch", "mdf", 38.1},
+		{"Oak 1/4", "oak", 6.35},
+		{"Stainless Steel 304", "stainless steel 304", 0},
+		{"Cardboard (thick) sheet", "cardboard", 0},
+	}
+	for _, tc := range cases {
+		p := parseMaterialName(tc.in)
+		if p.key != tc.key {
+			t.Errorf("parseMaterialName(%q) key = %q, want %q", tc.in, p.key, tc.key)
+		}
+		if p.hasThickness != (tc.thickness != 0) || math.Abs(p.thicknessMm-tc.thickness) > 1e-9 {
+			t.Errorf("parseMaterialName(%q) thickness = %v (%v), want %v", tc.in, p.thicknessMm, p.hasThickness, tc.thickness)
+		}
+	}
+}
+
+func TestStringSimilarity(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want float64
+	}{
+		{"acrylic", "acrylic", 1},
+		{"acrylic", "acryllic", 1 - 1.0/8},
+		{"kitten", "sitting", 1 - 3.0/7},
+		{"", "", 1},
+		{"oak", "", 0},
+	}
+	for _, tc := range cases {
+		if got := stringSimilarity(tc.a, tc.b); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("stringSimilarity(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestTokenSimilarity(t *testing.T) {
+	cases := []struct {
+		a, b []string
+		want float64
+	}{
+		{[]string{"birch", "plywood"}, []string{"birch", "plywood"}, 1},
+		// Half the words shared: Dice 0.5, coverage 0.5
+		{[]string{"birch", "plywood"}, []string{"birch", "mdf"}, 0.5},
+		// A prefix counts as 0.9 of a word
+		{[]string{"ply"}, []string{"plywood"}, 0.9},
+		// Typos below 0.75 similarity don't count at all
+		{[]string{"oak"}, []string{"ash"}, 0},
+		{nil, []string{"oak"}, 0},
+	}
+	for _, tc := range cases {
+		if got := tokenSimilarity(tc.a, tc.b); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("tokenSimilarity(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestMaterialNormalizerMatch(t *testing.T) {
+	n := testNormalizer(
+		map[int32]string{1: "Stainless Steel", 2: "Birch Plywood 3mm", 3: "Cast Acrylic"},
+		map[string]int32{"SS 304": 1, "Plexiglass": 3},
+	)
+	cases := []struct {
+		input      string
+		materialID int32 // 0 when nothing should match
+		matchedOn  string
+		confidence float64
+		action     string
+	}{
+		{"Stainless Steel", 1, matchedOnName, 1, materialActionAccept},
+		{"ss 304", 1, matchedOnAlias, 0.98, materialActionAccept},
+		{"stainless-steel", 1, matchedOnName, 0.95, materialActionAccept},
+		{"Birch plywood 1/8in", 2, matchedOnName, 0.95, materialActionAccept},
+		{"plexi glass", 3, matchedOnFuzzy, 0, materialActionCreate},
+		{"Stainles Steel", 1, matchedOnFuzzy, 0, materialActionCreate},
+		{"Birch Plywood 6mm", 2, matchedOnFuzzy, 0, materialActionCreate},
+		{"Granite", 0, "", 0, materialActionCreate},
+	}
+	for _, tc := range cases {
+		m := n.match(tc.input)
+		if m.Action != tc.action {
+			t.Errorf("match(%q) action = %q, want %q", tc.input, m.Action, tc.action)
+		}
+		if tc.materialID == 0 {
+			if m.Match != nil {
+				t.Errorf("match(%q) = %s, want no match", tc.input, m.Match.Name)
+			}
+			continue
+		}
+		if m.Match == nil {
+			t.Errorf("match(%q) found nothing, want material %d", tc.input, tc.materialID)
+			continue
+		}
+		if m.Match.MaterialID != tc.materialID || m.Match.MatchedOn != tc.matchedOn {
+			t.Errorf("match(%q) = material %d on %s, want %d on %s",
+				tc.input, m.Match.MaterialID, m.Match.MatchedOn, tc.materialID, tc.matchedOn)
+		}
+		// Fuzzy matches are never confident enough to be used unasked
+		if tc.matchedOn == matchedOnFuzzy {
+			if m.Match.Confidence < minMatchConfidence || m.Match.Confidence >= autoMatchConfidence {
+				t.Errorf("match(%q) fuzzy confidence = %v", tc.input, m.Match.Confidence)
+			}
+		} else if m.Match.Confidence != tc.confidence {
+			t.Errorf("match(%q) confidence = %v, want %v", tc.input, m.Match.Confidence, tc.confidence)
+		}
+	}
+}
+
+func TestMaterialNormalizerMatchThicknessPenalty(t *testing.T) {
+	n := testNormalizer(map[int32]string{1: "Birch Plywood 3mm", 2: "Birch Plywood 6mm"}, nil)
+	m := n.match("birch plywood 6 mm")
+	if m.Match == nil || m.Match.MaterialID != 2 || m.Action != materialActionAccept {
+		t.Fatalf("match = %+v, want the 6mm sheet", m.Match)
+	}
+	if len(m.Candidates) != 2 || m.Candidates[1].MaterialID != 1 || m.Candidates[1].Confidence >= m.Candidates[0].Confidence {
+		t.Errorf("candidates = %+v, want the 3mm sheet ranked second", m.Candidates)
+	}
+}
+
+func TestCatalogNormalizerCache(t *testing.T) {
+	cached := testNormalizer(map[int32]string{1: "Oak"}, nil)
+	materialCatalog.normalizer = cached
+	defer invalidateMaterialCatalog()
+
+	n, err := catalogNormalizer(context.Background())
+	if err != nil || n != cached {
+		t.Fatalf("catalogNormalizer = %p, %v; want the cached normalizer", n, err)
+	}
+	invalidateMaterialCatalog()
+	if materialCatalog.normalizer != nil {
+		t.Errorf("invalidateMaterialCatalog kept the cached normalizer")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"laserscribe/backend/clb"
+	"laserscribe/backend/db"
+	"strings"
+)
+
+// =====================
+// OPERATION TYPES
+// =====================
+
+// Every place that reads or writes an operation type (import, manual create,
+// search filters and export) goes through these helpers so they agree on the
+// mapping between the database enum and LightBurn's CutSetting type.
+
+// operationCLBTypes maps each stored operation type to its CutSetting type
+var operationCLBTypes = map[db.SettingsOperationType]string{
+	db.SettingsOperationTypeCut:        clb.TypeCut,
+	db.SettingsOperationTypeScan:       clb.TypeScan,
+	db.SettingsOperationTypeScanCut:    clb.TypeScanCut,
+	db.SettingsOperationTypeImage:      clb.TypeImage,
+	db.SettingsOperationTypeOffsetFill: clb.TypeOffsetFill,
+}
+
+// operationAliases accepts the enum values, LightBurn's type attributes and
+// the mode names shown in LightBurn's UI, compared case-insensitively
+var operationAliases = map[string]db.SettingsOperationType{
+	"cut":         db.SettingsOperationTypeCut,
+	"line":        db.SettingsOperationTypeCut,
+	"scan":        db.SettingsOperationTypeScan,
+	"fill":        db.SettingsOperationTypeScan,
+	"scancut":     db.SettingsOperationTypeScanCut,
+	"scan+cut":    db.SettingsOperationTypeScanCut,
+	"fill+line":   db.SettingsOperationTypeScanCut,
+	"image":       db.SettingsOperationTypeImage,
+	"offsetfill":  db.SettingsOperationTypeOffsetFill,
+	"offset fill": db.SettingsOperationTypeOffsetFill,
+}
+
+// parseOperationType resolves a user- or file-supplied operation name
+func parseOperationType(s string) (db.SettingsOperationType, bool) {
+	op, ok := operationAliases[strings.ToLower(strings.TrimSpace(s))]
+	return op, ok
+}
+
+// operationFromCLBType maps a CutSetting type attribute to an operation type,
+// treating unknown or missing types as Cut like LightBurn does
+func operationFromCLBType(cutType string) db.SettingsOperationType {
+	if op, ok := parseOperationType(cutType); ok {
+		return op
+	}
+	return db.SettingsOperationTypeCut
+}
+
+// clbTypeFromOperation maps an operation type to its CutSetting type attribute
+func clbTypeFromOperation(op db.SettingsOperationType) string {
+	if t, ok := operationCLBTypes[op]; ok {
+		return t
+	}
+	return clb.TypeCut
+}
+
+// operationLabel is the LightBurn UI name of an operation, used for entry
+// titles in exported libraries
+func operationLabel(op db.SettingsOperationType) string {
+	switch op {
+	case db.SettingsOperationTypeScan:
+		return "Fill"
+	case db.SettingsOperationTypeScanCut:
+		return "Fill+Line"
+	case db.SettingsOperationTypeImage:
+		return "Image"
+	case db.SettingsOperationTypeOffsetFill:
+		return "Offset Fill"
+	default:
+		return "Line"
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"laserscribe/backend/clb"
+	"laserscribe/backend/db"
+	"testing"
+)
+
+var allOperationTypes = []db.SettingsOperationType{
+	db.SettingsOperationTypeCut,
+	db.SettingsOperationTypeScan,
+	db.SettingsOperationTypeScanCut,
+	db.SettingsOperationTypeImage,
+	db.SettingsOperationTypeOffsetFill,
+}
+
+func TestOperationCLBTypeRoundTrip(t *testing.T) {
+	if len(operationCLBTypes) != len(allOperationTypes) {
+		t.Errorf("%d operation types map to a CutSetting type, want %d", len(operationCLBTypes), len(allOperationTypes))
+	}
+	for _, op := range allOperationTypes {
+		cutType := clbTypeFromOperation(op)
+		if got := operationFromCLBType(cutType); got != op {
+			t.Errorf("%s exports as %q, which imports as %s", op, cutType, got)
+		}
+		// The stored value and the UI label both name the operation too
+		for _, name := range []string{string(op), operationLabel(op)} {
+			if got, ok := parseOperationType(name); !ok || got != op {
+				t.Errorf("parseOperationType(%q) = %s, %v; want %s", name, got, ok, op)
+			}
+		}
+	}
+}
+
+func TestParseOperationType(t *testing.T) {
+	cases := map[string]db.SettingsOperationType{
+		"Fill+Line":   db.SettingsOperationTypeScanCut,
+		"Offset Fill": db.SettingsOperationTypeOffsetFill,
+		" SCAN+CUT ":  db.SettingsOperationTypeScanCut,
+		"line":        db.SettingsOperationTypeCut,
+		clb.TypeImage: db.SettingsOperationTypeImage,
+		"offsetfill":  db.SettingsOperationTypeOffsetFill,
+		"Fill":        db.SettingsOperationTypeScan,
+	}
+	for in, want := range cases {
+		if got, ok := parseOperationType(in); !ok || got != want {
+			t.Errorf("parseOperationType(%q) = %s, %v; want %s", in, got, ok, want)
+		}
+	}
+	for _, bad := range []string{"", "Engrave", "Fill Line"} {
+		if _, ok := parseOperationType(bad); ok {
+			t.Errorf("parseOperationType(%q) accepted", bad)
+		}
+		if got := operationFromCLBType(bad); got != db.SettingsOperationTypeCut {
+			t.Errorf("CutSetting type %q imports as %s, want Cut", bad, got)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"database/sql"
+	"laserscribe/backend/clb"
+	"laserscribe/backend/db"
+	"laserscribe/backend/units"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// UNITS
+// =====================
+
+// InputUnits names the units a request gives speed, scanInterval and
+// frequency in. Empty means the stored units, mm/s and mm, so clients that
+// don't send units keep working; a frequency without a unit is read as kHz
+// unless it is too high to be one (see units.DetectFrequencyUnit).
+type InputUnits struct {
+	SpeedUnit     string `json:"speedUnit" form:"speedUnit"`
+	IntervalUnit  string `json:"intervalUnit" form:"intervalUnit"`
+	FrequencyUnit string `json:"frequencyUnit" form:"frequencyUnit"`
+}
+
+// resolve checks the unit names, returning them in canonical spelling
+func (u InputUnits) resolve() (InputUnits, *ValidationError) {
+	var resolved InputUnits
+	errs := &ValidationError{}
+	var err error
+	if resolved.SpeedUnit, err = units.ParseSpeedUnit(u.SpeedUnit); err != nil {
+		errs.add("speedUnit", "%v", err)
+	}
+	if resolved.IntervalUnit, err = units.ParseIntervalUnit(u.IntervalUnit); err != nil {
+		errs.add("intervalUnit", "%v", err)
+	}
+	if resolved.FrequencyUnit, err = units.ParseFrequencyUnit(u.FrequencyUnit); err != nil {
+		errs.add("frequencyUnit", "%v", err)
+	}
+	if len(errs.Fields) > 0 {
+		return InputUnits{}, errs
+	}
+	return resolved, nil
+}
+
+// canonicalInterval converts an optional interval to mm
+func canonicalInterval(s sql.NullString, unit string) sql.NullString {
+	if !s.Valid {
+		return s
+	}
+	return sql.NullString{String: units.ConvertInterval(s.String, unit), Valid: true}
+}
+
+// canonicalFrequency converts an optional frequency to kHz
+func canonicalFrequency(s sql.NullString, unit string) sql.NullString {
+	if !s.Valid {
+		return s
+	}
+	return sql.NullString{String: units.ConvertFrequency(s.String, unit), Valid: true}
+}
+
+// convertCLBUnits rewrites the speed, interval and frequency of a
+// CutSetting or SubLayer read from a file into the stored units
+func convertCLBUnits(p *clb.Params, u InputUnits) {
+	if p.Speed.IsSet() {
+		p.Speed = clb.V(units.ConvertSpeed(p.Speed.String(), u.SpeedUnit))
+	}
+	if p.Interval.IsSet() {
+		p.Interval = clb.V(units.ConvertInterval(p.Interval.String(), u.IntervalUnit))
+	}
+	if p.Frequency.IsSet() {
+		p.Frequency = clb.V(units.ConvertFrequency(p.Frequency.String(), u.FrequencyUnit))
+	}
+}
+
+// =====================
+// UNIT PREFERENCES
+// =====================
+
+// UnitPreferences are the units a user reads and enters speeds and line
+// intervals in. Values are always stored in mm/s and mm; the preference
+// only changes how they are shown and entered.
+type UnitPreferences struct {
+	SpeedUnit    string `json:"speedUnit"`
+	IntervalUnit string `json:"intervalUnit"`
+}
+
+func getPreferencesHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	prefs, err := queries.GetUserUnits(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	c.JSON(http.StatusOK, UnitPreferences{SpeedUnit: prefs.SpeedUnit, IntervalUnit: prefs.IntervalUnit})
+}
+
+func updatePreferencesHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	var req InputUnits
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	u, verr := req.resolve()
+	if verr != nil {
+		validationFailed(c, verr)
+		return
+	}
+
+	err := queries.UpdateUserUnits(c.Request.Context(), db.UpdateUserUnitsParams{
+		SpeedUnit:    u.SpeedUnit,
+		IntervalUnit: u.IntervalUnit,
+		ID:           userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, UnitPreferences{SpeedUnit: u.SpeedUnit, IntervalUnit: u.IntervalUnit})
+}
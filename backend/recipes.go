@@ -0,0 +1,780 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"laserscribe/backend/clb"
+	"laserscribe/backend/db"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// RECIPE SETS
+// =====================
+
+// A recipe set is a palette of settings tested together on one machine and
+// material, each with the color it produces, such as the oxide colors a
+// MOPA fiber marks on stainless steel. Sets are drafts until published;
+// only published sets are listed, voted on and exported by others.
+
+// maxRecipeColors bounds the colors in one set
+const maxRecipeColors = 64
+
+// hexColorPattern is a #RRGGBB color, with or without the #
+var hexColorPattern = regexp.MustCompile(`^#?([0-9A-Fa-f]{6})$`)
+
+// recipeSetNoThickTitle groups a set's entries in an exported library
+const recipeSetNoThickTitle = "Colors"
+
+// RecipeColorRequest is one color of a set: a setting and the color it
+// marks
+type RecipeColorRequest struct {
+	SettingID int32  `json:"settingId" binding:"required"`
+	Hex       string `json:"hex" binding:"required"`
+	Name      string `json:"name"`
+}
+
+// RecipeSetRequest creates or replaces a recipe set. Every setting must be
+// for the set's material, laser type and wattage; colors keep their order.
+type RecipeSetRequest struct {
+	Name         string               `json:"name" binding:"required"`
+	Description  *string              `json:"description"`
+	MaterialID   int32                `json:"materialId" binding:"required"`
+	LaserType    string               `json:"laserType" binding:"required"`
+	Wattage      int32                `json:"wattage" binding:"required"`
+	LaserModelID *int32               `json:"laserModelId"`
+	Colors       []RecipeColorRequest `json:"colors" binding:"required"`
+}
+
+// RecipeColor is a color of a set with the main values of its setting
+type RecipeColor struct {
+	SettingID     int32                    `json:"settingId"`
+	Name          string                   `json:"name,omitempty"`
+	Hex           string                   `json:"hex"`
+	OperationType db.SettingsOperationType `json:"operationType"`
+	MaxPower      string                   `json:"maxPower"`
+	Speed         string                   `json:"speed"`
+	NumPasses     int32                    `json:"numPasses"`
+	ScanInterval  *string                  `json:"scanInterval,omitempty"`
+	Frequency     *string                  `json:"frequency,omitempty"`
+	PulseWidth    *string                  `json:"pulseWidth,omitempty"`
+}
+
+// RecipeSet is a recipe set as the API returns it
+type RecipeSet struct {
+	ID           int32                  `json:"id"`
+	UserID       int32                  `json:"userId"`
+	Author       string                 `json:"author"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description,omitempty"`
+	MaterialID   int32                  `json:"materialId"`
+	MaterialName string                 `json:"materialName"`
+	LaserType    db.RecipeSetsLaserType `json:"laserType"`
+	Wattage      int32                  `json:"wattage"`
+	LaserModelID *int32                 `json:"laserModelId,omitempty"`
+	Machine      string                 `json:"machine"`
+	Published    bool                   `json:"published"`
+	PublishedAt  string                 `json:"publishedAt,omitempty"`
+	VoteScore    int64                  `json:"voteScore"`
+	VoteCount    int64                  `json:"voteCount"`
+	Colors       []RecipeColor          `json:"colors"`
+	CreatedAt    string                 `json:"createdAt"`
+	UpdatedAt    string                 `json:"updatedAt"`
+}
+
+// recipeSetMachine names the machine a set was tested on: the catalog
+// model if there is one, else the laser type and wattage
+func recipeSetMachine(r db.GetRecipeSetRow) string {
+	if r.LaserModelName.Valid {
+		return strings.TrimSpace(r.ManufacturerName.String + " " + r.LaserModelName.String)
+	}
+	return fmt.Sprintf("%s %dW", r.LaserType, r.Wattage)
+}
+
+// nullStringPtr returns the value of a nullable column, or nil
+func nullStringPtr(ns sql.NullString) *string {
+	if !ns.Valid {
+		return nil
+	}
+	return &ns.String
+}
+
+// formatNullTime renders a nullable timestamp as RFC3339, or "" when NULL
+func formatNullTime(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.Format(time.RFC3339)
+}
+
+// recipeSetJSON renders a set row and its colors. The list queries return
+// the same columns as GetRecipeSet, so their rows convert to its row type.
+func recipeSetJSON(r db.GetRecipeSetRow, colors []db.GetRecipeSetColorsRow) RecipeSet {
+	out := RecipeSet{
+		ID:           r.ID,
+		UserID:       r.UserID,
+		Author:       authorName(r.DisplayName, r.FirstName, r.LastName),
+		Name:         r.Name,
+		Description:  r.Description.String,
+		MaterialID:   r.MaterialID,
+		MaterialName: r.MaterialName,
+		LaserType:    r.LaserType,
+		Wattage:      r.Wattage,
+		Machine:      recipeSetMachine(r),
+		Published:    r.PublishedAt.Valid,
+		PublishedAt:  formatNullTime(r.PublishedAt),
+		VoteScore:    r.VoteScore,
+		VoteCount:    r.VoteCount,
+		Colors:       []RecipeColor{},
+		CreatedAt:    formatNullTime(r.CreatedAt),
+		UpdatedAt:    formatNullTime(r.UpdatedAt),
+	}
+	if r.LaserModelID.Valid {
+		out.LaserModelID = &r.LaserModelID.Int32
+	}
+	for _, c := range colors {
+		out.Colors = append(out.Colors, RecipeColor{
+			SettingID:     c.SettingID,
+			Name:          c.ColorName.String,
+			Hex:           c.Hex,
+			OperationType: c.OperationType,
+			MaxPower:      c.MaxPower,
+			Speed:         c.Speed,
+			NumPasses:     c.NumPasses,
+			ScanInterval:  nullStringPtr(c.ScanInterval),
+			Frequency:     nullStringPtr(c.Frequency),
+			PulseWidth:    nullStringPtr(c.PulseWidth),
+		})
+	}
+	return out
+}
+
+// recipeSetColorsByID loads the colors of several sets at once
+func recipeSetColorsByID(ctx context.Context, ids []int32) (map[int32][]db.GetRecipeSetColorsRow, error) {
+	byID := make(map[int32][]db.GetRecipeSetColorsRow)
+	if len(ids) == 0 {
+		return byID, nil
+	}
+	colors, err := queries.GetRecipeSetColors(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range colors {
+		byID[c.RecipeSetID] = append(byID[c.RecipeSetID], c)
+	}
+	return byID, nil
+}
+
+// recipeSetValues are a validated set request ready to store
+type recipeSetValues struct {
+	name         string
+	description  sql.NullString
+	laserType    db.SettingsLaserType
+	laserModelID sql.NullInt32
+	colors       []db.CreateRecipeSetColorParams
+}
+
+// validateRecipeSet checks a set request against the settings it names,
+// which must be the user's own. Field errors are returned as a
+// ValidationError; other errors are lookup failures.
+func validateRecipeSet(ctx context.Context, userID int32, req RecipeSetRequest) (recipeSetValues, *ValidationError, error) {
+	errs := &ValidationError{}
+	values := recipeSetValues{
+		name:        strings.TrimSpace(req.Name),
+		description: nullString(req.Description),
+		laserType:   db.SettingsLaserType(req.LaserType),
+	}
+	if values.name == "" || len(values.name) > 200 {
+		errs.add("name", "must be 1 to 200 characters")
+	}
+	if _, ok := laserTypeLimits[values.laserType]; !ok {
+		errs.add("laserType", "unknown laser type %q", req.LaserType)
+	}
+	if req.Wattage <= 0 {
+		errs.add("wattage", "must be greater than 0")
+	}
+	if req.LaserModelID != nil {
+		machine, err := queries.GetLaserModelByID(ctx, *req.LaserModelID)
+		if err == sql.ErrNoRows {
+			errs.add("laserModelId", "unknown laser model")
+		} else if err != nil {
+			return values, nil, err
+		} else if db.SettingsLaserType(machine.LaserType) != values.laserType || machine.Wattage != req.Wattage {
+			errs.add("laserModelId", "does not match laserType and wattage")
+		}
+		values.laserModelID = sql.NullInt32{Int32: *req.LaserModelID, Valid: true}
+	}
+	if len(req.Colors) == 0 || len(req.Colors) > maxRecipeColors {
+		errs.add("colors", "must list 1 to %d colors", maxRecipeColors)
+	}
+
+	ids := make([]int32, 0, len(req.Colors))
+	for _, color := range req.Colors {
+		ids = append(ids, color.SettingID)
+	}
+	settings := make(map[int32]db.GetSettingsByIDsRow)
+	if len(ids) > 0 {
+		rows, err := queries.GetSettingsByIDs(ctx, ids)
+		if err != nil {
+			return values, nil, err
+		}
+		for _, s := range rows {
+			settings[s.ID] = s
+		}
+	}
+
+	seenSettings := make(map[int32]bool)
+	seenHex := make(map[string]bool)
+	for i, color := range req.Colors {
+		field := fmt.Sprintf("colors[%d]", i)
+		m := hexColorPattern.FindStringSubmatch(strings.TrimSpace(color.Hex))
+		hex := ""
+		if m == nil {
+			errs.add(field+".hex", "must be a #RRGGBB color")
+		} else {
+			hex = "#" + strings.ToUpper(m[1])
+			if seenHex[hex] {
+				errs.add(field+".hex", "%s is already in the set", hex)
+			}
+			seenHex[hex] = true
+		}
+		name := strings.TrimSpace(color.Name)
+		if len(name) > 50 {
+			errs.add(field+".name", "must be at most 50 characters")
+		}
+
+		s, ok := settings[color.SettingID]
+		switch {
+		case !ok:
+			errs.add(field+".settingId", "setting %d not found", color.SettingID)
+		case seenSettings[color.SettingID]:
+			errs.add(field+".settingId", "setting %d is already in the set", color.SettingID)
+		case s.UserID != userID:
+			errs.add(field+".settingId", "setting %d belongs to another user, fork it first", s.ID)
+		case s.MaterialID != req.MaterialID:
+			errs.add(field+".settingId", "setting %d is for %s, not this set's material", s.ID, s.MaterialName)
+		case s.LaserType != values.laserType || s.Wattage != req.Wattage:
+			errs.add(field+".settingId", "setting %d is for a %s %dW laser", s.ID, s.LaserType, s.Wattage)
+		case values.laserModelID.Valid && s.LaserModelID != values.laserModelID:
+			errs.add(field+".settingId", "setting %d is not for this set's machine", s.ID)
+		}
+		seenSettings[color.SettingID] = true
+
+		values.colors = append(values.colors, db.CreateRecipeSetColorParams{
+			SettingID: color.SettingID,
+			Position:  int32(i),
+			ColorName: sql.NullString{String: name, Valid: name != ""},
+			Hex:       hex,
+		})
+	}
+
+	if len(errs.Fields) > 0 {
+		return values, errs, nil
+	}
+	return values, nil, nil
+}
+
+// writeRecipeSetColors replaces the colors of a set
+func writeRecipeSetColors(ctx context.Context, q *db.Queries, setID int32, colors []db.CreateRecipeSetColorParams) error {
+	if err := q.DeleteRecipeSetColors(ctx, setID); err != nil {
+		return err
+	}
+	for _, color := range colors {
+		color.RecipeSetID = setID
+		if err := q.CreateRecipeSetColor(ctx, color); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// respondRecipeSet answers with the current state of a set
+func respondRecipeSet(c *gin.Context, status int, id int32) {
+	ctx := c.Request.Context()
+	set, err := queries.GetRecipeSet(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	colors, err := recipeSetColorsByID(ctx, []int32{id})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, recipeSetJSON(set, colors[id]))
+}
+
+// loadRecipeSet fetches the set named in the path, answering 404 unless it
+// is published or belongs to the caller
+func loadRecipeSet(c *gin.Context) (db.GetRecipeSetRow, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid recipe set id"})
+		return db.GetRecipeSetRow{}, false
+	}
+	set, err := queries.GetRecipeSet(c.Request.Context(), int32(id))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "recipe set not found"})
+		return set, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return set, false
+	}
+	userIDVal, signedIn := c.Get("user_id")
+	if !set.PublishedAt.Valid && (!signedIn || userIDVal.(int32) != set.UserID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "recipe set not found"})
+		return set, false
+	}
+	return set, true
+}
+
+// loadOwnRecipeSet is loadRecipeSet for changes, answering 403 when the
+// set belongs to someone else
+func loadOwnRecipeSet(c *gin.Context) (db.GetRecipeSetRow, bool) {
+	set, ok := loadRecipeSet(c)
+	if !ok {
+		return set, false
+	}
+	userIDVal, _ := c.Get("user_id")
+	if set.UserID != userIDVal.(int32) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you can only change your own recipe sets"})
+		return set, false
+	}
+	return set, true
+}
+
+func listRecipeSetsHandler(c *gin.Context) {
+	params := db.ListRecipeSetsParams{SortKey: "score", Limit: 20}
+	if v := c.Query("material_id"); v != "" {
+		id, _ := strconv.Atoi(v)
+		params.MaterialID = sql.NullInt32{Int32: int32(id), Valid: true}
+	}
+	if v := c.Query("laser_type"); v != "" {
+		params.LaserType = db.NullRecipeSetsLaserType{RecipeSetsLaserType: db.RecipeSetsLaserType(v), Valid: true}
+	}
+	if v := c.Query("wattage"); v != "" {
+		w, _ := strconv.Atoi(v)
+		params.Wattage = sql.NullInt32{Int32: int32(w), Valid: true}
+	}
+	if v := c.Query("laser_model_id"); v != "" {
+		id, _ := strconv.Atoi(v)
+		params.LaserModelID = sql.NullInt32{Int32: int32(id), Valid: true}
+	}
+	switch v := c.DefaultQuery("sort", "score"); v {
+	case "score", "newest":
+		params.SortKey = v
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be score or newest"})
+		return
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		if n > 100 {
+			n = 100
+		}
+		params.Limit = int32(n)
+	}
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must not be negative"})
+			return
+		}
+		params.Offset = int32(n)
+	}
+
+	ctx := c.Request.Context()
+	rows, err := queries.ListRecipeSets(ctx, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	total, err := queries.CountRecipeSets(ctx, db.CountRecipeSetsParams{
+		MaterialID:   params.MaterialID,
+		LaserType:    params.LaserType,
+		Wattage:      params.Wattage,
+		LaserModelID: params.LaserModelID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids := make([]int32, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	colors, err := recipeSetColorsByID(ctx, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sets := make([]RecipeSet, 0, len(rows))
+	for _, r := range rows {
+		sets = append(sets, recipeSetJSON(db.GetRecipeSetRow(r), colors[r.ID]))
+	}
+	c.JSON(http.StatusOK, gin.H{"recipeSets": sets, "total": total})
+}
+
+func getRecipeSetHandler(c *gin.Context) {
+	set, ok := loadRecipeSet(c)
+	if !ok {
+		return
+	}
+	respondRecipeSet(c, http.StatusOK, set.ID)
+}
+
+// getUserRecipeSetsHandler lists the caller's sets, drafts included
+func getUserRecipeSetsHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	ctx := c.Request.Context()
+
+	rows, err := queries.GetUserRecipeSets(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ids := make([]int32, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	colors, err := recipeSetColorsByID(ctx, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sets := make([]RecipeSet, 0, len(rows))
+	for _, r := range rows {
+		sets = append(sets, recipeSetJSON(db.GetRecipeSetRow(r), colors[r.ID]))
+	}
+	c.JSON(http.StatusOK, sets)
+}
+
+func createRecipeSetHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	ctx := c.Request.Context()
+
+	var req RecipeSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	values, verr, err := validateRecipeSet(ctx, userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if verr != nil {
+		validationFailed(c, verr)
+		return
+	}
+
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	result, err := qtx.CreateRecipeSet(ctx, db.CreateRecipeSetParams{
+		UserID:       userID,
+		MaterialID:   req.MaterialID,
+		LaserType:    db.RecipeSetsLaserType(values.laserType),
+		Wattage:      req.Wattage,
+		LaserModelID: values.laserModelID,
+		Name:         values.name,
+		Description:  values.description,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := writeRecipeSetColors(ctx, qtx, int32(id), values.colors); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	respondRecipeSet(c, http.StatusCreated, int32(id))
+}
+
+// updateRecipeSetHandler replaces a set's details and colors. A published
+// set stays published and keeps its votes.
+func updateRecipeSetHandler(c *gin.Context) {
+	set, ok := loadOwnRecipeSet(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	var req RecipeSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	values, verr, err := validateRecipeSet(ctx, set.UserID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if verr != nil {
+		validationFailed(c, verr)
+		return
+	}
+
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	err = qtx.UpdateRecipeSet(ctx, db.UpdateRecipeSetParams{
+		MaterialID:   req.MaterialID,
+		LaserType:    db.RecipeSetsLaserType(values.laserType),
+		Wattage:      req.Wattage,
+		LaserModelID: values.laserModelID,
+		Name:         values.name,
+		Description:  values.description,
+		ID:           set.ID,
+		UserID:       set.UserID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := writeRecipeSetColors(ctx, qtx, set.ID, values.colors); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	respondRecipeSet(c, http.StatusOK, set.ID)
+}
+
+func deleteRecipeSetHandler(c *gin.Context) {
+	set, ok := loadOwnRecipeSet(c)
+	if !ok {
+		return
+	}
+	if _, err := queries.DeleteRecipeSet(c.Request.Context(), db.DeleteRecipeSetParams{ID: set.ID, UserID: set.UserID}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Recipe set deleted"})
+}
+
+// publishRecipeSetHandler makes a draft set public. Publishing again keeps
+// the original publication time.
+func publishRecipeSetHandler(c *gin.Context) {
+	setRecipeSetPublished(c, true)
+}
+
+// unpublishRecipeSetHandler turns a set back into a draft
+func unpublishRecipeSetHandler(c *gin.Context) {
+	setRecipeSetPublished(c, false)
+}
+
+func setRecipeSetPublished(c *gin.Context, published bool) {
+	set, ok := loadOwnRecipeSet(c)
+	if !ok {
+		return
+	}
+	if set.PublishedAt.Valid != published {
+		publishedAt := sql.NullTime{Time: time.Now(), Valid: published}
+		_, err := queries.SetRecipeSetPublished(c.Request.Context(), db.SetRecipeSetPublishedParams{
+			PublishedAt: publishedAt,
+			ID:          set.ID,
+			UserID:      set.UserID,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	respondRecipeSet(c, http.StatusOK, set.ID)
+}
+
+// voteRecipeSetHandler records an up or down vote on a published set
+func voteRecipeSetHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	set, ok := loadRecipeSet(c)
+	if !ok {
+		return
+	}
+
+	var req VoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Value != 1 && req.Value != -1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "value must be 1 or -1"})
+		return
+	}
+	if !set.PublishedAt.Valid {
+		c.JSON(http.StatusConflict, gin.H{"error": "only published recipe sets can be voted on"})
+		return
+	}
+	if set.UserID == userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you cannot vote on your own recipe set"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := queries.UpsertRecipeSetVote(ctx, db.UpsertRecipeSetVoteParams{
+		UserID:      userID,
+		RecipeSetID: set.ID,
+		Value:       int8(req.Value),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	score, err := queries.GetRecipeSetVoteScore(ctx, set.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"score": score.Score, "total": score.Total})
+}
+
+// deleteRecipeSetVoteHandler retracts the caller's vote on a set
+func deleteRecipeSetVoteHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	set, ok := loadRecipeSet(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	n, err := queries.DeleteRecipeSetVote(ctx, db.DeleteRecipeSetVoteParams{UserID: userID, RecipeSetID: set.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "you have not voted on this recipe set"})
+		return
+	}
+	score, err := queries.GetRecipeSetVoteScore(ctx, set.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"score": score.Score, "total": score.Total})
+}
+
+// exportRecipeSetHandler downloads a set as a library holding one material
+// named after the set and its machine, with an entry per color in the
+// set's order
+func exportRecipeSetHandler(c *gin.Context) {
+	set, ok := loadRecipeSet(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	colors, err := recipeSetColorsByID(ctx, []int32{set.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve recipe set"})
+		return
+	}
+	ids := make([]int32, 0, len(colors[set.ID]))
+	for _, color := range colors[set.ID] {
+		ids = append(ids, color.SettingID)
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "the recipe set has no colors"})
+		return
+	}
+
+	settings, err := queries.GetSettingsByIDs(ctx, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve settings"})
+		return
+	}
+	settingsByID := make(map[int32]db.GetSettingsByIDsRow, len(settings))
+	for _, s := range settings {
+		settingsByID[s.ID] = s
+	}
+	subLayers, err := queries.GetSublayersBySettingIDs(ctx, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve settings"})
+		return
+	}
+	subLayersBySetting := make(map[int32][]db.SettingSublayer)
+	for _, sl := range subLayers {
+		subLayersBySetting[sl.SettingID] = append(subLayersBySetting[sl.SettingID], sl)
+	}
+
+	material, err := recipeSetMaterial(set, colors[set.ID], settingsByID, subLayersBySetting)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := clb.Marshal(&clb.Library{DisplayName: clb.DisplayName, Materials: []clb.Material{material}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate CLB file"})
+		return
+	}
+
+	filename := slugify(set.Name)
+	if filename == "" {
+		filename = "recipe-set"
+	}
+	c.Header("Content-Description", "File Transfer")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.clb", filename))
+	c.Header("Content-Type", "application/xml")
+	c.Data(http.StatusOK, "application/xml", data)
+}
+
+// recipeSetMaterial lays out a set as one library material with an entry
+// per color, in the set's order, titled by the color
+func recipeSetMaterial(set db.GetRecipeSetRow, colors []db.GetRecipeSetColorsRow, settingsByID map[int32]db.GetSettingsByIDsRow, subLayersBySetting map[int32][]db.SettingSublayer) (clb.Material, error) {
+	materialName := fmt.Sprintf("%s - %s (%s)", set.MaterialName, set.Name, recipeSetMachine(set))
+	material := clb.Material{Name: materialName}
+	for _, color := range colors {
+		s, ok := settingsByID[color.SettingID]
+		if !ok {
+			return material, fmt.Errorf("setting %d of the recipe set was not found", color.SettingID)
+		}
+		entry := clbEntryFromSetting(materialName, s, subLayersBySetting[color.SettingID], ConversionInfo{})
+		entry.Desc = color.Hex
+		if color.ColorName.Valid {
+			entry.Desc = color.ColorName.String + " " + color.Hex
+		}
+		entry.NoThickTitle = recipeSetNoThickTitle
+		entry.CutSetting.LinkPath = clb.V(clb.LinkPath(materialName, recipeSetNoThickTitle, entry.Desc))
+		material.Entries = append(material.Entries, entry)
+	}
+	return material, nil
+}
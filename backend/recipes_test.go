@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"laserscribe/backend/db"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// recipeSettings are the settings the recipe tests pick colors from: user 1
+// owns 10 to 13, all 20W fiber fills of material 3 on machine 7 unless noted
+func recipeSettings() map[int64]db.GetSettingsByIDsRow {
+	setting := func(id int32) db.GetSettingsByIDsRow {
+		return db.GetSettingsByIDsRow{
+			ID: id, UserID: 1, MaterialID: 3, LaserType: db.SettingsLaserTypeFiber, Wattage: 20,
+			LaserModelID: sql.NullInt32{Int32: 7, Valid: true}, OperationType: db.SettingsOperationTypeScan,
+			MaxPower: "80", MinPower: "0", Speed: strconv.Itoa(int(id) * 10), NumPasses: 1, Bidir: true,
+			ScanInterval: validString("0.05"), Frequency: validString("30"), MaterialName: "Stainless Steel",
+		}
+	}
+	settings := map[int64]db.GetSettingsByIDsRow{}
+	for _, id := range []int32{10, 11, 12, 13, 20} {
+		settings[int64(id)] = setting(id)
+	}
+	s := settings[11]
+	s.LaserModelID = sql.NullInt32{}
+	settings[11] = s
+	s = settings[12]
+	s.MaterialID, s.MaterialName = 4, "Titanium"
+	settings[12] = s
+	s = settings[13]
+	s.Wattage = 30
+	settings[13] = s
+	s = settings[20]
+	s.UserID = 2
+	settings[20] = s
+	return settings
+}
+
+func newRecipeDB() *fakeDB {
+	settings := recipeSettings()
+	return &fakeDB{
+		snapshot: func() interface{} { return nil },
+		restore:  func(interface{}) {},
+		queries: map[string]fakeQuery{
+			"GetLaserModelByID": func(args []driver.Value) ([]interface{}, int64, error) {
+				machines := map[int64]db.GetLaserModelByIDRow{
+					7: {ID: 7, Name: "G2", LaserType: db.LaserModelsLaserTypeFiber, Wattage: 20, ManufacturerName: "Gweike"},
+					8: {ID: 8, Name: "G2 Pro", LaserType: db.LaserModelsLaserTypeFiber, Wattage: 30, ManufacturerName: "Gweike"},
+				}
+				if m, ok := machines[args[0].(int64)]; ok {
+					return []interface{}{m}, 0, nil
+				}
+				return nil, 0, nil
+			},
+			"GetSettingsByIDs": func(args []driver.Value) ([]interface{}, int64, error) {
+				var rows []interface{}
+				for _, id := range args {
+					if s, ok := settings[id.(int64)]; ok {
+						rows = append(rows, s)
+					}
+				}
+				return rows, 0, nil
+			},
+		},
+	}
+}
+
+func TestValidateRecipeSet(t *testing.T) {
+	machine := func(id int32) *int32 { return &id }
+	colors := func(hexAndIDs ...interface{}) []RecipeColorRequest {
+		var c []RecipeColorRequest
+		for i := 0; i < len(hexAndIDs); i += 2 {
+			c = append(c, RecipeColorRequest{Hex: hexAndIDs[i].(string), SettingID: int32(hexAndIDs[i+1].(int))})
+		}
+		return c
+	}
+	cases := []struct {
+		name   string
+		req    RecipeSetRequest
+		fields []string
+	}{
+		{"valid", RecipeSetRequest{Colors: colors("#ff0000", 10, "00ff00", 11)}, nil},
+		{"on a machine", RecipeSetRequest{LaserModelID: machine(7), Colors: colors("#ff0000", 10)}, nil},
+		{"blank name", RecipeSetRequest{Name: " ", Colors: colors("#ff0000", 10)}, []string{"name"}},
+		{"unknown laser type", RecipeSetRequest{LaserType: "Plasma", Colors: colors("#ff0000", 10)}, []string{"laserType", "colors[0].settingId"}},
+		{"unknown machine", RecipeSetRequest{LaserModelID: machine(9), Colors: colors("#ff0000", 10)}, []string{"laserModelId", "colors[0].settingId"}},
+		{"machine of another wattage", RecipeSetRequest{LaserModelID: machine(8), Colors: colors("#ff0000", 10)},
+			[]string{"laserModelId", "colors[0].settingId"}},
+		{"no colors", RecipeSetRequest{}, []string{"colors"}},
+		{"bad hex", RecipeSetRequest{Colors: colors("red", 10, "#ff00", 11)}, []string{"colors[0].hex", "colors[1].hex"}},
+		{"duplicate hex", RecipeSetRequest{Colors: colors("#ff0000", 10, "FF0000", 11)}, []string{"colors[1].hex"}},
+		{"long name", RecipeSetRequest{Colors: []RecipeColorRequest{{Hex: "#ff0000", SettingID: 10, Name: strings.Repeat("x", 51)}}},
+			[]string{"colors[0].name"}},
+		{"missing setting", RecipeSetRequest{Colors: colors("#ff0000", 99)}, []string{"colors[0].settingId"}},
+		{"duplicate setting", RecipeSetRequest{Colors: colors("#ff0000", 10, "#00ff00", 10)}, []string{"colors[1].settingId"}},
+		{"another user's setting", RecipeSetRequest{Colors: colors("#ff0000", 20)}, []string{"colors[0].settingId"}},
+		{"another material", RecipeSetRequest{Colors: colors("#ff0000", 12)}, []string{"colors[0].settingId"}},
+		{"another wattage", RecipeSetRequest{Colors: colors("#ff0000", 13)}, []string{"colors[0].settingId"}},
+		{"another machine", RecipeSetRequest{LaserModelID: machine(7), Colors: colors("#ff0000", 11)}, []string{"colors[0].settingId"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			useFakeDB(t, newRecipeDB())
+			req := tc.req
+			if req.Name == "" {
+				req.Name = "Anodized colors"
+			}
+			if req.LaserType == "" {
+				req.LaserType = "Fiber"
+			}
+			req.MaterialID, req.Wattage = 3, 20
+
+			_, verr, err := validateRecipeSet(context.Background(), 1, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			if verr != nil {
+				for _, f := range verr.Fields {
+					got = append(got, f.Field)
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tc.fields, " ") {
+				t.Errorf("errors on %v, want %v", got, tc.fields)
+			}
+		})
+	}
+}
+
+func TestValidateRecipeSetNormalizesColors(t *testing.T) {
+	useFakeDB(t, newRecipeDB())
+	req := RecipeSetRequest{
+		Name: "Anodized colors", MaterialID: 3, LaserType: "Fiber", Wattage: 20,
+		Colors: []RecipeColorRequest{{Hex: " ab12ef ", SettingID: 11, Name: " Blue "}, {Hex: "#00ff00", SettingID: 10}},
+	}
+	values, verr, err := validateRecipeSet(context.Background(), 1, req)
+	if err != nil || verr != nil {
+		t.Fatalf("%v %v", verr, err)
+	}
+	want := []db.CreateRecipeSetColorParams{
+		{SettingID: 11, Position: 0, ColorName: validString("Blue"), Hex: "#AB12EF"},
+		{SettingID: 10, Position: 1, Hex: "#00FF00"},
+	}
+	if len(values.colors) != len(want) {
+		t.Fatalf("colors = %+v", values.colors)
+	}
+	for i := range want {
+		if values.colors[i] != want[i] {
+			t.Errorf("color %d = %+v, want %+v", i, values.colors[i], want[i])
+		}
+	}
+}
+
+func TestRecipeSetMaterial(t *testing.T) {
+	set := db.GetRecipeSetRow{
+		ID: 1, Name: "Anodized colors", LaserType: db.RecipeSetsLaserTypeFiber, Wattage: 20,
+		MaterialName: "Stainless Steel", LaserModelName: validString("G2"), ManufacturerName: validString("Gweike"),
+	}
+	settings := map[int32]db.GetSettingsByIDsRow{}
+	for id, s := range recipeSettings() {
+		settings[int32(id)] = s
+	}
+	colors := []db.GetRecipeSetColorsRow{
+		{SettingID: 12, Hex: "#FF0000"},
+		{SettingID: 10, Hex: "#00FF00", ColorName: validString("Green")},
+		{SettingID: 11, Hex: "#0000FF"},
+	}
+
+	material, err := recipeSetMaterial(set, colors, settings, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const name = "Stainless Steel - Anodized colors (Gweike G2)"
+	if material.Name != name {
+		t.Errorf("material %q, want %q", material.Name, name)
+	}
+	want := []struct{ desc, speed string }{
+		{"#FF0000", "120"},
+		{"Green #00FF00", "100"},
+		{"#0000FF", "110"},
+	}
+	if len(material.Entries) != len(want) {
+		t.Fatalf("%d entries, want %d", len(material.Entries), len(want))
+	}
+	for i, w := range want {
+		e := material.Entries[i]
+		if e.Desc != w.desc || e.NoThickTitle != recipeSetNoThickTitle {
+			t.Errorf("entry %d titled %q/%q, want %q/%q", i, e.NoThickTitle, e.Desc, recipeSetNoThickTitle, w.desc)
+		}
+		if link := name + "/Colors/" + w.desc; e.CutSetting.LinkPath == nil || e.CutSetting.LinkPath.Value != link {
+			t.Errorf("entry %d links to %v, want %q", i, e.CutSetting.LinkPath, link)
+		}
+		if e.CutSetting.Speed == nil || e.CutSetting.Speed.Value != w.speed {
+			t.Errorf("entry %d has the speed of another setting: %v", i, e.CutSetting.Speed)
+		}
+	}
+
+	set.LaserModelName, set.ManufacturerName = sql.NullString{}, sql.NullString{}
+	if material, _ := recipeSetMaterial(set, colors[:1], settings, nil); material.Name != "Stainless Steel - Anodized colors (Fiber 20W)" {
+		t.Errorf("set without a machine exported as %q", material.Name)
+	}
+	if _, err := recipeSetMaterial(set, []db.GetRecipeSetColorsRow{{SettingID: 99, Hex: "#FFFFFF"}}, settings, nil); err == nil {
+		t.Errorf("missing setting exported")
+	}
+}
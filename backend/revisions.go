@@ -0,0 +1,439 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"laserscribe/backend/db"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// SETTING REVISIONS
+// =====================
+
+// Revisions are numbered per setting from 1. A setting that has never been
+// edited has no setting_revisions rows: its values are revision 1, and that
+// revision is written out from the stored values on the first change.
+
+// errRevisionConflict is returned when another change claimed the next
+// revision number first
+var errRevisionConflict = errors.New("the setting was changed by another request, reload and try again")
+
+// settingSnapshot holds the editable values of a setting keyed by their
+// API field names (maxPower, scanInterval, ...), with NULL as nil
+type settingSnapshot map[string]interface{}
+
+// revisionField is one editable value captured in a snapshot
+type revisionField struct {
+	name string // Go field name, shared by GetSettingValuesRow and UpdateSettingParams
+	key  string // API field name
+}
+
+// revisionFields lists the captured values in column order
+var revisionFields = func() []revisionField {
+	t := reflect.TypeOf(db.GetSettingValuesRow{})
+	fields := make([]revisionField, t.NumField())
+	for i := range fields {
+		name := t.Field(i).Name
+		fields[i] = revisionField{name: name, key: strings.ToLower(name[:1]) + name[1:]}
+	}
+	return fields
+}()
+
+// snapshotSetting captures the current values of a setting
+func snapshotSetting(ctx context.Context, q *db.Queries, settingID int32) (settingSnapshot, error) {
+	values, err := q.GetSettingValues(ctx, settingID)
+	if err != nil {
+		return nil, err
+	}
+	return newSnapshot(values), nil
+}
+
+// newSnapshot captures stored values
+func newSnapshot(values db.GetSettingValuesRow) settingSnapshot {
+	v := reflect.ValueOf(values)
+	snapshot := settingSnapshot{}
+	for _, f := range revisionFields {
+		field := v.FieldByName(f.name).Interface()
+		if valuer, ok := field.(driver.Valuer); ok {
+			field, _ = valuer.Value()
+		}
+		snapshot[f.key] = field
+	}
+	return snapshot
+}
+
+// parseSnapshot decodes a stored snapshot
+func parseSnapshot(s string) (settingSnapshot, error) {
+	var snapshot settingSnapshot
+	if err := json.Unmarshal([]byte(s), &snapshot); err != nil {
+		return nil, fmt.Errorf("invalid revision snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// encode renders the snapshot for storage. Keys are sorted, so equal
+// snapshots encode identically.
+func (s settingSnapshot) encode() (string, error) {
+	data, err := json.Marshal(s)
+	return string(data), err
+}
+
+// updateParams turns a snapshot back into the update that restores it.
+// Values may be as stored or as decoded from JSON.
+func (s settingSnapshot) updateParams(settingID, userID int32) (db.UpdateSettingParams, error) {
+	params := db.UpdateSettingParams{ID: settingID, UserID: userID}
+	v := reflect.ValueOf(&params).Elem()
+	for _, f := range revisionFields {
+		field := v.FieldByName(f.name)
+		value := s[f.key]
+		if scanner, ok := field.Addr().Interface().(sql.Scanner); ok {
+			if err := scanner.Scan(value); err != nil {
+				return params, fmt.Errorf("%s: %w", f.key, err)
+			}
+			continue
+		}
+		switch x := value.(type) {
+		case string:
+			if field.Kind() != reflect.String {
+				return params, fmt.Errorf("%s: unexpected value %q", f.key, x)
+			}
+			field.SetString(x)
+		case bool:
+			if field.Kind() != reflect.Bool {
+				return params, fmt.Errorf("%s: unexpected value %v", f.key, x)
+			}
+			field.SetBool(x)
+		case float64, int32, int64:
+			n := reflect.ValueOf(x).Convert(reflect.TypeOf(float64(0))).Float()
+			switch field.Kind() {
+			case reflect.Int32:
+				field.SetInt(int64(n))
+			case reflect.String:
+				field.SetString(strconv.FormatFloat(n, 'f', -1, 64))
+			default:
+				return params, fmt.Errorf("%s: unexpected value %v", f.key, x)
+			}
+		default:
+			return params, fmt.Errorf("%s: missing value", f.key)
+		}
+	}
+	return params, nil
+}
+
+// FieldChange is one value that differs between two revisions
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// diffSnapshots lists the values that changed from one snapshot to the next
+func diffSnapshots(from, to settingSnapshot) []FieldChange {
+	changes := []FieldChange{}
+	for _, f := range revisionFields {
+		a, _ := json.Marshal(from[f.key])
+		b, _ := json.Marshal(to[f.key])
+		if string(a) != string(b) {
+			changes = append(changes, FieldChange{Field: f.key, From: from[f.key], To: to[f.key]})
+		}
+	}
+	return changes
+}
+
+// currentRevision is the revision number a setting's values are at
+func currentRevision(ctx context.Context, q *db.Queries, settingID int32) (int32, error) {
+	latest, err := q.GetLatestSettingRevision(ctx, settingID)
+	if err != nil {
+		return 0, err
+	}
+	if latest == 0 {
+		return 1, nil
+	}
+	return int32(latest), nil
+}
+
+// saveSettingRevision applies an update and records it as a new revision in
+// one transaction. An update that changes no values records nothing; the
+// returned revision is the one the setting is at afterwards.
+func saveSettingRevision(ctx context.Context, editorID int32, params db.UpdateSettingParams, action db.SettingRevisionsAction, revertedFrom sql.NullInt32) (int32, bool, error) {
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	latest, err := qtx.GetLatestSettingRevision(ctx, params.ID)
+	if err != nil {
+		return 0, false, err
+	}
+	var previous settingSnapshot
+	if latest == 0 {
+		// First change: record the values as they stood as revision 1
+		previous, err = snapshotSetting(ctx, qtx, params.ID)
+		if err != nil {
+			return 0, false, err
+		}
+		encoded, err := previous.encode()
+		if err != nil {
+			return 0, false, err
+		}
+		err = qtx.CreateBaselineSettingRevision(ctx, db.CreateBaselineSettingRevisionParams{Snapshot: encoded, ID: params.ID})
+		if err != nil {
+			return 0, false, revisionError(err)
+		}
+		latest = 1
+	} else {
+		rev, err := qtx.GetSettingRevision(ctx, db.GetSettingRevisionParams{SettingID: params.ID, Revision: int32(latest)})
+		if err != nil {
+			return 0, false, err
+		}
+		if previous, err = parseSnapshot(rev.Snapshot); err != nil {
+			return 0, false, err
+		}
+	}
+
+	if err := qtx.UpdateSetting(ctx, params); err != nil {
+		return 0, false, err
+	}
+	next, err := snapshotSetting(ctx, qtx, params.ID)
+	if err != nil {
+		return 0, false, err
+	}
+	encoded, err := next.encode()
+	if err != nil {
+		return 0, false, err
+	}
+	if previousEncoded, _ := previous.encode(); encoded == previousEncoded {
+		return int32(latest), false, tx.Commit()
+	}
+
+	revision := int32(latest) + 1
+	err = qtx.CreateSettingRevision(ctx, db.CreateSettingRevisionParams{
+		SettingID:    params.ID,
+		Revision:     revision,
+		UserID:       sql.NullInt32{Int32: editorID, Valid: true},
+		Action:       action,
+		RevertedFrom: revertedFrom,
+		Snapshot:     encoded,
+	})
+	if err != nil {
+		return 0, false, revisionError(err)
+	}
+	return revision, true, tx.Commit()
+}
+
+// revisionError reports a lost race for a revision number as a conflict
+func revisionError(err error) error {
+	if strings.Contains(err.Error(), "Duplicate") {
+		return errRevisionConflict
+	}
+	return err
+}
+
+// settingWriteError reports a failed update or revert
+func settingWriteError(c *gin.Context, err error) {
+	if errors.Is(err, errRevisionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.Contains(err.Error(), "Duplicate") {
+		c.JSON(http.StatusConflict, gin.H{"error": "you already have a setting for this material/operation/laser combination"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// getSettingHistoryHandler lists every revision of a setting, newest first,
+// with the values changed from the revision before and the votes cast on it
+func getSettingHistoryHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid setting id"})
+		return
+	}
+	setting, err := queries.GetSettingByID(ctx, int32(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "setting not found"})
+		return
+	}
+
+	rows, err := queries.GetSettingRevisions(ctx, setting.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(rows) == 0 {
+		// Never edited: the stored values are revision 1
+		snapshot, err := snapshotSetting(ctx, queries, setting.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		encoded, err := snapshot.encode()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		rows = []db.GetSettingRevisionsRow{{
+			SettingID:   setting.ID,
+			Revision:    1,
+			UserID:      sql.NullInt32{Int32: setting.UserID, Valid: true},
+			Action:      db.SettingRevisionsActionCreate,
+			Snapshot:    encoded,
+			CreatedAt:   setting.UpdatedAt,
+			DisplayName: setting.DisplayName,
+			FirstName:   sql.NullString{String: setting.FirstName, Valid: true},
+			LastName:    sql.NullString{String: setting.LastName, Valid: true},
+		}}
+	}
+
+	voteRows, err := queries.GetVotesByRevision(ctx, setting.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	votes := make(map[int32]db.GetVotesByRevisionRow)
+	var unversioned db.GetVotesByRevisionRow
+	for _, v := range voteRows {
+		if v.Revision.Valid {
+			votes[v.Revision.Int32] = v
+		} else {
+			unversioned = v
+		}
+	}
+
+	snapshots := make([]settingSnapshot, len(rows))
+	for i, r := range rows {
+		if snapshots[i], err = parseSnapshot(r.Snapshot); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	revisions := make([]gin.H, 0, len(rows))
+	for i, r := range rows {
+		changes := []FieldChange{}
+		if i+1 < len(rows) {
+			changes = diffSnapshots(snapshots[i+1], snapshots[i])
+		}
+		var revertedFrom *int32
+		if r.RevertedFrom.Valid {
+			revertedFrom = &r.RevertedFrom.Int32
+		}
+		var userID *int32
+		author := ""
+		if r.UserID.Valid {
+			userID = &r.UserID.Int32
+			author = authorName(r.DisplayName, r.FirstName.String, r.LastName.String)
+		}
+		createdAt := ""
+		if r.CreatedAt.Valid {
+			createdAt = r.CreatedAt.Time.Format(time.RFC3339)
+		}
+		revisions = append(revisions, gin.H{
+			"revision":     r.Revision,
+			"action":       r.Action,
+			"revertedFrom": revertedFrom,
+			"userId":       userID,
+			"author":       author,
+			"createdAt":    createdAt,
+			"upvotes":      votes[r.Revision].Upvotes,
+			"downvotes":    votes[r.Revision].Downvotes,
+			"values":       snapshots[i],
+			"changes":      changes,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settingId":       setting.ID,
+		"currentRevision": rows[0].Revision,
+		"revisions":       revisions,
+		// Votes cast before history was kept can't be tied to a revision
+		"unversionedVotes": gin.H{"upvotes": unversioned.Upvotes, "downvotes": unversioned.Downvotes},
+	})
+}
+
+type RevertSettingRequest struct {
+	Revision int32 `json:"revision" binding:"required"`
+}
+
+// revertSettingHandler restores the values of an earlier revision. The
+// revert is itself a new revision, so history is never rewritten.
+func revertSettingHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	settingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid setting id"})
+		return
+	}
+
+	// Verify ownership
+	setting, err := queries.GetSettingByID(ctx, int32(settingID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "setting not found"})
+		return
+	}
+	if setting.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you can only revert your own settings"})
+		return
+	}
+
+	var req RevertSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	target, err := queries.GetSettingRevision(ctx, db.GetSettingRevisionParams{SettingID: setting.ID, Revision: req.Revision})
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("revision %d not found", req.Revision)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	snapshot, err := parseSnapshot(target.Snapshot)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	params, err := snapshot.updateParams(setting.ID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// Old revisions predate some of the current rules, so they are checked
+	// like any other update
+	if verr := validateSetting(setting.LaserType, setting.Wattage, setting.OperationType, updateValues(params)); verr != nil {
+		validationFailed(c, verr)
+		return
+	}
+
+	revision, changed, err := saveSettingRevision(ctx, userID, params, db.SettingRevisionsActionRevert, sql.NullInt32{Int32: target.Revision, Valid: true})
+	if err != nil {
+		settingWriteError(c, err)
+		return
+	}
+	if !changed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("the setting already has the values of revision %d", req.Revision)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "reverted", "revision": revision})
+}
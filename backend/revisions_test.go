@@ -0,0 +1,114 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"laserscribe/backend/db"
+	"reflect"
+	"testing"
+)
+
+func TestRevisionFieldsMatchUpdateParams(t *testing.T) {
+	values := reflect.TypeOf(db.GetSettingValuesRow{})
+	update := reflect.TypeOf(db.UpdateSettingParams{})
+	for i := 0; i < values.NumField(); i++ {
+		f := values.Field(i)
+		u, ok := update.FieldByName(f.Name)
+		if !ok {
+			t.Errorf("%s is kept in revisions but can't be updated", f.Name)
+		} else if u.Type != f.Type {
+			t.Errorf("%s is a %s in revisions but a %s in updates", f.Name, f.Type, u.Type)
+		}
+	}
+	for i := 0; i < update.NumField(); i++ {
+		name := update.Field(i).Name
+		if name == "ID" || name == "UserID" {
+			continue
+		}
+		if _, ok := values.FieldByName(name); !ok {
+			t.Errorf("%s can be updated but isn't kept in revisions", name)
+		}
+	}
+}
+
+// testSettingValues sets every field, NULLs included, to a distinct value
+func testSettingValues(t *testing.T) db.GetSettingValuesRow {
+	var values db.GetSettingValuesRow
+	v := reflect.ValueOf(&values).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		switch f := v.Field(i).Addr().Interface().(type) {
+		case *string:
+			*f = fmt.Sprintf("%d.5", i)
+		case *int32:
+			*f = int32(i)
+		case *bool:
+			*f = i%2 == 0
+		case *sql.NullString:
+			*f = sql.NullString{String: fmt.Sprintf("%d.25", i), Valid: true}
+		case *sql.NullInt32:
+			*f = sql.NullInt32{Int32: int32(i), Valid: true}
+		case *sql.NullBool:
+			*f = sql.NullBool{Bool: i%2 == 1, Valid: true}
+		default:
+			t.Fatalf("no test value for %s", v.Type().Field(i).Name)
+		}
+	}
+	return values
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	cases := map[string]db.GetSettingValuesRow{
+		"every value set": testSettingValues(t),
+		"NULLs":           {MaxPower: "80", MinPower: "0", Speed: "1000", NumPasses: 1},
+	}
+	for name, values := range cases {
+		snapshot := newSnapshot(values)
+		stored, err := snapshot.encode()
+		if err != nil {
+			t.Fatalf("%s: encode: %v", name, err)
+		}
+		decoded, err := parseSnapshot(stored)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", name, err)
+		}
+
+		// Both a fresh snapshot and one read back from a revision restore
+		// the values they were taken from
+		for from, s := range map[string]settingSnapshot{"snapshot": snapshot, "stored snapshot": decoded} {
+			params, err := s.updateParams(3, 4)
+			if err != nil {
+				t.Fatalf("%s: %s: %v", name, from, err)
+			}
+			if params.ID != 3 || params.UserID != 4 {
+				t.Errorf("%s: %s updates setting %d of user %d", name, from, params.ID, params.UserID)
+			}
+			got := reflect.ValueOf(params)
+			want := reflect.ValueOf(values)
+			for _, f := range revisionFields {
+				if g, w := got.FieldByName(f.name).Interface(), want.FieldByName(f.name).Interface(); g != w {
+					t.Errorf("%s: %s restores %s as %v, want %v", name, from, f.key, g, w)
+				}
+			}
+		}
+	}
+}
+
+func TestSnapshotUpdateParamsRejects(t *testing.T) {
+	base := newSnapshot(testSettingValues(t))
+	cases := map[string]interface{}{
+		"speed":     true,
+		"numPasses": "many",
+		"bidir":     "yes",
+		"maxPower":  nil,
+	}
+	for key, value := range cases {
+		s := settingSnapshot{}
+		for k, v := range base {
+			s[k] = v
+		}
+		s[key] = value
+		if _, err := s.updateParams(1, 1); err == nil {
+			t.Errorf("%s = %v accepted", key, value)
+		}
+	}
+}
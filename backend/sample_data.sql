@@ -103,6 +103,21 @@ INSERT INTO material_aliases (material_id, alias) VALUES
 INSERT INTO users (username, email, password_hash, display_name) VALUES
 ('demo', 'demo@laserscribe.io', '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', 'Demo User');
 
+-- Sample laser machines
+INSERT INTO laser_manufacturers (name, slug) VALUES
+('xTool', 'xtool'),
+('Glowforge', 'glowforge'),
+('OMTech', 'omtech'),
+('Gweike', 'gweike');
+
+INSERT INTO laser_models (manufacturer_id, name, slug, laser_type, wattage, source, lens_mm, field_size_mm, motion_system) VALUES
+(1, 'D1 Pro', 'd1-pro', 'Diode', 10, NULL, NULL, NULL, 'Gantry'),
+(2, 'Pro', 'pro', 'CO2', 45, 'Glass tube', NULL, NULL, 'Gantry'),
+(3, 'Polar', 'polar', 'CO2', 50, 'Glass tube', 50.80, NULL, 'Gantry'),
+(3, 'AF2436-60', 'af2436-60', 'CO2', 60, 'Glass tube', 50.80, NULL, 'Gantry'),
+(4, 'G2', 'g2', 'Fiber', 20, 'Raycus', 210.00, 150.00, 'Galvo'),
+(4, 'G2', 'g2', 'Fiber', 30, 'Raycus', 210.00, 150.00, 'Galvo');
+
 -- Sample settings: xTool D1 Pro 10W (Diode)
 INSERT INTO settings (user_id, material_id, laser_type, wattage, operation_type, max_power, speed, num_passes, notes) VALUES
 (1, 1, 'Diode', 10, 'Cut', 100, 3, 2, 'Clean cut through 3mm birch. Two passes recommended for consistent results.'),
@@ -126,12 +141,18 @@ INSERT INTO settings (user_id, material_id, laser_type, wattage, operation_type,
 
 -- Sample settings: Gweike G2 20W (Fiber) — from Gweike default library
 INSERT INTO settings (user_id, material_id, laser_type, wattage, operation_type, max_power, speed, frequency, scan_interval, cross_hatch, angle, angle_per_pass, notes) VALUES
-(1, 23, 'Fiber', 20, 'Cut', 70, 1000, 50000, NULL, FALSE, NULL, NULL, 'Gweike 20W default - Stainless Steel line'),
-(1, 23, 'Fiber', 20, 'Scan', 75, 1000, 50000, 0.03, TRUE, 25, 15, 'Gweike 20W default - Stainless Steel fill'),
-(1, 24, 'Fiber', 20, 'Cut', 90, 1000, 50000, NULL, FALSE, NULL, NULL, 'Gweike 20W default - Brass line'),
-(1, 24, 'Fiber', 20, 'Scan', 90, 800, 50000, 0.03, TRUE, 25, 15, 'Gweike 20W default - Brass fill'),
-(1, 26, 'Fiber', 20, 'Cut', 90, 800, 50000, NULL, FALSE, NULL, NULL, 'Gweike 20W default - Aluminum Sheet line'),
-(1, 26, 'Fiber', 20, 'Scan', 75, 1000, 50000, 0.03, TRUE, 25, 15, 'Gweike 20W default - Aluminum Sheet fill');
+(1, 23, 'Fiber', 20, 'Cut', 70, 1000, 50, NULL, FALSE, NULL, NULL, 'Gweike 20W default - Stainless Steel line'),
+(1, 23, 'Fiber', 20, 'Scan', 75, 1000, 50, 0.03, TRUE, 25, 15, 'Gweike 20W default - Stainless Steel fill'),
+(1, 24, 'Fiber', 20, 'Cut', 90, 1000, 50, NULL, FALSE, NULL, NULL, 'Gweike 20W default - Brass line'),
+(1, 24, 'Fiber', 20, 'Scan', 90, 800, 50, 0.03, TRUE, 25, 15, 'Gweike 20W default - Brass fill'),
+(1, 26, 'Fiber', 20, 'Cut', 90, 800, 50, NULL, FALSE, NULL, NULL, 'Gweike 20W default - Aluminum Sheet line'),
+(1, 26, 'Fiber', 20, 'Scan', 75, 1000, 50, 0.03, TRUE, 25, 15, 'Gweike 20W default - Aluminum Sheet fill');
+
+-- Link sample settings to their machines
+UPDATE settings SET laser_model_id = 1 WHERE laser_type = 'Diode' AND wattage = 10;
+UPDATE settings SET laser_model_id = 2 WHERE laser_type = 'CO2' AND wattage = 45;
+UPDATE settings SET laser_model_id = 4 WHERE laser_type = 'CO2' AND wattage = 60;
+UPDATE settings SET laser_model_id = 5 WHERE laser_type = 'Fiber' AND wattage = 20;
 
 -- Sample votes
 INSERT INTO votes (user_id, setting_id, value) VALUES
@@ -15,6 +15,10 @@ CREATE TABLE users (
     is_admin BOOLEAN NOT NULL DEFAULT FALSE,
     verification_token VARCHAR(64),
     verification_expires TIMESTAMP NULL,
+    -- Units the user reads and enters speeds and line intervals in; stored
+    -- values are always mm/s and mm
+    speed_unit VARCHAR(10) NOT NULL DEFAULT 'mm/s',
+    interval_unit VARCHAR(10) NOT NULL DEFAULT 'mm',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -47,6 +51,108 @@ CREATE TABLE material_aliases (
     FOREIGN KEY (material_id) REFERENCES materials(id) ON DELETE CASCADE
 );
 
+-- =============================================================================
+-- TAXONOMY AUDIT
+--
+-- One row per admin change to categories, materials or aliases. details
+-- holds a JSON object describing the change, e.g. the old and new name or,
+-- for a merge, the source material and how many settings and votes moved.
+-- =============================================================================
+CREATE TABLE taxonomy_audit (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    admin_user_id INT,
+    action VARCHAR(50) NOT NULL,
+    entity_type ENUM('category', 'material', 'alias') NOT NULL,
+    entity_id INT NOT NULL,
+    details TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (admin_user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- =============================================================================
+-- LASER MACHINES
+--
+-- Catalog of laser manufacturers and models so settings can reference a
+-- specific machine instead of a free-text make/model string. A model is one
+-- laser type + wattage combination; the same model name sold in several
+-- wattages gets one row per wattage.
+--
+-- source:        laser source or tube, e.g. "JPT MOPA M7", "RECI W2"
+-- lens_mm:       focal length of the lens (galvo field lens or gantry lens)
+-- field_size_mm: galvo working field edge length, NULL for gantry machines
+-- =============================================================================
+CREATE TABLE laser_manufacturers (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE laser_models (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    manufacturer_id INT NOT NULL,
+    name VARCHAR(150) NOT NULL,
+    slug VARCHAR(150) NOT NULL,
+    laser_type ENUM('CO2', 'Fiber', 'Diode', 'UV', 'Infrared') NOT NULL,
+    wattage INT NOT NULL,
+    source VARCHAR(100),
+    lens_mm DECIMAL(7,2),
+    field_size_mm DECIMAL(7,2),
+    motion_system ENUM('Galvo', 'Gantry') NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (manufacturer_id) REFERENCES laser_manufacturers(id) ON DELETE CASCADE,
+    UNIQUE KEY uq_laser_model (manufacturer_id, slug, laser_type, wattage)
+);
+
+-- =============================================================================
+-- USER MACHINES
+--
+-- The lasers a user owns ("my machines"). laser_type and wattage are copied
+-- from the catalog model when one is picked, so machines missing from the
+-- catalog can still be registered. The default machine (at most one per user,
+-- enforced by the API) prefills search filters and the .clb import form.
+-- =============================================================================
+CREATE TABLE user_machines (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    laser_model_id INT,
+    make_model VARCHAR(255),
+    laser_type ENUM('CO2', 'Fiber', 'Diode', 'UV', 'Infrared') NOT NULL,
+    wattage INT NOT NULL,
+    lens_mm DECIMAL(7,2),
+    notes TEXT,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (laser_model_id) REFERENCES laser_models(id) ON DELETE SET NULL
+);
+
+-- =============================================================================
+-- IMPORTS
+--
+-- One row per .clb upload. content_hash is the SHA-256 of the uploaded file,
+-- used together with the laser fields to detect re-uploads of the same file.
+-- Settings created by an upload point back here through settings.import_id so
+-- the whole batch can be rolled back. errors holds a JSON array of messages.
+-- =============================================================================
+CREATE TABLE imports (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    filename VARCHAR(255) NOT NULL DEFAULT '',
+    content_hash CHAR(64) NOT NULL,
+    laser_make_model VARCHAR(200) NOT NULL,
+    laser_type ENUM('CO2', 'Fiber', 'Diode', 'UV', 'Infrared') NOT NULL,
+    wattage INT NOT NULL,
+    imported_count INT NOT NULL DEFAULT 0,
+    skipped_count INT NOT NULL DEFAULT 0,
+    conflict_count INT NOT NULL DEFAULT 0,
+    failed_count INT NOT NULL DEFAULT 0,
+    errors TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
 -- =============================================================================
 -- SETTINGS
 --
@@ -54,10 +160,18 @@ CREATE TABLE material_aliases (
 -- fields. Decoupled from machine models — laser_type and wattage are stored
 -- directly so .clb imports don't require picking a specific machine.
 --
+-- Derived metrics are generated columns so they can be filtered and sorted on:
+--   effective_watts  W      wattage x max_power
+--   line_energy      J/mm   effective_watts / speed, summed over passes
+--   fluence          J/cm2  line_energy / scan_interval (fill operations only)
+--   pulse_energy     mJ     effective_watts / frequency in kHz (Fiber only)
+--
 -- operation_type maps to LightBurn CutSetting type attribute:
---   'Cut'     = type="Cut"      (Line mode)
---   'Scan'    = type="Scan"     (Fill mode)
---   'ScanCut' = type="Scan+Cut" (Fill+Line mode)
+--   'Cut'        = type="Cut"         (Line mode)
+--   'Scan'       = type="Scan"        (Fill mode)
+--   'ScanCut'    = type="Scan+Cut"    (Fill+Line mode)
+--   'Image'      = type="Image"       (Image mode, dither in image_mode)
+--   'OffsetFill' = type="Offset Fill" (Offset Fill mode)
 -- =============================================================================
 CREATE TABLE settings (
     id INT AUTO_INCREMENT PRIMARY KEY,
@@ -65,13 +179,16 @@ CREATE TABLE settings (
     -- Attribution
     user_id INT NOT NULL,
     material_id INT NOT NULL,
+    import_id INT,
+    forked_from_id INT,
 
     -- Laser identification (decoupled from machine models)
     laser_type ENUM('CO2', 'Fiber', 'Diode', 'UV', 'Infrared') NOT NULL,
     wattage INT NOT NULL,
+    laser_model_id INT,
 
     -- Operation type (maps to CutSetting type attribute)
-    operation_type ENUM('Cut', 'Scan', 'ScanCut') NOT NULL,
+    operation_type ENUM('Cut', 'Scan', 'ScanCut', 'Image', 'OffsetFill') NOT NULL,
 
     -- Core power/speed
     max_power DECIMAL(7,3) NOT NULL,
@@ -97,9 +214,14 @@ CREATE TABLE settings (
     overscan DECIMAL(8,3),
     overscan_percent DECIMAL(7,3),
 
-    -- Fiber / Galvo specific
+    -- Fiber / Galvo specific. frequency is in kHz, as LightBurn writes it;
+    -- pulse_width is the MOPA pulse width in ns (QPulseWidth), and
+    -- wobble_diameter/wobble_step are in mm (wobbleSize/wobbleStep).
     frequency DECIMAL(12,3),
+    pulse_width DECIMAL(8,2),
     wobble_enable BOOLEAN,
+    wobble_diameter DECIMAL(8,4),
+    wobble_step DECIMAL(8,4),
     use_dot_correction BOOLEAN,
     perforation_mode BOOLEAN NOT NULL DEFAULT FALSE,
     enable_dot_width_adjust BOOLEAN NOT NULL DEFAULT FALSE,
@@ -122,6 +244,31 @@ CREATE TABLE settings (
     tab_count INT,
     tab_count_max INT,
 
+    -- Material attributes of the stock the setting was made on. finish
+    -- covers surface treatments and coatings (anodized, painted, powder
+    -- coated); grade is an alloy or quality grade such as 304 or 316.
+    thickness_mm DECIMAL(7,3),
+    color VARCHAR(50),
+    finish VARCHAR(100),
+    grade VARCHAR(50),
+
+    -- "I tested this" reports, kept in step with test_reports: how many
+    -- there are and the confidence score they add up to (NULL with none)
+    test_count INT NOT NULL DEFAULT 0,
+    confidence DECIMAL(5,4),
+
+    -- Derived metrics
+    effective_watts DECIMAL(10,3) GENERATED ALWAYS AS (wattage * max_power / 100) STORED,
+    line_energy DECIMAL(14,6) GENERATED ALWAYS AS (wattage * max_power / 100 / NULLIF(speed, 0) * num_passes) STORED,
+    fluence DECIMAL(14,6) GENERATED ALWAYS AS (
+        CASE WHEN operation_type <> 'Cut' AND scan_interval > 0
+             THEN wattage * max_power / NULLIF(speed, 0) / scan_interval * num_passes END
+    ) STORED,
+    pulse_energy DECIMAL(12,6) GENERATED ALWAYS AS (
+        CASE WHEN laser_type = 'Fiber' AND frequency > 0
+             THEN wattage * max_power / 100 / frequency END
+    ) STORED,
+
     -- User notes (not from CLB)
     notes TEXT,
 
@@ -131,23 +278,154 @@ CREATE TABLE settings (
 
     -- Foreign keys
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-    FOREIGN KEY (material_id) REFERENCES materials(id) ON DELETE CASCADE
+    FOREIGN KEY (material_id) REFERENCES materials(id) ON DELETE CASCADE,
+    FOREIGN KEY (import_id) REFERENCES imports(id) ON DELETE SET NULL,
+    FOREIGN KEY (forked_from_id) REFERENCES settings(id) ON DELETE SET NULL,
+    FOREIGN KEY (laser_model_id) REFERENCES laser_models(id) ON DELETE SET NULL
+);
+
+-- =============================================================================
+-- SETTING SUBLAYERS
+--
+-- Additional passes nested inside a setting's CutSetting, mapped 1:1 to
+-- LightBurn <SubLayer> elements (e.g. the cleanup pass of a 3D engraving).
+-- sublayer_type holds the SubLayer type attribute as written by LightBurn.
+-- =============================================================================
+CREATE TABLE setting_sublayers (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    setting_id INT NOT NULL,
+    sublayer_index INT NOT NULL DEFAULT 0,
+    sublayer_type VARCHAR(20) NOT NULL,
+    is_cleanup BOOLEAN NOT NULL DEFAULT FALSE,
+    max_power DECIMAL(7,3) NOT NULL,
+    min_power DECIMAL(7,3) NOT NULL DEFAULT 0,
+    max_power2 DECIMAL(7,3),
+    min_power2 DECIMAL(7,3),
+    speed DECIMAL(10,3) NOT NULL,
+    num_passes INT NOT NULL DEFAULT 1,
+    frequency DECIMAL(12,3),
+    scan_interval DECIMAL(8,4),
+    subname VARCHAR(200),
+    FOREIGN KEY (setting_id) REFERENCES settings(id) ON DELETE CASCADE
+);
+
+-- =============================================================================
+-- SETTING REVISIONS
+--
+-- Every change to a setting's values, numbered per setting from 1. snapshot
+-- is a JSON object of the editable fields as they stood after the change.
+-- A setting that has never been edited has no rows; its first revision is
+-- recorded from the stored values when it is first changed.
+-- =============================================================================
+CREATE TABLE setting_revisions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    setting_id INT NOT NULL,
+    revision INT NOT NULL,
+    user_id INT,
+    action ENUM('create', 'update', 'revert') NOT NULL,
+    reverted_from INT,
+    snapshot TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (setting_id) REFERENCES settings(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE KEY uq_setting_revision (setting_id, revision)
 );
 
 -- =============================================================================
 -- VOTES
+--
+-- revision is the setting revision the vote was cast on; NULL for votes
+-- that predate revision history.
 -- =============================================================================
 CREATE TABLE votes (
     id INT AUTO_INCREMENT PRIMARY KEY,
     user_id INT NOT NULL,
     setting_id INT NOT NULL,
     value TINYINT NOT NULL CHECK (value IN (-1, 1)),
+    revision INT,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
     FOREIGN KEY (setting_id) REFERENCES settings(id) ON DELETE CASCADE,
     UNIQUE KEY uq_user_setting_vote (user_id, setting_id)
 );
 
+-- =============================================================================
+-- TEST REPORTS
+--
+-- "I tested this" confirmations: one per user and setting, on the machine
+-- the tester ran it on. revision is the setting revision that was tested;
+-- adjusted_values holds a JSON object of the values the tester changed to
+-- make it work, keyed like the setting fields (speed, maxPower, ...).
+-- =============================================================================
+CREATE TABLE test_reports (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    setting_id INT NOT NULL,
+    user_id INT NOT NULL,
+    revision INT NOT NULL,
+    laser_model_id INT,
+    make_model VARCHAR(255),
+    laser_type ENUM('CO2', 'Fiber', 'Diode', 'UV', 'Infrared') NOT NULL,
+    wattage INT NOT NULL,
+    material_batch VARCHAR(200),
+    outcome ENUM('worked', 'adjusted', 'failed') NOT NULL,
+    adjusted_values TEXT,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (setting_id) REFERENCES settings(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (laser_model_id) REFERENCES laser_models(id) ON DELETE SET NULL,
+    UNIQUE KEY uq_user_setting_test (user_id, setting_id)
+);
+
+-- =============================================================================
+-- RECIPE SETS
+--
+-- Palettes of settings tested together on one machine and material, such as
+-- the colors a MOPA fiber laser marks on stainless steel. Each color is a
+-- setting with its target color as a #RRGGBB hex; a set is a draft until
+-- published_at is set, and only published sets can be browsed and voted on.
+-- =============================================================================
+CREATE TABLE recipe_sets (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    material_id INT NOT NULL,
+    laser_type ENUM('CO2', 'Fiber', 'Diode', 'UV', 'Infrared') NOT NULL,
+    wattage INT NOT NULL,
+    laser_model_id INT,
+    name VARCHAR(200) NOT NULL,
+    description TEXT,
+    published_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (material_id) REFERENCES materials(id) ON DELETE CASCADE,
+    FOREIGN KEY (laser_model_id) REFERENCES laser_models(id) ON DELETE SET NULL
+);
+
+CREATE TABLE recipe_set_colors (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    recipe_set_id INT NOT NULL,
+    setting_id INT NOT NULL,
+    position INT NOT NULL,
+    color_name VARCHAR(50),
+    hex CHAR(7) NOT NULL,
+    FOREIGN KEY (recipe_set_id) REFERENCES recipe_sets(id) ON DELETE CASCADE,
+    FOREIGN KEY (setting_id) REFERENCES settings(id) ON DELETE CASCADE,
+    UNIQUE KEY uq_recipe_set_setting (recipe_set_id, setting_id)
+);
+
+CREATE TABLE recipe_set_votes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    recipe_set_id INT NOT NULL,
+    value TINYINT NOT NULL CHECK (value IN (-1, 1)),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (recipe_set_id) REFERENCES recipe_sets(id) ON DELETE CASCADE,
+    UNIQUE KEY uq_user_recipe_set_vote (user_id, recipe_set_id)
+);
+
 -- =============================================================================
 -- INDEXES
 -- =============================================================================
@@ -162,16 +440,64 @@ CREATE INDEX idx_settings_laser_watt ON settings(laser_type, wattage);
 CREATE INDEX idx_settings_user ON settings(user_id);
 CREATE INDEX idx_settings_operation_type ON settings(operation_type);
 
+-- Settings: material attribute filters
+CREATE INDEX idx_settings_material_thickness ON settings(material_id, thickness_mm);
+
 -- Settings: profile page (my settings, newest first)
 CREATE INDEX idx_settings_user_created ON settings(user_id, created_at DESC);
 
+-- Setting sublayers
+CREATE INDEX idx_sublayers_setting ON setting_sublayers(setting_id, sublayer_index);
+
 -- Votes
 CREATE INDEX idx_votes_setting ON votes(setting_id);
 
+-- Test reports: a setting's reports, newest first
+CREATE INDEX idx_test_reports_setting ON test_reports(setting_id, updated_at DESC);
+
+-- Settings: rank by test confidence
+CREATE INDEX idx_settings_confidence ON settings(confidence);
+
+-- Imports: re-upload detection
+CREATE INDEX idx_imports_user_hash ON imports(user_id, content_hash);
+
+-- Settings: "settings for my machine"
+CREATE INDEX idx_settings_laser_model ON settings(laser_model_id);
+
+-- Laser models: autocomplete filtered by laser config
+CREATE INDEX idx_laser_models_type_watt ON laser_models(laser_type, wattage);
+
+-- Settings: derived metric filters and sorts
+CREATE INDEX idx_settings_line_energy ON settings(line_energy);
+CREATE INDEX idx_settings_fluence ON settings(fluence);
+
+-- User machines: profile list and default lookup
+CREATE INDEX idx_user_machines_user ON user_machines(user_id, is_default);
+
+-- Settings: rollback of an import batch
+CREATE INDEX idx_settings_import ON settings(import_id);
+
+-- Settings: forks of a setting
+CREATE INDEX idx_settings_forked_from ON settings(forked_from_id);
+
+-- Recipe sets: browse published sets for a machine and material
+CREATE INDEX idx_recipe_sets_browse ON recipe_sets(laser_type, wattage, material_id, published_at);
+CREATE INDEX idx_recipe_sets_user ON recipe_sets(user_id, updated_at DESC);
+CREATE INDEX idx_recipe_set_votes_set ON recipe_set_votes(recipe_set_id);
+
 -- Materials
 CREATE INDEX idx_materials_category ON materials(category_id);
 CREATE INDEX idx_aliases_material ON material_aliases(material_id);
 
+-- Taxonomy audit: newest changes first
+CREATE INDEX idx_taxonomy_audit_created ON taxonomy_audit(created_at DESC);
+
 -- Full-text search for material name and aliases
 ALTER TABLE materials ADD FULLTEXT INDEX ft_material_name (name);
 ALTER TABLE material_aliases ADD FULLTEXT INDEX ft_alias (alias);
+
+-- Full-text search across the rest of a setting's keyword-searchable text
+ALTER TABLE material_categories ADD FULLTEXT INDEX ft_category_name (name);
+ALTER TABLE settings ADD FULLTEXT INDEX ft_setting_text (notes, layer_name);
+ALTER TABLE laser_models ADD FULLTEXT INDEX ft_laser_model_name (name);
+ALTER TABLE laser_manufacturers ADD FULLTEXT INDEX ft_manufacturer_name (name);
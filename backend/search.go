@@ -0,0 +1,253 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"laserscribe/backend/db"
+	"laserscribe/backend/units"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// SETTINGS SEARCH
+// =====================
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+// searchSortKeys are the accepted ?sort= values. Each maps onto the
+// sort_value column of SearchSettings. The default is relevance for keyword
+// searches and score otherwise.
+var searchSortKeys = map[string]bool{
+	"relevance":       true,
+	"score":           true,
+	"newest":          true,
+	"speed":           true,
+	"power":           true,
+	"effective_watts": true,
+	"line_energy":     true,
+	"fluence":         true,
+	"pulse_energy":    true,
+	"confidence":      true,
+}
+
+// SearchSettingsResponse is one page of settings search results
+type SearchSettingsResponse struct {
+	Settings   []db.SearchSettingsRow `json:"settings"`
+	Total      int64                  `json:"total"`
+	NextCursor string                 `json:"nextCursor,omitempty"`
+}
+
+// applySearchRanges reads the min_/max_ range filters on the stored setting
+// columns. Integer ranges are wattage and passes; speed, power, frequency and
+// pulse width are decimal. Speeds may be given in another unit with
+// speed_unit and frequencies with frequency_unit (kHz when it is left out,
+// or Hz for values only Hz can be). wobble=true or false filters on whether
+// wobble is enabled.
+func applySearchRanges(c *gin.Context, params *db.SearchSettingsParams) error {
+	speedUnit, err := units.ParseSpeedUnit(c.Query("speed_unit"))
+	if err != nil {
+		return err
+	}
+	frequencyUnit, err := units.ParseFrequencyUnit(c.Query("frequency_unit"))
+	if err != nil {
+		return err
+	}
+
+	ints := []struct {
+		name  string
+		field *sql.NullInt32
+	}{
+		{"min_wattage", &params.MinWattage},
+		{"max_wattage", &params.MaxWattage},
+		{"min_passes", &params.MinPasses},
+		{"max_passes", &params.MaxPasses},
+	}
+	for _, r := range ints {
+		v := c.Query(r.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%s must be a whole number", r.name)
+		}
+		*r.field = sql.NullInt32{Int32: int32(n), Valid: true}
+	}
+
+	speed := func(v string) string { return units.ConvertSpeed(v, speedUnit) }
+	frequency := func(v string) string { return units.ConvertFrequency(v, frequencyUnit) }
+	decimals := []struct {
+		name    string
+		field   *sql.NullString
+		convert func(string) string
+	}{
+		{"min_speed", &params.MinSpeed, speed},
+		{"max_speed", &params.MaxSpeed, speed},
+		{"min_power", &params.MinPower, nil},
+		{"max_power", &params.MaxPower, nil},
+		{"min_frequency", &params.MinFrequency, frequency},
+		{"max_frequency", &params.MaxFrequency, frequency},
+		{"min_pulse_width", &params.MinPulseWidth, nil},
+		{"max_pulse_width", &params.MaxPulseWidth, nil},
+	}
+	for _, r := range decimals {
+		v := c.Query(r.name)
+		if v == "" {
+			continue
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return fmt.Errorf("%s must be a number", r.name)
+		}
+		if r.convert != nil {
+			v = r.convert(v)
+		}
+		*r.field = sql.NullString{String: v, Valid: true}
+	}
+
+	if v := c.Query("wobble"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("wobble must be true or false")
+		}
+		params.Wobble = sql.NullBool{Bool: b, Valid: true}
+	}
+	return nil
+}
+
+// applySearchPage reads sort, order, limit and cursor. The cursor is only
+// valid for the sort and order it was issued for.
+func applySearchPage(c *gin.Context, params *db.SearchSettingsParams) error {
+	defaultSort := "score"
+	if params.Keyword.Valid {
+		defaultSort = "relevance"
+	}
+	sortKey := c.DefaultQuery("sort", defaultSort)
+	if !searchSortKeys[sortKey] {
+		return fmt.Errorf("invalid sort: %s", sortKey)
+	}
+	order := strings.ToLower(c.DefaultQuery("order", "desc"))
+	switch order {
+	case "desc":
+		params.SortSign = 1
+	case "asc":
+		params.SortSign = -1
+	default:
+		return fmt.Errorf("order must be asc or desc")
+	}
+	params.SortKey = sortKey
+
+	params.Limit = defaultSearchLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("limit must be a positive number")
+		}
+		if n > maxSearchLimit {
+			n = maxSearchLimit
+		}
+		params.Limit = int32(n)
+	}
+
+	if v := c.Query("cursor"); v != "" {
+		cursorValue, cursorID, err := decodeSearchCursor(v, sortKey, order)
+		if err != nil {
+			return err
+		}
+		params.CursorValue = cursorValue
+		params.CursorID = sql.NullInt32{Int32: cursorID, Valid: true}
+	}
+	return nil
+}
+
+// encodeSearchCursor builds the opaque cursor for the page after row. The
+// stored value is already multiplied by the sort sign, matching the HAVING
+// clause of SearchSettings.
+func encodeSearchCursor(row db.SearchSettingsRow, sortKey, order string) string {
+	value := ""
+	if row.SortValue.Valid {
+		value = row.SortValue.String
+		// Negate textually so DECIMAL precision survives the round trip
+		if order == "asc" {
+			if strings.HasPrefix(value, "-") {
+				value = value[1:]
+			} else {
+				value = "-" + value
+			}
+		}
+	}
+	raw := fmt.Sprintf("%s:%s:%s:%d", sortKey, order, value, row.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSearchCursor(cursor, sortKey, order string) (sql.NullString, int32, error) {
+	invalid := fmt.Errorf("invalid cursor")
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return sql.NullString{}, 0, invalid
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 4 {
+		return sql.NullString{}, 0, invalid
+	}
+	if parts[0] != sortKey || parts[1] != order {
+		return sql.NullString{}, 0, fmt.Errorf("cursor was issued for a different sort")
+	}
+	id, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return sql.NullString{}, 0, invalid
+	}
+	if parts[2] == "" {
+		return sql.NullString{}, int32(id), nil
+	}
+	if _, err := strconv.ParseFloat(parts[2], 64); err != nil {
+		return sql.NullString{}, 0, invalid
+	}
+	return sql.NullString{String: parts[2], Valid: true}, int32(id), nil
+}
+
+// countSearchParams copies the filters of a search, leaving out paging
+func countSearchParams(p db.SearchSettingsParams) db.CountSearchSettingsParams {
+	return db.CountSearchSettingsParams{
+		MaterialID:        p.MaterialID,
+		LaserType:         p.LaserType,
+		Wattage:           p.Wattage,
+		MinWattage:        p.MinWattage,
+		MaxWattage:        p.MaxWattage,
+		OperationType:     p.OperationType,
+		UserID:            p.UserID,
+		LaserModelID:      p.LaserModelID,
+		MinSpeed:          p.MinSpeed,
+		MaxSpeed:          p.MaxSpeed,
+		MinPower:          p.MinPower,
+		MaxPower:          p.MaxPower,
+		MinPasses:         p.MinPasses,
+		MaxPasses:         p.MaxPasses,
+		MinEffectiveWatts: p.MinEffectiveWatts,
+		MaxEffectiveWatts: p.MaxEffectiveWatts,
+		MinLineEnergy:     p.MinLineEnergy,
+		MaxLineEnergy:     p.MaxLineEnergy,
+		MinFluence:        p.MinFluence,
+		MaxFluence:        p.MaxFluence,
+		MinPulseEnergy:    p.MinPulseEnergy,
+		MaxPulseEnergy:    p.MaxPulseEnergy,
+		MinFrequency:      p.MinFrequency,
+		MaxFrequency:      p.MaxFrequency,
+		MinPulseWidth:     p.MinPulseWidth,
+		MaxPulseWidth:     p.MaxPulseWidth,
+		Wobble:            p.Wobble,
+		MinThickness:      p.MinThickness,
+		MaxThickness:      p.MaxThickness,
+		Color:             p.Color,
+		Finish:            p.Finish,
+		Grade:             p.Grade,
+		Keyword:           p.Keyword,
+		KeywordQuery:      p.KeywordQuery,
+	}
+}
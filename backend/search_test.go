@@ -0,0 +1,58 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"laserscribe/backend/db"
+	"testing"
+)
+
+func TestSearchCursorRoundTrip(t *testing.T) {
+	cases := []struct {
+		sortKey, order string
+		value          sql.NullString
+		want           sql.NullString
+	}{
+		// Descending cursors keep the value; ascending ones store it negated
+		{"score", "desc", sql.NullString{String: "12", Valid: true}, sql.NullString{String: "12", Valid: true}},
+		{"speed", "asc", sql.NullString{String: "250.50", Valid: true}, sql.NullString{String: "-250.50", Valid: true}},
+		{"speed", "asc", sql.NullString{String: "-3", Valid: true}, sql.NullString{String: "3", Valid: true}},
+		// Rows without a sort value page on id alone
+		{"confidence", "desc", sql.NullString{}, sql.NullString{}},
+	}
+	for _, tc := range cases {
+		row := db.SearchSettingsRow{ID: 42, SortValue: tc.value}
+		cursor := encodeSearchCursor(row, tc.sortKey, tc.order)
+		value, id, err := decodeSearchCursor(cursor, tc.sortKey, tc.order)
+		if err != nil {
+			t.Errorf("%s %s: decode: %v", tc.sortKey, tc.order, err)
+			continue
+		}
+		if value != tc.want || id != 42 {
+			t.Errorf("%s %s: decoded %+v, %d; want %+v, 42", tc.sortKey, tc.order, value, id, tc.want)
+		}
+	}
+}
+
+func TestDecodeSearchCursorRejects(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	valid := encode("speed:desc:100:7")
+	cases := []struct {
+		name, cursor, sortKey, order string
+	}{
+		{"not base64", "!!!", "speed", "desc"},
+		{"wrong part count", encode("speed:desc:7"), "speed", "desc"},
+		{"other sort", valid, "score", "desc"},
+		{"other order", valid, "speed", "asc"},
+		{"bad id", encode("speed:desc:100:x"), "speed", "desc"},
+		{"bad value", encode("speed:desc:fast:7"), "speed", "desc"},
+	}
+	for _, tc := range cases {
+		if _, _, err := decodeSearchCursor(tc.cursor, tc.sortKey, tc.order); err == nil {
+			t.Errorf("%s: cursor accepted", tc.name)
+		}
+	}
+	if _, id, err := decodeSearchCursor(valid, "speed", "desc"); err != nil || id != 7 {
+		t.Errorf("valid cursor = %d, %v", id, err)
+	}
+}
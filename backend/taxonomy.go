@@ -0,0 +1,729 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"laserscribe/backend/db"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// MATERIAL TAXONOMY (ADMIN)
+// =====================
+
+// Every change below is written together with a taxonomy_audit row in one
+// transaction, so the audit log never disagrees with the catalog.
+
+// Audit actions
+const (
+	auditCategoryCreate       = "category.create"
+	auditCategoryRename       = "category.rename"
+	auditCategoryDelete       = "category.delete"
+	auditMaterialCreate       = "material.create"
+	auditMaterialUpdate       = "material.update"
+	auditMaterialRecategorize = "material.recategorize"
+	auditMaterialDelete       = "material.delete"
+	auditMaterialMerge        = "material.merge"
+	auditAliasCreate          = "alias.create"
+	auditAliasDelete          = "alias.delete"
+)
+
+// materialSlug derives the unique URL slug of a material from its name
+func materialSlug(name string) string {
+	slug := strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+	return strings.ReplaceAll(slug, "/", "-")
+}
+
+// taxonomyChange runs fn in a transaction and records its audit entry. fn
+// returns the id of the entity it changed and the details to log. The
+// material matcher reloads the catalog once the change is committed.
+func taxonomyChange(c *gin.Context, action string, entityType db.TaxonomyAuditEntityType, fn func(q *db.Queries) (int32, gin.H, error)) (int32, error) {
+	ctx := c.Request.Context()
+	userIDVal, _ := c.Get("user_id")
+	adminID := userIDVal.(int32)
+
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	entityID, details, err := fn(qtx)
+	if err != nil {
+		return 0, err
+	}
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return 0, err
+	}
+	err = qtx.CreateTaxonomyAudit(ctx, db.CreateTaxonomyAuditParams{
+		AdminUserID: sql.NullInt32{Int32: adminID, Valid: true},
+		Action:      action,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Details:     sql.NullString{String: string(detailsJSON), Valid: true},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	invalidateMaterialCatalog()
+	return entityID, nil
+}
+
+// taxonomyError reports a failed change, turning unique key violations into
+// a conflict
+func taxonomyError(c *gin.Context, err error, conflict string) {
+	if strings.Contains(err.Error(), "Duplicate") {
+		c.JSON(http.StatusConflict, gin.H{"error": conflict})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// lookupCategory answers 400 and returns false when the category is unknown
+func lookupCategory(c *gin.Context, id int32) (db.MaterialCategory, bool) {
+	category, err := queries.GetCategoryByID(c.Request.Context(), id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown category %d", id)})
+		return category, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return category, false
+	}
+	return category, true
+}
+
+// lookupMaterial answers 404 and returns false when the material is unknown
+func lookupMaterial(c *gin.Context, param string) (db.GetMaterialByIDRow, bool) {
+	id, err := strconv.Atoi(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid material id"})
+		return db.GetMaterialByIDRow{}, false
+	}
+	material, err := queries.GetMaterialByID(c.Request.Context(), int32(id))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "material not found"})
+		return material, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return material, false
+	}
+	return material, true
+}
+
+// ---------------------
+// Categories
+// ---------------------
+
+type CategoryRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func adminCategoriesHandler(c *gin.Context) {
+	categories, err := queries.GetCategoriesAdmin(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]map[string]interface{}, len(categories))
+	for i, category := range categories {
+		response[i] = map[string]interface{}{
+			"id":            category.ID,
+			"name":          category.Name,
+			"materialCount": category.MaterialCount,
+			"isDefault":     category.ID == defaultMaterialCategoryID,
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func adminCreateCategoryHandler(c *gin.Context) {
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	id, err := taxonomyChange(c, auditCategoryCreate, db.TaxonomyAuditEntityTypeCategory, func(q *db.Queries) (int32, gin.H, error) {
+		result, err := q.CreateCategory(c.Request.Context(), name)
+		if err != nil {
+			return 0, nil, err
+		}
+		id, err := result.LastInsertId()
+		return int32(id), gin.H{"name": name}, err
+	})
+	if err != nil {
+		taxonomyError(c, err, "a category with this name already exists")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func adminRenameCategoryHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		return
+	}
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	category, err := queries.GetCategoryByID(c.Request.Context(), int32(id))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = taxonomyChange(c, auditCategoryRename, db.TaxonomyAuditEntityTypeCategory, func(q *db.Queries) (int32, gin.H, error) {
+		_, err := q.RenameCategory(c.Request.Context(), db.RenameCategoryParams{Name: name, ID: category.ID})
+		return category.ID, gin.H{"from": category.Name, "to": name}, err
+	})
+	if err != nil {
+		taxonomyError(c, err, "a category with this name already exists")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "category renamed"})
+}
+
+// adminDeleteCategoryHandler only deletes empty categories, since deleting a
+// category cascades to its materials and their settings. The default
+// category is kept because new materials are created in it.
+func adminDeleteCategoryHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		return
+	}
+	if id == defaultMaterialCategoryID {
+		c.JSON(http.StatusConflict, gin.H{"error": "the default category cannot be deleted"})
+		return
+	}
+
+	category, err := queries.GetCategoryByID(ctx, int32(id))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	count, err := queries.CountMaterialsInCategory(ctx, category.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("category still has %d materials, recategorize them first", count)})
+		return
+	}
+
+	_, err = taxonomyChange(c, auditCategoryDelete, db.TaxonomyAuditEntityTypeCategory, func(q *db.Queries) (int32, gin.H, error) {
+		_, err := q.DeleteCategory(ctx, category.ID)
+		return category.ID, gin.H{"name": category.Name}, err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "category deleted"})
+}
+
+// ---------------------
+// Materials
+// ---------------------
+
+type CreateMaterialRequest struct {
+	Name       string `json:"name" binding:"required"`
+	CategoryID int32  `json:"categoryId" binding:"required"`
+}
+
+// UpdateMaterialRequest renames and/or recategorizes a material
+type UpdateMaterialRequest struct {
+	Name       *string `json:"name"`
+	CategoryID *int32  `json:"categoryId"`
+}
+
+type RecategorizeMaterialsRequest struct {
+	MaterialIDs []int32 `json:"materialIds" binding:"required"`
+	CategoryID  int32   `json:"categoryId" binding:"required"`
+}
+
+type MergeMaterialRequest struct {
+	IntoID int32 `json:"intoId" binding:"required"`
+}
+
+// adminMaterialsHandler lists materials with usage counts. category_id=1
+// lists what imports filed under the default category.
+func adminMaterialsHandler(c *gin.Context) {
+	var params db.GetMaterialsAdminParams
+	if v := c.Query("category_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category_id"})
+			return
+		}
+		params.CategoryID = sql.NullInt32{Int32: int32(id), Valid: true}
+	}
+	if v := c.Query("search"); v != "" {
+		params.Search = sql.NullString{String: v, Valid: true}
+	}
+
+	materials, err := queries.GetMaterialsAdmin(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]map[string]interface{}, len(materials))
+	for i, m := range materials {
+		response[i] = map[string]interface{}{
+			"id":           m.ID,
+			"name":         m.Name,
+			"slug":         m.Slug,
+			"categoryId":   m.CategoryID,
+			"categoryName": m.CategoryName,
+			"settingCount": m.SettingCount,
+			"aliasCount":   m.AliasCount,
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func adminCreateMaterialHandler(c *gin.Context) {
+	var req CreateMaterialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	category, ok := lookupCategory(c, req.CategoryID)
+	if !ok {
+		return
+	}
+
+	id, err := taxonomyChange(c, auditMaterialCreate, db.TaxonomyAuditEntityTypeMaterial, func(q *db.Queries) (int32, gin.H, error) {
+		result, err := q.CreateMaterial(c.Request.Context(), db.CreateMaterialParams{
+			CategoryID: category.ID,
+			Name:       name,
+			Slug:       materialSlug(name),
+		})
+		if err != nil {
+			return 0, nil, err
+		}
+		id, err := result.LastInsertId()
+		return int32(id), gin.H{"name": name, "categoryId": category.ID}, err
+	})
+	if err != nil {
+		taxonomyError(c, err, "a material with this name already exists")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func adminUpdateMaterialHandler(c *gin.Context) {
+	material, ok := lookupMaterial(c, "id")
+	if !ok {
+		return
+	}
+	var req UpdateMaterialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := material.Name
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name cannot be empty"})
+			return
+		}
+	}
+	categoryID := material.CategoryID
+	if req.CategoryID != nil {
+		if _, ok := lookupCategory(c, *req.CategoryID); !ok {
+			return
+		}
+		categoryID = *req.CategoryID
+	}
+
+	// Renaming keeps the slug in step so it stays unique per name
+	slug := material.Slug
+	if name != material.Name {
+		slug = materialSlug(name)
+	}
+
+	_, err := taxonomyChange(c, auditMaterialUpdate, db.TaxonomyAuditEntityTypeMaterial, func(q *db.Queries) (int32, gin.H, error) {
+		_, err := q.UpdateMaterial(c.Request.Context(), db.UpdateMaterialParams{
+			CategoryID: categoryID,
+			Name:       name,
+			Slug:       slug,
+			ID:         material.ID,
+		})
+		return material.ID, gin.H{
+			"from": gin.H{"name": material.Name, "categoryId": material.CategoryID},
+			"to":   gin.H{"name": name, "categoryId": categoryID},
+		}, err
+	})
+	if err != nil {
+		taxonomyError(c, err, "a material with this name already exists")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "material updated"})
+}
+
+// adminRecategorizeMaterialsHandler moves several materials into one
+// category, e.g. the ones imports created under the default category
+func adminRecategorizeMaterialsHandler(c *gin.Context) {
+	var req RecategorizeMaterialsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.MaterialIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "materialIds is required"})
+		return
+	}
+	category, ok := lookupCategory(c, req.CategoryID)
+	if !ok {
+		return
+	}
+
+	var moved int64
+	_, err := taxonomyChange(c, auditMaterialRecategorize, db.TaxonomyAuditEntityTypeCategory, func(q *db.Queries) (int32, gin.H, error) {
+		var err error
+		moved, err = q.RecategorizeMaterials(c.Request.Context(), db.RecategorizeMaterialsParams{
+			CategoryID: category.ID,
+			Ids:        req.MaterialIDs,
+		})
+		return category.ID, gin.H{"materialIds": req.MaterialIDs, "updated": moved}, err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": moved})
+}
+
+// adminDeleteMaterialHandler only deletes unused materials; ones with
+// settings or recipe sets should be merged into the material they
+// duplicate instead
+func adminDeleteMaterialHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	material, ok := lookupMaterial(c, "id")
+	if !ok {
+		return
+	}
+	count, err := queries.CountMaterialSettings(ctx, material.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("material has %d settings, merge it into another material instead", count)})
+		return
+	}
+	recipeSets, err := queries.CountMaterialRecipeSets(ctx, material.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if recipeSets > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("material has %d recipe sets, merge it into another material instead", recipeSets)})
+		return
+	}
+
+	_, err = taxonomyChange(c, auditMaterialDelete, db.TaxonomyAuditEntityTypeMaterial, func(q *db.Queries) (int32, gin.H, error) {
+		_, err := q.DeleteMaterial(ctx, material.ID)
+		return material.ID, gin.H{"name": material.Name, "categoryId": material.CategoryID}, err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "material deleted"})
+}
+
+// adminMergeMaterialHandler folds a duplicate material into another one.
+// Its settings (and with them their votes and sublayers), recipe sets and
+// aliases move to the target, its name becomes an alias of the target so
+// imports keep resolving it, and the duplicate is deleted.
+func adminMergeMaterialHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	source, ok := lookupMaterial(c, "id")
+	if !ok {
+		return
+	}
+	var req MergeMaterialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.IntoID == source.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a material cannot be merged into itself"})
+		return
+	}
+	target, err := queries.GetMaterialByID(ctx, req.IntoID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown target material"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var settingsMoved, votesMoved, recipeSetsMoved, aliasesMoved int64
+	_, err = taxonomyChange(c, auditMaterialMerge, db.TaxonomyAuditEntityTypeMaterial, func(q *db.Queries) (int32, gin.H, error) {
+		var err error
+		if votesMoved, err = q.CountMaterialVotes(ctx, source.ID); err != nil {
+			return 0, nil, err
+		}
+		move := db.MoveMaterialSettingsParams{TargetID: target.ID, SourceID: source.ID}
+		if settingsMoved, err = q.MoveMaterialSettings(ctx, move); err != nil {
+			return 0, nil, err
+		}
+		if recipeSetsMoved, err = q.MoveMaterialRecipeSets(ctx, db.MoveMaterialRecipeSetsParams(move)); err != nil {
+			return 0, nil, err
+		}
+		if aliasesMoved, err = q.MoveMaterialAliases(ctx, db.MoveMaterialAliasesParams(move)); err != nil {
+			return 0, nil, err
+		}
+		if err := addAliasIfMissing(ctx, q, target.ID, source.Name); err != nil {
+			return 0, nil, err
+		}
+		if _, err := q.DeleteMaterial(ctx, source.ID); err != nil {
+			return 0, nil, err
+		}
+		return target.ID, gin.H{
+			"sourceId":        source.ID,
+			"sourceName":      source.Name,
+			"targetName":      target.Name,
+			"settingsMoved":   settingsMoved,
+			"votesMoved":      votesMoved,
+			"recipeSetsMoved": recipeSetsMoved,
+			"aliasesMoved":    aliasesMoved,
+		}, nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "materials merged",
+		"materialId":      target.ID,
+		"settingsMoved":   settingsMoved,
+		"votesMoved":      votesMoved,
+		"recipeSetsMoved": recipeSetsMoved,
+		"aliasesMoved":    aliasesMoved,
+	})
+}
+
+// addAliasIfMissing adds alias to a material unless it already has it,
+// comparing case-insensitively
+func addAliasIfMissing(ctx context.Context, q *db.Queries, materialID int32, alias string) error {
+	aliases, err := q.GetAliasesByMaterial(ctx, materialID)
+	if err != nil {
+		return err
+	}
+	for _, a := range aliases {
+		if strings.EqualFold(a.Alias, alias) {
+			return nil
+		}
+	}
+	_, err = q.CreateMaterialAlias(ctx, db.CreateMaterialAliasParams{MaterialID: materialID, Alias: alias})
+	return err
+}
+
+// ---------------------
+// Aliases
+// ---------------------
+
+type AliasRequest struct {
+	Alias string `json:"alias" binding:"required"`
+}
+
+func adminAddAliasHandler(c *gin.Context) {
+	material, ok := lookupMaterial(c, "id")
+	if !ok {
+		return
+	}
+	var req AliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	alias := strings.TrimSpace(req.Alias)
+	if alias == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "alias is required"})
+		return
+	}
+	if strings.EqualFold(alias, material.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "alias is the material's own name"})
+		return
+	}
+
+	aliases, err := queries.GetAliasesByMaterial(c.Request.Context(), material.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, a := range aliases {
+		if strings.EqualFold(a.Alias, alias) {
+			c.JSON(http.StatusConflict, gin.H{"error": "material already has this alias"})
+			return
+		}
+	}
+
+	id, err := taxonomyChange(c, auditAliasCreate, db.TaxonomyAuditEntityTypeAlias, func(q *db.Queries) (int32, gin.H, error) {
+		result, err := q.CreateMaterialAlias(c.Request.Context(), db.CreateMaterialAliasParams{
+			MaterialID: material.ID,
+			Alias:      alias,
+		})
+		if err != nil {
+			return 0, nil, err
+		}
+		id, err := result.LastInsertId()
+		return int32(id), gin.H{"materialId": material.ID, "alias": alias}, err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func adminDeleteAliasHandler(c *gin.Context) {
+	material, ok := lookupMaterial(c, "id")
+	if !ok {
+		return
+	}
+	aliasID, err := strconv.Atoi(c.Param("aliasId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid alias id"})
+		return
+	}
+
+	var deleted int64
+	_, err = taxonomyChange(c, auditAliasDelete, db.TaxonomyAuditEntityTypeAlias, func(q *db.Queries) (int32, gin.H, error) {
+		var err error
+		deleted, err = q.DeleteMaterialAlias(c.Request.Context(), db.DeleteMaterialAliasParams{
+			ID:         int32(aliasID),
+			MaterialID: material.ID,
+		})
+		if err == nil && deleted == 0 {
+			err = sql.ErrNoRows
+		}
+		return int32(aliasID), gin.H{"materialId": material.ID}, err
+	})
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "alias not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "alias deleted"})
+}
+
+// ---------------------
+// Audit log
+// ---------------------
+
+func adminTaxonomyAuditHandler(c *gin.Context) {
+	// Parse pagination params
+	limit := 50
+	offset := 0
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+	if o := c.Query("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	entries, err := queries.GetTaxonomyAudit(c.Request.Context(), db.GetTaxonomyAuditParams{
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	count, err := queries.GetTaxonomyAuditCount(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]map[string]interface{}, len(entries))
+	for i, e := range entries {
+		createdAt := ""
+		if e.CreatedAt.Valid {
+			createdAt = e.CreatedAt.Time.Format(time.RFC3339)
+		}
+		var details interface{}
+		if e.Details.Valid {
+			json.Unmarshal([]byte(e.Details.String), &details)
+		}
+		response[i] = map[string]interface{}{
+			"id":          e.ID,
+			"adminUserId": e.AdminUserID.Int32,
+			"adminEmail":  e.AdminEmail.String,
+			"action":      e.Action,
+			"entityType":  string(e.EntityType),
+			"entityId":    e.EntityID,
+			"details":     details,
+			"createdAt":   createdAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": response,
+		"total":   count,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
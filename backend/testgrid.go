@@ -0,0 +1,471 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"laserscribe/backend/clb"
+	"laserscribe/backend/db"
+	"laserscribe/backend/lbrn"
+	"laserscribe/backend/units"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// TEST GRID
+// =====================
+
+// A test grid is the material test burned before settling on a setting: a
+// square per combination of two variables, each on its own layer, with the
+// values labelled along the edges. The grid comes back as a LightBurn
+// project to burn and as a .clb library with one entry per square, which
+// can be imported (POST /api/settings/import) once the squares that worked
+// are known.
+
+// Grid variable pairs
+const (
+	gridPowerSpeed        = "power-speed"
+	gridFrequencyInterval = "frequency-interval"
+)
+
+// maxGridCells leaves the last color layer of a project for the labels
+const maxGridCells = lbrn.MaxLayers - 1
+
+// Grid layout in mm
+const (
+	defaultGridCellSize = 10.0
+	gridGap             = 2.0
+	gridLabelHeight     = 3.0
+	gridRowLabelWidth   = 20.0
+)
+
+// GridAxis is the range of one variable, from Min to Max in Steps evenly
+// spaced values. A single step uses Min.
+type GridAxis struct {
+	Min   string `json:"min" binding:"required"`
+	Max   string `json:"max" binding:"required"`
+	Steps int    `json:"steps" binding:"required"`
+}
+
+// TestGridRequest describes a grid. Columns vary speed and rows power, or
+// columns frequency and rows scanInterval; the other values are fixed for
+// every square. Speeds, intervals and frequencies are in InputUnits, and
+// the labels are written in the same units.
+type TestGridRequest struct {
+	LaserType     string   `json:"laserType" binding:"required"`
+	Wattage       int32    `json:"wattage" binding:"required"`
+	MaterialID    int32    `json:"materialId" binding:"required"`
+	OperationType string   `json:"operationType"`
+	Variables     string   `json:"variables"`
+	Columns       GridAxis `json:"columns" binding:"required"`
+	Rows          GridAxis `json:"rows" binding:"required"`
+	MaxPower      string   `json:"maxPower"`
+	Speed         string   `json:"speed"`
+	NumPasses     int32    `json:"numPasses"`
+	ScanInterval  *string  `json:"scanInterval"`
+	Frequency     *string  `json:"frequency"`
+	PulseWidth    *string  `json:"pulseWidth"`
+	// CellSize is the side of each square in mm, 10 by default
+	CellSize *float64 `json:"cellSize"`
+	InputUnits
+}
+
+// TestGridCell is one square of a grid. Setting is a body for POST
+// /api/settings that records the square as a setting.
+type TestGridCell struct {
+	Row     int                    `json:"row"`
+	Column  int                    `json:"column"`
+	Layer   int                    `json:"layer"`
+	Label   string                 `json:"label"`
+	Setting map[string]interface{} `json:"setting"`
+}
+
+// gridVariable is a value a grid axis can vary
+type gridVariable struct {
+	field string
+	title string
+	unit  string
+	// canonical converts a value in the request's unit to a stored one
+	canonical func(string) string
+	// display converts a stored value back to the request's unit
+	display  func(float64) float64
+	decimals int
+}
+
+// label renders a stored value in the request's unit, without the unit
+func (g gridVariable) label(v float64) string {
+	return units.Format(g.display(v), g.decimals)
+}
+
+// labelWithUnit is label followed by the unit, as in "40%" or "800 mm/s"
+func (g gridVariable) labelWithUnit(v float64) string {
+	if g.unit == "%" {
+		return g.label(v) + g.unit
+	}
+	return g.label(v) + " " + g.unit
+}
+
+// gridVariables returns the column and row variables of a grid
+func gridVariables(pair string, u InputUnits) (gridVariable, gridVariable, bool) {
+	speedUnit := u.SpeedUnit
+	if speedUnit == "" {
+		speedUnit = units.CanonicalSpeed
+	}
+	intervalUnit := u.IntervalUnit
+	if intervalUnit == "" {
+		intervalUnit = units.CanonicalInterval
+	}
+	frequencyUnit := u.FrequencyUnit
+	if frequencyUnit == "" {
+		frequencyUnit = units.CanonicalFrequency
+	}
+
+	power := gridVariable{
+		field:     "maxPower",
+		title:     "Power",
+		unit:      "%",
+		canonical: func(s string) string { return s },
+		display:   func(v float64) float64 { return v },
+		decimals:  1,
+	}
+	speed := gridVariable{
+		field:     "speed",
+		title:     "Speed",
+		unit:      speedUnit,
+		canonical: func(s string) string { return units.ConvertSpeed(s, u.SpeedUnit) },
+		display:   func(v float64) float64 { return units.SpeedFromCanonical(v, speedUnit) },
+		decimals:  1,
+	}
+	frequency := gridVariable{
+		field:     "frequency",
+		title:     "Frequency",
+		unit:      frequencyUnit,
+		canonical: func(s string) string { return units.ConvertFrequency(s, u.FrequencyUnit) },
+		display:   func(v float64) float64 { return units.FrequencyFromCanonical(v, frequencyUnit) },
+		decimals:  3,
+	}
+	interval := gridVariable{
+		field:     "scanInterval",
+		title:     "Interval",
+		unit:      intervalUnit,
+		canonical: func(s string) string { return units.ConvertInterval(s, u.IntervalUnit) },
+		display:   func(v float64) float64 { return units.IntervalFromCanonical(v, intervalUnit) },
+		decimals:  4,
+	}
+
+	switch pair {
+	case "", gridPowerSpeed:
+		return speed, power, true
+	case gridFrequencyInterval:
+		return frequency, interval, true
+	}
+	return gridVariable{}, gridVariable{}, false
+}
+
+// steps spreads an axis over its range in stored units
+func (a GridAxis) steps(field string, v gridVariable, errs *ValidationError) []float64 {
+	if a.Steps < 1 || a.Steps > maxGridCells {
+		errs.add(field+".steps", "must be between 1 and %d", maxGridCells)
+		return nil
+	}
+	n := numberChecker{errs: errs}
+	min, minOK := n.parse(field+".min", v.canonical(a.Min), 0, 1e9)
+	max, maxOK := n.parse(field+".max", v.canonical(a.Max), 0, 1e9)
+	if !minOK || !maxOK {
+		return nil
+	}
+	values := make([]float64, a.Steps)
+	for i := range values {
+		values[i] = min
+		if a.Steps > 1 {
+			values[i] = min + (max-min)*float64(i)/float64(a.Steps-1)
+		}
+	}
+	return values
+}
+
+// setGridValue sets the stored value of a grid variable
+func setGridValue(v *settingValues, field, value string) {
+	switch field {
+	case "maxPower":
+		v.MaxPower = value
+	case "speed":
+		v.Speed = value
+	case "frequency":
+		v.Frequency = sql.NullString{String: value, Valid: true}
+	case "scanInterval":
+		v.ScanInterval = sql.NullString{String: value, Valid: true}
+	}
+}
+
+// gridEntry is the library entry for one square
+func gridEntry(materialName, title, label, cutType string, v settingValues) clb.Entry {
+	cs := clb.CutSetting{
+		Type:     cutType,
+		Index:    clb.Int(0),
+		Name:     clb.V(""),
+		LinkPath: clb.V(clb.LinkPath(materialName, title, label)),
+	}
+	gridParams(&cs.Params, v)
+	return clb.Entry{
+		Thickness:    clb.NoThickness,
+		Desc:         label,
+		NoThickTitle: title,
+		CutSetting:   cs,
+	}
+}
+
+// gridParams writes the values of a square to a CutSetting
+func gridParams(p *clb.Params, v settingValues) {
+	p.MinPower = clb.Num(v.MinPower)
+	p.MaxPower = clb.Num(v.MaxPower)
+	p.Speed = clb.Num(v.Speed)
+	p.Frequency = optionalNum(v.Frequency)
+	p.QPulseWidth = optionalNum(v.PulseWidth)
+	if v.NumPasses > 1 {
+		p.NumPasses = clb.Int(int(v.NumPasses))
+	}
+	p.Interval = optionalNum(v.ScanInterval)
+}
+
+// gridCellCenter is where the square at row, col of a grid goes in the
+// project: rows go up and columns to the right of the row labels
+func gridCellCenter(row, col int, cellSize float64) (float64, float64) {
+	pitch := cellSize + gridGap
+	return gridRowLabelWidth + float64(col)*pitch + cellSize/2, float64(row)*pitch + cellSize/2
+}
+
+// gridSetting is the POST /api/settings body recording a square
+func gridSetting(req TestGridRequest, op db.SettingsOperationType, label string, v settingValues) map[string]interface{} {
+	setting := map[string]interface{}{
+		"materialId":    req.MaterialID,
+		"laserType":     req.LaserType,
+		"wattage":       req.Wattage,
+		"operationType": string(op),
+		"maxPower":      v.MaxPower,
+		"speed":         v.Speed,
+		"numPasses":     v.NumPasses,
+		"notes":         "Test grid square " + label,
+		// The values are already in stored units, which POST /api/settings
+		// must not convert again
+		"speedUnit":     units.CanonicalSpeed,
+		"intervalUnit":  units.CanonicalInterval,
+		"frequencyUnit": units.CanonicalFrequency,
+	}
+	for field, value := range map[string]sql.NullString{
+		"scanInterval": v.ScanInterval,
+		"frequency":    v.Frequency,
+		"pulseWidth":   v.PulseWidth,
+	} {
+		if value.Valid {
+			setting[field] = value.String
+		}
+	}
+	return setting
+}
+
+// testGridHandler generates a material test grid. It answers with both
+// files and the squares as JSON, or with just one file for
+// ?format=lbrn2 or ?format=clb.
+func testGridHandler(c *gin.Context) {
+	var req TestGridRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "lbrn2" && format != "clb" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, lbrn2 or clb"})
+		return
+	}
+
+	opName := req.OperationType
+	if opName == "" {
+		opName = string(db.SettingsOperationTypeScan)
+	}
+	op, ok := parseOperationType(opName)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid operationType: " + req.OperationType})
+		return
+	}
+	inputUnits, verr := req.resolve()
+	if verr != nil {
+		validationFailed(c, verr)
+		return
+	}
+	colVar, rowVar, ok := gridVariables(req.Variables, inputUnits)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "variables must be power-speed or frequency-interval"})
+		return
+	}
+	cellSize := defaultGridCellSize
+	if req.CellSize != nil {
+		if *req.CellSize < 2 || *req.CellSize > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cellSize must be between 2 and 100 mm"})
+			return
+		}
+		cellSize = *req.CellSize
+	}
+
+	errs := &ValidationError{}
+	columns := req.Columns.steps("columns", colVar, errs)
+	rows := req.Rows.steps("rows", rowVar, errs)
+	if len(columns)*len(rows) > maxGridCells {
+		errs.add("rows", "a grid can have at most %d squares, got %d", maxGridCells, len(columns)*len(rows))
+	}
+	if rowVar.field == "scanInterval" && !scanOperations[op] {
+		errs.add("operationType", "must be a fill operation to vary scanInterval")
+	}
+	if len(errs.Fields) > 0 {
+		validationFailed(c, errs)
+		return
+	}
+
+	material, err := queries.GetMaterialByID(c.Request.Context(), req.MaterialID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown material"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	numPasses := req.NumPasses
+	if numPasses == 0 {
+		numPasses = 1
+	}
+	fixed := settingValues{
+		MaxPower:     req.MaxPower,
+		MinPower:     "0",
+		Speed:        units.ConvertSpeed(req.Speed, inputUnits.SpeedUnit),
+		NumPasses:    numPasses,
+		ScanInterval: canonicalInterval(nullString(req.ScanInterval), inputUnits.IntervalUnit),
+		Frequency:    canonicalFrequency(nullString(req.Frequency), inputUnits.FrequencyUnit),
+		PulseWidth:   nullString(req.PulseWidth),
+	}
+
+	// Every square is checked like a setting; errors in a varied value are
+	// reported against its axis
+	laserType := db.SettingsLaserType(req.LaserType)
+	cutType := clbTypeFromOperation(op)
+	title := operationLabel(op) + " Test Grid"
+	var cells []TestGridCell
+	var cellValues []settingValues
+	seen := make(map[FieldError]bool)
+	for r, rowValue := range rows {
+		for col, colValue := range columns {
+			v := fixed
+			setGridValue(&v, rowVar.field, units.Format(rowValue, 4))
+			setGridValue(&v, colVar.field, units.Format(colValue, 4))
+			if verr := validateSetting(laserType, req.Wattage, op, v); verr != nil {
+				for _, f := range verr.Fields {
+					switch f.Field {
+					case rowVar.field:
+						f.Field = "rows"
+					case colVar.field:
+						f.Field = "columns"
+					}
+					if !seen[f] {
+						seen[f] = true
+						errs.Fields = append(errs.Fields, f)
+					}
+				}
+				continue
+			}
+			label := rowVar.labelWithUnit(rowValue) + ", " + colVar.labelWithUnit(colValue)
+			cells = append(cells, TestGridCell{
+				Row:     r,
+				Column:  col,
+				Layer:   len(cells),
+				Label:   label,
+				Setting: gridSetting(req, op, label, v),
+			})
+			cellValues = append(cellValues, v)
+		}
+	}
+	if len(errs.Fields) > 0 {
+		validationFailed(c, errs)
+		return
+	}
+
+	// The project: squares laid out with rows going up and columns to the
+	// right, value labels along the left and top edges, all labels on the
+	// layer after the squares
+	project := lbrn.New()
+	library := clb.Material{Name: material.Name}
+	pitch := cellSize + gridGap
+	labelLayer := len(cells)
+	for i, cell := range cells {
+		layer := lbrn.Layer(cell.Layer, cutType, cell.Label)
+		gridParams(&layer.Params, cellValues[i])
+		project.CutSettings = append(project.CutSettings, layer)
+
+		x, y := gridCellCenter(cell.Row, cell.Column, cellSize)
+		project.Shapes = append(project.Shapes, lbrn.Rect(cell.Layer, x, y, cellSize, cellSize))
+
+		library.Entries = append(library.Entries, gridEntry(material.Name, title, cell.Label, cutType, cellValues[i]))
+	}
+
+	// Labels are filled text engraved with the first square's values
+	labels := lbrn.Layer(labelLayer, clb.TypeScan, "Labels")
+	gridParams(&labels.Params, cellValues[0])
+	// A grid of lines has no interval to fill the text with
+	if !labels.Interval.IsSet() {
+		labels.Interval = clb.V("0.1")
+	}
+	project.CutSettings = append(project.CutSettings, labels)
+
+	top := float64(len(rows))*pitch + gridLabelHeight
+	for r, rowValue := range rows {
+		_, y := gridCellCenter(r, 0, cellSize)
+		project.Shapes = append(project.Shapes, lbrn.Text(labelLayer, gridRowLabelWidth/2, y, gridLabelHeight, rowVar.label(rowValue)))
+	}
+	for col, colValue := range columns {
+		x, _ := gridCellCenter(0, col, cellSize)
+		project.Shapes = append(project.Shapes, lbrn.Text(labelLayer, x, top, gridLabelHeight, colVar.label(colValue)))
+	}
+	gridWidth := float64(len(columns))*pitch - gridGap
+	project.Shapes = append(project.Shapes,
+		lbrn.Text(labelLayer, gridRowLabelWidth/2, top, gridLabelHeight, fmt.Sprintf("%s (%s)", rowVar.title, rowVar.unit)),
+		lbrn.Text(labelLayer, gridRowLabelWidth+gridWidth/2, top+2*gridLabelHeight, gridLabelHeight, fmt.Sprintf("%s (%s)", colVar.title, colVar.unit)),
+		lbrn.Text(labelLayer, gridRowLabelWidth+gridWidth/2, top+4*gridLabelHeight, gridLabelHeight, fmt.Sprintf("%s - %s %dW", material.Name, req.LaserType, req.Wattage)),
+	)
+	project.Notes = lbrn.Notes{
+		ShowOnLoad: "1",
+		Notes: fmt.Sprintf("%s test grid for %s on a %s %dW laser: %s across, %s up. Labels are engraved with the values of the bottom left square.",
+			operationLabel(op), material.Name, req.LaserType, req.Wattage, strings.ToLower(colVar.title), strings.ToLower(rowVar.title)),
+	}
+
+	projectData, err := lbrn.Marshal(project)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate LightBurn project"})
+		return
+	}
+	libraryData, err := clb.Marshal(&clb.Library{DisplayName: clb.DisplayName, Materials: []clb.Material{library}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate CLB file"})
+		return
+	}
+
+	filename := slugify(material.Name + " " + req.LaserType + " " + strconv.Itoa(int(req.Wattage)) + "W test grid")
+	switch format {
+	case "lbrn2":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.lbrn2", filename))
+		c.Data(http.StatusOK, "application/xml", projectData)
+	case "clb":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.clb", filename))
+		c.Data(http.StatusOK, "application/xml", libraryData)
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"cells":           cells,
+			"projectFilename": filename + ".lbrn2",
+			"project":         string(projectData),
+			"libraryFilename": filename + ".clb",
+			"library":         string(libraryData),
+		})
+	}
+}
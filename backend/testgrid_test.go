@@ -0,0 +1,105 @@
+package main
+
+import (
+	"laserscribe/backend/db"
+	"laserscribe/backend/units"
+	"reflect"
+	"testing"
+)
+
+func TestGridAxisSteps(t *testing.T) {
+	colVar, rowVar, _ := gridVariables(gridPowerSpeed, InputUnits{SpeedUnit: units.MillimetersPerMinute})
+	cases := []struct {
+		name   string
+		axis   GridAxis
+		v      gridVariable
+		want   []float64
+		errors int
+	}{
+		{"power", GridAxis{Min: "20", Max: "80", Steps: 4}, rowVar, []float64{20, 40, 60, 80}, 0},
+		{"speed in mm/min", GridAxis{Min: "6000", Max: "12000", Steps: 3}, colVar, []float64{100, 150, 200}, 0},
+		{"one step uses min", GridAxis{Min: "50", Max: "90", Steps: 1}, rowVar, []float64{50}, 0},
+		{"too many steps", GridAxis{Min: "10", Max: "90", Steps: maxGridCells + 1}, rowVar, nil, 1},
+		{"not a number", GridAxis{Min: "low", Max: "high", Steps: 2}, rowVar, nil, 2},
+	}
+	for _, tc := range cases {
+		errs := &ValidationError{}
+		got := tc.axis.steps("rows", tc.v, errs)
+		if !reflect.DeepEqual(got, tc.want) || len(errs.Fields) != tc.errors {
+			t.Errorf("%s: steps = %v with %d errors (%v), want %v with %d", tc.name, got, len(errs.Fields), errs, tc.want, tc.errors)
+		}
+	}
+}
+
+func TestGridVariableLabels(t *testing.T) {
+	colVar, rowVar, ok := gridVariables(gridPowerSpeed, InputUnits{SpeedUnit: units.MillimetersPerMinute})
+	if !ok || colVar.field != "speed" || rowVar.field != "maxPower" {
+		t.Fatalf("power-speed grid varies %s and %s", colVar.field, rowVar.field)
+	}
+	if got := colVar.labelWithUnit(100); got != "6000 mm/min" {
+		t.Errorf("speed label = %q", got)
+	}
+	if got := rowVar.labelWithUnit(42.5); got != "42.5%" {
+		t.Errorf("power label = %q", got)
+	}
+
+	colVar, rowVar, _ = gridVariables(gridFrequencyInterval, InputUnits{IntervalUnit: units.LinesPerInch})
+	if got := colVar.labelWithUnit(30); got != "30 kHz" {
+		t.Errorf("frequency label = %q", got)
+	}
+	if got := rowVar.labelWithUnit(0.1); got != "254 lpi" {
+		t.Errorf("interval label = %q", got)
+	}
+
+	if _, _, ok := gridVariables("speed-passes", InputUnits{}); ok {
+		t.Errorf("unknown variable pair accepted")
+	}
+}
+
+func TestGridCellCenter(t *testing.T) {
+	cases := []struct {
+		row, col int
+		cellSize float64
+		x, y     float64
+	}{
+		// The bottom left square sits right of the row labels
+		{0, 0, 10, gridRowLabelWidth + 5, 5},
+		{0, 2, 10, gridRowLabelWidth + 2*(10+gridGap) + 5, 5},
+		{3, 1, 5, gridRowLabelWidth + (5 + gridGap) + 2.5, 3*(5+gridGap) + 2.5},
+	}
+	for _, tc := range cases {
+		x, y := gridCellCenter(tc.row, tc.col, tc.cellSize)
+		if x != tc.x || y != tc.y {
+			t.Errorf("gridCellCenter(%d, %d, %v) = %v, %v; want %v, %v", tc.row, tc.col, tc.cellSize, x, y, tc.x, tc.y)
+		}
+	}
+}
+
+func TestGridSettingUsesStoredUnits(t *testing.T) {
+	req := TestGridRequest{
+		LaserType:  "Fiber",
+		Wattage:    30,
+		MaterialID: 7,
+		InputUnits: InputUnits{SpeedUnit: units.InchesPerSecond, FrequencyUnit: units.Hertz},
+	}
+	v := validValues()
+	v.Frequency = validString("30")
+	setting := gridSetting(req, db.SettingsOperationTypeScan, "40%, 800 mm/s", v)
+
+	want := map[string]interface{}{
+		"speed":         "1000",
+		"frequency":     "30",
+		"scanInterval":  "0.05",
+		"speedUnit":     units.CanonicalSpeed,
+		"intervalUnit":  units.CanonicalInterval,
+		"frequencyUnit": units.CanonicalFrequency,
+	}
+	for field, value := range want {
+		if setting[field] != value {
+			t.Errorf("%s = %v, want %v", field, setting[field], value)
+		}
+	}
+	if _, ok := setting["pulseWidth"]; ok {
+		t.Errorf("unset pulseWidth included")
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"laserscribe/backend/db"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// TEST REPORTS
+// =====================
+
+// A test report says a user ran a setting on their machine and whether it
+// worked as is, worked after adjusting some values, or failed. Reports add
+// up to the setting's confidence score, kept on the settings row so search
+// can rank by it.
+
+// confidenceZ is the z-score of the confidence score's interval (95%)
+const confidenceZ = 1.96
+
+// outcomeCredit is how much a report counts as a success
+var outcomeCredit = map[db.TestReportsOutcome]float64{
+	db.TestReportsOutcomeWorked:   1,
+	db.TestReportsOutcomeAdjusted: 0.5,
+	db.TestReportsOutcomeFailed:   0,
+}
+
+// confidenceScore is the lower bound of the Wilson score interval of the
+// success rate, counting an adjusted result as half a success. It is 0..1
+// and grows with the number of reports, so one "worked" ranks below ten.
+func confidenceScore(summary db.GetTestReportSummaryRow) (float64, int64) {
+	n := summary.Worked + summary.Adjusted + summary.Failed
+	if n == 0 {
+		return 0, 0
+	}
+	total := float64(n)
+	p := (float64(summary.Worked)*outcomeCredit[db.TestReportsOutcomeWorked] +
+		float64(summary.Adjusted)*outcomeCredit[db.TestReportsOutcomeAdjusted]) / total
+	z2 := confidenceZ * confidenceZ
+	lower := (p + z2/(2*total) - confidenceZ*math.Sqrt(p*(1-p)/total+z2/(4*total*total))) / (1 + z2/total)
+	return math.Max(0, lower), n
+}
+
+// refreshConfidence recomputes a setting's confidence from its reports
+func refreshConfidence(ctx context.Context, q *db.Queries, settingID int32) (db.GetTestReportSummaryRow, sql.NullString, error) {
+	summary, err := q.GetTestReportSummary(ctx, settingID)
+	if err != nil {
+		return summary, sql.NullString{}, err
+	}
+	score, n := confidenceScore(summary)
+	confidence := sql.NullString{String: strconv.FormatFloat(score, 'f', 4, 64), Valid: n > 0}
+	err = q.UpdateSettingConfidence(ctx, db.UpdateSettingConfidenceParams{
+		TestCount:  int32(n),
+		Confidence: confidence,
+		ID:         settingID,
+	})
+	return summary, confidence, err
+}
+
+// testSummaryJSON renders report counts and the confidence score
+func testSummaryJSON(summary db.GetTestReportSummaryRow, confidence sql.NullString) gin.H {
+	var score *string
+	if confidence.Valid {
+		score = &confidence.String
+	}
+	return gin.H{
+		"total":      summary.Worked + summary.Adjusted + summary.Failed,
+		"worked":     summary.Worked,
+		"adjusted":   summary.Adjusted,
+		"failed":     summary.Failed,
+		"confidence": score,
+	}
+}
+
+// TestReportRequest reports a test of a setting. The machine is one of the
+// tester's saved machines, or a laser type and wattage, or else their
+// default machine. AdjustedValues are keyed like the setting fields.
+type TestReportRequest struct {
+	UserMachineID  *int32          `json:"userMachineId"`
+	LaserType      string          `json:"laserType"`
+	Wattage        int32           `json:"wattage"`
+	LaserModelID   *int32          `json:"laserModelId"`
+	MaterialBatch  *string         `json:"materialBatch"`
+	Outcome        string          `json:"outcome" binding:"required"`
+	AdjustedValues settingSnapshot `json:"adjustedValues"`
+	Notes          *string         `json:"notes"`
+}
+
+// testerMachine is the machine a report was made on
+type testerMachine struct {
+	laserModelID sql.NullInt32
+	makeModel    sql.NullString
+	laserType    db.TestReportsLaserType
+	wattage      int32
+}
+
+// resolveTesterMachine works out the machine of a report, answering 400 and
+// returning false when there is none
+func resolveTesterMachine(c *gin.Context, userID int32, req TestReportRequest) (testerMachine, bool) {
+	ctx := c.Request.Context()
+	if req.UserMachineID != nil {
+		m, err := queries.GetUserMachineByID(ctx, db.GetUserMachineByIDParams{ID: *req.UserMachineID, UserID: userID})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown machine"})
+			return testerMachine{}, false
+		}
+		return testerMachine{m.LaserModelID, m.MakeModel, db.TestReportsLaserType(m.LaserType), m.Wattage}, true
+	}
+
+	if req.LaserType != "" || req.Wattage != 0 {
+		if req.LaserType == "" || req.Wattage <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "laserType and wattage are required together"})
+			return testerMachine{}, false
+		}
+		lt, ok := stringToLaserType(req.LaserType)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "laserType must be one of " + laserTypeNames})
+			return testerMachine{}, false
+		}
+		machine := testerMachine{
+			laserType: db.TestReportsLaserType(lt),
+			wattage:   req.Wattage,
+		}
+		if req.LaserModelID != nil {
+			model, err := queries.GetLaserModelByID(ctx, *req.LaserModelID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unknown laser model"})
+				return testerMachine{}, false
+			}
+			machine.laserModelID = sql.NullInt32{Int32: model.ID, Valid: true}
+			machine.makeModel = sql.NullString{String: machineDisplayName(model), Valid: true}
+		}
+		return machine, true
+	}
+
+	m, err := queries.GetDefaultUserMachine(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "userMachineId or laserType and wattage are required"})
+		return testerMachine{}, false
+	}
+	return testerMachine{m.LaserModelID, m.MakeModel, db.TestReportsLaserType(m.LaserType), m.Wattage}, true
+}
+
+// reportTestHandler records or replaces the current user's test report
+func reportTestHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid setting id"})
+		return
+	}
+	setting, err := queries.GetSettingByID(ctx, int32(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "setting not found"})
+		return
+	}
+	if setting.UserID == userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you can't report tests of your own settings"})
+		return
+	}
+
+	var req TestReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	outcome := db.TestReportsOutcome(strings.ToLower(req.Outcome))
+	if _, ok := outcomeCredit[outcome]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "outcome must be worked, adjusted or failed"})
+		return
+	}
+
+	var adjusted sql.NullString
+	if len(req.AdjustedValues) > 0 {
+		if outcome != db.TestReportsOutcomeAdjusted {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "adjustedValues are only for the adjusted outcome"})
+			return
+		}
+		known := make(map[string]bool, len(revisionFields))
+		for _, f := range revisionFields {
+			known[f.key] = true
+		}
+		for key, value := range req.AdjustedValues {
+			if !known[key] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unknown field in adjustedValues: " + key})
+				return
+			}
+			if value == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "adjustedValues." + key + " must have a value"})
+				return
+			}
+		}
+		encoded, err := req.AdjustedValues.encode()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		adjusted = sql.NullString{String: encoded, Valid: true}
+	}
+
+	machine, ok := resolveTesterMachine(c, userID, req)
+	if !ok {
+		return
+	}
+
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	revision, err := currentRevision(ctx, qtx, setting.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	err = qtx.UpsertTestReport(ctx, db.UpsertTestReportParams{
+		SettingID:      setting.ID,
+		UserID:         userID,
+		Revision:       revision,
+		LaserModelID:   machine.laserModelID,
+		MakeModel:      machine.makeModel,
+		LaserType:      machine.laserType,
+		Wattage:        machine.wattage,
+		MaterialBatch:  attributeValue(req.MaterialBatch),
+		Outcome:        outcome,
+		AdjustedValues: adjusted,
+		Notes:          attributeValue(req.Notes),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	summary, confidence, err := refreshConfidence(ctx, qtx, setting.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, testSummaryJSON(summary, confidence))
+}
+
+// deleteTestReportHandler withdraws the current user's test report
+func deleteTestReportHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid setting id"})
+		return
+	}
+
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	n, err := qtx.DeleteTestReport(ctx, db.DeleteTestReportParams{UserID: userID, SettingID: int32(id)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "you have not reported a test of this setting"})
+		return
+	}
+	summary, confidence, err := refreshConfidence(ctx, qtx, int32(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, testSummaryJSON(summary, confidence))
+}
+
+// getTestReportsHandler lists a setting's test reports, newest first, with
+// their summary
+func getTestReportsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid setting id"})
+		return
+	}
+	setting, err := queries.GetSettingByID(ctx, int32(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "setting not found"})
+		return
+	}
+
+	rows, err := queries.GetTestReports(ctx, setting.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	summary := db.GetTestReportSummaryRow{}
+	reports := make([]gin.H, 0, len(rows))
+	for _, r := range rows {
+		switch r.Outcome {
+		case db.TestReportsOutcomeWorked:
+			summary.Worked++
+		case db.TestReportsOutcomeAdjusted:
+			summary.Adjusted++
+		case db.TestReportsOutcomeFailed:
+			summary.Failed++
+		}
+
+		var adjusted settingSnapshot
+		if r.AdjustedValues.Valid {
+			if err := json.Unmarshal([]byte(r.AdjustedValues.String), &adjusted); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		var laserModelID *int32
+		if r.LaserModelID.Valid {
+			laserModelID = &r.LaserModelID.Int32
+		}
+		createdAt, updatedAt := "", ""
+		if r.CreatedAt.Valid {
+			createdAt = r.CreatedAt.Time.Format(time.RFC3339)
+		}
+		if r.UpdatedAt.Valid {
+			updatedAt = r.UpdatedAt.Time.Format(time.RFC3339)
+		}
+		reports = append(reports, gin.H{
+			"id":             r.ID,
+			"userId":         r.UserID,
+			"author":         authorName(r.DisplayName, r.FirstName, r.LastName),
+			"revision":       r.Revision,
+			"laserType":      r.LaserType,
+			"wattage":        r.Wattage,
+			"laserModelId":   laserModelID,
+			"makeModel":      r.MakeModel.String,
+			"materialBatch":  r.MaterialBatch.String,
+			"outcome":        r.Outcome,
+			"adjustedValues": adjusted,
+			"notes":          r.Notes.String,
+			"createdAt":      createdAt,
+			"updatedAt":      updatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"summary": testSummaryJSON(summary, setting.Confidence),
+		"reports": reports,
+	})
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"laserscribe/backend/db"
+	"math"
+	"testing"
+)
+
+func TestConfidenceScore(t *testing.T) {
+	cases := []struct {
+		name    string
+		summary db.GetTestReportSummaryRow
+		want    float64
+	}{
+		{"no reports", db.GetTestReportSummaryRow{}, 0},
+		// With every report a success the bound is n / (n + z²)
+		{"one worked", db.GetTestReportSummaryRow{Worked: 1}, 1 / (1 + confidenceZ*confidenceZ)},
+		{"ten worked", db.GetTestReportSummaryRow{Worked: 10}, 10 / (10 + confidenceZ*confidenceZ)},
+		{"all failed", db.GetTestReportSummaryRow{Failed: 3}, 0},
+		{"half worked", db.GetTestReportSummaryRow{Worked: 5, Failed: 5}, 0.2365895936154873},
+		{"adjusted counts half", db.GetTestReportSummaryRow{Adjusted: 10}, 0.2365895936154873},
+		{"mixed", db.GetTestReportSummaryRow{Worked: 2, Adjusted: 2}, 0.30063605244263664},
+	}
+	for _, tc := range cases {
+		got, n := confidenceScore(tc.summary)
+		if math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: score = %v, want %v", tc.name, got, tc.want)
+		}
+		if want := tc.summary.Worked + tc.summary.Adjusted + tc.summary.Failed; n != want {
+			t.Errorf("%s: count = %d, want %d", tc.name, n, want)
+		}
+	}
+}
+
+func TestConfidenceScoreGrowsWithReports(t *testing.T) {
+	prev := 0.0
+	for worked := int64(1); worked <= 20; worked++ {
+		score, _ := confidenceScore(db.GetTestReportSummaryRow{Worked: worked})
+		if score <= prev || score >= 1 {
+			t.Fatalf("%d worked reports scored %v after %v", worked, score, prev)
+		}
+		prev = score
+	}
+}
@@ -0,0 +1,249 @@
+// Package units converts setting values between the units people enter them
+// in and the canonical units Laserscribe stores.
+//
+// Speeds are stored in mm/s, line intervals in mm and pulse frequencies in
+// kHz, the units LightBurn uses in .clb files, so stored values can be
+// exported unchanged.
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Speed units
+const (
+	MillimetersPerSecond = "mm/s"
+	MillimetersPerMinute = "mm/min"
+	InchesPerSecond      = "in/s"
+	InchesPerMinute      = "in/min"
+)
+
+// Interval units. LinesPerInch is the reciprocal form LightBurn also shows
+// for fills (254 LPI is a 0.1 mm interval).
+const (
+	Millimeters  = "mm"
+	Inches       = "in"
+	LinesPerInch = "lpi"
+)
+
+// Frequency units
+const (
+	Hertz     = "Hz"
+	Kilohertz = "kHz"
+	Megahertz = "MHz"
+)
+
+// Canonical units of stored values
+const (
+	CanonicalSpeed     = MillimetersPerSecond
+	CanonicalInterval  = Millimeters
+	CanonicalFrequency = Kilohertz
+)
+
+// MaxKilohertz is the highest pulse frequency a fiber or UV source runs at
+// (MOPA sources reach 4 MHz). A frequency with no unit above it can only
+// be in Hz.
+const MaxKilohertz = 4000
+
+const mmPerInch = 25.4
+
+// speedFactors is how many mm/s one of each unit is
+var speedFactors = map[string]float64{
+	MillimetersPerSecond: 1,
+	MillimetersPerMinute: 1.0 / 60,
+	InchesPerSecond:      mmPerInch,
+	InchesPerMinute:      mmPerInch / 60,
+}
+
+// frequencyFactors is how many kHz one of each unit is
+var frequencyFactors = map[string]float64{
+	Hertz:     0.001,
+	Kilohertz: 1,
+	Megahertz: 1000,
+}
+
+// SpeedUnits lists the accepted speed units
+var SpeedUnits = []string{MillimetersPerSecond, MillimetersPerMinute, InchesPerSecond, InchesPerMinute}
+
+// IntervalUnits lists the accepted interval units
+var IntervalUnits = []string{Millimeters, Inches, LinesPerInch}
+
+// FrequencyUnits lists the accepted frequency units
+var FrequencyUnits = []string{Hertz, Kilohertz, Megahertz}
+
+// unitAliases maps other spellings onto the unit names above
+var unitAliases = map[string]string{
+	"mm/sec":    MillimetersPerSecond,
+	"mmps":      MillimetersPerSecond,
+	"mm/minute": MillimetersPerMinute,
+	"mmpm":      MillimetersPerMinute,
+	"in/sec":    InchesPerSecond,
+	"ips":       InchesPerSecond,
+	"in/minute": InchesPerMinute,
+	"ipm":       InchesPerMinute,
+	"inch":      Inches,
+	"inches":    Inches,
+	"\"":        Inches,
+	"hz":        Hertz,
+	"khz":       Kilohertz,
+	"mhz":       Megahertz,
+}
+
+func normalize(unit string) string {
+	u := strings.ToLower(strings.TrimSpace(unit))
+	if alias, ok := unitAliases[u]; ok {
+		return alias
+	}
+	return u
+}
+
+// ParseSpeedUnit resolves a speed unit name. An empty name is the
+// canonical unit.
+func ParseSpeedUnit(unit string) (string, error) {
+	if strings.TrimSpace(unit) == "" {
+		return CanonicalSpeed, nil
+	}
+	u := normalize(unit)
+	if _, ok := speedFactors[u]; !ok {
+		return "", fmt.Errorf("unknown speed unit %q, use one of %s", unit, strings.Join(SpeedUnits, ", "))
+	}
+	return u, nil
+}
+
+// ParseIntervalUnit resolves an interval unit name. An empty name is the
+// canonical unit.
+func ParseIntervalUnit(unit string) (string, error) {
+	if strings.TrimSpace(unit) == "" {
+		return CanonicalInterval, nil
+	}
+	u := normalize(unit)
+	switch u {
+	case Millimeters, Inches, LinesPerInch:
+		return u, nil
+	}
+	return "", fmt.Errorf("unknown interval unit %q, use one of %s", unit, strings.Join(IntervalUnits, ", "))
+}
+
+// ParseFrequencyUnit resolves a frequency unit name. An empty name leaves
+// the unit to be detected from each value, see DetectFrequencyUnit.
+func ParseFrequencyUnit(unit string) (string, error) {
+	if strings.TrimSpace(unit) == "" {
+		return "", nil
+	}
+	u := normalize(unit)
+	if _, ok := frequencyFactors[u]; !ok {
+		return "", fmt.Errorf("unknown frequency unit %q, use one of %s", unit, strings.Join(FrequencyUnits, ", "))
+	}
+	return u, nil
+}
+
+// DetectFrequencyUnit guesses the unit of a frequency given without one.
+// Values above MaxKilohertz are taken as Hz (30000 is 30 kHz), the rest
+// as kHz.
+func DetectFrequencyUnit(v float64) string {
+	if v > MaxKilohertz {
+		return Hertz
+	}
+	return Kilohertz
+}
+
+// SpeedToCanonical converts a speed in unit to mm/s
+func SpeedToCanonical(v float64, unit string) float64 {
+	return v * speedFactors[unit]
+}
+
+// SpeedFromCanonical converts a speed in mm/s to unit
+func SpeedFromCanonical(v float64, unit string) float64 {
+	return v / speedFactors[unit]
+}
+
+// IntervalToCanonical converts an interval in unit to mm. A zero LPI has no
+// interval and converts to zero.
+func IntervalToCanonical(v float64, unit string) float64 {
+	switch unit {
+	case Inches:
+		return v * mmPerInch
+	case LinesPerInch:
+		if v == 0 {
+			return 0
+		}
+		return mmPerInch / v
+	}
+	return v
+}
+
+// IntervalFromCanonical converts an interval in mm to unit
+func IntervalFromCanonical(v float64, unit string) float64 {
+	// The conversion to and from lines per inch is the same reciprocal
+	if unit == LinesPerInch {
+		return IntervalToCanonical(v, unit)
+	}
+	return v / IntervalToCanonical(1, unit)
+}
+
+// FrequencyToCanonical converts a frequency in unit to kHz
+func FrequencyToCanonical(v float64, unit string) float64 {
+	return v * frequencyFactors[unit]
+}
+
+// FrequencyFromCanonical converts a frequency in kHz to unit
+func FrequencyFromCanonical(v float64, unit string) float64 {
+	return v / frequencyFactors[unit]
+}
+
+// Stored precision of each canonical value, matching the DECIMAL columns
+const (
+	speedDecimals     = 3
+	intervalDecimals  = 4
+	frequencyDecimals = 3
+)
+
+// ConvertSpeed converts a decimal string speed in unit to a canonical
+// decimal string. Blank values stay blank, and values that are not numbers
+// are returned unchanged for validation to report.
+func ConvertSpeed(s, unit string) string {
+	if unit == CanonicalSpeed {
+		return s
+	}
+	return convert(s, speedDecimals, func(v float64) float64 { return SpeedToCanonical(v, unit) })
+}
+
+// ConvertInterval converts a decimal string interval in unit to a canonical
+// decimal string, like ConvertSpeed
+func ConvertInterval(s, unit string) string {
+	if unit == CanonicalInterval {
+		return s
+	}
+	return convert(s, intervalDecimals, func(v float64) float64 { return IntervalToCanonical(v, unit) })
+}
+
+// ConvertFrequency converts a decimal string frequency in unit to kHz, like
+// ConvertSpeed. With no unit, the unit of each value is detected.
+func ConvertFrequency(s, unit string) string {
+	if unit == CanonicalFrequency {
+		return s
+	}
+	return convert(s, frequencyDecimals, func(v float64) float64 {
+		if unit == "" {
+			return FrequencyToCanonical(v, DetectFrequencyUnit(v))
+		}
+		return FrequencyToCanonical(v, unit)
+	})
+}
+
+func convert(s string, decimals int, f func(float64) float64) string {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return s
+	}
+	return Format(f(v), decimals)
+}
+
+// Format renders v rounded to decimals places without trailing zeros
+func Format(v float64, decimals int) string {
+	scale := math.Pow(10, float64(decimals))
+	return strconv.FormatFloat(math.Round(v*scale)/scale, 'f', -1, 64)
+}
@@ -0,0 +1,156 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseUnits(t *testing.T) {
+	cases := []struct {
+		parse func(string) (string, error)
+		in    string
+		want  string
+	}{
+		{ParseSpeedUnit, "", MillimetersPerSecond},
+		{ParseSpeedUnit, "MM/MIN", MillimetersPerMinute},
+		{ParseSpeedUnit, " ips ", InchesPerSecond},
+		{ParseSpeedUnit, "in/minute", InchesPerMinute},
+		{ParseIntervalUnit, "", Millimeters},
+		{ParseIntervalUnit, "LPI", LinesPerInch},
+		{ParseIntervalUnit, `"`, Inches},
+	}
+	for _, tc := range cases {
+		got, err := tc.parse(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("parse(%q) = %q, %v; want %q", tc.in, got, err, tc.want)
+		}
+	}
+	for _, bad := range []string{"m/s", "furlongs"} {
+		if _, err := ParseSpeedUnit(bad); err == nil {
+			t.Errorf("ParseSpeedUnit(%q) accepted", bad)
+		}
+		if _, err := ParseIntervalUnit(bad); err == nil {
+			t.Errorf("ParseIntervalUnit(%q) accepted", bad)
+		}
+	}
+}
+
+func TestConvertSpeed(t *testing.T) {
+	cases := []struct {
+		in, unit, want string
+	}{
+		{"6000", MillimetersPerMinute, "100"},
+		{"100", MillimetersPerMinute, "1.667"},
+		{"2", InchesPerSecond, "50.8"},
+		{"60", InchesPerMinute, "25.4"},
+		// Canonical, blank and unparseable values pass through
+		{"12.50", MillimetersPerSecond, "12.50"},
+		{"", MillimetersPerMinute, ""},
+		{"fast", MillimetersPerMinute, "fast"},
+	}
+	for _, tc := range cases {
+		if got := ConvertSpeed(tc.in, tc.unit); got != tc.want {
+			t.Errorf("ConvertSpeed(%q, %s) = %q, want %q", tc.in, tc.unit, got, tc.want)
+		}
+	}
+}
+
+func TestConvertInterval(t *testing.T) {
+	cases := []struct {
+		in, unit, want string
+	}{
+		{"254", LinesPerInch, "0.1"},
+		{"0", LinesPerInch, "0"},
+		{"0.004", Inches, "0.1016"},
+		{"0.08", Millimeters, "0.08"},
+	}
+	for _, tc := range cases {
+		if got := ConvertInterval(tc.in, tc.unit); got != tc.want {
+			t.Errorf("ConvertInterval(%q, %s) = %q, want %q", tc.in, tc.unit, got, tc.want)
+		}
+	}
+}
+
+func TestCanonicalRoundTrip(t *testing.T) {
+	for _, unit := range SpeedUnits {
+		if got := SpeedFromCanonical(SpeedToCanonical(123.4, unit), unit); math.Abs(got-123.4) > 1e-9 {
+			t.Errorf("speed in %s round trips to %v", unit, got)
+		}
+	}
+	for _, unit := range IntervalUnits {
+		if got := IntervalFromCanonical(IntervalToCanonical(0.25, unit), unit); math.Abs(got-0.25) > 1e-9 {
+			t.Errorf("interval in %s round trips to %v", unit, got)
+		}
+	}
+	if got := IntervalFromCanonical(0.1, LinesPerInch); math.Abs(got-254) > 1e-9 {
+		t.Errorf("0.1 mm = %v lpi, want 254", got)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		v        float64
+		decimals int
+		want     string
+	}{
+		{1.23456, 3, "1.235"},
+		{100, 3, "100"},
+		{0.10000001, 4, "0.1"},
+	}
+	for _, tc := range cases {
+		if got := Format(tc.v, tc.decimals); got != tc.want {
+			t.Errorf("Format(%v, %d) = %q, want %q", tc.v, tc.decimals, got, tc.want)
+		}
+	}
+}
+
+func TestDetectFrequencyUnit(t *testing.T) {
+	cases := map[float64]string{
+		30:           Kilohertz,
+		MaxKilohertz: Kilohertz,
+		4001:         Hertz,
+		30000:        Hertz,
+	}
+	for v, want := range cases {
+		if got := DetectFrequencyUnit(v); got != want {
+			t.Errorf("DetectFrequencyUnit(%v) = %s, want %s", v, got, want)
+		}
+	}
+}
+
+func TestConvertFrequency(t *testing.T) {
+	cases := []struct {
+		in, unit, want string
+	}{
+		// No unit: large values are Hz, the rest kHz
+		{"30000", "", "30"},
+		{"60", "", "60"},
+		{"4000", "", "4000"},
+		{"1500", Hertz, "1.5"},
+		{"2", Megahertz, "2000"},
+		{"45.5", Kilohertz, "45.5"},
+		{"", "", ""},
+	}
+	for _, tc := range cases {
+		if got := ConvertFrequency(tc.in, tc.unit); got != tc.want {
+			t.Errorf("ConvertFrequency(%q, %q) = %q, want %q", tc.in, tc.unit, got, tc.want)
+		}
+	}
+}
+
+func TestParseFrequencyUnit(t *testing.T) {
+	cases := map[string]string{
+		"":    "",
+		"hz":  Hertz,
+		"KHZ": Kilohertz,
+		"MHz": Megahertz,
+	}
+	for in, want := range cases {
+		if got, err := ParseFrequencyUnit(in); err != nil || got != want {
+			t.Errorf("ParseFrequencyUnit(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseFrequencyUnit("rpm"); err == nil {
+		t.Errorf("ParseFrequencyUnit accepted rpm")
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"laserscribe/backend/db"
+	"laserscribe/backend/units"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// SETTING VALIDATION
+// =====================
+
+// Settings created by hand, edited, forked or imported from a library all
+// pass through validateSetting before they are written, so a value MySQL
+// would reject or silently truncate comes back as a field error instead.
+
+// FieldError is a problem with one value, keyed by its API field name
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every problem found in a setting
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + " " + f.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// validationFailed responds with the field errors of a rejected setting
+func validationFailed(c *gin.Context, err *ValidationError) {
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "fields": err.Fields})
+}
+
+// laserLimits bounds the values a setting can have on one kind of laser.
+// Speeds are in mm/s, as LightBurn stores them in .clb files.
+type laserLimits struct {
+	MaxSpeed float64
+	// Pulsed sources are the only ones with a pulse frequency to set
+	Pulsed bool
+}
+
+var laserTypeLimits = map[db.SettingsLaserType]laserLimits{
+	db.SettingsLaserTypeCO2:      {MaxSpeed: 3000},
+	db.SettingsLaserTypeDiode:    {MaxSpeed: 5000},
+	db.SettingsLaserTypeInfrared: {MaxSpeed: 10000},
+	db.SettingsLaserTypeFiber:    {MaxSpeed: 20000, Pulsed: true},
+	db.SettingsLaserTypeUV:       {MaxSpeed: 20000, Pulsed: true},
+}
+
+// scanOperations are the operations that fill an area line by line
+var scanOperations = map[db.SettingsOperationType]bool{
+	db.SettingsOperationTypeScan:       true,
+	db.SettingsOperationTypeScanCut:    true,
+	db.SettingsOperationTypeImage:      true,
+	db.SettingsOperationTypeOffsetFill: true,
+}
+
+// settingValues are the values of a setting that are checked, in the form
+// both the create and update params carry them
+type settingValues struct {
+	MaxPower        string
+	MinPower        string
+	MaxPower2       sql.NullString
+	MinPower2       sql.NullString
+	Speed           string
+	NumPasses       int32
+	ZOffset         sql.NullString
+	ZPerPass        sql.NullString
+	ScanInterval    sql.NullString
+	Angle           sql.NullString
+	AnglePerPass    sql.NullString
+	Overscan        sql.NullString
+	OverscanPercent sql.NullString
+	Frequency       sql.NullString
+	PulseWidth      sql.NullString
+	WobbleDiameter  sql.NullString
+	WobbleStep      sql.NullString
+	DotWidth        sql.NullString
+	Kerf            sql.NullString
+	TabCount        sql.NullInt32
+	TabCountMax     sql.NullInt32
+}
+
+func createValues(p db.CreateSettingParams) settingValues {
+	return settingValues{
+		MaxPower:        p.MaxPower,
+		MinPower:        p.MinPower,
+		MaxPower2:       p.MaxPower2,
+		MinPower2:       p.MinPower2,
+		Speed:           p.Speed,
+		NumPasses:       p.NumPasses,
+		ZOffset:         p.ZOffset,
+		ZPerPass:        p.ZPerPass,
+		ScanInterval:    p.ScanInterval,
+		Angle:           p.Angle,
+		AnglePerPass:    p.AnglePerPass,
+		Overscan:        p.Overscan,
+		OverscanPercent: p.OverscanPercent,
+		Frequency:       p.Frequency,
+		PulseWidth:      p.PulseWidth,
+		WobbleDiameter:  p.WobbleDiameter,
+		WobbleStep:      p.WobbleStep,
+		DotWidth:        p.DotWidth,
+		Kerf:            p.Kerf,
+		TabCount:        p.TabCount,
+		TabCountMax:     p.TabCountMax,
+	}
+}
+
+func updateValues(p db.UpdateSettingParams) settingValues {
+	return settingValues{
+		MaxPower:        p.MaxPower,
+		MinPower:        p.MinPower,
+		MaxPower2:       p.MaxPower2,
+		MinPower2:       p.MinPower2,
+		Speed:           p.Speed,
+		NumPasses:       p.NumPasses,
+		ZOffset:         p.ZOffset,
+		ZPerPass:        p.ZPerPass,
+		ScanInterval:    p.ScanInterval,
+		Angle:           p.Angle,
+		AnglePerPass:    p.AnglePerPass,
+		Overscan:        p.Overscan,
+		OverscanPercent: p.OverscanPercent,
+		Frequency:       p.Frequency,
+		PulseWidth:      p.PulseWidth,
+		WobbleDiameter:  p.WobbleDiameter,
+		WobbleStep:      p.WobbleStep,
+		DotWidth:        p.DotWidth,
+		Kerf:            p.Kerf,
+		TabCount:        p.TabCount,
+		TabCountMax:     p.TabCountMax,
+	}
+}
+
+// decimalPattern is a plain decimal number, the only form the DECIMAL
+// columns are given
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)$`)
+
+// numberChecker parses values for validateSetting, recording a field error
+// for anything that is not a number in range
+type numberChecker struct {
+	errs *ValidationError
+}
+
+// required parses a value that must be present
+func (n numberChecker) required(field, s string, min, max float64) (float64, bool) {
+	if strings.TrimSpace(s) == "" {
+		n.errs.add(field, "is required")
+		return 0, false
+	}
+	return n.parse(field, s, min, max)
+}
+
+// optional parses a value that may be NULL
+func (n numberChecker) optional(field string, s sql.NullString, min, max float64) (float64, bool) {
+	if !s.Valid || strings.TrimSpace(s.String) == "" {
+		return 0, false
+	}
+	return n.parse(field, s.String, min, max)
+}
+
+func (n numberChecker) parse(field, s string, min, max float64) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		n.errs.add(field, "must be a number, got %q", s)
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		n.errs.add(field, "must be a number, got %q", s)
+		return 0, false
+	}
+	if v < min || v > max {
+		n.errs.add(field, "must be between %s and %s", formatLimit(min), formatLimit(max))
+		return v, false
+	}
+	return v, true
+}
+
+func formatLimit(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// validateSetting checks the values of a setting for laserType and the
+// operation it runs. It returns nil when they can be stored.
+func validateSetting(laserType db.SettingsLaserType, wattage int32, op db.SettingsOperationType, v settingValues) *ValidationError {
+	errs := &ValidationError{}
+	n := numberChecker{errs: errs}
+
+	limits, knownLaser := laserTypeLimits[laserType]
+	if !knownLaser {
+		errs.add("laserType", "must be one of CO2, Fiber, Diode, UV or Infrared")
+		limits = laserLimits{MaxSpeed: 20000}
+	}
+	if wattage <= 0 {
+		errs.add("wattage", "must be greater than 0")
+	}
+
+	// Powers are percentages of the laser's rated output
+	maxPower, maxOK := n.required("maxPower", v.MaxPower, 0, 100)
+	if maxOK && maxPower == 0 {
+		errs.add("maxPower", "must be greater than 0")
+	}
+	minPower, minOK := n.optional("minPower", sql.NullString{String: v.MinPower, Valid: true}, 0, 100)
+	if maxOK && minOK && minPower > maxPower {
+		errs.add("minPower", "must not be more than maxPower")
+	}
+	maxPower2, max2OK := n.optional("maxPower2", v.MaxPower2, 0, 100)
+	minPower2, min2OK := n.optional("minPower2", v.MinPower2, 0, 100)
+	if max2OK && min2OK && minPower2 > maxPower2 {
+		errs.add("minPower2", "must not be more than maxPower2")
+	}
+
+	if speed, ok := n.required("speed", v.Speed, 0, limits.MaxSpeed); ok && speed == 0 {
+		errs.add("speed", "must be greater than 0")
+	}
+	if v.NumPasses < 1 || v.NumPasses > 1000 {
+		errs.add("numPasses", "must be between 1 and 1000")
+	}
+	n.optional("zOffset", v.ZOffset, -100, 100)
+	n.optional("zPerPass", v.ZPerPass, -100, 100)
+
+	// A fill needs a line interval, and a zero one would never advance to
+	// the next line
+	if scanOperations[op] && (!v.ScanInterval.Valid || strings.TrimSpace(v.ScanInterval.String) == "") {
+		errs.add("scanInterval", "is required for fill operations")
+	} else if interval, ok := n.optional("scanInterval", v.ScanInterval, 0, 10); ok && interval == 0 && scanOperations[op] {
+		errs.add("scanInterval", "must be greater than 0")
+	}
+	n.optional("angle", v.Angle, -360, 360)
+	n.optional("anglePerPass", v.AnglePerPass, -360, 360)
+	n.optional("overscan", v.Overscan, 0, 1000)
+	n.optional("overscanPercent", v.OverscanPercent, 0, 100)
+
+	// Frequency is in kHz and pulse width in ns
+	pulsed := func(field string, value sql.NullString, max float64) {
+		if !value.Valid || strings.TrimSpace(value.String) == "" {
+			return
+		}
+		if knownLaser && !limits.Pulsed {
+			errs.add(field, "only applies to Fiber and UV lasers")
+		} else if f, ok := n.optional(field, value, 0, max); ok && f == 0 {
+			errs.add(field, "must be greater than 0")
+		}
+	}
+	pulsed("frequency", v.Frequency, units.MaxKilohertz)
+	pulsed("pulseWidth", v.PulseWidth, 1000)
+	n.optional("wobbleDiameter", v.WobbleDiameter, 0, 10)
+	n.optional("wobbleStep", v.WobbleStep, 0, 10)
+	n.optional("dotWidth", v.DotWidth, 0, 10)
+	n.optional("kerf", v.Kerf, -10, 10)
+
+	if v.TabCount.Valid && v.TabCount.Int32 < 0 {
+		errs.add("tabCount", "must not be negative")
+	}
+	if v.TabCount.Valid && v.TabCountMax.Valid && v.TabCountMax.Int32 < v.TabCount.Int32 {
+		errs.add("tabCountMax", "must not be less than tabCount")
+	}
+
+	if len(errs.Fields) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateSublayer checks the values of one of a setting's sublayers for
+// laserType, recording errors under prefix, such as "subLayers[0].", in errs
+func validateSublayer(errs *ValidationError, prefix string, laserType db.SettingsLaserType, p db.CreateSettingSublayerParams) {
+	n := numberChecker{errs: errs}
+	limits, knownLaser := laserTypeLimits[laserType]
+	if !knownLaser {
+		limits = laserLimits{MaxSpeed: 20000}
+	}
+
+	maxPower, maxOK := n.required(prefix+"maxPower", p.MaxPower, 0, 100)
+	if maxOK && maxPower == 0 {
+		errs.add(prefix+"maxPower", "must be greater than 0")
+	}
+	minPower, minOK := n.optional(prefix+"minPower", sql.NullString{String: p.MinPower, Valid: true}, 0, 100)
+	if maxOK && minOK && minPower > maxPower {
+		errs.add(prefix+"minPower", "must not be more than maxPower")
+	}
+	maxPower2, max2OK := n.optional(prefix+"maxPower2", p.MaxPower2, 0, 100)
+	minPower2, min2OK := n.optional(prefix+"minPower2", p.MinPower2, 0, 100)
+	if max2OK && min2OK && minPower2 > maxPower2 {
+		errs.add(prefix+"minPower2", "must not be more than maxPower2")
+	}
+	if speed, ok := n.required(prefix+"speed", p.Speed, 0, limits.MaxSpeed); ok && speed == 0 {
+		errs.add(prefix+"speed", "must be greater than 0")
+	}
+	if p.NumPasses < 1 || p.NumPasses > 1000 {
+		errs.add(prefix+"numPasses", "must be between 1 and 1000")
+	}
+	n.optional(prefix+"scanInterval", p.ScanInterval, 0, 10)
+	if p.Frequency.Valid && strings.TrimSpace(p.Frequency.String) != "" {
+		if knownLaser && !limits.Pulsed {
+			errs.add(prefix+"frequency", "only applies to Fiber and UV lasers")
+		} else if f, ok := n.optional(prefix+"frequency", p.Frequency, 0, units.MaxKilohertz); ok && f == 0 {
+			errs.add(prefix+"frequency", "must be greater than 0")
+		}
+	}
+}
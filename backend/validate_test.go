@@ -0,0 +1,150 @@
+package main
+
+import (
+	"database/sql"
+	"laserscribe/backend/clb"
+	"laserscribe/backend/db"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func validValues() settingValues {
+	return settingValues{
+		MaxPower:     "80",
+		MinPower:     "10",
+		Speed:        "1000",
+		NumPasses:    1,
+		ScanInterval: validString("0.05"),
+	}
+}
+
+func validString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: true}
+}
+
+func TestValidateSetting(t *testing.T) {
+	cases := []struct {
+		name      string
+		laserType db.SettingsLaserType
+		wattage   int32
+		op        db.SettingsOperationType
+		change    func(v *settingValues)
+		fields    []string // fields with errors, none when valid
+	}{
+		{"valid fill", db.SettingsLaserTypeFiber, 20, db.SettingsOperationTypeScan, nil, nil},
+		{"unknown laser type", "Plasma", 20, db.SettingsOperationTypeScan, nil, []string{"laserType"}},
+		{"zero wattage", db.SettingsLaserTypeCO2, 0, db.SettingsOperationTypeCut, nil, []string{"wattage"}},
+		{"power over 100", db.SettingsLaserTypeCO2, 60, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.MaxPower = "120" }, []string{"maxPower"}},
+		{"zero power", db.SettingsLaserTypeCO2, 60, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.MaxPower, v.MinPower = "0", "0" }, []string{"maxPower"}},
+		{"min above max", db.SettingsLaserTypeCO2, 60, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.MinPower = "90" }, []string{"minPower"}},
+		{"second laser min above max", db.SettingsLaserTypeCO2, 60, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.MaxPower2, v.MinPower2 = validString("20"), validString("30") }, []string{"minPower2"}},
+		{"not a number", db.SettingsLaserTypeCO2, 60, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.Speed = "1e3" }, []string{"speed"}},
+		{"missing speed", db.SettingsLaserTypeCO2, 60, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.Speed = " " }, []string{"speed"}},
+		{"CO2 too fast", db.SettingsLaserTypeCO2, 60, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.Speed = "3001" }, []string{"speed"}},
+		{"fiber at the same speed", db.SettingsLaserTypeFiber, 20, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.Speed = "3001" }, nil},
+		{"no passes", db.SettingsLaserTypeCO2, 60, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.NumPasses = 0 }, []string{"numPasses"}},
+		{"zero interval on a fill", db.SettingsLaserTypeCO2, 60, db.SettingsOperationTypeScan,
+			func(v *settingValues) { v.ScanInterval = validString("0") }, []string{"scanInterval"}},
+		{"no interval on a fill", db.SettingsLaserTypeCO2, 60, db.SettingsOperationTypeImage,
+			func(v *settingValues) { v.ScanInterval = sql.NullString{} }, []string{"scanInterval"}},
+		{"blank interval on a fill", db.SettingsLaserTypeFiber, 20, db.SettingsOperationTypeOffsetFill,
+			func(v *settingValues) { v.ScanInterval = validString(" ") }, []string{"scanInterval"}},
+		{"no interval on a cut", db.SettingsLaserTypeCO2, 60, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.ScanInterval = sql.NullString{} }, nil},
+		{"zero interval on a cut", db.SettingsLaserTypeCO2, 60, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.ScanInterval = validString("0") }, nil},
+		{"frequency on a CO2", db.SettingsLaserTypeCO2, 60, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.Frequency = validString("30") }, []string{"frequency"}},
+		{"frequency in Hz on a fiber", db.SettingsLaserTypeFiber, 20, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.Frequency = validString("30000") }, []string{"frequency"}},
+		{"pulse width on a UV", db.SettingsLaserTypeUV, 5, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.PulseWidth = validString("200") }, nil},
+		{"tabs", db.SettingsLaserTypeCO2, 60, db.SettingsOperationTypeCut,
+			func(v *settingValues) {
+				v.TabCount = sql.NullInt32{Int32: 4, Valid: true}
+				v.TabCountMax = sql.NullInt32{Int32: 2, Valid: true}
+			}, []string{"tabCountMax"}},
+		{"every error is reported", db.SettingsLaserTypeDiode, 10, db.SettingsOperationTypeCut,
+			func(v *settingValues) { v.MaxPower, v.Speed, v.Kerf = "x", "6000", validString("11") }, []string{"kerf", "maxPower", "speed"}},
+	}
+	for _, tc := range cases {
+		v := validValues()
+		if tc.change != nil {
+			tc.change(&v)
+		}
+		verr := validateSetting(tc.laserType, tc.wattage, tc.op, v)
+		var fields []string
+		if verr != nil {
+			for _, f := range verr.Fields {
+				fields = append(fields, f.Field)
+			}
+			sort.Strings(fields)
+		}
+		if !reflect.DeepEqual(fields, tc.fields) {
+			t.Errorf("%s: errors on %v, want %v (%v)", tc.name, fields, tc.fields, verr)
+		}
+	}
+}
+
+func TestValidateImportItemSubLayers(t *testing.T) {
+	params := db.CreateSettingParams{
+		OperationType: db.SettingsOperationTypeScan,
+		MaxPower:      "80",
+		MinPower:      "10",
+		Speed:         "1000",
+		NumPasses:     1,
+		ScanInterval:  validString("0.05"),
+	}
+	subLayer := func(maxPower, speed, frequency, interval string) clb.SubLayer {
+		var sl clb.SubLayer
+		sl.MaxPower, sl.Speed, sl.Interval = clb.V(maxPower), clb.V(speed), clb.V(interval)
+		if frequency != "" {
+			sl.Frequency = clb.V(frequency)
+		}
+		return sl
+	}
+	cases := []struct {
+		name      string
+		laserType db.SettingsLaserType
+		subLayers []clb.SubLayer
+		fields    []string
+	}{
+		{"valid", db.SettingsLaserTypeFiber, []clb.SubLayer{subLayer("50", "2000", "30", "0.02")}, nil},
+		{"power over 100", db.SettingsLaserTypeFiber,
+			[]clb.SubLayer{subLayer("50", "2000", "", "0.02"), subLayer("150", "2000", "", "0.02")},
+			[]string{"subLayers[1].maxPower"}},
+		{"too fast for CO2", db.SettingsLaserTypeCO2, []clb.SubLayer{subLayer("50", "5000", "", "0.1")},
+			[]string{"subLayers[0].speed"}},
+		{"frequency in Hz", db.SettingsLaserTypeFiber, []clb.SubLayer{subLayer("50", "2000", "30000", "0.02")},
+			[]string{"subLayers[0].frequency"}},
+		{"frequency on a CO2", db.SettingsLaserTypeCO2, []clb.SubLayer{subLayer("50", "500", "30", "0.1")},
+			[]string{"subLayers[0].frequency"}},
+		{"interval out of range", db.SettingsLaserTypeFiber, []clb.SubLayer{subLayer("50", "2000", "", "12")},
+			[]string{"subLayers[0].scanInterval"}},
+		{"missing speed", db.SettingsLaserTypeFiber, []clb.SubLayer{subLayer("50", "", "", "0.02")},
+			[]string{"subLayers[0].speed"}},
+	}
+	for _, tc := range cases {
+		verr := validateImportItem(tc.laserType, 20, params, tc.subLayers)
+		var fields []string
+		if verr != nil {
+			for _, f := range verr.Fields {
+				fields = append(fields, f.Field)
+			}
+			sort.Strings(fields)
+		}
+		if !reflect.DeepEqual(fields, tc.fields) {
+			t.Errorf("%s: errors on %v, want %v (%v)", tc.name, fields, tc.fields, verr)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"laserscribe/backend/db"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =====================
+// VOTE RATE LIMITS
+// =====================
+
+// rateWindow caps how many requests fit in a sliding window
+type rateWindow struct {
+	Limit  int
+	Period time.Duration
+}
+
+// rateLimiter keeps, per key, the times of recent requests. It is in memory,
+// so limits reset when the API restarts.
+type rateLimiter struct {
+	mu      sync.Mutex
+	windows []rateWindow
+	hits    map[int32][]time.Time
+	// lastSweep is when keys that went idle were last dropped
+	lastSweep time.Time
+}
+
+func newRateLimiter(windows ...rateWindow) *rateLimiter {
+	return &rateLimiter{windows: windows, hits: make(map[int32][]time.Time)}
+}
+
+// allow records a request for key and reports whether it is within every
+// window. When it is not, it returns how long until it would be.
+func (l *rateLimiter) allow(key int32, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Anything older than the longest window can never count again
+	longest := time.Duration(0)
+	for _, w := range l.windows {
+		if w.Period > longest {
+			longest = w.Period
+		}
+	}
+	if now.Sub(l.lastSweep) >= longest {
+		l.sweep(now, longest)
+	}
+	hits := l.hits[key]
+	for len(hits) > 0 && now.Sub(hits[0]) >= longest {
+		hits = hits[1:]
+	}
+
+	var wait time.Duration
+	for _, w := range l.windows {
+		inWindow := 0
+		for i := len(hits) - 1; i >= 0 && now.Sub(hits[i]) < w.Period; i-- {
+			inWindow++
+		}
+		if inWindow >= w.Limit {
+			// Room frees up when the oldest hit in the window leaves it
+			oldest := hits[len(hits)-inWindow]
+			if d := w.Period - now.Sub(oldest); d > wait {
+				wait = d
+			}
+		}
+	}
+	if wait > 0 {
+		l.hits[key] = hits
+		return false, wait
+	}
+	l.hits[key] = append(hits, now)
+	return true, 0
+}
+
+// sweep forgets the keys with no hits in the longest window, so callers who
+// stopped making requests don't keep memory. It runs at most once per
+// window, keeping allow cheap.
+func (l *rateLimiter) sweep(now time.Time, longest time.Duration) {
+	for key, hits := range l.hits {
+		if len(hits) == 0 || now.Sub(hits[len(hits)-1]) >= longest {
+			delete(l.hits, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// voteLimiter is shared by casting and retracting votes, so toggling a vote
+// back and forth counts against the same budget
+var voteLimiter = newRateLimiter(
+	rateWindow{Limit: 30, Period: time.Minute},
+	rateWindow{Limit: 500, Period: 24 * time.Hour},
+)
+
+// voteRateLimit rejects vote requests from a user over the vote limits
+func voteRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDVal, _ := c.Get("user_id")
+		userID := userIDVal.(int32)
+		if ok, wait := voteLimiter.allow(userID, time.Now()); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many votes, try again later"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// =====================
+// VOTE RETRACTION
+// =====================
+
+func deleteVoteHandler(c *gin.Context) {
+	userIDVal, _ := c.Get("user_id")
+	userID := userIDVal.(int32)
+	settingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid setting id"})
+		return
+	}
+
+	removed, err := queries.DeleteVote(c.Request.Context(), db.DeleteVoteParams{
+		UserID:    userID,
+		SettingID: int32(settingID),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if removed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "you have not voted on this setting"})
+		return
+	}
+
+	score, err := queries.GetVoteScore(c.Request.Context(), int32(settingID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"score": score.Score, "total": score.Total})
+}
+
+// =====================
+// VOTE RING REPORT
+// =====================
+
+// voteRing is a group of accounts linked by upvoting each other's settings
+type voteRing struct {
+	Members []int32
+	// Upvotes counts the upvotes members gave to settings of other members
+	Upvotes int64
+	// Insularity is the mean, over members, of the share of their votes that
+	// went to other members
+	Insularity float64
+}
+
+// findVoteRings links two accounts when each has upvoted a setting of the
+// other and returns the connected groups. A member's insularity is the share
+// of all the votes they cast that went to the rest of the group, so accounts
+// that also vote on the wider catalog score low.
+func findVoteRings(edges []db.GetUpvoteEdgesRow, voteCounts map[int32]int64) []voteRing {
+	upvotes := make(map[int32]map[int32]int64)
+	for _, e := range edges {
+		if upvotes[e.VoterID] == nil {
+			upvotes[e.VoterID] = make(map[int32]int64)
+		}
+		upvotes[e.VoterID][e.OwnerID] = e.Upvotes
+	}
+
+	parent := make(map[int32]int32)
+	var find func(int32) int32
+	find = func(id int32) int32 {
+		if p, ok := parent[id]; ok && p != id {
+			root := find(p)
+			parent[id] = root
+			return root
+		}
+		parent[id] = id
+		return id
+	}
+	for voter, owners := range upvotes {
+		for owner := range owners {
+			if upvotes[owner][voter] > 0 {
+				parent[find(voter)] = find(owner)
+			}
+		}
+	}
+
+	groups := make(map[int32][]int32)
+	for id := range parent {
+		root := find(id)
+		groups[root] = append(groups[root], id)
+	}
+
+	rings := make([]voteRing, 0)
+	for _, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+		sort.Slice(members, func(i, j int) bool { return members[i] < members[j] })
+		ring := voteRing{Members: members}
+		for _, m := range members {
+			var inside int64
+			for _, o := range members {
+				inside += upvotes[m][o]
+			}
+			ring.Upvotes += inside
+			if total := voteCounts[m]; total > 0 {
+				ring.Insularity += float64(inside) / float64(total)
+			}
+		}
+		ring.Insularity /= float64(len(members))
+		rings = append(rings, ring)
+	}
+	return rings
+}
+
+// adminVoteRingsHandler lists groups of accounts that mostly upvote each
+// other. min_insularity (0-1, default 0.8) and min_votes (default 3, the
+// upvotes inside the group) tune what is reported.
+func adminVoteRingsHandler(c *gin.Context) {
+	minInsularity := 0.8
+	if v := c.Query("min_insularity"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_insularity must be between 0 and 1"})
+			return
+		}
+		minInsularity = parsed
+	}
+	minVotes := int64(3)
+	if v := c.Query("min_votes"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_votes must be a positive integer"})
+			return
+		}
+		minVotes = int64(parsed)
+	}
+
+	edges, err := queries.GetUpvoteEdges(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	voters, err := queries.GetVoterTotals(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	voteCounts := make(map[int32]int64, len(voters))
+	byID := make(map[int32]db.GetVoterTotalsRow, len(voters))
+	for _, v := range voters {
+		voteCounts[v.ID] = v.VoteCount
+		byID[v.ID] = v
+	}
+
+	upvotes := make(map[[2]int32]int64, len(edges))
+	for _, e := range edges {
+		upvotes[[2]int32{e.VoterID, e.OwnerID}] = e.Upvotes
+	}
+
+	rings := findVoteRings(edges, voteCounts)
+	sort.Slice(rings, func(i, j int) bool {
+		if rings[i].Insularity != rings[j].Insularity {
+			return rings[i].Insularity > rings[j].Insularity
+		}
+		return rings[i].Upvotes > rings[j].Upvotes
+	})
+
+	response := make([]gin.H, 0)
+	for _, ring := range rings {
+		if ring.Insularity < minInsularity || ring.Upvotes < minVotes {
+			continue
+		}
+		members := make([]gin.H, 0, len(ring.Members))
+		for _, id := range ring.Members {
+			v := byID[id]
+			var inside int64
+			for _, o := range ring.Members {
+				inside += upvotes[[2]int32{id, o}]
+			}
+			createdAt := ""
+			if v.CreatedAt.Valid {
+				createdAt = v.CreatedAt.Time.Format(time.RFC3339)
+			}
+			members = append(members, gin.H{
+				"id":            id,
+				"email":         v.Email,
+				"name":          authorName(v.DisplayName, v.FirstName, v.LastName),
+				"emailVerified": v.EmailVerified,
+				"createdAt":     createdAt,
+				"votes":         v.VoteCount,
+				"upvotes":       v.UpvoteCount,
+				"upvotesInRing": inside,
+			})
+		}
+		response = append(response, gin.H{
+			"members":    members,
+			"upvotes":    ring.Upvotes,
+			"insularity": math.Round(ring.Insularity*1000) / 1000,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rings":         response,
+		"minInsularity": minInsularity,
+		"minVotes":      minVotes,
+	})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"laserscribe/backend/db"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	l := newRateLimiter(
+		rateWindow{Limit: 2, Period: time.Minute},
+		rateWindow{Limit: 3, Period: time.Hour},
+	)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	steps := []struct {
+		key   int32
+		after time.Duration
+		ok    bool
+		wait  time.Duration
+	}{
+		{1, 0, true, 0},
+		{1, 10 * time.Second, true, 0},
+		// Third in a minute: wait for the first to leave the window
+		{1, 20 * time.Second, false, 40 * time.Second},
+		// Other keys have their own budget
+		{2, 20 * time.Second, true, 0},
+		// Refused requests don't count, so room frees up on time
+		{1, 61 * time.Second, true, 0},
+		// Both windows are full now; the hourly one decides the wait
+		{1, 62 * time.Second, false, time.Hour - 62*time.Second},
+		{1, time.Hour + 11*time.Second, true, 0},
+	}
+	for i, s := range steps {
+		ok, wait := l.allow(s.key, start.Add(s.after))
+		if ok != s.ok || wait != s.wait {
+			t.Errorf("step %d: allow = %v, %v; want %v, %v", i, ok, wait, s.ok, s.wait)
+		}
+	}
+}
+
+func TestRateLimiterForgetsIdleKeys(t *testing.T) {
+	l := newRateLimiter(rateWindow{Limit: 1, Period: time.Minute}, rateWindow{Limit: 5, Period: time.Hour})
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for key := int32(1); key <= 100; key++ {
+		l.allow(key, start)
+	}
+	l.allow(1, start.Add(30*time.Minute))
+	if len(l.hits) != 100 {
+		t.Fatalf("%d keys kept within the hour, want 100", len(l.hits))
+	}
+	// An hour on, only the key used in the last hour is left
+	l.allow(2, start.Add(time.Hour))
+	if len(l.hits) != 2 {
+		t.Errorf("%d keys kept after an hour, want 2", len(l.hits))
+	}
+	if _, ok := l.hits[1]; !ok {
+		t.Errorf("key 1 was forgotten within its window")
+	}
+}
+
+func TestFindVoteRings(t *testing.T) {
+	edges := []db.GetUpvoteEdgesRow{
+		{VoterID: 1, OwnerID: 2, Upvotes: 3},
+		{VoterID: 2, OwnerID: 1, Upvotes: 2},
+		// One-way votes don't link accounts
+		{VoterID: 2, OwnerID: 3, Upvotes: 2},
+		{VoterID: 4, OwnerID: 5, Upvotes: 1},
+		{VoterID: 5, OwnerID: 4, Upvotes: 1},
+		{VoterID: 5, OwnerID: 6, Upvotes: 2},
+		{VoterID: 6, OwnerID: 5, Upvotes: 2},
+	}
+	voteCounts := map[int32]int64{1: 3, 2: 4, 3: 10, 4: 2, 5: 3, 6: 4}
+
+	rings := findVoteRings(edges, voteCounts)
+	sort.Slice(rings, func(i, j int) bool { return rings[i].Members[0] < rings[j].Members[0] })
+	want := []voteRing{
+		{Members: []int32{1, 2}, Upvotes: 5, Insularity: (1 + 0.5) / 2},
+		{Members: []int32{4, 5, 6}, Upvotes: 6, Insularity: (0.5 + 1 + 0.5) / 3},
+	}
+	if len(rings) != len(want) {
+		t.Fatalf("got %d rings, want %d: %+v", len(rings), len(want), rings)
+	}
+	for i, w := range want {
+		r := rings[i]
+		if !reflect.DeepEqual(r.Members, w.Members) || r.Upvotes != w.Upvotes || math.Abs(r.Insularity-w.Insularity) > 1e-9 {
+			t.Errorf("ring %d = %+v, want %+v", i, r, w)
+		}
+	}
+}
+
+func TestFindVoteRingsNoMutualVotes(t *testing.T) {
+	edges := []db.GetUpvoteEdgesRow{
+		{VoterID: 1, OwnerID: 2, Upvotes: 5},
+		{VoterID: 2, OwnerID: 3, Upvotes: 5},
+	}
+	if rings := findVoteRings(edges, map[int32]int64{1: 5, 2: 5}); len(rings) != 0 {
+		t.Errorf("got rings %+v, want none", rings)
+	}
+}
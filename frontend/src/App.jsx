@@ -8,6 +8,8 @@ import SearchPage from './pages/SearchPage'
 import ReviewCartPage from './pages/ReviewCartPage'
 import ContributePage from './pages/ContributePage'
 import SettingDetailPage from './pages/SettingDetailPage'
+import RecipeSetsPage from './pages/RecipeSetsPage'
+import TestGridPage from './pages/TestGridPage'
 import ProfilePage from './pages/ProfilePage'
 import LoginPage from './pages/LoginPage'
 import RegisterPage from './pages/RegisterPage'
@@ -63,8 +65,10 @@ function App() {
               <Route path="/search" element={user ? <SearchPage user={user} /> : <Navigate to="/powerscale" />} />
               <Route path="/cart" element={user ? <ReviewCartPage user={user} /> : <Navigate to="/powerscale" />} />
               <Route path="/settings/:id" element={<SettingDetailPage user={user} />} />
+              <Route path="/recipes" element={<RecipeSetsPage user={user} />} />
+              <Route path="/test-grid" element={user ? <TestGridPage user={user} /> : <Navigate to="/login" />} />
               <Route path="/contribute" element={user ? <ContributePage user={user} /> : <Navigate to="/login" />} />
-              <Route path="/profile" element={user ? <ProfilePage user={user} /> : <Navigate to="/login" />} />
+              <Route path="/profile" element={user ? <ProfilePage user={user} onUserChange={setUser} /> : <Navigate to="/login" />} />
               <Route path="/login" element={user ? <Navigate to="/search" /> : <LoginPage onLogin={login} />} />
               <Route path="/register" element={user ? <Navigate to="/" /> : <RegisterPage />} />
               <Route path="/verified" element={<VerifiedPage />} />
@@ -1,10 +1,11 @@
-import { useState } from 'react'
+import { useEffect, useState } from 'react'
 import { useNavigate } from 'react-router-dom'
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
 import { Select, SelectItem } from './ui/Select'
 import Input from './ui/Input'
 import Button from './ui/Button'
 import Card from './ui/Card'
+import { frequencyUnits, intervalUnits, speedUnits, userIntervalUnit, userSpeedUnit } from '../units'
 
 function ContributeForm({ user, initialMode = 'manual' }) {
   const navigate = useNavigate()
@@ -32,18 +33,32 @@ function ContributeForm({ user, initialMode = 'manual' }) {
     speed: '',
     numPasses: '1',
     frequency: '',
+    pulseWidth: '',
+    wobbleDiameter: '',
+    wobbleStep: '',
     layerName: '',
     notes: '',
+    thicknessMm: '',
+    color: '',
+    finish: '',
+    grade: '',
   })
   const [importForm, setImportForm] = useState({
     file: null,
+    laserModelId: '',
     laserMakeModel: '',
     laserType: '',
     wattage: '',
+    speedUnit: 'mm/s',
+    intervalUnit: 'mm',
+    frequencyUnit: 'auto',
   })
   const [success, setSuccess] = useState(false)
   const [error, setError] = useState('')
   const [importResult, setImportResult] = useState(null)
+  const [importPreview, setImportPreview] = useState(null)
+  const [materialId, setMaterialId] = useState(null) // Catalog material picked for a typed name
+  const [materialDecisions, setMaterialDecisions] = useState({}) // Import material name -> decision
 
   const { data: categories } = useQuery({
     queryKey: ['categories'],
@@ -67,6 +82,7 @@ function ContributeForm({ user, initialMode = 'manual' }) {
     onSuccess: () => {
       setSuccess(true)
       setError('')
+      setMaterialId(null)
       queryClient.invalidateQueries({ queryKey: ['settings'] })
       setForm({
         materialName: '', laserType: '', wattage: '', mode: '',
@@ -76,6 +92,8 @@ function ContributeForm({ user, initialMode = 'manual' }) {
         wobbleEnable: false, imageMode: '', negativeImage: false,
         useDotCorrection: false, dotWidth: '',
         speed: '', numPasses: '1', frequency: '', layerName: '', notes: '',
+        pulseWidth: '', wobbleDiameter: '', wobbleStep: '',
+        thicknessMm: '', color: '', finish: '', grade: '',
       })
     },
     onError: (err) => {
@@ -84,6 +102,66 @@ function ContributeForm({ user, initialMode = 'manual' }) {
     },
   })
 
+  // Propose the catalog material the typed name most likely means
+  const { data: materialMatch } = useQuery({
+    queryKey: ['materials', 'match', form.materialName],
+    queryFn: () =>
+      fetch(`/api/materials/match?name=${encodeURIComponent(form.materialName)}`).then(r => r.json()),
+    enabled: form.materialName.trim().length >= 2,
+  })
+
+  const { data: machines } = useQuery({
+    queryKey: ['machines', importForm.laserMakeModel],
+    queryFn: () =>
+      fetch(`/api/machines?q=${encodeURIComponent(importForm.laserMakeModel)}`).then(r => r.json()),
+    enabled: importForm.laserMakeModel.length >= 2,
+  })
+
+  const { data: myMachines } = useQuery({
+    queryKey: ['profile', 'machines'],
+    queryFn: () =>
+      fetch('/api/profile/machines', { credentials: 'include' }).then(r => r.json()),
+    enabled: !!user,
+  })
+  const defaultMachine = Array.isArray(myMachines) ? myMachines.find((m) => m.isDefault) : null
+
+  // The import form starts out filled in for the user's default machine
+  function defaultImportForm() {
+    return {
+      file: null,
+      laserModelId: defaultMachine?.laserModelId ? String(defaultMachine.laserModelId) : '',
+      laserMakeModel: defaultMachine?.makeModel || '',
+      laserType: defaultMachine?.laserType || '',
+      wattage: defaultMachine ? String(defaultMachine.wattage) : '',
+    }
+  }
+
+  useEffect(() => {
+    if (defaultMachine) {
+      setImportForm((f) => (f.laserMakeModel || f.laserType || f.wattage ? f : { ...defaultImportForm(), file: f.file }))
+    }
+  }, [defaultMachine?.id])
+
+  function machineLabel(m) {
+    return `${m.ManufacturerName} ${m.Name} ${m.Wattage}W`
+  }
+
+  // Picking a catalog machine fills in its laser type and wattage
+  function handleMakeModelChange(value) {
+    const machine = machines?.find((m) => machineLabel(m) === value)
+    if (machine) {
+      setImportForm({
+        ...importForm,
+        laserModelId: String(machine.ID),
+        laserMakeModel: value,
+        laserType: machine.LaserType,
+        wattage: String(machine.Wattage),
+      })
+    } else {
+      setImportForm({ ...importForm, laserModelId: '', laserMakeModel: value })
+    }
+  }
+
   const importMutation = useMutation({
     mutationFn: (formData) =>
       fetch('/api/settings/import', {
@@ -99,9 +177,11 @@ function ContributeForm({ user, initialMode = 'manual' }) {
       }),
     onSuccess: (data) => {
       setImportResult(data)
+      setImportPreview(null)
+      setMaterialDecisions({})
       setError('')
       queryClient.invalidateQueries({ queryKey: ['settings'] })
-      setImportForm({ file: null, laserMakeModel: '', laserType: '', wattage: '' })
+      setImportForm(defaultImportForm())
     },
     onError: (err) => {
       setError(err.message)
@@ -109,6 +189,37 @@ function ContributeForm({ user, initialMode = 'manual' }) {
     },
   })
 
+  const previewMutation = useMutation({
+    mutationFn: (formData) =>
+      fetch('/api/settings/import', {
+        method: 'POST',
+        credentials: 'include',
+        body: formData,
+      }).then(async (r) => {
+        if (!r.ok) {
+          const err = await r.json()
+          throw new Error(err.error || 'Failed to preview import')
+        }
+        return r.json()
+      }),
+    onSuccess: (data) => {
+      setImportPreview(data)
+      setError('')
+    },
+    onError: (err) => {
+      setError(err.message)
+      setImportPreview(null)
+    },
+  })
+
+  // Values are entered in the user's preferred units and converted by the API
+  const speedUnit = userSpeedUnit(user)
+  const intervalUnit = userIntervalUnit(user)
+
+  // Only pulsed sources take a frequency or pulse width; the API rejects
+  // them for the rest
+  const pulsedLaser = form.laserType === 'Fiber' || form.laserType === 'UV'
+
   function handleManualSubmit(e) {
     e.preventDefault()
     setError('')
@@ -119,14 +230,26 @@ function ContributeForm({ user, initialMode = 'manual' }) {
       laserType: form.laserType,
       wattage: parseInt(form.wattage),
       mode: form.mode,
+      operationType: form.mode,
       maxPower: form.maxPower,
       speed: form.speed,
+      speedUnit,
+      intervalUnit,
       numPasses: parseInt(form.numPasses) || 1,
     }
 
-    if (form.frequency) data.frequency = form.frequency
+    if (materialId) data.materialId = materialId
+    if (pulsedLaser && form.frequency) {
+      data.frequency = form.frequency
+      data.frequencyUnit = 'kHz'
+    }
+    if (pulsedLaser && form.pulseWidth) data.pulseWidth = form.pulseWidth
     if (form.layerName) data.layerName = form.layerName
     if (form.notes) data.notes = form.notes
+    if (form.thicknessMm) data.thicknessMm = form.thicknessMm
+    if (form.color) data.color = form.color
+    if (form.finish) data.finish = form.finish
+    if (form.grade) data.grade = form.grade
 
     // Additional parameters
     data.bidir = form.bidir
@@ -135,6 +258,8 @@ function ContributeForm({ user, initialMode = 'manual' }) {
     data.floodFill = form.floodFill
     data.perforationMode = form.perforationMode
     data.wobbleEnable = form.wobbleEnable
+    if (form.wobbleEnable && form.wobbleDiameter) data.wobbleDiameter = form.wobbleDiameter
+    if (form.wobbleEnable && form.wobbleStep) data.wobbleStep = form.wobbleStep
     data.negativeImage = form.negativeImage
     data.useDotCorrection = form.useDotCorrection
     if (form.imageMode) data.imageMode = form.imageMode
@@ -146,6 +271,31 @@ function ContributeForm({ user, initialMode = 'manual' }) {
     mutation.mutate(data)
   }
 
+  function importFormData(dryRun) {
+    const formData = new FormData()
+    formData.append('file', importForm.file)
+    if (importForm.laserModelId) formData.append('laserModelId', importForm.laserModelId)
+    formData.append('laserMakeModel', importForm.laserMakeModel)
+    formData.append('laserType', importForm.laserType)
+    formData.append('wattage', importForm.wattage)
+    formData.append('speedUnit', importForm.speedUnit)
+    formData.append('intervalUnit', importForm.intervalUnit)
+    if (importForm.frequencyUnit !== 'auto') formData.append('frequencyUnit', importForm.frequencyUnit)
+    if (dryRun) formData.append('dryRun', 'true')
+    const decisions = Object.entries(materialDecisions).map(([name, d]) => ({ name, ...d }))
+    if (decisions.length > 0) formData.append('materialDecisions', JSON.stringify(decisions))
+    return formData
+  }
+
+  function handleImportPreview() {
+    setError('')
+    if (!importForm.file || !importForm.laserMakeModel || !importForm.laserType || !importForm.wattage) {
+      setError('Please fill in the laser details and select a .clb file')
+      return
+    }
+    previewMutation.mutate(importFormData(true))
+  }
+
   function handleImportSubmit(e) {
     e.preventDefault()
     setError('')
@@ -156,13 +306,7 @@ function ContributeForm({ user, initialMode = 'manual' }) {
       return
     }
 
-    const formData = new FormData()
-    formData.append('file', importForm.file)
-    formData.append('laserMakeModel', importForm.laserMakeModel)
-    formData.append('laserType', importForm.laserType)
-    formData.append('wattage', importForm.wattage)
-
-    importMutation.mutate(formData)
+    importMutation.mutate(importFormData(false))
   }
 
   return (
@@ -217,6 +361,16 @@ function ContributeForm({ user, initialMode = 'manual' }) {
                 <p className="text-lg text-ls-green">
                   ✅ {importResult.imported} settings imported successfully
                 </p>
+                {importResult.skipped > 0 && (
+                  <p className="text-ls-text-muted">
+                    {importResult.skipped} duplicates skipped
+                  </p>
+                )}
+                {importResult.conflicts > 0 && (
+                  <p className="text-ls-text-muted">
+                    {importResult.conflicts} imported with values that differ from your existing settings
+                  </p>
+                )}
                 {importResult.failed > 0 && (
                   <p className="text-ls-red">
                     ❌ {importResult.failed} settings failed
@@ -293,9 +447,31 @@ function ContributeForm({ user, initialMode = 'manual' }) {
                 id="materialName"
                 placeholder="e.g., Brass, Copper, etc."
                 value={form.materialName}
-                onChange={(e) => setForm({ ...form, materialName: e.target.value })}
+                onChange={(e) => {
+                  setForm({ ...form, materialName: e.target.value })
+                  setMaterialId(null)
+                }}
                 required
               />
+              {materialMatch?.match && (
+                <p className="text-xs text-ls-text-muted sm:col-span-2 -mt-2">
+                  {materialId === materialMatch.match.materialId || materialMatch.action === 'accept' ? (
+                    <>Will be saved as <span className="text-ls-text">{materialMatch.match.name}</span></>
+                  ) : (
+                    <>
+                      Did you mean <span className="text-ls-text">{materialMatch.match.name}</span>
+                      {' '}({Math.round(materialMatch.match.confidence * 100)}% match)?{' '}
+                      <button
+                        type="button"
+                        onClick={() => setMaterialId(materialMatch.match.materialId)}
+                        className="text-ls-accent hover:underline cursor-pointer"
+                      >
+                        Use it
+                      </button>
+                    </>
+                  )}
+                </p>
+              )}
 
               <Select
                 label="Mode"
@@ -309,6 +485,38 @@ function ContributeForm({ user, initialMode = 'manual' }) {
                 <SelectItem value="Image">Image</SelectItem>
               </Select>
             </div>
+            <div className="grid grid-cols-2 sm:grid-cols-4 gap-4">
+              <Input
+                label="Thickness (mm)"
+                id="thicknessMm"
+                type="number"
+                step="any"
+                min="0"
+                value={form.thicknessMm}
+                onChange={(e) => setForm({ ...form, thicknessMm: e.target.value })}
+              />
+              <Input
+                label="Color"
+                id="color"
+                placeholder="e.g., Black"
+                value={form.color}
+                onChange={(e) => setForm({ ...form, color: e.target.value })}
+              />
+              <Input
+                label="Finish / Coating"
+                id="finish"
+                placeholder="e.g., Anodized"
+                value={form.finish}
+                onChange={(e) => setForm({ ...form, finish: e.target.value })}
+              />
+              <Input
+                label="Grade"
+                id="grade"
+                placeholder="e.g., 304"
+                value={form.grade}
+                onChange={(e) => setForm({ ...form, grade: e.target.value })}
+              />
+            </div>
           </div>
 
           {/* Settings Values */}
@@ -329,27 +537,78 @@ function ContributeForm({ user, initialMode = 'manual' }) {
                 required
               />
               <Input
-                label="Speed (mm/s)"
+                label={`Speed (${speedUnit})`}
                 id="speed"
                 type="number"
-                step="0.01"
+                step="any"
                 min="0"
                 value={form.speed}
                 onChange={(e) => setForm({ ...form, speed: e.target.value })}
                 required
               />
-              <Input
-                label="Frequency (kHz)"
-                id="frequency"
-                type="number"
-                step="0.01"
-                min="0"
-                value={form.frequency}
-                onChange={(e) => setForm({ ...form, frequency: e.target.value })}
-                required
-              />
+              {pulsedLaser && (
+                <Input
+                  label="Frequency (kHz)"
+                  id="frequency"
+                  type="number"
+                  step="0.01"
+                  min="0"
+                  value={form.frequency}
+                  onChange={(e) => setForm({ ...form, frequency: e.target.value })}
+                  required
+                />
+              )}
             </div>
 
+            {/* Fiber / UV: MOPA pulse width and wobble */}
+            {pulsedLaser && (
+              <div className="grid grid-cols-1 sm:grid-cols-3 gap-4 items-end">
+                <Input
+                  label="Pulse Width (ns)"
+                  id="pulseWidth"
+                  type="number"
+                  step="any"
+                  min="0"
+                  value={form.pulseWidth}
+                  onChange={(e) => setForm({ ...form, pulseWidth: e.target.value })}
+                />
+                {form.wobbleEnable ? (
+                  <>
+                    <Input
+                      label="Wobble Diameter (mm)"
+                      id="wobbleDiameter"
+                      type="number"
+                      step="any"
+                      min="0"
+                      value={form.wobbleDiameter}
+                      onChange={(e) => setForm({ ...form, wobbleDiameter: e.target.value })}
+                    />
+                    <Input
+                      label="Wobble Step (mm)"
+                      id="wobbleStep"
+                      type="number"
+                      step="any"
+                      min="0"
+                      value={form.wobbleStep}
+                      onChange={(e) => setForm({ ...form, wobbleStep: e.target.value })}
+                    />
+                  </>
+                ) : <div className="sm:col-span-2" />}
+                <div className="flex items-center gap-2 sm:col-start-1">
+                  <input
+                    type="checkbox"
+                    id="wobbleEnable"
+                    checked={form.wobbleEnable}
+                    onChange={(e) => setForm({ ...form, wobbleEnable: e.target.checked })}
+                    className="w-4 h-4 rounded border-ls-border bg-ls-surface text-ls-accent focus:ring-2 focus:ring-ls-accent"
+                  />
+                  <label htmlFor="wobbleEnable" className="text-sm text-ls-text cursor-pointer">
+                    Wobble
+                  </label>
+                </div>
+              </div>
+            )}
+
             {/* Line Mode: Passes */}
             {form.mode === 'Line' && (
               <div className="grid grid-cols-1 gap-4">
@@ -432,12 +691,12 @@ function ContributeForm({ user, initialMode = 'manual' }) {
             {(form.mode === 'Fill' || form.mode === 'Image' || form.mode === 'Offset Fill') && (
               <div className="grid grid-cols-1 sm:grid-cols-3 gap-4">
                 <Input
-                  label="Line Interval (mm)"
+                  label={`Line Interval (${intervalUnit})`}
                   id="scanInterval"
                   type="number"
-                  step="0.001"
+                  step="any"
                   min="0"
-                  placeholder="e.g., 0.1"
+                  placeholder={intervalUnit === 'lpi' ? 'e.g., 254' : intervalUnit === 'in' ? 'e.g., 0.004' : 'e.g., 0.1'}
                   value={form.scanInterval}
                   onChange={(e) => setForm({ ...form, scanInterval: e.target.value })}
                 />
@@ -598,10 +857,16 @@ function ContributeForm({ user, initialMode = 'manual' }) {
                 label="Laser Make/Model"
                 id="importLaserMakeModel"
                 placeholder="e.g., Gweike G2 Max 50"
+                list="importMachineOptions"
                 value={importForm.laserMakeModel}
-                onChange={(e) => setImportForm({ ...importForm, laserMakeModel: e.target.value })}
+                onChange={(e) => handleMakeModelChange(e.target.value)}
                 required
               />
+              <datalist id="importMachineOptions">
+                {machines?.map((m) => (
+                  <option key={m.ID} value={machineLabel(m)} />
+                ))}
+              </datalist>
 
               <Select
                 label="Laser Type"
@@ -627,6 +892,31 @@ function ContributeForm({ user, initialMode = 'manual' }) {
                 required
               />
             </div>
+            {/* LightBurn writes mm/s, mm and kHz; hand-made libraries may not */}
+            <div className="grid grid-cols-3 gap-4">
+              <Select
+                label="Speeds in file"
+                value={importForm.speedUnit}
+                onValueChange={(val) => setImportForm({ ...importForm, speedUnit: val })}
+              >
+                {speedUnits.map((u) => <SelectItem key={u} value={u}>{u}</SelectItem>)}
+              </Select>
+              <Select
+                label="Intervals in file"
+                value={importForm.intervalUnit}
+                onValueChange={(val) => setImportForm({ ...importForm, intervalUnit: val })}
+              >
+                {intervalUnits.map((u) => <SelectItem key={u} value={u}>{u}</SelectItem>)}
+              </Select>
+              <Select
+                label="Frequencies in file"
+                value={importForm.frequencyUnit}
+                onValueChange={(val) => setImportForm({ ...importForm, frequencyUnit: val })}
+              >
+                <SelectItem value="auto">Detect</SelectItem>
+                {frequencyUnits.map((u) => <SelectItem key={u} value={u}>{u}</SelectItem>)}
+              </Select>
+            </div>
           </div>
 
           <div className="space-y-1.5">
@@ -637,7 +927,11 @@ function ContributeForm({ user, initialMode = 'manual' }) {
               type="file"
               id="clbFile"
               accept=".clb"
-              onChange={(e) => setImportForm({ ...importForm, file: e.target.files[0] })}
+              onChange={(e) => {
+                setImportForm({ ...importForm, file: e.target.files[0] })
+                setImportPreview(null)
+                setMaterialDecisions({})
+              }}
               className="block w-full text-sm text-ls-text
                 file:mr-4 file:py-2 file:px-4
                 file:rounded-lg file:border-0
@@ -655,9 +949,73 @@ function ContributeForm({ user, initialMode = 'manual' }) {
             )}
           </div>
 
-          <Button type="submit" disabled={importMutation.isPending} size="lg">
-            {importMutation.isPending ? 'Importing...' : 'Import Settings'}
-          </Button>
+          {importPreview && (
+            <div className="p-4 bg-ls-surface rounded-lg text-sm space-y-1">
+              {importPreview.alreadyImported && (
+                <p className="text-ls-red">This file has already been imported for this laser.</p>
+              )}
+              <p className="text-ls-text">{importPreview.new} new settings</p>
+              <p className="text-ls-text-muted">{importPreview.duplicates} duplicates will be skipped</p>
+              <p className="text-ls-text-muted">{importPreview.conflicts} differ from settings you already have</p>
+              {importPreview.invalid > 0 && (
+                <div className="pt-2">
+                  <p className="text-ls-red">{importPreview.invalid} have invalid values and will not be imported</p>
+                  <ul className="text-xs text-ls-text-muted mt-1 space-y-1">
+                    {importPreview.settings.filter((s) => s.status === 'invalid').map((s, i) => (
+                      <li key={i}>
+                        {s.material} / {s.desc}: {s.errors.map((e) => `${e.field} ${e.message}`).join('; ')}
+                      </li>
+                    ))}
+                  </ul>
+                </div>
+              )}
+
+              {importPreview.materials?.some((m) => m.match && m.match.confidence < 1) && (
+                <div className="pt-3 space-y-2">
+                  <p className="text-ls-text">Materials</p>
+                  {importPreview.materials.filter((m) => m.match && m.match.confidence < 1).map((m) => {
+                    const decision = materialDecisions[m.input]
+                    const value = decision
+                      ? `${decision.action}:${decision.materialId || ''}`
+                      : `${m.action}:${m.action === 'create' ? '' : m.match.materialId}`
+                    return (
+                      <div key={m.input} className="flex flex-wrap items-center gap-2">
+                        <span className="text-ls-text-muted flex-1">{m.input}</span>
+                        <select
+                          value={value}
+                          onChange={(e) => {
+                            const [action, id] = e.target.value.split(':')
+                            setMaterialDecisions({
+                              ...materialDecisions,
+                              [m.input]: { action, materialId: id ? parseInt(id, 10) : 0 },
+                            })
+                          }}
+                          className="px-3 py-1.5 bg-ls-surface border border-ls-border rounded-lg text-ls-text focus:outline-none focus:ring-2 focus:ring-ls-accent"
+                        >
+                          {m.candidates.map((cand) => (
+                            <optgroup key={cand.materialId} label={`${cand.name} (${Math.round(cand.confidence * 100)}%)`}>
+                              <option value={`accept:${cand.materialId}`}>Use {cand.name}</option>
+                              <option value={`alias:${cand.materialId}`}>Use {cand.name} and remember as alias</option>
+                            </optgroup>
+                          ))}
+                          <option value="create:">Create new material</option>
+                        </select>
+                      </div>
+                    )
+                  })}
+                </div>
+              )}
+            </div>
+          )}
+
+          <div className="flex gap-3">
+            <Button type="button" variant="outline" onClick={handleImportPreview} disabled={previewMutation.isPending} size="lg">
+              {previewMutation.isPending ? 'Checking...' : 'Preview'}
+            </Button>
+            <Button type="submit" disabled={importMutation.isPending} size="lg">
+              {importMutation.isPending ? 'Importing...' : 'Import Settings'}
+            </Button>
+          </div>
         </form>
       )}
     </div>
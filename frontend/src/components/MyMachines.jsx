@@ -0,0 +1,236 @@
+import { useState } from 'react'
+import { useQuery, useQueryClient } from '@tanstack/react-query'
+import Input from './ui/Input'
+import Button from './ui/Button'
+
+const emptyMachine = { laserModelId: '', makeModel: '', laserType: '', wattage: '', lensMm: '', notes: '' }
+
+function machineLabel(m) {
+  return `${m.ManufacturerName} ${m.Name} ${m.Wattage}W`
+}
+
+function MyMachines() {
+  const queryClient = useQueryClient()
+  const [form, setForm] = useState(emptyMachine)
+  const [isAdding, setIsAdding] = useState(false)
+  const [error, setError] = useState('')
+
+  const { data: machines } = useQuery({
+    queryKey: ['profile', 'machines'],
+    queryFn: () =>
+      fetch('/api/profile/machines', { credentials: 'include' }).then(r => r.json()),
+  })
+
+  const { data: catalog } = useQuery({
+    queryKey: ['machines', form.makeModel],
+    queryFn: () =>
+      fetch(`/api/machines?q=${encodeURIComponent(form.makeModel)}`).then(r => r.json()),
+    enabled: form.makeModel.length >= 2,
+  })
+
+  // Picking a catalog machine fills in its laser type, wattage and lens
+  const handleMakeModelChange = (value) => {
+    const machine = catalog?.find((m) => machineLabel(m) === value)
+    if (machine) {
+      setForm({
+        ...form,
+        laserModelId: machine.ID,
+        makeModel: `${machine.ManufacturerName} ${machine.Name}`,
+        laserType: machine.LaserType,
+        wattage: String(machine.Wattage),
+        lensMm: machine.LensMm.Valid ? machine.LensMm.String : form.lensMm,
+      })
+    } else {
+      setForm({ ...form, laserModelId: '', makeModel: value })
+    }
+  }
+
+  const request = async (url, options) => {
+    const response = await fetch(url, { credentials: 'include', ...options })
+    if (!response.ok) {
+      const data = await response.json()
+      throw new Error(data.error || 'Request failed')
+    }
+    await queryClient.refetchQueries(['profile', 'machines'])
+  }
+
+  const handleAdd = async (e) => {
+    e.preventDefault()
+    try {
+      await request('/api/profile/machines', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({
+          laserModelId: form.laserModelId || null,
+          makeModel: form.makeModel || null,
+          laserType: form.laserType,
+          wattage: parseInt(form.wattage, 10) || 0,
+          lensMm: form.lensMm || null,
+          notes: form.notes || null,
+        }),
+      })
+      setForm(emptyMachine)
+      setIsAdding(false)
+      setError('')
+    } catch (err) {
+      setError(err.message)
+    }
+  }
+
+  const handleMakeDefault = async (machine) => {
+    try {
+      await request(`/api/profile/machines/${machine.id}/default`, { method: 'POST' })
+    } catch (err) {
+      alert(err.message)
+    }
+  }
+
+  const handleRemove = async (machine) => {
+    if (!confirm(`Remove ${machine.makeModel || 'this machine'}?`)) {
+      return
+    }
+    try {
+      await request(`/api/profile/machines/${machine.id}`, { method: 'DELETE' })
+    } catch (err) {
+      alert(err.message)
+    }
+  }
+
+  return (
+    <div className="bg-ls-surface border border-ls-border rounded-xl p-6 mb-8">
+      <div className="flex items-center mb-4">
+        <h2 className="text-lg font-semibold text-ls-text">My Machines</h2>
+        {!isAdding && (
+          <Button type="button" variant="ghost" size="sm" className="ml-auto" onClick={() => setIsAdding(true)}>
+            Add machine
+          </Button>
+        )}
+      </div>
+
+      {machines?.length > 0 ? (
+        <ul className="divide-y divide-ls-border">
+          {machines.map((m) => (
+            <li key={m.id} className="py-3 flex items-center gap-4">
+              <div className="flex-1">
+                <p className="text-ls-text">
+                  {m.makeModel || `${m.laserType} laser`}
+                  {m.isDefault && (
+                    <span className="ml-2 text-xs font-semibold text-ls-accent">Default</span>
+                  )}
+                </p>
+                <p className="text-xs text-ls-text-muted">
+                  {m.laserType} {m.wattage}W{m.lensMm && ` · ${m.lensMm}mm lens`}{m.notes && ` · ${m.notes}`}
+                </p>
+              </div>
+              {!m.isDefault && (
+                <button
+                  type="button"
+                  onClick={() => handleMakeDefault(m)}
+                  className="text-sm text-ls-accent hover:underline cursor-pointer"
+                >
+                  Make default
+                </button>
+              )}
+              <button
+                type="button"
+                onClick={() => handleRemove(m)}
+                className="text-sm text-ls-red hover:underline cursor-pointer"
+              >
+                Remove
+              </button>
+            </li>
+          ))}
+        </ul>
+      ) : (
+        !isAdding && (
+          <p className="text-sm text-ls-text-muted">
+            Add the lasers you own and search will show settings for your default machine.
+          </p>
+        )
+      )}
+
+      {isAdding && (
+        <form onSubmit={handleAdd} className="mt-4 space-y-4">
+          <div className="grid grid-cols-1 sm:grid-cols-3 gap-4">
+            <Input
+              label="Make/Model"
+              id="machineMakeModel"
+              placeholder="e.g., xTool F1 Ultra"
+              list="myMachineOptions"
+              value={form.makeModel}
+              onChange={(e) => handleMakeModelChange(e.target.value)}
+            />
+            <datalist id="myMachineOptions">
+              {catalog?.map((m) => (
+                <option key={m.ID} value={machineLabel(m)} />
+              ))}
+            </datalist>
+
+            <div className="space-y-1.5">
+              <label htmlFor="machineLaserType" className="block text-sm font-medium text-ls-text-muted">
+                Laser Type
+              </label>
+              <select
+                id="machineLaserType"
+                value={form.laserType}
+                onChange={(e) => setForm({ ...form, laserType: e.target.value })}
+                required
+                className="w-full h-11 px-4 bg-ls-surface border border-ls-border rounded-lg text-ls-text focus:outline-none focus:ring-2 focus:ring-ls-accent focus:border-transparent transition-all"
+              >
+                <option value="">Select type...</option>
+                <option value="CO2">CO2</option>
+                <option value="Fiber">Fiber</option>
+                <option value="Diode">Diode</option>
+                <option value="UV">UV</option>
+                <option value="Infrared">Infrared</option>
+              </select>
+            </div>
+
+            <Input
+              label="Wattage (W)"
+              id="machineWattage"
+              type="number"
+              min="1"
+              value={form.wattage}
+              onChange={(e) => setForm({ ...form, wattage: e.target.value })}
+              required
+            />
+
+            <Input
+              label="Lens (mm)"
+              id="machineLens"
+              type="number"
+              step="any"
+              min="0"
+              value={form.lensMm}
+              onChange={(e) => setForm({ ...form, lensMm: e.target.value })}
+            />
+
+            <div className="sm:col-span-2">
+              <Input
+                label="Notes"
+                id="machineNotes"
+                placeholder="e.g., rotary attachment, air assist upgrade"
+                value={form.notes}
+                onChange={(e) => setForm({ ...form, notes: e.target.value })}
+              />
+            </div>
+          </div>
+
+          {error && <p className="text-sm text-ls-red">{error}</p>}
+
+          <div className="flex justify-end gap-3">
+            <Button type="button" variant="ghost" size="sm" onClick={() => { setIsAdding(false); setError('') }}>
+              Cancel
+            </Button>
+            <Button type="submit" size="sm">
+              Save machine
+            </Button>
+          </div>
+        </form>
+      )}
+    </div>
+  )
+}
+
+export default MyMachines
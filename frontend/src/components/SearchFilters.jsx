@@ -68,6 +68,8 @@ function SearchFilters({ filters, onFilterChange }) {
           <SelectItem value="Cut">Cut</SelectItem>
           <SelectItem value="Scan">Scan/Engrave</SelectItem>
           <SelectItem value="ScanCut">Scan+Cut</SelectItem>
+          <SelectItem value="Image">Image</SelectItem>
+          <SelectItem value="OffsetFill">Offset Fill</SelectItem>
         </Select>
       </div>
 
@@ -1,6 +1,7 @@
 import { Link } from 'react-router-dom'
 import Badge from './ui/Badge'
 import VoteButtons from './VoteButtons'
+import { formatFrequency, formatSpeed, userSpeedUnit } from '../units'
 
 function SettingCard({ setting, user, onVote, showAttribution = true, onDelete }) {
   // Helper to extract value from SQL null types
@@ -55,6 +56,10 @@ function SettingCard({ setting, user, onVote, showAttribution = true, onDelete }
     modeDisplay = `Image - ${imageModeDisplay}`
   } else if (operationType === 'Scan') {
     modeDisplay = 'Fill'
+  } else if (operationType === 'ScanCut') {
+    modeDisplay = 'Fill+Line'
+  } else if (operationType === 'OffsetFill') {
+    modeDisplay = 'Offset Fill'
   } else if (operationType === 'Cut') {
     modeDisplay = 'Line'
   }
@@ -81,7 +86,7 @@ function SettingCard({ setting, user, onVote, showAttribution = true, onDelete }
           <div className="grid grid-cols-2 sm:grid-cols-4 gap-3 mb-3">
             <div>
               <p className="text-xs text-ls-text-muted">Speed</p>
-              <p className="text-sm font-semibold text-ls-text">{parseFloat(speed).toFixed(0)} mm/s</p>
+              <p className="text-sm font-semibold text-ls-text">{formatSpeed(speed, userSpeedUnit(user))}</p>
             </div>
             <div>
               <p className="text-xs text-ls-text-muted">Power</p>
@@ -89,7 +94,7 @@ function SettingCard({ setting, user, onVote, showAttribution = true, onDelete }
             </div>
             <div>
               <p className="text-xs text-ls-text-muted">Frequency</p>
-              <p className="text-sm font-semibold text-ls-text">{frequency ? `${(parseFloat(frequency) / 1000).toFixed(0)} kHz` : '-'}</p>
+              <p className="text-sm font-semibold text-ls-text">{formatFrequency(frequency)}</p>
             </div>
             <div>
               <p className="text-xs text-ls-text-muted">Passes</p>
@@ -0,0 +1,107 @@
+import { useState } from 'react'
+import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
+import Card from './ui/Card'
+import Badge from './ui/Badge'
+import Button from './ui/Button'
+
+const actionLabels = { create: 'Created', update: 'Edited', revert: 'Reverted' }
+
+function formatValue(value) {
+  if (value === null || value === undefined) return '—'
+  if (typeof value === 'boolean') return value ? 'on' : 'off'
+  return String(value)
+}
+
+// Revision list for a setting: what changed in each edit, the votes cast on
+// each revision, and a revert button for the owner
+function SettingHistory({ settingId, isOwner }) {
+  const queryClient = useQueryClient()
+  const [error, setError] = useState('')
+
+  const { data: history } = useQuery({
+    queryKey: ['setting', settingId, 'history'],
+    queryFn: () => fetch(`/api/settings/${settingId}/history`).then(r => r.json()),
+  })
+
+  const revert = useMutation({
+    mutationFn: (revision) =>
+      fetch(`/api/settings/${settingId}/revert`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        credentials: 'include',
+        body: JSON.stringify({ revision }),
+      }).then(async (r) => {
+        if (!r.ok) {
+          const err = await r.json()
+          throw new Error(err.error || 'Failed to revert')
+        }
+        return r.json()
+      }),
+    onSuccess: () => {
+      setError('')
+      queryClient.invalidateQueries({ queryKey: ['setting', settingId] })
+    },
+    onError: (err) => setError(err.message),
+  })
+
+  if (!history?.revisions) return null
+
+  const unversioned = history.unversionedVotes
+  return (
+    <Card className="mt-6">
+      <h2 className="text-lg font-semibold text-ls-text mb-4">History</h2>
+      {error && <p className="text-sm text-ls-red mb-3">{error}</p>}
+      <ol className="space-y-4">
+        {history.revisions.map((rev) => (
+          <li key={rev.revision} className="p-4 bg-ls-dark/50 rounded-lg">
+            <div className="flex items-center justify-between gap-4 mb-2">
+              <div className="flex flex-wrap items-center gap-2 text-sm">
+                <Badge variant={rev.revision === history.currentRevision ? 'accent' : 'default'}>
+                  Revision {rev.revision}
+                </Badge>
+                <span className="text-ls-text">
+                  {actionLabels[rev.action] || rev.action}
+                  {rev.revertedFrom && ` to revision ${rev.revertedFrom}`}
+                </span>
+                {rev.author && <span className="text-ls-text-muted">by {rev.author}</span>}
+                {rev.createdAt && (
+                  <span className="text-ls-text-muted">{new Date(rev.createdAt).toLocaleString()}</span>
+                )}
+              </div>
+              <div className="flex items-center gap-3 text-sm text-ls-text-muted">
+                <span>+{rev.upvotes} / −{rev.downvotes}</span>
+                {isOwner && rev.revision !== history.currentRevision && (
+                  <Button
+                    size="sm"
+                    variant="outline"
+                    disabled={revert.isPending}
+                    onClick={() => revert.mutate(rev.revision)}
+                  >
+                    Revert
+                  </Button>
+                )}
+              </div>
+            </div>
+            {rev.changes.length > 0 && (
+              <ul className="text-sm space-y-1">
+                {rev.changes.map((change) => (
+                  <li key={change.field} className="text-ls-text-muted">
+                    <span className="text-ls-text">{change.field}</span>{' '}
+                    <span className="line-through">{formatValue(change.from)}</span> → {formatValue(change.to)}
+                  </li>
+                ))}
+              </ul>
+            )}
+          </li>
+        ))}
+      </ol>
+      {(unversioned.upvotes > 0 || unversioned.downvotes > 0) && (
+        <p className="text-xs text-ls-text-muted mt-4">
+          +{unversioned.upvotes} / −{unversioned.downvotes} votes were cast before history was kept.
+        </p>
+      )}
+    </Card>
+  )
+}
+
+export default SettingHistory
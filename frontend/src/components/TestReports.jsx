@@ -0,0 +1,154 @@
+import { useState } from 'react'
+import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
+import Card from './ui/Card'
+import Badge from './ui/Badge'
+import Button from './ui/Button'
+import Input from './ui/Input'
+
+const outcomes = [
+  { value: 'worked', label: 'Worked', variant: 'accent' },
+  { value: 'adjusted', label: 'Needed adjustment', variant: 'blue' },
+  { value: 'failed', label: 'Failed', variant: 'default' },
+]
+
+const emptyReport = { outcome: 'worked', materialBatch: '', notes: '', adjustedSpeed: '', adjustedPower: '' }
+
+// "I tested this" reports for a setting and a form to add one, run on the
+// user's default machine
+function TestReports({ settingId, user, isOwner }) {
+  const queryClient = useQueryClient()
+  const [form, setForm] = useState(emptyReport)
+  const [error, setError] = useState('')
+
+  const { data } = useQuery({
+    queryKey: ['setting', settingId, 'tests'],
+    queryFn: () => fetch(`/api/settings/${settingId}/tests`).then(r => r.json()),
+  })
+
+  const report = useMutation({
+    mutationFn: (body) =>
+      fetch(`/api/settings/${settingId}/tests`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        credentials: 'include',
+        body: JSON.stringify(body),
+      }).then(async (r) => {
+        if (!r.ok) {
+          const err = await r.json()
+          throw new Error(err.error || 'Failed to report')
+        }
+        return r.json()
+      }),
+    onSuccess: () => {
+      setError('')
+      setForm(emptyReport)
+      queryClient.invalidateQueries({ queryKey: ['setting', settingId] })
+    },
+    onError: (err) => setError(err.message),
+  })
+
+  function handleSubmit(e) {
+    e.preventDefault()
+    const body = { outcome: form.outcome, materialBatch: form.materialBatch, notes: form.notes }
+    if (form.outcome === 'adjusted') {
+      const adjustedValues = {}
+      if (form.adjustedSpeed) adjustedValues.speed = form.adjustedSpeed
+      if (form.adjustedPower) adjustedValues.maxPower = form.adjustedPower
+      if (Object.keys(adjustedValues).length > 0) body.adjustedValues = adjustedValues
+    }
+    report.mutate(body)
+  }
+
+  if (!data?.reports) return null
+
+  const { summary, reports } = data
+  return (
+    <Card className="mt-6">
+      <div className="flex items-center justify-between mb-4">
+        <h2 className="text-lg font-semibold text-ls-text">Tested by the community</h2>
+        {summary.confidence !== null && (
+          <span className="text-sm text-ls-text-muted">
+            Confidence <span className="text-ls-text font-medium">{Math.round(parseFloat(summary.confidence) * 100)}%</span>
+          </span>
+        )}
+      </div>
+      <p className="text-sm text-ls-text-muted mb-4">
+        {summary.worked} worked · {summary.adjusted} needed adjustment · {summary.failed} failed
+      </p>
+
+      {reports.length > 0 && (
+        <ul className="space-y-3 mb-6">
+          {reports.map((r) => (
+            <li key={r.id} className="p-3 bg-ls-dark/50 rounded-lg text-sm">
+              <div className="flex flex-wrap items-center gap-2 mb-1">
+                <Badge variant={outcomes.find(o => o.value === r.outcome)?.variant}>
+                  {outcomes.find(o => o.value === r.outcome)?.label}
+                </Badge>
+                <span className="text-ls-text">{r.author}</span>
+                <span className="text-ls-text-muted">
+                  {r.makeModel || r.laserType} {r.wattage}W · revision {r.revision}
+                </span>
+              </div>
+              {r.materialBatch && <p className="text-ls-text-muted">Batch: {r.materialBatch}</p>}
+              {r.adjustedValues && (
+                <p className="text-ls-text-muted">
+                  Adjusted: {Object.entries(r.adjustedValues).map(([k, v]) => `${k} ${v}`).join(', ')}
+                </p>
+              )}
+              {r.notes && <p className="text-ls-text mt-1">{r.notes}</p>}
+            </li>
+          ))}
+        </ul>
+      )}
+
+      {user && !isOwner && (
+        <form onSubmit={handleSubmit} className="space-y-3 border-t border-ls-border pt-4">
+          <h3 className="text-sm font-medium text-ls-text-muted uppercase tracking-wider">I tested this</h3>
+          {error && <p className="text-sm text-ls-red">{error}</p>}
+          <div className="flex flex-wrap gap-2">
+            {outcomes.map((o) => (
+              <Button
+                key={o.value}
+                type="button"
+                size="sm"
+                variant={form.outcome === o.value ? 'default' : 'outline'}
+                onClick={() => setForm({ ...form, outcome: o.value })}
+              >
+                {o.label}
+              </Button>
+            ))}
+          </div>
+          {form.outcome === 'adjusted' && (
+            <div className="grid grid-cols-2 gap-3">
+              <Input
+                placeholder="Speed I used"
+                value={form.adjustedSpeed}
+                onChange={(e) => setForm({ ...form, adjustedSpeed: e.target.value })}
+              />
+              <Input
+                placeholder="Max power I used (%)"
+                value={form.adjustedPower}
+                onChange={(e) => setForm({ ...form, adjustedPower: e.target.value })}
+              />
+            </div>
+          )}
+          <Input
+            placeholder="Material batch (supplier, lot, purchase date...)"
+            value={form.materialBatch}
+            onChange={(e) => setForm({ ...form, materialBatch: e.target.value })}
+          />
+          <Input
+            placeholder="Notes"
+            value={form.notes}
+            onChange={(e) => setForm({ ...form, notes: e.target.value })}
+          />
+          <Button type="submit" size="sm" disabled={report.isPending}>
+            Submit report
+          </Button>
+        </form>
+      )}
+    </Card>
+  )
+}
+
+export default TestReports
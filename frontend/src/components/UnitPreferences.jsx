@@ -0,0 +1,54 @@
+import { useState } from 'react'
+import { Select, SelectItem } from './ui/Select'
+import { intervalUnits, speedUnits, userIntervalUnit, userSpeedUnit } from '../units'
+
+// The units speeds and line intervals are shown and entered in. Saved
+// values stay in mm/s and mm whatever is picked here.
+function UnitPreferences({ user, onUserChange }) {
+  const [error, setError] = useState('')
+
+  const save = async (prefs) => {
+    setError('')
+    const response = await fetch('/api/profile/preferences', {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      credentials: 'include',
+      body: JSON.stringify({
+        speedUnit: userSpeedUnit(user),
+        intervalUnit: userIntervalUnit(user),
+        ...prefs,
+      }),
+    })
+    const data = await response.json()
+    if (!response.ok) {
+      setError(data.error || 'Failed to save preferences')
+      return
+    }
+    onUserChange({ ...user, ...data })
+  }
+
+  return (
+    <div className="bg-ls-surface border border-ls-border rounded-xl p-6 mb-8">
+      <h2 className="text-lg font-semibold text-ls-text mb-4">Units</h2>
+      {error && <p className="text-sm text-ls-red mb-3">{error}</p>}
+      <div className="grid grid-cols-1 sm:grid-cols-2 gap-4">
+        <Select
+          label="Speed"
+          value={userSpeedUnit(user)}
+          onValueChange={(val) => save({ speedUnit: val })}
+        >
+          {speedUnits.map((u) => <SelectItem key={u} value={u}>{u}</SelectItem>)}
+        </Select>
+        <Select
+          label="Line interval"
+          value={userIntervalUnit(user)}
+          onValueChange={(val) => save({ intervalUnit: val })}
+        >
+          {intervalUnits.map((u) => <SelectItem key={u} value={u}>{u}</SelectItem>)}
+        </Select>
+      </div>
+    </div>
+  )
+}
+
+export default UnitPreferences
@@ -1,8 +1,9 @@
 import { useState } from 'react'
 import { useQuery } from '@tanstack/react-query'
 import { Link } from 'react-router-dom'
+import { formatSpeed, userSpeedUnit } from '../units'
 
-function AdminSettingsPage() {
+function AdminSettingsPage({ user }) {
   const [materialFilter, setMaterialFilter] = useState('')
   const [laserTypeFilter, setLaserTypeFilter] = useState('')
   const [page, setPage] = useState(0)
@@ -147,7 +148,7 @@ function AdminSettingsPage() {
                         </span>
                       </td>
                       <td className="px-6 py-4 whitespace-nowrap">
-                        <p className="text-sm text-ls-text">{setting.maxPower}% / {setting.speed}mm/s</p>
+                        <p className="text-sm text-ls-text">{setting.maxPower}% / {formatSpeed(setting.speed, userSpeedUnit(user))}</p>
                       </td>
                       <td className="px-6 py-4">
                         <p className="text-sm text-ls-text">{setting.userDisplayName || setting.userEmail}</p>
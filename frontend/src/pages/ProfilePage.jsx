@@ -1,7 +1,9 @@
 import { useQuery, useQueryClient } from '@tanstack/react-query'
 import SettingsTable from '../components/SettingsTable'
+import MyMachines from '../components/MyMachines'
+import UnitPreferences from '../components/UnitPreferences'
 
-function ProfilePage({ user }) {
+function ProfilePage({ user, onUserChange }) {
   const queryClient = useQueryClient()
 
   const { data: settings, isLoading } = useQuery({
@@ -10,6 +12,33 @@ function ProfilePage({ user }) {
       fetch('/api/profile/settings', { credentials: 'include' }).then(r => r.json()),
   })
 
+  const { data: imports } = useQuery({
+    queryKey: ['profile', 'imports'],
+    queryFn: () =>
+      fetch('/api/profile/imports', { credentials: 'include' }).then(r => r.json()),
+  })
+
+  const handleRollback = async (imp) => {
+    if (!confirm(`Remove all ${imp.settingCount} settings imported from ${imp.filename || 'this file'}?`)) {
+      return
+    }
+    try {
+      const response = await fetch(`/api/profile/imports/${imp.id}`, {
+        method: 'DELETE',
+        credentials: 'include',
+      })
+      if (response.ok) {
+        await queryClient.refetchQueries(['profile'])
+      } else {
+        const data = await response.json()
+        alert(data.error || 'Failed to undo import')
+      }
+    } catch (error) {
+      console.error('Error undoing import:', error)
+      alert('Failed to undo import')
+    }
+  }
+
   const handleDelete = async (settingId) => {
     console.log('Deleting setting:', settingId)
     try {
@@ -61,6 +90,38 @@ function ProfilePage({ user }) {
         </div>
       </div>
 
+      <MyMachines />
+
+      <UnitPreferences user={user} onUserChange={onUserChange} />
+
+      {imports?.length > 0 && (
+        <div className="bg-ls-surface border border-ls-border rounded-xl p-6 mb-8">
+          <h2 className="text-lg font-semibold text-ls-text mb-4">Imports</h2>
+          <ul className="divide-y divide-ls-border">
+            {imports.map((imp) => (
+              <li key={imp.id} className="py-3 flex items-center gap-4">
+                <div className="flex-1">
+                  <p className="text-ls-text">{imp.filename || 'Untitled library'}</p>
+                  <p className="text-xs text-ls-text-muted">
+                    {imp.laserMakeModel} · {imp.laserType} {imp.wattage}W · {new Date(imp.createdAt).toLocaleDateString()}
+                  </p>
+                </div>
+                <p className="text-sm text-ls-text-muted">
+                  {imp.settingCount} settings{imp.skipped > 0 && `, ${imp.skipped} skipped`}{imp.failed > 0 && `, ${imp.failed} failed`}
+                </p>
+                <button
+                  type="button"
+                  onClick={() => handleRollback(imp)}
+                  className="text-sm text-ls-red hover:underline cursor-pointer"
+                >
+                  Undo
+                </button>
+              </li>
+            ))}
+          </ul>
+        </div>
+      )}
+
       <SettingsTable settings={settings} isLoading={isLoading} user={user} showAttribution={false} onDelete={handleDelete} />
     </div>
   )
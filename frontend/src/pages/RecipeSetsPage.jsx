@@ -0,0 +1,134 @@
+import { useState } from 'react'
+import { useQuery, useQueryClient } from '@tanstack/react-query'
+import Card from '../components/ui/Card'
+import Button from '../components/ui/Button'
+import { Select, SelectItem } from '../components/ui/Select'
+import VoteButtons from '../components/VoteButtons'
+import { formatFrequency, formatInterval, formatSpeed, userIntervalUnit, userSpeedUnit } from '../units'
+
+const laserTypes = ['CO2', 'Diode', 'Fiber', 'UV', 'Infrared']
+
+// Published color-marking recipe sets: one setting per color, tested
+// together on one machine and material
+function RecipeSetsPage({ user }) {
+  const queryClient = useQueryClient()
+  const [laserType, setLaserType] = useState('')
+  const [sort, setSort] = useState('score')
+  const [userVotes, setUserVotes] = useState({})
+
+  const { data, isLoading } = useQuery({
+    queryKey: ['recipeSets', laserType, sort],
+    queryFn: () => {
+      const params = new URLSearchParams({ sort })
+      if (laserType) params.set('laser_type', laserType)
+      return fetch(`/api/recipe-sets?${params}`).then(r => r.json())
+    },
+  })
+
+  const handleVote = async (set, value) => {
+    const response = await fetch(`/api/recipe-sets/${set.id}/vote`, {
+      method: value === 0 ? 'DELETE' : 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      credentials: 'include',
+      body: value === 0 ? undefined : JSON.stringify({ value }),
+    })
+    if (!response.ok) {
+      const err = await response.json().catch(() => ({}))
+      alert(err.error || 'Failed to vote')
+      return
+    }
+    setUserVotes({ ...userVotes, [set.id]: value })
+    queryClient.invalidateQueries({ queryKey: ['recipeSets'] })
+  }
+
+  const handleExport = async (set) => {
+    const response = await fetch(`/api/recipe-sets/${set.id}/export`, { credentials: 'include' })
+    if (!response.ok) {
+      alert('Failed to export CLB file. Please try again.')
+      return
+    }
+    const blob = await response.blob()
+    const url = window.URL.createObjectURL(blob)
+    const a = document.createElement('a')
+    a.href = url
+    a.download = `${set.name}.clb`
+    document.body.appendChild(a)
+    a.click()
+    document.body.removeChild(a)
+    window.URL.revokeObjectURL(url)
+  }
+
+  const sets = data?.recipeSets || []
+
+  return (
+    <div className="max-w-7xl mx-auto px-6 py-8">
+      <h1 className="text-3xl font-bold text-ls-text mb-2">Color Recipes</h1>
+      <p className="text-ls-text-muted mb-6">
+        Sets of settings that mark a range of colors, such as oxide colors on stainless steel with a MOPA fiber.
+      </p>
+
+      <div className="grid grid-cols-1 sm:grid-cols-2 gap-4 mb-6 max-w-xl">
+        <Select label="Laser type" value={laserType} onValueChange={setLaserType}>
+          <SelectItem value="">All</SelectItem>
+          {laserTypes.map((t) => <SelectItem key={t} value={t}>{t}</SelectItem>)}
+        </Select>
+        <Select label="Sort by" value={sort} onValueChange={setSort}>
+          <SelectItem value="score">Top voted</SelectItem>
+          <SelectItem value="newest">Newest</SelectItem>
+        </Select>
+      </div>
+
+      {isLoading && <p className="text-ls-text-muted">Loading...</p>}
+      {!isLoading && sets.length === 0 && (
+        <p className="text-ls-text-muted">No recipe sets have been published yet.</p>
+      )}
+
+      <div className="space-y-6">
+        {sets.map((set) => (
+          <Card key={set.id}>
+            <div className="flex items-start justify-between gap-4 mb-4">
+              <div>
+                <h2 className="text-xl font-semibold text-ls-text">{set.name}</h2>
+                <p className="text-sm text-ls-text-muted">
+                  {set.materialName} · {set.machine} · by {set.author}
+                </p>
+                {set.description && <p className="text-sm text-ls-text mt-2">{set.description}</p>}
+              </div>
+              <div className="flex items-center gap-3">
+                {user && user.id !== set.userId && (
+                  <VoteButtons score={set.voteScore} userVote={userVotes[set.id] || 0} onVote={(v) => handleVote(set, v)} size="sm" />
+                )}
+                {user && (
+                  <Button size="sm" onClick={() => handleExport(set)}>Export .clb</Button>
+                )}
+              </div>
+            </div>
+
+            <div className="grid grid-cols-1 sm:grid-cols-2 lg:grid-cols-3 gap-3">
+              {set.colors.map((color) => (
+                <div key={color.settingId} className="flex items-center gap-3 p-3 bg-ls-dark/50 rounded-lg">
+                  <div
+                    className="w-10 h-10 rounded-md border border-ls-border shrink-0"
+                    style={{ backgroundColor: color.hex }}
+                    title={color.hex}
+                  />
+                  <div className="text-sm">
+                    <p className="text-ls-text font-medium">{color.name || color.hex}</p>
+                    <p className="text-ls-text-muted">
+                      {parseFloat(color.maxPower).toFixed(0)}% · {formatSpeed(color.speed, userSpeedUnit(user))}
+                      {color.frequency && ` · ${formatFrequency(color.frequency)}`}
+                      {color.scanInterval && ` · ${formatInterval(color.scanInterval, userIntervalUnit(user))}`}
+                      {color.pulseWidth && ` · ${parseFloat(color.pulseWidth)} ns`}
+                    </p>
+                  </div>
+                </div>
+              ))}
+            </div>
+          </Card>
+        ))}
+      </div>
+    </div>
+  )
+}
+
+export default RecipeSetsPage
@@ -1,23 +1,79 @@
+import { useState } from 'react'
 import { useLocation, useNavigate, Link } from 'react-router-dom'
+import { formatFrequency, formatSpeed, userSpeedUnit } from '../units'
 
-function ReviewCartPage() {
+function ReviewCartPage({ user }) {
   const location = useLocation()
   const navigate = useNavigate()
   const cartItems = location.state?.cartItems || []
+  const [targetWattage, setTargetWattage] = useState('')
+  const [recipeName, setRecipeName] = useState('')
+  const [recipeColors, setRecipeColors] = useState({})
+  const [recipeMessage, setRecipeMessage] = useState('')
+
+  // A color recipe set needs every setting on the same material and laser
+  const canSaveRecipe = cartItems.length > 0 && cartItems.every(item =>
+    item.MaterialID === cartItems[0].MaterialID &&
+    item.LaserType === cartItems[0].LaserType &&
+    item.Wattage === cartItems[0].Wattage)
+
+  const handleSaveRecipe = async () => {
+    setRecipeMessage('')
+    const first = cartItems[0]
+    const response = await fetch('/api/recipe-sets', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      credentials: 'include',
+      body: JSON.stringify({
+        name: recipeName,
+        materialId: first.MaterialID,
+        laserType: first.LaserType,
+        wattage: first.Wattage,
+        colors: cartItems.map(item => ({
+          settingId: item.ID,
+          hex: recipeColors[item.ID]?.hex || '#808080',
+          name: recipeColors[item.ID]?.name || '',
+        })),
+      }),
+    })
+    const data = await response.json()
+    if (!response.ok) {
+      const details = data.fields?.map(f => `${f.field}: ${f.message}`).join('; ')
+      setRecipeMessage(details || data.error || 'Failed to save recipe set')
+      return
+    }
+    const publish = await fetch(`/api/recipe-sets/${data.id}/publish`, { method: 'POST', credentials: 'include' })
+    setRecipeMessage(publish.ok ? 'Recipe set published.' : 'Recipe set saved as a draft.')
+  }
+
+  const setRecipeColor = (id, key, value) => {
+    setRecipeColors({ ...recipeColors, [id]: { ...recipeColors[id], [key]: value } })
+  }
 
   const handleExportCLB = async () => {
     try {
       // Extract setting IDs from cart items
       const settingIds = cartItems.map(item => item.ID).join(',')
+      const queryParams = new URLSearchParams({ ids: settingIds })
+      if (targetWattage) queryParams.set('wattage', targetWattage)
 
       // Call export endpoint with setting IDs
-      const response = await fetch(`/api/settings/export?ids=${settingIds}`, {
+      const response = await fetch(`/api/settings/export?${queryParams}`, {
         method: 'GET',
         credentials: 'include',
       })
 
       if (!response.ok) {
-        throw new Error('Export failed')
+        const data = await response.json().catch(() => ({}))
+        if (data.missing?.length) {
+          alert(`These settings no longer exist and were not exported: ${data.missing.map(id => `#${id}`).join(', ')}. Remove them from your cart and try again.`)
+          return
+        }
+        if (data.details?.length) {
+          alert(`Cannot scale this cart: ${data.details.join('; ')}`)
+          return
+        }
+        throw new Error(data.error || 'Export failed')
       }
 
       // Create download link
@@ -99,6 +155,10 @@ function ReviewCartPage() {
               modeDisplay = `Image - ${imageModeDisplay}`
             } else if (setting.OperationType === 'Scan') {
               modeDisplay = 'Fill'
+            } else if (setting.OperationType === 'ScanCut') {
+              modeDisplay = 'Fill+Line'
+            } else if (setting.OperationType === 'OffsetFill') {
+              modeDisplay = 'Offset Fill'
             } else if (setting.OperationType === 'Cut') {
               modeDisplay = 'Line'
             }
@@ -129,7 +189,7 @@ function ReviewCartPage() {
                     <span className="text-ls-text-muted">{modeDisplay}</span>
                     <span className="text-ls-text-muted">•</span>
                     <span className="text-ls-text font-semibold">
-                      {parseFloat(setting.Speed).toFixed(0)} mm/s
+                      {formatSpeed(setting.Speed, userSpeedUnit(user))}
                     </span>
                     <span className="text-ls-text-muted">•</span>
                     <span className="text-ls-text font-semibold">
@@ -139,7 +199,7 @@ function ReviewCartPage() {
                       <>
                         <span className="text-ls-text-muted">•</span>
                         <span className="text-ls-text font-semibold">
-                          {(parseFloat(frequency) / 1000).toFixed(0)} kHz
+                          {formatFrequency(frequency)}
                         </span>
                       </>
                     )}
@@ -163,7 +223,21 @@ function ReviewCartPage() {
         </div>
       </div>
 
-      <div className="flex justify-end">
+      <div className="flex items-end justify-end gap-4">
+        <div>
+          <label htmlFor="targetWattage" className="block text-sm font-medium text-ls-text-muted mb-1.5">
+            Scale to wattage (optional)
+          </label>
+          <input
+            type="number"
+            id="targetWattage"
+            min="1"
+            placeholder="Keep original"
+            value={targetWattage}
+            onChange={(e) => setTargetWattage(e.target.value)}
+            className="w-40 h-11 px-4 bg-ls-surface border border-ls-border rounded-lg text-ls-text placeholder:text-ls-text-muted/50 focus:outline-none focus:ring-2 focus:ring-ls-accent focus:border-transparent transition-all"
+          />
+        </div>
         <button
           onClick={handleExportCLB}
           className="inline-flex items-center justify-center rounded-lg font-semibold transition-all duration-200 h-11 px-6 text-sm bg-ls-accent text-white hover:bg-ls-accent-dark shadow-lg"
@@ -174,6 +248,51 @@ function ReviewCartPage() {
           Export to LightBurn Library
         </button>
       </div>
+
+      {canSaveRecipe && (
+        <div className="bg-ls-surface border border-ls-border rounded-xl p-6 mt-8">
+          <h2 className="text-xl font-semibold text-ls-text mb-1">Publish as a color recipe set</h2>
+          <p className="text-sm text-ls-text-muted mb-4">
+            Pick the color each setting marks to share these settings as one palette.
+          </p>
+          <div className="space-y-2 mb-4">
+            {cartItems.map((item) => (
+              <div key={item.ID} className="flex items-center gap-3">
+                <input
+                  type="color"
+                  value={recipeColors[item.ID]?.hex || '#808080'}
+                  onChange={(e) => setRecipeColor(item.ID, 'hex', e.target.value)}
+                  className="w-10 h-10 bg-transparent cursor-pointer"
+                />
+                <input
+                  type="text"
+                  placeholder={`Color name for setting #${item.ID}`}
+                  value={recipeColors[item.ID]?.name || ''}
+                  onChange={(e) => setRecipeColor(item.ID, 'name', e.target.value)}
+                  className="flex-1 h-10 px-4 bg-ls-darker border border-ls-border rounded-lg text-ls-text placeholder:text-ls-text-muted/50 focus:outline-none focus:ring-2 focus:ring-ls-accent"
+                />
+              </div>
+            ))}
+          </div>
+          <div className="flex items-center gap-4">
+            <input
+              type="text"
+              placeholder="Recipe set name"
+              value={recipeName}
+              onChange={(e) => setRecipeName(e.target.value)}
+              className="flex-1 h-11 px-4 bg-ls-darker border border-ls-border rounded-lg text-ls-text placeholder:text-ls-text-muted/50 focus:outline-none focus:ring-2 focus:ring-ls-accent"
+            />
+            <button
+              onClick={handleSaveRecipe}
+              disabled={!recipeName.trim()}
+              className="inline-flex items-center justify-center rounded-lg font-semibold transition-all duration-200 h-11 px-6 text-sm bg-ls-accent text-white hover:bg-ls-accent-dark disabled:opacity-50"
+            >
+              Publish
+            </button>
+          </div>
+          {recipeMessage && <p className="text-sm text-ls-text-muted mt-3">{recipeMessage}</p>}
+        </div>
+      )}
     </div>
   )
 }
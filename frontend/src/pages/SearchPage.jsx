@@ -1,6 +1,8 @@
-import { useState } from 'react'
+import { useEffect, useState } from 'react'
 import { useNavigate } from 'react-router-dom'
+import { useQuery } from '@tanstack/react-query'
 import ContributeModal from '../components/ContributeModal'
+import { formatFrequency, formatInterval, formatSpeed, userIntervalUnit, userSpeedUnit } from '../units'
 
 const StarburstSvg = () => (
   <svg viewBox="0 0 24 24" fill="none" xmlns="http://www.w3.org/2000/svg" className="absolute top-1/2 left-1/2 w-[0.55em] h-[0.55em]" style={{ transform: 'translate(-50%, -35%)' }}>
@@ -21,8 +23,12 @@ function SearchPage({ user }) {
   const [laserType, setLaserType] = useState('')
   const [wattage, setWattage] = useState('')
   const [keyword, setKeyword] = useState('')
+  const [thickness, setThickness] = useState('')
   const [currentPage, setCurrentPage] = useState(1)
   const [settings, setSettings] = useState(null)
+  const [total, setTotal] = useState(0)
+  const [nextCursor, setNextCursor] = useState(null)
+  const [sort, setSort] = useState('')
   const [isLoading, setIsLoading] = useState(false)
   const [selectedSettings, setSelectedSettings] = useState([]) // Checkboxes - ready to add
   const [cartItems, setCartItems] = useState([]) // Actually in cart
@@ -31,10 +37,25 @@ function SearchPage({ user }) {
   const [viewingSetting, setViewingSetting] = useState(null) // Setting being viewed in modal
   const itemsPerPage = 10
 
+  const { data: myMachines } = useQuery({
+    queryKey: ['profile', 'machines'],
+    queryFn: () =>
+      fetch('/api/profile/machines', { credentials: 'include' }).then(r => r.json()),
+  })
+  const defaultMachine = Array.isArray(myMachines) ? myMachines.find((m) => m.isDefault) : null
+
+  // Start out searching for the user's default machine
+  useEffect(() => {
+    if (defaultMachine && !laserType && !wattage) {
+      setLaserType(defaultMachine.laserType)
+      setWattage(String(defaultMachine.wattage))
+    }
+  }, [defaultMachine?.id])
+
   const hasFilters = laserType || wattage || keyword
 
-  // Fetch settings when filters change
-  const fetchSettings = async () => {
+  // Fetch the first page of settings, or the page after cursor
+  const fetchSettings = async (cursor) => {
     if (!hasFilters) {
       setSettings(null)
       return
@@ -46,20 +67,29 @@ function SearchPage({ user }) {
       if (laserType) queryParams.set('laser_type', laserType)
       if (wattage) queryParams.set('wattage', wattage)
       if (keyword) queryParams.set('keyword', keyword)
+      if (thickness) queryParams.set('thickness', thickness)
+      // Without this the API would scope the search to the default machine
+      if (!laserType && !wattage) queryParams.set('machine', 'none')
+      if (sort) queryParams.set('sort', sort)
+      if (cursor) queryParams.set('cursor', cursor)
 
       const response = await fetch(`/api/settings?${queryParams}`, { credentials: 'include' })
       const data = await response.json()
-      setSettings(data)
+      setSettings(prev => (cursor ? [...prev, ...data.settings] : data.settings))
+      setTotal(data.total)
+      setNextCursor(data.nextCursor || null)
     } catch (error) {
       console.error('Error fetching settings:', error)
       setSettings([])
+      setTotal(0)
+      setNextCursor(null)
     } finally {
       setIsLoading(false)
     }
   }
 
   // Calculate pagination
-  const totalPages = settings ? Math.ceil(settings.length / itemsPerPage) : 0
+  const totalPages = settings ? Math.ceil(total / itemsPerPage) : 0
   const startIndex = (currentPage - 1) * itemsPerPage
   const endIndex = startIndex + itemsPerPage
   const paginatedSettings = settings ? settings.slice(startIndex, endIndex) : []
@@ -68,8 +98,10 @@ function SearchPage({ user }) {
     setLaserType('')
     setWattage('')
     setKeyword('')
+    setThickness('')
     setCurrentPage(1)
     setSettings(null)
+    setNextCursor(null)
   }
 
   const handleLaserTypeChange = (e) => {
@@ -87,10 +119,29 @@ function SearchPage({ user }) {
     setCurrentPage(1)
   }
 
+  const handleThicknessChange = (e) => {
+    setThickness(e.target.value)
+    setCurrentPage(1)
+  }
+
+  const handleSortChange = (e) => {
+    setSort(e.target.value)
+    setCurrentPage(1)
+  }
+
   const handleSearch = () => {
+    setCurrentPage(1)
     fetchSettings()
   }
 
+  // Pages are loaded from the API as the user reaches the end of what is loaded
+  const handleNextPage = async () => {
+    if (endIndex >= settings.length && nextCursor) {
+      await fetchSettings(nextCursor)
+    }
+    setCurrentPage(p => Math.min(totalPages, p + 1))
+  }
+
   const handleVote = async (settingId, value) => {
     const currentVote = userVotes[settingId] || 0
 
@@ -100,12 +151,19 @@ function SearchPage({ user }) {
     }
 
     try {
-      await fetch(`/api/settings/${settingId}/vote`, {
-        method: 'POST',
-        headers: { 'Content-Type': 'application/json' },
-        credentials: 'include',
-        body: JSON.stringify({ value })
-      })
+      const response = await fetch(`/api/settings/${settingId}/vote`, value === 0
+        ? { method: 'DELETE', credentials: 'include' }
+        : {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            credentials: 'include',
+            body: JSON.stringify({ value })
+          })
+      if (!response.ok) {
+        const data = await response.json()
+        alert(data.message || data.error || 'Failed to vote')
+        return
+      }
 
       // Calculate the score change
       const scoreDelta = value - currentVote
@@ -228,7 +286,7 @@ function SearchPage({ user }) {
           </p>
         </div>
 
-        <div className="grid grid-cols-1 sm:grid-cols-2 lg:grid-cols-3 gap-4">
+        <div className="grid grid-cols-1 sm:grid-cols-2 lg:grid-cols-5 gap-4">
           {/* Laser Type */}
           <div>
             <label htmlFor="laserType" className="block text-sm font-medium text-ls-accent mb-1.5">
@@ -268,17 +326,54 @@ function SearchPage({ user }) {
           {/* Keyword */}
           <div>
             <label htmlFor="keyword" className="block text-sm font-medium text-ls-accent mb-1.5">
-              Material Keyword
+              Keyword
             </label>
             <input
               type="text"
               id="keyword"
-              placeholder="Search materials..."
+              placeholder="Material, alias, category, notes or machine..."
               value={keyword}
               onChange={handleKeywordChange}
               className="w-full px-4 py-2.5 bg-ls-surface border border-ls-border rounded-lg text-ls-text placeholder:text-ls-text-muted/50 focus:outline-none focus:ring-2 focus:ring-ls-accent focus:border-transparent transition-all"
             />
           </div>
+
+          {/* Thickness */}
+          <div>
+            <label htmlFor="thickness" className="block text-sm font-medium text-ls-accent mb-1.5">
+              Thickness
+            </label>
+            <input
+              type="text"
+              id="thickness"
+              placeholder="e.g. 3mm or 1/8in"
+              value={thickness}
+              onChange={handleThicknessChange}
+              className="w-full px-4 py-2.5 bg-ls-surface border border-ls-border rounded-lg text-ls-text placeholder:text-ls-text-muted/50 focus:outline-none focus:ring-2 focus:ring-ls-accent focus:border-transparent transition-all"
+            />
+          </div>
+
+          {/* Sort */}
+          <div>
+            <label htmlFor="sort" className="block text-sm font-medium text-ls-accent mb-1.5">
+              Sort By
+            </label>
+            <select
+              id="sort"
+              value={sort}
+              onChange={handleSortChange}
+              className="w-full px-4 py-2.5 bg-ls-surface border border-ls-border rounded-lg text-ls-text focus:outline-none focus:ring-2 focus:ring-ls-accent focus:border-transparent transition-all"
+            >
+              <option value="">Best match</option>
+              <option value="score">Top voted</option>
+              <option value="confidence">Most confirmed</option>
+              <option value="newest">Newest</option>
+              <option value="speed">Speed</option>
+              <option value="power">Power</option>
+              <option value="line_energy">Line energy (J/mm)</option>
+              <option value="fluence">Fluence (J/cm²)</option>
+            </select>
+          </div>
         </div>
 
         <div className="mt-4 flex justify-end gap-3">
@@ -374,6 +469,10 @@ function SearchPage({ user }) {
                     modeDisplay = `Image - ${imageModeDisplay}`
                   } else if (setting.OperationType === 'Scan') {
                     modeDisplay = 'Fill'
+                  } else if (setting.OperationType === 'ScanCut') {
+                    modeDisplay = 'Fill+Line'
+                  } else if (setting.OperationType === 'OffsetFill') {
+                    modeDisplay = 'Offset Fill'
                   } else if (setting.OperationType === 'Cut') {
                     modeDisplay = 'Line'
                   }
@@ -421,7 +520,7 @@ function SearchPage({ user }) {
                         <div className="grid grid-cols-2 gap-3 text-sm">
                           <div>
                             <span className="text-ls-text-muted">Speed:</span>
-                            <span className="text-ls-text font-semibold ml-2">{parseFloat(setting.Speed).toFixed(0)} mm/s</span>
+                            <span className="text-ls-text font-semibold ml-2">{formatSpeed(setting.Speed, userSpeedUnit(user))}</span>
                           </div>
                           <div>
                             <span className="text-ls-text-muted">Power:</span>
@@ -430,7 +529,7 @@ function SearchPage({ user }) {
                           {frequency && (
                             <div className="col-span-2">
                               <span className="text-ls-text-muted">Frequency:</span>
-                              <span className="text-ls-text font-semibold ml-2">{(parseFloat(frequency) / 1000).toFixed(0)} kHz</span>
+                              <span className="text-ls-text font-semibold ml-2">{formatFrequency(frequency)}</span>
                             </div>
                           )}
                         </div>
@@ -508,7 +607,7 @@ function SearchPage({ user }) {
 
                           {/* Speed */}
                           <div className="text-ls-text font-semibold text-sm whitespace-nowrap">
-                            {parseFloat(setting.Speed).toFixed(0)} mm/s
+                            {formatSpeed(setting.Speed, userSpeedUnit(user))}
                           </div>
 
                           {/* Power */}
@@ -518,7 +617,7 @@ function SearchPage({ user }) {
 
                           {/* Frequency */}
                           <div className="text-ls-text font-semibold text-sm whitespace-nowrap">
-                            {frequency ? `${(parseFloat(frequency) / 1000).toFixed(0)} kHz` : '-'}
+                            {formatFrequency(frequency)}
                           </div>
                         </div>
 
@@ -580,7 +679,7 @@ function SearchPage({ user }) {
                     Page {currentPage} of {totalPages}
                   </span>
                   <button
-                    onClick={() => setCurrentPage(p => Math.min(totalPages, p + 1))}
+                    onClick={handleNextPage}
                     disabled={currentPage === totalPages}
                     className="inline-flex items-center justify-center rounded-lg font-semibold transition-all duration-200 h-9 px-4 text-xs border-2 border-ls-accent text-ls-accent bg-transparent hover:bg-ls-accent hover:text-white disabled:opacity-50 disabled:cursor-not-allowed"
                   >
@@ -647,6 +746,10 @@ function ViewSettingModal({ setting, onClose }) {
     modeDisplay = `Image - ${imageModeDisplay}`
   } else if (setting.OperationType === 'Scan') {
     modeDisplay = 'Fill'
+  } else if (setting.OperationType === 'ScanCut') {
+    modeDisplay = 'Fill+Line'
+  } else if (setting.OperationType === 'OffsetFill') {
+    modeDisplay = 'Offset Fill'
   } else if (setting.OperationType === 'Cut') {
     modeDisplay = 'Line'
   }
@@ -685,8 +788,8 @@ function ViewSettingModal({ setting, onClose }) {
             {/* Common Fields */}
             <div className="grid grid-cols-2 gap-4">
               <div>
-                <label className="block text-sm font-medium text-ls-accent mb-1">Speed (mm/s)</label>
-                <div className="text-ls-text font-semibold">{parseFloat(setting.Speed).toFixed(2)}</div>
+                <label className="block text-sm font-medium text-ls-accent mb-1">Speed</label>
+                <div className="text-ls-text font-semibold">{formatSpeed(setting.Speed, userSpeedUnit(user))}</div>
               </div>
               {/* Frequency */}
               {(() => {
@@ -700,8 +803,8 @@ function ViewSettingModal({ setting, onClose }) {
                 }
                 return freq ? (
                   <div>
-                    <label className="block text-sm font-medium text-ls-accent mb-1">Frequency (kHz)</label>
-                    <div className="text-ls-text font-semibold">{(parseFloat(freq) / 1000).toFixed(2)}</div>
+                    <label className="block text-sm font-medium text-ls-accent mb-1">Frequency</label>
+                    <div className="text-ls-text font-semibold">{formatFrequency(freq)}</div>
                   </div>
                 ) : <div></div>
               })()}
@@ -729,8 +832,8 @@ function ViewSettingModal({ setting, onClose }) {
 
                 {setting.ScanInterval && setting.ScanInterval.String && (
                   <div>
-                    <label className="block text-sm font-medium text-ls-accent mb-1">Scan Interval (mm)</label>
-                    <div className="text-ls-text">{setting.ScanInterval.String}</div>
+                    <label className="block text-sm font-medium text-ls-accent mb-1">Scan Interval</label>
+                    <div className="text-ls-text">{formatInterval(setting.ScanInterval.String, userIntervalUnit(user))}</div>
                   </div>
                 )}
 
@@ -763,8 +866,8 @@ function ViewSettingModal({ setting, onClose }) {
 
                 {setting.ScanInterval && setting.ScanInterval.String && (
                   <div>
-                    <label className="block text-sm font-medium text-ls-accent mb-1">Scan Interval (mm)</label>
-                    <div className="text-ls-text">{setting.ScanInterval.String}</div>
+                    <label className="block text-sm font-medium text-ls-accent mb-1">Scan Interval</label>
+                    <div className="text-ls-text">{formatInterval(setting.ScanInterval.String, userIntervalUnit(user))}</div>
                   </div>
                 )}
 
@@ -1,28 +1,71 @@
-import { useParams, Link } from 'react-router-dom'
-import { useQuery } from '@tanstack/react-query'
+import { useParams, Link, useNavigate } from 'react-router-dom'
+import { useQuery, useQueryClient } from '@tanstack/react-query'
 import Card from '../components/ui/Card'
 import Badge from '../components/ui/Badge'
+import Button from '../components/ui/Button'
 import VoteButtons from '../components/VoteButtons'
+import SettingHistory from '../components/SettingHistory'
+import TestReports from '../components/TestReports'
+import { formatFrequency, formatInterval, formatSpeed, userIntervalUnit, userSpeedUnit } from '../units'
+
+// "3 mm · Black · Anodized · 304" from the material attribute columns
+function materialAttributes(setting) {
+  const parts = []
+  if (setting.ThicknessMm?.Valid) parts.push(`${parseFloat(setting.ThicknessMm.String)} mm`)
+  for (const field of ['Color', 'Finish', 'Grade']) {
+    if (setting[field]?.Valid) parts.push(setting[field].String)
+  }
+  return parts.join(' · ')
+}
 
 function SettingDetailPage({ user }) {
   const { id } = useParams()
+  const navigate = useNavigate()
+  const queryClient = useQueryClient()
 
   const { data: setting, isLoading } = useQuery({
     queryKey: ['setting', id],
     queryFn: () => fetch(`/api/settings/${id}`).then(r => r.json()),
   })
 
-  function handleVote(value) {
+  // A value of 0 takes the user's vote back
+  async function handleVote(value) {
     if (!user) {
       alert('Sign in to vote')
       return
     }
-    fetch(`/api/settings/${id}/vote`, {
+    const response = await fetch(`/api/settings/${id}/vote`, value === 0
+      ? { method: 'DELETE', credentials: 'include' }
+      : {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          credentials: 'include',
+          body: JSON.stringify({ value }),
+        })
+    if (!response.ok) {
+      const data = await response.json()
+      alert(data.message || data.error || 'Failed to vote')
+      return
+    }
+    queryClient.invalidateQueries({ queryKey: ['setting', id] })
+  }
+
+  // Copy the setting to the user's account and open the copy to tune it
+  async function handleFork() {
+    if (!user) {
+      alert('Sign in to fork')
+      return
+    }
+    const response = await fetch(`/api/settings/${id}/fork`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
       credentials: 'include',
-      body: JSON.stringify({ value }),
     })
+    const data = await response.json()
+    if (!response.ok) {
+      alert(data.error || 'Failed to fork')
+      return
+    }
+    navigate(`/settings/${data.id}`)
   }
 
   if (isLoading) {
@@ -63,6 +106,18 @@ function SettingDetailPage({ user }) {
               {setting.BrandName} {setting.ModelName}
             </h1>
             <p className="text-lg text-ls-accent font-medium">{setting.MaterialName}</p>
+            {materialAttributes(setting) && (
+              <p className="text-sm text-ls-text-muted mt-1">{materialAttributes(setting)}</p>
+            )}
+            {setting.forkedFrom && (
+              <p className="text-sm text-ls-text-muted mt-1">
+                Forked from{' '}
+                <Link to={`/settings/${setting.forkedFrom.id}`} className="text-ls-accent hover:underline">
+                  #{setting.forkedFrom.id}
+                </Link>{' '}
+                by {setting.forkedFrom.author}
+              </p>
+            )}
           </div>
           <VoteButtons
             score={setting.VoteScore}
@@ -75,6 +130,8 @@ function SettingDetailPage({ user }) {
           <Badge variant="accent">{setting.OperationName}</Badge>
           <Badge variant="blue">{setting.CategoryName}</Badge>
           <Badge variant="default">{setting.VoteCount} votes</Badge>
+          {setting.TestCount > 0 && <Badge variant="default">{setting.TestCount} tested</Badge>}
+          {setting.revision > 1 && <Badge variant="default">Revision {setting.revision}</Badge>}
         </div>
 
         {/* Settings grid */}
@@ -85,7 +142,7 @@ function SettingDetailPage({ user }) {
           </div>
           <div>
             <p className="text-xs text-ls-text-muted uppercase tracking-wider mb-1">Speed</p>
-            <p className="text-2xl font-bold text-ls-text">{setting.Speed}</p>
+            <p className="text-2xl font-bold text-ls-text">{formatSpeed(setting.Speed, userSpeedUnit(user))}</p>
           </div>
           <div>
             <p className="text-xs text-ls-text-muted uppercase tracking-wider mb-1">Passes</p>
@@ -100,11 +157,64 @@ function SettingDetailPage({ user }) {
           {setting.Frequency?.Valid && (
             <div>
               <p className="text-xs text-ls-text-muted uppercase tracking-wider mb-1">Frequency</p>
-              <p className="text-2xl font-bold text-ls-text">{setting.Frequency.Int32}</p>
+              <p className="text-2xl font-bold text-ls-text">{formatFrequency(setting.Frequency.String)}</p>
+            </div>
+          )}
+          {setting.PulseWidth?.Valid && (
+            <div>
+              <p className="text-xs text-ls-text-muted uppercase tracking-wider mb-1">Pulse Width</p>
+              <p className="text-2xl font-bold text-ls-text">{parseFloat(setting.PulseWidth.String)} ns</p>
+            </div>
+          )}
+          {setting.WobbleEnable?.Bool && (
+            <div>
+              <p className="text-xs text-ls-text-muted uppercase tracking-wider mb-1">Wobble</p>
+              <p className="text-2xl font-bold text-ls-text">
+                {setting.WobbleDiameter?.Valid ? `${parseFloat(setting.WobbleDiameter.String)} mm` : 'On'}
+                {setting.WobbleStep?.Valid && <span className="text-sm font-normal text-ls-text-muted"> · {parseFloat(setting.WobbleStep.String)} mm step</span>}
+              </p>
             </div>
           )}
         </div>
 
+        {/* Derived metrics */}
+        {setting.EffectiveWatts?.Valid && (
+          <div className="flex flex-wrap gap-x-6 gap-y-1 mb-6 text-sm text-ls-text-muted">
+            <span>Effective power <span className="text-ls-text font-medium">{parseFloat(setting.EffectiveWatts.String)} W</span></span>
+            {setting.LineEnergy?.Valid && (
+              <span>Line energy <span className="text-ls-text font-medium">{parseFloat(setting.LineEnergy.String).toPrecision(3)} J/mm</span></span>
+            )}
+            {setting.Fluence?.Valid && (
+              <span>Fluence <span className="text-ls-text font-medium">{parseFloat(setting.Fluence.String).toPrecision(3)} J/cm²</span></span>
+            )}
+            {setting.PulseEnergy?.Valid && (
+              <span>Pulse energy <span className="text-ls-text font-medium">{parseFloat(setting.PulseEnergy.String).toPrecision(3)} mJ</span></span>
+            )}
+          </div>
+        )}
+
+        {/* SubLayers */}
+        {setting.subLayers?.length > 0 && (
+          <div className="mb-6">
+            <h3 className="text-sm font-medium text-ls-text-muted uppercase tracking-wider mb-2">Sub-layers</h3>
+            <ul className="space-y-2">
+              {setting.subLayers.map((sl) => (
+                <li key={sl.ID} className="p-3 bg-ls-dark/50 rounded-lg text-sm text-ls-text">
+                  <span className="font-medium">
+                    {sl.Subname?.Valid ? sl.Subname.String : sl.SublayerType}
+                    {sl.IsCleanup && ' (cleanup)'}
+                  </span>
+                  <span className="text-ls-text-muted">
+                    {' '}· {sl.MaxPower}% · {formatSpeed(sl.Speed, userSpeedUnit(user))}
+                    {sl.ScanInterval?.Valid && ` · ${formatInterval(sl.ScanInterval.String, userIntervalUnit(user))} interval`}
+                    {sl.Frequency?.Valid && ` · ${formatFrequency(sl.Frequency.String)}`}
+                  </span>
+                </li>
+              ))}
+            </ul>
+          </div>
+        )}
+
         {/* Notes */}
         {setting.Notes?.Valid && setting.Notes.String && (
           <div className="mb-6">
@@ -113,14 +223,38 @@ function SettingDetailPage({ user }) {
           </div>
         )}
 
+        {/* Forks */}
+        {setting.forks?.length > 0 && (
+          <div className="mb-6">
+            <h3 className="text-sm font-medium text-ls-text-muted uppercase tracking-wider mb-2">Forks</h3>
+            <ul className="flex flex-wrap gap-2 text-sm">
+              {setting.forks.map((fork) => (
+                <li key={fork.id}>
+                  <Link to={`/settings/${fork.id}`} className="text-ls-accent hover:underline">
+                    #{fork.id}
+                  </Link>
+                  <span className="text-ls-text-muted"> by {fork.author} · {fork.laserType} {fork.wattage}W</span>
+                </li>
+              ))}
+            </ul>
+          </div>
+        )}
+
         {/* Attribution */}
         <div className="border-t border-ls-border pt-4 flex items-center justify-between text-sm text-ls-text-muted">
           <span>
             Contributed by <span className="text-ls-text font-medium">{setting.DisplayName?.Valid ? setting.DisplayName.String : setting.Username}</span>
           </span>
-          <span>{new Date(setting.CreatedAt).toLocaleDateString()}</span>
+          <span className="flex items-center gap-4">
+            {new Date(setting.CreatedAt).toLocaleDateString()}
+            <Button size="sm" variant="outline" onClick={handleFork}>Fork</Button>
+          </span>
         </div>
       </Card>
+
+      <TestReports settingId={id} user={user} isOwner={user && Number(user.id) === Number(setting.UserID)} />
+
+      <SettingHistory settingId={id} isOwner={user && Number(user.id) === Number(setting.UserID)} />
     </div>
   )
 }
@@ -0,0 +1,166 @@
+import { useState } from 'react'
+import { useQuery } from '@tanstack/react-query'
+import Card from '../components/ui/Card'
+import Button from '../components/ui/Button'
+import Input from '../components/ui/Input'
+import { Select, SelectItem } from '../components/ui/Select'
+import { userIntervalUnit, userSpeedUnit } from '../units'
+
+const axes = {
+  'power-speed': { columns: 'Speed', rows: 'Power (%)', fixed: ['scanInterval', 'frequency'] },
+  'frequency-interval': { columns: 'Frequency (kHz)', rows: 'Interval', fixed: ['maxPower', 'speed'] },
+}
+
+const fixedLabels = {
+  maxPower: 'Power (%)',
+  speed: 'Speed',
+  scanInterval: 'Interval',
+  frequency: 'Frequency (kHz)',
+}
+
+function download(filename, content) {
+  const url = window.URL.createObjectURL(new Blob([content], { type: 'application/xml' }))
+  const a = document.createElement('a')
+  a.href = url
+  a.download = filename
+  document.body.appendChild(a)
+  a.click()
+  document.body.removeChild(a)
+  window.URL.revokeObjectURL(url)
+}
+
+// Material test grid generator: a LightBurn project with a square per
+// combination of two variables, plus a library with the same settings
+function TestGridPage({ user }) {
+  const [form, setForm] = useState({
+    laserType: 'CO2',
+    wattage: '',
+    materialId: '',
+    variables: 'power-speed',
+    columns: { min: '', max: '', steps: 5 },
+    rows: { min: '', max: '', steps: 5 },
+    maxPower: '',
+    speed: '',
+    scanInterval: '',
+    frequency: '',
+  })
+  const [result, setResult] = useState(null)
+  const [error, setError] = useState('')
+
+  const { data: materials } = useQuery({
+    queryKey: ['materials'],
+    queryFn: () => fetch('/api/materials', { credentials: 'include' }).then(r => r.json()),
+  })
+
+  const axis = axes[form.variables]
+  const setAxis = (name, key, value) => setForm({ ...form, [name]: { ...form[name], [key]: value } })
+
+  const handleGenerate = async (e) => {
+    e.preventDefault()
+    setError('')
+    setResult(null)
+    const body = {
+      laserType: form.laserType,
+      wattage: parseInt(form.wattage) || 0,
+      materialId: parseInt(form.materialId) || 0,
+      variables: form.variables,
+      columns: { ...form.columns, steps: parseInt(form.columns.steps) || 0 },
+      rows: { ...form.rows, steps: parseInt(form.rows.steps) || 0 },
+      speedUnit: userSpeedUnit(user),
+      intervalUnit: userIntervalUnit(user),
+      frequencyUnit: 'kHz',
+    }
+    for (const field of axis.fixed) {
+      if (form[field]) body[field] = form[field]
+    }
+    const response = await fetch('/api/tools/test-grid', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      credentials: 'include',
+      body: JSON.stringify(body),
+    })
+    const data = await response.json()
+    if (!response.ok) {
+      setError(data.fields?.map(f => `${f.field}: ${f.message}`).join('; ') || data.error || 'Failed to generate grid')
+      return
+    }
+    setResult(data)
+  }
+
+  const unitLabel = (label) => label
+    .replace(/^Speed$/, `Speed (${userSpeedUnit(user)})`)
+    .replace(/^Interval$/, `Interval (${userIntervalUnit(user)})`)
+
+  return (
+    <div className="max-w-4xl mx-auto px-6 py-8">
+      <h1 className="text-3xl font-bold text-ls-text mb-2">Test Grid</h1>
+      <p className="text-ls-text-muted mb-6">
+        Burn a grid of squares to find the right setting for a new material. Each square is on its own layer and labelled with its values.
+      </p>
+
+      <Card>
+        <form onSubmit={handleGenerate} className="space-y-4">
+          <div className="grid grid-cols-1 sm:grid-cols-3 gap-4">
+            <Select label="Laser Type" value={form.laserType} onValueChange={(val) => setForm({ ...form, laserType: val })}>
+              <SelectItem value="CO2">CO2</SelectItem>
+              <SelectItem value="Fiber">Fiber</SelectItem>
+              <SelectItem value="Diode">Diode</SelectItem>
+              <SelectItem value="UV">UV</SelectItem>
+              <SelectItem value="Infrared">Infrared</SelectItem>
+            </Select>
+            <Input label="Wattage (W)" type="number" min="1" value={form.wattage} onChange={(e) => setForm({ ...form, wattage: e.target.value })} />
+            <Select label="Material" placeholder="Choose a material" value={form.materialId} onValueChange={(val) => setForm({ ...form, materialId: val })}>
+              {(materials || []).map((m) => (
+                <SelectItem key={m.ID || m.id} value={String(m.ID || m.id)}>
+                  {m.Name || m.name}
+                </SelectItem>
+              ))}
+            </Select>
+          </div>
+
+          <Select label="Variables" value={form.variables} onValueChange={(val) => setForm({ ...form, variables: val })}>
+            <SelectItem value="power-speed">Power × speed</SelectItem>
+            <SelectItem value="frequency-interval">Frequency × interval (Fiber, UV)</SelectItem>
+          </Select>
+
+          {['columns', 'rows'].map((name) => (
+            <div key={name} className="grid grid-cols-3 gap-4">
+              <Input label={`${unitLabel(axis[name])} from`} value={form[name].min} onChange={(e) => setAxis(name, 'min', e.target.value)} />
+              <Input label="to" value={form[name].max} onChange={(e) => setAxis(name, 'max', e.target.value)} />
+              <Input label="steps" type="number" min="1" value={form[name].steps} onChange={(e) => setAxis(name, 'steps', e.target.value)} />
+            </div>
+          ))}
+
+          <div className="grid grid-cols-2 gap-4">
+            {axis.fixed.map((field) => (
+              <Input
+                key={field}
+                label={unitLabel(fixedLabels[field])}
+                value={form[field]}
+                onChange={(e) => setForm({ ...form, [field]: e.target.value })}
+              />
+            ))}
+          </div>
+
+          {error && <p className="text-sm text-ls-red">{error}</p>}
+          <Button type="submit">Generate grid</Button>
+        </form>
+      </Card>
+
+      {result && (
+        <Card className="mt-6">
+          <h2 className="text-lg font-semibold text-ls-text mb-2">{result.cells.length} squares</h2>
+          <p className="text-sm text-ls-text-muted mb-4">
+            Burn the project, then import the library and keep the entries for the squares that came out right.
+          </p>
+          <div className="flex flex-wrap gap-3">
+            <Button onClick={() => download(result.projectFilename, result.project)}>Download .lbrn2 project</Button>
+            <Button variant="outline" onClick={() => download(result.libraryFilename, result.library)}>Download .clb library</Button>
+          </div>
+        </Card>
+      )}
+    </div>
+  )
+}
+
+export default TestGridPage
@@ -0,0 +1,50 @@
+// Speeds are stored in mm/s, line intervals in mm and pulse frequencies in
+// kHz, as in .clb files.
+// These helpers show them in the units a user prefers; values a user enters
+// are sent with their unit and converted by the API.
+
+export const speedUnits = ['mm/s', 'mm/min', 'in/s', 'in/min']
+export const intervalUnits = ['mm', 'in', 'lpi']
+export const frequencyUnits = ['Hz', 'kHz', 'MHz']
+
+const speedFactors = { 'mm/s': 1, 'mm/min': 1 / 60, 'in/s': 25.4, 'in/min': 25.4 / 60 }
+const speedDecimals = { 'mm/s': 0, 'mm/min': 0, 'in/s': 2, 'in/min': 1 }
+const intervalDecimals = { mm: 3, in: 4, lpi: 0 }
+
+export function userSpeedUnit(user) {
+  return user?.speedUnit || 'mm/s'
+}
+
+export function userIntervalUnit(user) {
+  return user?.intervalUnit || 'mm'
+}
+
+export function speedFromCanonical(value, unit) {
+  return parseFloat(value) / (speedFactors[unit] || 1)
+}
+
+export function intervalFromCanonical(value, unit) {
+  const mm = parseFloat(value)
+  if (unit === 'in') return mm / 25.4
+  if (unit === 'lpi') return mm ? 25.4 / mm : 0
+  return mm
+}
+
+// "30 kHz" for a stored frequency of 30
+export function formatFrequency(value) {
+  if (value === null || value === undefined || value === '') return '-'
+  const khz = parseFloat(value)
+  return khz >= 1000 ? `${+(khz / 1000).toFixed(3)} MHz` : `${+khz.toFixed(3)} kHz`
+}
+
+// "6000 mm/min" for a stored speed of 100
+export function formatSpeed(value, unit = 'mm/s') {
+  if (value === null || value === undefined || value === '') return '-'
+  return `${+speedFromCanonical(value, unit).toFixed(speedDecimals[unit] ?? 0)} ${unit}`
+}
+
+// "254 lpi" for a stored interval of 0.1
+export function formatInterval(value, unit = 'mm') {
+  if (value === null || value === undefined || value === '') return '-'
+  return `${+intervalFromCanonical(value, unit).toFixed(intervalDecimals[unit] ?? 3)} ${unit}`
+}